@@ -29,7 +29,9 @@ func (t *allocTracker) register(obj interface{}, size uint64) {
 	t.mtx.Lock()
 	defer t.mtx.Unlock()
 
-	if _, ok := obj.([]byte); ok {
+	// finalizers can only be set on pointers, so values such as byte slices or integers
+	// are tracked using a pointer to them.
+	if reflect.ValueOf(obj).Kind() != reflect.Ptr {
 		obj = &obj
 	}
 
@@ -96,3 +96,30 @@ func TestAllocTrackerTriggerNoError(t *testing.T) {
 		}
 	}
 }
+
+func TestAllocTrackerInt64Column(t *testing.T) {
+	var buf bytes.Buffer
+
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 foo;
+	}`)
+	require.NoError(t, err)
+
+	wr := NewFileWriter(&buf, WithSchemaDefinition(sd))
+	for i := 0; i < 1000; i++ {
+		require.NoError(t, wr.AddData(map[string]interface{}{"foo": int64(i)}))
+	}
+	require.NoError(t, wr.Close())
+
+	r, err := NewFileReaderWithOptions(bytes.NewReader(buf.Bytes()), WithMaximumMemorySize(1024*1024))
+	require.NoError(t, err)
+
+	for i := 0; i < 1000; i++ {
+		row, err := r.NextRow()
+		require.NoError(t, err)
+		require.Equal(t, int64(i), row["foo"])
+	}
+
+	_, err = r.NextRow()
+	require.True(t, errors.Is(err, io.EOF))
+}
@@ -0,0 +1,343 @@
+package goparquet
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/fraugster/parquet-go/parquet"
+)
+
+const (
+	// bloomFilterBlockSize is the size of a single block of a split-block bloom filter in bytes.
+	bloomFilterBlockSize = 32
+
+	bloomFilterMinBytes = bloomFilterBlockSize
+	bloomFilterMaxBytes = 128 * 1024 * 1024
+
+	// defaultBloomFilterFPP is the false positive probability that is used if an invalid
+	// value was provided.
+	defaultBloomFilterFPP = 0.01
+)
+
+var bloomFilterSalt = [8]uint32{
+	0x47b6137b,
+	0x44974d91,
+	0x8824ad5b,
+	0xa2b7289d,
+	0x705495c7,
+	0x2df1424b,
+	0x9efc4947,
+	0x5c6bfb31,
+}
+
+// splitBlockBloomFilter implements the split block bloom filter (SBBF) as described
+// in the parquet specification. The filter consists of blocks of eight 32 bit words,
+// and every inserted hash sets exactly one bit in each word of a single block.
+type splitBlockBloomFilter struct {
+	bitset []uint32
+}
+
+func newSplitBlockBloomFilter(numBytes int) *splitBlockBloomFilter {
+	return &splitBlockBloomFilter{
+		bitset: make([]uint32, numBytes/4),
+	}
+}
+
+// bloomFilterNumBytes returns the optimal size of a bloom filter for ndv distinct values
+// and the false positive probability fpp, rounded up to the next power of 2.
+func bloomFilterNumBytes(ndv int64, fpp float64) int {
+	numBits := -8 * float64(ndv) / math.Log(1-math.Pow(fpp, 1.0/8))
+
+	numBytes := bloomFilterMinBytes
+	for numBytes < bloomFilterMaxBytes && float64(numBytes)*8 < numBits {
+		numBytes <<= 1
+	}
+
+	return numBytes
+}
+
+func (f *splitBlockBloomFilter) numBlocks() uint64 {
+	return uint64(len(f.bitset) / 8)
+}
+
+func (f *splitBlockBloomFilter) block(h uint64) []uint32 {
+	idx := ((h >> 32) * f.numBlocks()) >> 32
+	return f.bitset[idx*8 : idx*8+8]
+}
+
+func (f *splitBlockBloomFilter) insert(h uint64) {
+	block := f.block(h)
+	key := uint32(h)
+	for i := range block {
+		block[i] |= 1 << ((key * bloomFilterSalt[i]) >> 27)
+	}
+}
+
+func (f *splitBlockBloomFilter) check(h uint64) bool {
+	block := f.block(h)
+	key := uint32(h)
+	for i := range block {
+		if block[i]&(1<<((key*bloomFilterSalt[i])>>27)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *splitBlockBloomFilter) header() *parquet.BloomFilterHeader {
+	return &parquet.BloomFilterHeader{
+		NumBytes: int32(len(f.bitset) * 4),
+		Algorithm: &parquet.BloomFilterAlgorithm{
+			BLOCK: parquet.NewSplitBlockAlgorithm(),
+		},
+		Hash: &parquet.BloomFilterHash{
+			XXHASH: parquet.NewXxHash(),
+		},
+		Compression: &parquet.BloomFilterCompression{
+			UNCOMPRESSED: parquet.NewUncompressed(),
+		},
+	}
+}
+
+func (f *splitBlockBloomFilter) write(ctx context.Context, w io.Writer) error {
+	if err := writeThrift(ctx, f.header(), w); err != nil {
+		return err
+	}
+
+	return binary.Write(w, binary.LittleEndian, f.bitset)
+}
+
+func readSplitBlockBloomFilter(ctx context.Context, r io.Reader, alloc *allocTracker) (*splitBlockBloomFilter, error) {
+	header := &parquet.BloomFilterHeader{}
+	if err := readThrift(ctx, header, r); err != nil {
+		return nil, err
+	}
+
+	return newSplitBlockBloomFilterFromHeader(header, r, alloc)
+}
+
+// newSplitBlockBloomFilterFromHeader creates the bloom filter described by header, and reads
+// its bitset from r.
+func newSplitBlockBloomFilterFromHeader(header *parquet.BloomFilterHeader, r io.Reader, alloc *allocTracker) (*splitBlockBloomFilter, error) {
+	if header.Algorithm == nil || !header.Algorithm.IsSetBLOCK() {
+		return nil, errors.New("unsupported bloom filter algorithm")
+	}
+	if header.Hash == nil || !header.Hash.IsSetXXHASH() {
+		return nil, errors.New("unsupported bloom filter hash")
+	}
+	if header.Compression == nil || !header.Compression.IsSetUNCOMPRESSED() {
+		return nil, errors.New("unsupported bloom filter compression")
+	}
+	if header.NumBytes < bloomFilterMinBytes || header.NumBytes > bloomFilterMaxBytes || header.NumBytes%bloomFilterBlockSize != 0 {
+		return nil, fmt.Errorf("invalid bloom filter size %d", header.NumBytes)
+	}
+
+	alloc.test(uint64(header.NumBytes))
+	f := newSplitBlockBloomFilter(int(header.NumBytes))
+	alloc.register(f, uint64(header.NumBytes))
+
+	if err := binary.Read(r, binary.LittleEndian, f.bitset); err != nil {
+		return nil, fmt.Errorf("reading bloom filter bitset failed: %w", err)
+	}
+
+	return f, nil
+}
+
+// bloomFilterHash returns the hash of the plain encoding of v, which must be
+// a value of a column of physical type typ.
+func bloomFilterHash(typ parquet.Type, v interface{}) (uint64, error) {
+	var buf []byte
+
+	switch typ {
+	case parquet.Type_INT32:
+		i, ok := v.(int32)
+		if !ok {
+			return 0, fmt.Errorf("unsupported type %T for int32 column", v)
+		}
+		buf = make([]byte, 4)
+		binary.LittleEndian.PutUint32(buf, uint32(i))
+	case parquet.Type_INT64:
+		i, ok := v.(int64)
+		if !ok {
+			return 0, fmt.Errorf("unsupported type %T for int64 column", v)
+		}
+		buf = make([]byte, 8)
+		binary.LittleEndian.PutUint64(buf, uint64(i))
+	case parquet.Type_FLOAT:
+		f, ok := v.(float32)
+		if !ok {
+			return 0, fmt.Errorf("unsupported type %T for float column", v)
+		}
+		buf = make([]byte, 4)
+		binary.LittleEndian.PutUint32(buf, math.Float32bits(f))
+	case parquet.Type_DOUBLE:
+		f, ok := v.(float64)
+		if !ok {
+			return 0, fmt.Errorf("unsupported type %T for double column", v)
+		}
+		buf = make([]byte, 8)
+		binary.LittleEndian.PutUint64(buf, math.Float64bits(f))
+	case parquet.Type_INT96:
+		i, ok := v.([12]byte)
+		if !ok {
+			return 0, fmt.Errorf("unsupported type %T for int96 column", v)
+		}
+		buf = i[:]
+	case parquet.Type_BYTE_ARRAY, parquet.Type_FIXED_LEN_BYTE_ARRAY:
+		switch b := v.(type) {
+		case []byte:
+			buf = b
+		case string:
+			buf = []byte(b)
+		default:
+			return 0, fmt.Errorf("unsupported type %T for %s column", v, typ)
+		}
+	default:
+		return 0, fmt.Errorf("bloom filters are not supported for %s columns", typ)
+	}
+
+	return xxhash64(buf), nil
+}
+
+type bloomFilterColumn struct {
+	path ColumnPath
+	fpp  float64
+}
+
+// bloomFilterFPP returns the false positive probability of the bloom filter configured
+// for the column identified by path.
+func (r *schema) bloomFilterFPP(path ColumnPath) (float64, bool) {
+	for _, bf := range r.bloomFilters {
+		if bf.path.Equal(path) {
+			return bf.fpp, true
+		}
+	}
+	return 0, false
+}
+
+// buildBloomFilter creates the bloom filter of a column chunk from all of its data pages.
+func buildBloomFilter(typ parquet.Type, pages []*dataPage, fpp float64) (*splitBlockBloomFilter, error) {
+	hashes := make(map[uint64]struct{})
+	for _, page := range pages {
+		if err := addBloomFilterHashes(hashes, typ, page); err != nil {
+			return nil, err
+		}
+	}
+
+	return newBloomFilterFromHashes(hashes, fpp), nil
+}
+
+// addBloomFilterHashes adds the hashes of all values of page to hashes.
+func addBloomFilterHashes(hashes map[uint64]struct{}, typ parquet.Type, page *dataPage) error {
+	for i := 0; i < page.values.len(); i++ {
+		h, err := bloomFilterHash(typ, page.values.value(i))
+		if err != nil {
+			return err
+		}
+		hashes[h] = struct{}{}
+	}
+	return nil
+}
+
+// newBloomFilterFromHashes creates a bloom filter that is sized for the number of distinct
+// hashes and the false positive probability fpp, and inserts all hashes.
+func newBloomFilterFromHashes(hashes map[uint64]struct{}, fpp float64) *splitBlockBloomFilter {
+	f := newSplitBlockBloomFilter(bloomFilterNumBytes(int64(len(hashes)), fpp))
+	for h := range hashes {
+		f.insert(h)
+	}
+
+	return f
+}
+
+// writeBloomFilters writes the bloom filters of all column chunks written so far,
+// and updates the column chunks' meta data accordingly.
+func writeBloomFilters(ctx context.Context, w writePos, indexes []*chunkIndex) error {
+	for _, ci := range indexes {
+		if ci.bloomFilter == nil {
+			continue
+		}
+
+		pos := w.Pos()
+		if ci.crypto != nil {
+			if err := ci.bloomFilter.writeEncrypted(ctx, w, ci.crypto); err != nil {
+				return err
+			}
+		} else if err := ci.bloomFilter.write(ctx, w); err != nil {
+			return err
+		}
+		ci.chunk.MetaData.BloomFilterOffset = &pos
+	}
+
+	return nil
+}
+
+// MightContain checks the bloom filter of the column identified by path in the row group with
+// the index rowGroupIdx whether it may contain value. If it returns false, the column chunk
+// definitely doesn't contain the value, and the row group can be skipped for point lookups.
+// If the column chunk has no bloom filter, true is returned.
+//
+// The value needs to be of the same Go type that is used when writing data to the column,
+// e.g. int32 for INT32 columns and []byte for BYTE_ARRAY columns. For BYTE_ARRAY and
+// FIXED_LEN_BYTE_ARRAY columns, string values are accepted as well.
+func (f *FileReader) MightContain(rowGroupIdx int, path ColumnPath, value interface{}) (bool, error) {
+	return f.MightContainWithContext(f.ctx, rowGroupIdx, path, value)
+}
+
+// MightContainWithContext checks the bloom filter of the column identified by path in the row group
+// with the index rowGroupIdx whether it may contain value. If the column chunk has no bloom filter, true
+// is returned.
+func (f *FileReader) MightContainWithContext(ctx context.Context, rowGroupIdx int, path ColumnPath, value interface{}) (ok bool, err error) {
+	defer f.recover(&err)
+
+	chunk, err := f.columnChunkByPath(rowGroupIdx, path)
+	if err != nil {
+		return false, err
+	}
+
+	cc, err := f.columnCrypto(rowGroupIdx, chunk)
+	if err != nil {
+		return false, err
+	}
+
+	if chunk.MetaData.BloomFilterOffset == nil {
+		return true, nil
+	}
+
+	h, err := bloomFilterHash(chunk.MetaData.Type, value)
+	if err != nil {
+		return false, err
+	}
+
+	filter, ok := f.bloomFilters[chunk]
+	if !ok {
+		r, err := f.chunkReader(chunk)
+		if err != nil {
+			return false, err
+		}
+
+		if _, err := r.Seek(*chunk.MetaData.BloomFilterOffset, io.SeekStart); err != nil {
+			return false, err
+		}
+
+		if cc != nil {
+			filter, err = readEncryptedSplitBlockBloomFilter(ctx, r, cc, f.allocTracker)
+		} else {
+			filter, err = readSplitBlockBloomFilter(ctx, r, f.allocTracker)
+		}
+		if err != nil {
+			return false, fmt.Errorf("reading bloom filter failed: %w", err)
+		}
+
+		if f.bloomFilters == nil {
+			f.bloomFilters = make(map[*parquet.ColumnChunk]*splitBlockBloomFilter)
+		}
+		f.bloomFilters[chunk] = filter
+	}
+
+	return filter.check(h), nil
+}
@@ -0,0 +1,142 @@
+package goparquet
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXXHash64(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected uint64
+	}{
+		{"", 0xef46db3751d8e999},
+		{"a", 0xd24ec4f1a98c6e5b},
+		{"abc", 0x44bc2cf5ad770999},
+		{"Nobody inspects the spammish repetition", 0xfbcea83c8a378bf1},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, xxhash64([]byte(tt.input)), "input %q", tt.input)
+	}
+}
+
+func TestSplitBlockBloomFilter(t *testing.T) {
+	const (
+		numValues = 10000
+		fpp       = 0.01
+	)
+
+	f := newSplitBlockBloomFilter(bloomFilterNumBytes(numValues, fpp))
+	for i := 0; i < numValues; i++ {
+		f.insert(xxhash64([]byte(fmt.Sprintf("value-%d", i))))
+	}
+
+	for i := 0; i < numValues; i++ {
+		require.True(t, f.check(xxhash64([]byte(fmt.Sprintf("value-%d", i)))), "false negative for value %d", i)
+	}
+
+	falsePositives := 0
+	for i := 0; i < numValues; i++ {
+		if f.check(xxhash64([]byte(fmt.Sprintf("other-%d", i)))) {
+			falsePositives++
+		}
+	}
+	require.True(t, float64(falsePositives)/numValues < 2*fpp, "too many false positives: %d", falsePositives)
+}
+
+func TestBloomFilterNumBytes(t *testing.T) {
+	require.Equal(t, bloomFilterMinBytes, bloomFilterNumBytes(0, 0.01))
+	require.Equal(t, bloomFilterMaxBytes, bloomFilterNumBytes(1<<40, 0.01))
+
+	n := bloomFilterNumBytes(1000000, 0.01)
+	require.Equal(t, 0, n&(n-1), "size %d is not a power of 2", n)
+}
+
+func TestBloomFilterWriteAndRead(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 id;
+		optional binary name (STRING);
+		required int32 num;
+	}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	fw := NewFileWriter(buf,
+		WithSchemaDefinition(sd),
+		WithBloomFilter(ColumnPath{"id"}, 0.01),
+		WithBloomFilter(ColumnPath{"name"}, 0.001),
+	)
+
+	const rowsPerGroup = 1000
+
+	for i := 0; i < 3*rowsPerGroup; i++ {
+		data := map[string]interface{}{
+			"id":  int64(i),
+			"num": int32(i),
+		}
+		if i%2 == 0 {
+			data["name"] = []byte(fmt.Sprintf("name-%d", i))
+		}
+		require.NoError(t, fw.AddData(data))
+		if (i+1)%rowsPerGroup == 0 {
+			require.NoError(t, fw.FlushRowGroup())
+		}
+	}
+	require.NoError(t, fw.Close())
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, 3, r.RowGroupCount())
+
+	for rg := 0; rg < r.RowGroupCount(); rg++ {
+		require.NotNil(t, r.meta.RowGroups[rg].Columns[0].MetaData.BloomFilterOffset)
+		require.NotNil(t, r.meta.RowGroups[rg].Columns[1].MetaData.BloomFilterOffset)
+		require.Nil(t, r.meta.RowGroups[rg].Columns[2].MetaData.BloomFilterOffset)
+
+		falsePositives := 0
+		for i := 0; i < 3*rowsPerGroup; i++ {
+			inRowGroup := i/rowsPerGroup == rg
+
+			ok, err := r.MightContain(rg, ColumnPath{"id"}, int64(i))
+			require.NoError(t, err)
+			if inRowGroup {
+				require.True(t, ok, "false negative for id %d in row group %d", i, rg)
+			} else if ok {
+				falsePositives++
+			}
+
+			if i%2 == 0 {
+				ok, err := r.MightContain(rg, ColumnPath{"name"}, fmt.Sprintf("name-%d", i))
+				require.NoError(t, err)
+				if inRowGroup {
+					require.True(t, ok, "false negative for name %d in row group %d", i, rg)
+				}
+			}
+
+			ok, err = r.MightContain(rg, ColumnPath{"num"}, int32(i))
+			require.NoError(t, err)
+			require.True(t, ok, "column without bloom filter must always return true")
+		}
+		require.True(t, falsePositives < 2*rowsPerGroup/50, "too many false positives: %d", falsePositives)
+	}
+
+	_, err = r.MightContain(0, ColumnPath{"id"}, "not an int64")
+	require.Error(t, err)
+
+	_, err = r.MightContain(0, ColumnPath{"does_not_exist"}, int64(1))
+	require.Error(t, err)
+
+	var rows int
+	for {
+		if _, err := r.NextRow(); err != nil {
+			break
+		}
+		rows++
+	}
+	require.Equal(t, 3*rowsPerGroup, rows)
+}
@@ -0,0 +1,259 @@
+package goparquet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+)
+
+// The BYTE_STREAM_SPLIT encoding scatters the bytes of fixed-width values into one
+// stream per byte position: the first stream holds the first byte of every value,
+// the second stream the second byte, and so on. The encoding doesn't reduce the size
+// by itself, but it often makes the data a lot more compressible.
+
+type byteStreamSplitDecoder struct {
+	width int
+	// decode converts the little endian representation of len(data)/width values to the
+	// first values of dst.
+	decode func(dst valuesBuffer, data []byte) error
+
+	data      []byte
+	numValues int
+	pos       int
+	buf       []byte
+}
+
+func newFloatByteStreamSplitDecoder() *byteStreamSplitDecoder {
+	return &byteStreamSplitDecoder{width: 4, decode: func(dst valuesBuffer, data []byte) error {
+		values, ok := dst.(floatValues)
+		if !ok {
+			return unexpectedValuesBuffer("float", dst)
+		}
+		for i := range values[:len(data)/4] {
+			values[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+		}
+		return nil
+	}}
+}
+
+func newDoubleByteStreamSplitDecoder() *byteStreamSplitDecoder {
+	return &byteStreamSplitDecoder{width: 8, decode: func(dst valuesBuffer, data []byte) error {
+		values, ok := dst.(doubleValues)
+		if !ok {
+			return unexpectedValuesBuffer("double", dst)
+		}
+		for i := range values[:len(data)/8] {
+			values[i] = math.Float64frombits(binary.LittleEndian.Uint64(data[8*i:]))
+		}
+		return nil
+	}}
+}
+
+func newInt32ByteStreamSplitDecoder() *byteStreamSplitDecoder {
+	return &byteStreamSplitDecoder{width: 4, decode: func(dst valuesBuffer, data []byte) error {
+		values, ok := dst.(int32Values)
+		if !ok {
+			return unexpectedValuesBuffer("int32", dst)
+		}
+		for i := range values[:len(data)/4] {
+			values[i] = int32(binary.LittleEndian.Uint32(data[4*i:]))
+		}
+		return nil
+	}}
+}
+
+func newInt64ByteStreamSplitDecoder() *byteStreamSplitDecoder {
+	return &byteStreamSplitDecoder{width: 8, decode: func(dst valuesBuffer, data []byte) error {
+		values, ok := dst.(int64Values)
+		if !ok {
+			return unexpectedValuesBuffer("int64", dst)
+		}
+		for i := range values[:len(data)/8] {
+			values[i] = int64(binary.LittleEndian.Uint64(data[8*i:]))
+		}
+		return nil
+	}}
+}
+
+func newFixedLenByteArrayByteStreamSplitDecoder(length int) *byteStreamSplitDecoder {
+	return &byteStreamSplitDecoder{width: length, decode: func(dst valuesBuffer, data []byte) error {
+		values, ok := dst.(byteArrayValues)
+		if !ok {
+			return unexpectedValuesBuffer("byte array", dst)
+		}
+		// the values share one copy of the data instead of allocating each one separately.
+		data = append([]byte(nil), data...)
+		for i := range values[:len(data)/length] {
+			values[i] = data[i*length : (i+1)*length : (i+1)*length]
+		}
+		return nil
+	}}
+}
+
+func (d *byteStreamSplitDecoder) init(r io.Reader) error {
+	if d.width <= 0 {
+		return fmt.Errorf("invalid value width %d for byte stream split encoding", d.width)
+	}
+
+	// the number of values is only known from the size of the data, as every stream
+	// holds one byte of each value.
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	if len(data)%d.width != 0 {
+		return fmt.Errorf("byte stream split data of %d bytes is not a multiple of the value width %d", len(data), d.width)
+	}
+
+	d.data = data
+	d.numValues = len(data) / d.width
+	d.pos = 0
+	d.buf = make([]byte, d.width)
+
+	return nil
+}
+
+func (d *byteStreamSplitDecoder) decodeValues(dst valuesBuffer) (int, error) {
+	n, err := dst.len(), error(nil)
+	if remaining := d.numValues - d.pos; remaining < n {
+		n, err = remaining, io.EOF
+	}
+
+	// gather the bytes of the next n values from the streams into their little endian
+	// representation, and convert all of them at once.
+	if cap(d.buf) < n*d.width {
+		d.buf = make([]byte, n*d.width)
+	}
+	buf := d.buf[:n*d.width]
+	for i := 0; i < n; i++ {
+		for k := 0; k < d.width; k++ {
+			buf[i*d.width+k] = d.data[k*d.numValues+d.pos+i]
+		}
+	}
+
+	if decodeErr := d.decode(dst, buf); decodeErr != nil {
+		return 0, decodeErr
+	}
+	d.pos += n
+
+	return n, err
+}
+
+type byteStreamSplitEncoder struct {
+	width int
+	// encode appends the little endian representation of all values to dst.
+	encode func(dst []byte, values valuesBuffer) ([]byte, error)
+
+	w    io.Writer
+	data []byte
+}
+
+func newFloatByteStreamSplitEncoder() *byteStreamSplitEncoder {
+	return &byteStreamSplitEncoder{width: 4, encode: func(dst []byte, values valuesBuffer) ([]byte, error) {
+		v, ok := values.(floatValues)
+		if !ok {
+			return nil, unexpectedValuesBuffer("float", values)
+		}
+		for i := range v {
+			dst = appendUint32LE(dst, math.Float32bits(v[i]))
+		}
+		return dst, nil
+	}}
+}
+
+func newDoubleByteStreamSplitEncoder() *byteStreamSplitEncoder {
+	return &byteStreamSplitEncoder{width: 8, encode: func(dst []byte, values valuesBuffer) ([]byte, error) {
+		v, ok := values.(doubleValues)
+		if !ok {
+			return nil, unexpectedValuesBuffer("double", values)
+		}
+		for i := range v {
+			dst = appendUint64LE(dst, math.Float64bits(v[i]))
+		}
+		return dst, nil
+	}}
+}
+
+func newInt32ByteStreamSplitEncoder() *byteStreamSplitEncoder {
+	return &byteStreamSplitEncoder{width: 4, encode: func(dst []byte, values valuesBuffer) ([]byte, error) {
+		v, ok := values.(int32Values)
+		if !ok {
+			return nil, unexpectedValuesBuffer("int32", values)
+		}
+		for i := range v {
+			dst = appendUint32LE(dst, uint32(v[i]))
+		}
+		return dst, nil
+	}}
+}
+
+func newInt64ByteStreamSplitEncoder() *byteStreamSplitEncoder {
+	return &byteStreamSplitEncoder{width: 8, encode: func(dst []byte, values valuesBuffer) ([]byte, error) {
+		v, ok := values.(int64Values)
+		if !ok {
+			return nil, unexpectedValuesBuffer("int64", values)
+		}
+		for i := range v {
+			dst = appendUint64LE(dst, uint64(v[i]))
+		}
+		return dst, nil
+	}}
+}
+
+func newFixedLenByteArrayByteStreamSplitEncoder(length int) *byteStreamSplitEncoder {
+	return &byteStreamSplitEncoder{width: length, encode: func(dst []byte, values valuesBuffer) ([]byte, error) {
+		v, ok := values.(byteArrayValues)
+		if !ok {
+			return nil, unexpectedValuesBuffer("byte array", values)
+		}
+		for i := range v {
+			if len(v[i]) != length {
+				return nil, fmt.Errorf("the byte array should be with length %d but is %d", length, len(v[i]))
+			}
+			dst = append(dst, v[i]...)
+		}
+		return dst, nil
+	}}
+}
+
+func (e *byteStreamSplitEncoder) init(w io.Writer) error {
+	e.w = w
+	e.data = e.data[:0]
+
+	return nil
+}
+
+func (e *byteStreamSplitEncoder) encodeValues(values valuesBuffer) error {
+	data, err := e.encode(e.data, values)
+	if err != nil {
+		return err
+	}
+	e.data = data
+
+	return nil
+}
+
+// Close scatters the bytes of all values that were encoded into their streams and
+// writes them.
+func (e *byteStreamSplitEncoder) Close() error {
+	numValues := len(e.data) / e.width
+	buf := make([]byte, len(e.data))
+	for i := 0; i < numValues; i++ {
+		for k := 0; k < e.width; k++ {
+			buf[k*numValues+i] = e.data[i*e.width+k]
+		}
+	}
+
+	return writeFull(e.w, buf)
+}
+
+func appendUint32LE(dst []byte, v uint32) []byte {
+	return append(dst, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func appendUint64LE(dst []byte, v uint64) []byte {
+	return appendUint32LE(appendUint32LE(dst, uint32(v)), uint32(v>>32))
+}
@@ -0,0 +1,38 @@
+package goparquet
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestByteStreamSplitLayout(t *testing.T) {
+	enc := newInt32ByteStreamSplitEncoder()
+
+	var buf bytes.Buffer
+	require.NoError(t, enc.init(&buf))
+	require.NoError(t, enc.encodeValues(int32Values{0x04030201, 0x08070605, 0x0C0B0A09}))
+	require.NoError(t, enc.Close())
+
+	require.Equal(t, []byte{0x01, 0x05, 0x09, 0x02, 0x06, 0x0A, 0x03, 0x07, 0x0B, 0x04, 0x08, 0x0C}, buf.Bytes())
+
+	dec := newInt32ByteStreamSplitDecoder()
+	require.NoError(t, dec.init(bytes.NewReader(buf.Bytes())))
+
+	dst := make(int32Values, 4)
+	n, err := dec.decodeValues(dst)
+	require.Equal(t, io.EOF, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, int32Values{0x04030201, 0x08070605, 0x0C0B0A09}, dst[:n])
+}
+
+func TestByteStreamSplitInvalidData(t *testing.T) {
+	dec := newDoubleByteStreamSplitDecoder()
+	require.Error(t, dec.init(bytes.NewReader(make([]byte, 12))))
+
+	enc := newFixedLenByteArrayByteStreamSplitEncoder(4)
+	require.NoError(t, enc.init(&bytes.Buffer{}))
+	require.Error(t, enc.encodeValues(byteArrayValues{{1, 2, 3}}))
+}
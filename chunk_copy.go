@@ -0,0 +1,143 @@
+package goparquet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/fraugster/parquet-go/parquet"
+)
+
+// chunkRange returns the offset and the size of the pages of a column chunk in its file.
+func chunkRange(chunk *parquet.ColumnChunk) (int64, int64, error) {
+	if chunk.FilePath != nil {
+		return 0, 0, fmt.Errorf("nyi: data is in another file: '%s'", *chunk.FilePath)
+	}
+	if chunk.MetaData == nil {
+		return 0, 0, errors.New("missing meta data for column chunk")
+	}
+
+	offset := chunk.MetaData.DataPageOffset
+	if dictOffset := chunk.MetaData.DictionaryPageOffset; dictOffset != nil && *dictOffset > 0 && *dictOffset < offset {
+		offset = *dictOffset
+	}
+
+	return offset, chunk.MetaData.TotalCompressedSize, nil
+}
+
+// copyColumnChunk copies the pages of chunk from r to w without decoding them, and returns
+// the meta data of the copied column chunk along with its page index and bloom filter, which
+// are read from r as well, so that they can be written before the file footer.
+func copyColumnChunk(ctx context.Context, w writePos, r io.ReadSeeker, chunk *parquet.ColumnChunk) (*parquet.ColumnChunk, *chunkIndex, error) {
+	if chunk.CryptoMetadata != nil {
+		return nil, nil, errors.New("copying encrypted column chunks is not supported")
+	}
+
+	offset, size, err := chunkRange(chunk)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	index := &chunkIndex{copied: true}
+
+	if chunk.ColumnIndexOffset != nil && chunk.ColumnIndexLength != nil {
+		index.columnIndex = &parquet.ColumnIndex{}
+		if err := readThriftAt(ctx, r, index.columnIndex, *chunk.ColumnIndexOffset, *chunk.ColumnIndexLength); err != nil {
+			return nil, nil, fmt.Errorf("reading column index failed: %w", err)
+		}
+	}
+
+	if chunk.OffsetIndexOffset != nil && chunk.OffsetIndexLength != nil {
+		index.offsetIndex = &parquet.OffsetIndex{}
+		if err := readThriftAt(ctx, r, index.offsetIndex, *chunk.OffsetIndexOffset, *chunk.OffsetIndexLength); err != nil {
+			return nil, nil, fmt.Errorf("reading offset index failed: %w", err)
+		}
+	}
+
+	if chunk.MetaData.BloomFilterOffset != nil {
+		if _, err := r.Seek(*chunk.MetaData.BloomFilterOffset, io.SeekStart); err != nil {
+			return nil, nil, err
+		}
+		if index.bloomFilter, err = readSplitBlockBloomFilter(ctx, r, nil); err != nil {
+			return nil, nil, fmt.Errorf("reading bloom filter failed: %w", err)
+		}
+	}
+
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+
+	pos := w.Pos()
+	if n, err := io.CopyN(w, r, size); err != nil {
+		return nil, nil, fmt.Errorf("copying column chunk failed after %d of %d bytes: %w", n, size, err)
+	}
+
+	metaData := *chunk.MetaData
+	metaData.BloomFilterOffset = nil
+	if metaData.IndexPageOffset != nil {
+		indexPageOffset := *metaData.IndexPageOffset + pos - offset
+		metaData.IndexPageOffset = &indexPageOffset
+	}
+
+	ch := &parquet.ColumnChunk{
+		FileOffset: chunk.FileOffset,
+		MetaData:   &metaData,
+	}
+	shiftChunkOffsets(ch, index, pos-offset)
+	index.chunk = ch
+
+	return ch, index, nil
+}
+
+// copyRowGroup copies all column chunks of the row group rg from r to the file, and adds the
+// row group to the file's meta data.
+func (fw *FileWriter) copyRowGroup(ctx context.Context, r io.ReadSeeker, rg *parquet.RowGroup) error {
+	if fw.encryption != nil {
+		return errors.New("copying column chunks into encrypted files is not supported")
+	}
+
+	if fw.w.Pos() == 0 {
+		if err := writeFull(fw.w, magic); err != nil {
+			return err
+		}
+	}
+
+	var (
+		chunks              = make([]*parquet.ColumnChunk, 0, len(rg.Columns))
+		totalCompressedSize int64
+	)
+	for _, chunk := range rg.Columns {
+		ch, index, err := copyColumnChunk(ctx, fw.w, r, chunk)
+		if err != nil {
+			return err
+		}
+		chunks = append(chunks, ch)
+		fw.chunkIndexes = append(fw.chunkIndexes, index)
+		totalCompressedSize += ch.MetaData.TotalCompressedSize
+	}
+
+	fw.rowGroups = append(fw.rowGroups, &parquet.RowGroup{
+		Columns:             chunks,
+		TotalByteSize:       rg.TotalByteSize,
+		TotalCompressedSize: &totalCompressedSize,
+		NumRows:             rg.NumRows,
+		SortingColumns:      rg.SortingColumns,
+	})
+	fw.totalNumRecords += rg.NumRows
+
+	return nil
+}
+
+// readThriftAt reads tr from r at offset, where it occupies length bytes.
+func readThriftAt(ctx context.Context, r io.ReadSeeker, tr thriftReader, offset int64, length int32) error {
+	if offset < 0 || length <= 0 {
+		return fmt.Errorf("invalid offset %d or length %d", offset, length)
+	}
+
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	return readThrift(ctx, tr, io.LimitReader(r, int64(length)))
+}
@@ -0,0 +1,219 @@
+package goparquet
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/fraugster/parquet-go/parquet"
+)
+
+// ColumnChunkInfo describes a column chunk of a row group as it is recorded in the
+// meta data of the file.
+type ColumnChunkInfo struct {
+	Path  ColumnPath
+	Type  parquet.Type
+	Codec parquet.CompressionCodec
+
+	// NumValues is the number of values in the column chunk, including null values.
+	NumValues             int64
+	TotalCompressedSize   int64
+	TotalUncompressedSize int64
+
+	// Encodings lists all encodings that are used in the column chunk, including the
+	// encodings of the repetition and definition levels.
+	Encodings []parquet.Encoding
+
+	// EncodingStats holds the number of pages per page type and encoding. It is nil if
+	// the writer of the file didn't record them.
+	EncodingStats []*parquet.PageEncodingStats
+
+	// HasDictionaryPage is true if the column chunk starts with a dictionary page.
+	HasDictionaryPage bool
+
+	Statistics *parquet.Statistics
+}
+
+// FullyDictionaryEncoded returns true if all data pages of the column chunk are
+// dictionary-encoded. If the encoding stats weren't recorded, this is derived from the
+// list of encodings, which is only possible if the column chunk doesn't use PLAIN
+// encoding besides the dictionary encodings, as the dictionary page is PLAIN-encoded, too.
+func (ci *ColumnChunkInfo) FullyDictionaryEncoded() bool {
+	if !ci.HasDictionaryPage {
+		return false
+	}
+
+	if ci.EncodingStats != nil {
+		for _, st := range ci.EncodingStats {
+			if st.PageType != parquet.PageType_DATA_PAGE && st.PageType != parquet.PageType_DATA_PAGE_V2 {
+				continue
+			}
+			if st.Count > 0 && !isDictionaryEncoding(st.Encoding) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, enc := range ci.Encodings {
+		switch enc {
+		case parquet.Encoding_RLE, parquet.Encoding_BIT_PACKED, parquet.Encoding_PLAIN_DICTIONARY, parquet.Encoding_RLE_DICTIONARY:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func isDictionaryEncoding(enc parquet.Encoding) bool {
+	return enc == parquet.Encoding_PLAIN_DICTIONARY || enc == parquet.Encoding_RLE_DICTIONARY
+}
+
+// PageInfo describes a single page of a column chunk as it is recorded in its page header.
+type PageInfo struct {
+	// Offset is the position of the page header in the file.
+	Offset int64
+	Type   parquet.PageType
+
+	// Encoding is the encoding of the page's values. For dictionary pages, it is the
+	// encoding of the dictionary values.
+	Encoding parquet.Encoding
+
+	// DefinitionLevelEncoding and RepetitionLevelEncoding are the encodings of the
+	// levels of a data page. For dictionary pages, they are unset.
+	DefinitionLevelEncoding parquet.Encoding
+	RepetitionLevelEncoding parquet.Encoding
+
+	// NumValues is the number of values in the page, including null values.
+	NumValues        int32
+	CompressedSize   int32
+	UncompressedSize int32
+
+	Header *parquet.PageHeader
+}
+
+// ColumnChunkInfo returns information about the column chunk of the column identified by
+// path in the row group with the index rowGroupIdx, such as its encodings and the encoding
+// stats of its pages.
+func (f *FileReader) ColumnChunkInfo(rowGroupIdx int, path ColumnPath) (*ColumnChunkInfo, error) {
+	chunk, err := f.columnChunkByPath(rowGroupIdx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := chunk.MetaData
+	return &ColumnChunkInfo{
+		Path:                  ColumnPath(meta.PathInSchema),
+		Type:                  meta.Type,
+		Codec:                 meta.Codec,
+		NumValues:             meta.NumValues,
+		TotalCompressedSize:   meta.TotalCompressedSize,
+		TotalUncompressedSize: meta.TotalUncompressedSize,
+		Encodings:             meta.Encodings,
+		EncodingStats:         meta.EncodingStats,
+		HasDictionaryPage:     meta.DictionaryPageOffset != nil && *meta.DictionaryPageOffset > 0,
+		Statistics:            meta.Statistics,
+	}, nil
+}
+
+// ColumnChunkPages returns information about all pages of the column chunk of the column
+// identified by path in the row group with the index rowGroupIdx. Only the page headers
+// are read, the pages' data is skipped.
+func (f *FileReader) ColumnChunkPages(rowGroupIdx int, path ColumnPath) ([]PageInfo, error) {
+	return f.ColumnChunkPagesWithContext(f.ctx, rowGroupIdx, path)
+}
+
+// ColumnChunkPagesWithContext returns information about all pages of the column chunk of the
+// column identified by path in the row group with the index rowGroupIdx. Only the page headers
+// are read, the pages' data is skipped.
+func (f *FileReader) ColumnChunkPagesWithContext(ctx context.Context, rowGroupIdx int, path ColumnPath) ([]PageInfo, error) {
+	chunk, err := f.columnChunkByPath(rowGroupIdx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	cr, err := f.chunkReader(chunk)
+	if err != nil {
+		return nil, err
+	}
+
+	cc, err := f.columnCrypto(rowGroupIdx, chunk)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := chunk.MetaData
+	offset := meta.DataPageOffset
+	if meta.DictionaryPageOffset != nil && *meta.DictionaryPageOffset > 0 {
+		offset = *meta.DictionaryPageOffset
+	}
+
+	if _, err := cr.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	r := &offsetReader{
+		inner:  cr,
+		offset: offset,
+	}
+
+	var pages []PageInfo
+	for r.Count() < meta.TotalCompressedSize {
+		pageOffset := r.offset
+
+		ph := &parquet.PageHeader{}
+		if cc != nil {
+			// the dictionary page, if any, is the first page of the column chunk, and the
+			// data pages are numbered in order.
+			headerType, pageOrdinal := moduleDataPageHeader, len(pages)
+			if meta.DictionaryPageOffset != nil && *meta.DictionaryPageOffset > 0 {
+				if len(pages) == 0 {
+					headerType = moduleDictionaryPageHeader
+				}
+				pageOrdinal--
+			}
+			if err := cc.readThrift(ctx, headerType, pageOrdinal, ph, r, f.allocTracker); err != nil {
+				return nil, fmt.Errorf("reading page header at offset %d failed: %w", pageOffset, err)
+			}
+		} else if err := readThrift(ctx, ph, r); err != nil {
+			return nil, fmt.Errorf("reading page header at offset %d failed: %w", pageOffset, err)
+		}
+
+		if ph.CompressedPageSize < 0 {
+			return nil, fmt.Errorf("invalid compressed page size %d at offset %d", ph.CompressedPageSize, pageOffset)
+		}
+
+		page := PageInfo{
+			Offset:           pageOffset,
+			Type:             ph.Type,
+			CompressedSize:   ph.CompressedPageSize,
+			UncompressedSize: ph.UncompressedPageSize,
+			Header:           ph,
+		}
+
+		switch {
+		case ph.Type == parquet.PageType_DICTIONARY_PAGE && ph.DictionaryPageHeader != nil:
+			page.Encoding = ph.DictionaryPageHeader.Encoding
+			page.NumValues = ph.DictionaryPageHeader.NumValues
+		case ph.Type == parquet.PageType_DATA_PAGE && ph.DataPageHeader != nil:
+			page.Encoding = ph.DataPageHeader.Encoding
+			page.DefinitionLevelEncoding = ph.DataPageHeader.DefinitionLevelEncoding
+			page.RepetitionLevelEncoding = ph.DataPageHeader.RepetitionLevelEncoding
+			page.NumValues = ph.DataPageHeader.NumValues
+		case ph.Type == parquet.PageType_DATA_PAGE_V2 && ph.DataPageHeaderV2 != nil:
+			page.Encoding = ph.DataPageHeaderV2.Encoding
+			page.DefinitionLevelEncoding = parquet.Encoding_RLE
+			page.RepetitionLevelEncoding = parquet.Encoding_RLE
+			page.NumValues = ph.DataPageHeaderV2.NumValues
+		}
+
+		pages = append(pages, page)
+
+		if _, err := r.Seek(int64(ph.CompressedPageSize), io.SeekCurrent); err != nil {
+			return nil, err
+		}
+	}
+
+	return pages, nil
+}
@@ -0,0 +1,95 @@
+package goparquet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnChunkInfo(t *testing.T) {
+	for _, v2 := range []bool{false, true} {
+		opts := []FileWriterOption{WithDictionaryLimits(1000, 0), WithCompressionCodec(parquet.CompressionCodec_SNAPPY)}
+		dataPageType := parquet.PageType_DATA_PAGE
+		if v2 {
+			opts = append(opts, WithDataPageV2())
+			dataPageType = parquet.PageType_DATA_PAGE_V2
+		}
+
+		data := writeDictionaryLimitsTestFile(t, 3000, opts...)
+
+		r, err := NewFileReader(bytes.NewReader(data))
+		require.NoError(t, err)
+
+		id, err := r.ColumnChunkInfo(0, ColumnPath{"id"})
+		require.NoError(t, err)
+		require.Equal(t, ColumnPath{"id"}, id.Path)
+		require.Equal(t, parquet.Type_INT64, id.Type)
+		require.Equal(t, parquet.CompressionCodec_SNAPPY, id.Codec)
+		require.Equal(t, int64(3000), id.NumValues)
+		require.True(t, id.HasDictionaryPage)
+		require.False(t, id.FullyDictionaryEncoded())
+
+		name, err := r.ColumnChunkInfo(0, ColumnPath{"name"})
+		require.NoError(t, err)
+		require.True(t, name.HasDictionaryPage)
+		require.True(t, name.FullyDictionaryEncoded())
+
+		for _, info := range []*ColumnChunkInfo{id, name} {
+			pages, err := r.ColumnChunkPages(0, info.Path)
+			require.NoError(t, err)
+			require.True(t, len(pages) > 2)
+
+			require.Equal(t, parquet.PageType_DICTIONARY_PAGE, pages[0].Type)
+			require.Equal(t, parquet.Encoding_PLAIN, pages[0].Encoding)
+
+			counts := map[parquet.PageType]map[parquet.Encoding]int32{}
+			var numValues int64
+			for _, page := range pages {
+				if counts[page.Type] == nil {
+					counts[page.Type] = map[parquet.Encoding]int32{}
+				}
+				counts[page.Type][page.Encoding]++
+				if page.Type != parquet.PageType_DICTIONARY_PAGE {
+					require.Equal(t, dataPageType, page.Type)
+					require.Equal(t, parquet.Encoding_RLE, page.DefinitionLevelEncoding)
+					numValues += int64(page.NumValues)
+				}
+			}
+			require.Equal(t, info.NumValues, numValues)
+
+			require.Len(t, info.EncodingStats, len(counts[parquet.PageType_DICTIONARY_PAGE])+len(counts[dataPageType]))
+			for _, st := range info.EncodingStats {
+				require.Equal(t, counts[st.PageType][st.Encoding], st.Count, "%s %s", st.PageType, st.Encoding)
+			}
+		}
+
+		_, err = r.ColumnChunkInfo(0, ColumnPath{"foo"})
+		require.Error(t, err)
+		_, err = r.ColumnChunkPages(1, ColumnPath{"id"})
+		require.Error(t, err)
+
+		// the pages can still be read after inspecting them.
+		row, err := r.NextRow()
+		require.NoError(t, err)
+		require.Equal(t, int64(0), row["id"])
+	}
+}
+
+func TestColumnChunkInfoFullyDictionaryEncodedWithoutStats(t *testing.T) {
+	testData := []struct {
+		info     ColumnChunkInfo
+		expected bool
+	}{
+		{ColumnChunkInfo{HasDictionaryPage: true, Encodings: []parquet.Encoding{parquet.Encoding_RLE, parquet.Encoding_RLE_DICTIONARY}}, true},
+		{ColumnChunkInfo{HasDictionaryPage: true, Encodings: []parquet.Encoding{parquet.Encoding_BIT_PACKED, parquet.Encoding_PLAIN_DICTIONARY}}, true},
+		{ColumnChunkInfo{HasDictionaryPage: true, Encodings: []parquet.Encoding{parquet.Encoding_RLE, parquet.Encoding_PLAIN, parquet.Encoding_RLE_DICTIONARY}}, false},
+		{ColumnChunkInfo{HasDictionaryPage: false, Encodings: []parquet.Encoding{parquet.Encoding_RLE, parquet.Encoding_RLE_DICTIONARY}}, false},
+		{ColumnChunkInfo{HasDictionaryPage: false, Encodings: []parquet.Encoding{parquet.Encoding_RLE, parquet.Encoding_PLAIN}}, false},
+	}
+
+	for idx, tt := range testData {
+		require.Equal(t, tt.expected, tt.info.FullyDictionaryEncoded(), "%d. unexpected result", idx)
+	}
+}
@@ -1,6 +1,7 @@
 package goparquet
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -49,7 +50,7 @@ func getBooleanValuesDecoder(pageEncoding parquet.Encoding) (valuesDecoder, erro
 	}
 }
 
-func getByteArrayValuesDecoder(pageEncoding parquet.Encoding, dictValues []interface{}) (valuesDecoder, error) {
+func getByteArrayValuesDecoder(pageEncoding parquet.Encoding, dictValues valuesBuffer) (valuesDecoder, error) {
 	switch pageEncoding {
 	case parquet.Encoding_PLAIN:
 		return &byteArrayPlainDecoder{}, nil
@@ -64,12 +65,14 @@ func getByteArrayValuesDecoder(pageEncoding parquet.Encoding, dictValues []inter
 	}
 }
 
-func getFixedLenByteArrayValuesDecoder(pageEncoding parquet.Encoding, len int, dictValues []interface{}) (valuesDecoder, error) {
+func getFixedLenByteArrayValuesDecoder(pageEncoding parquet.Encoding, len int, dictValues valuesBuffer) (valuesDecoder, error) {
 	switch pageEncoding {
 	case parquet.Encoding_PLAIN:
 		return &byteArrayPlainDecoder{length: len}, nil
 	case parquet.Encoding_DELTA_BYTE_ARRAY:
 		return &byteArrayDeltaDecoder{}, nil
+	case parquet.Encoding_BYTE_STREAM_SPLIT:
+		return newFixedLenByteArrayByteStreamSplitDecoder(len), nil
 	case parquet.Encoding_RLE_DICTIONARY:
 		return &dictDecoder{uniqueValues: dictValues}, nil
 	default:
@@ -77,12 +80,14 @@ func getFixedLenByteArrayValuesDecoder(pageEncoding parquet.Encoding, len int, d
 	}
 }
 
-func getInt32ValuesDecoder(pageEncoding parquet.Encoding, typ *parquet.SchemaElement, dictValues []interface{}) (valuesDecoder, error) {
+func getInt32ValuesDecoder(pageEncoding parquet.Encoding, typ *parquet.SchemaElement, dictValues valuesBuffer) (valuesDecoder, error) {
 	switch pageEncoding {
 	case parquet.Encoding_PLAIN:
 		return &int32PlainDecoder{}, nil
 	case parquet.Encoding_DELTA_BINARY_PACKED:
 		return &int32DeltaBPDecoder{}, nil
+	case parquet.Encoding_BYTE_STREAM_SPLIT:
+		return newInt32ByteStreamSplitDecoder(), nil
 	case parquet.Encoding_RLE_DICTIONARY:
 		return &dictDecoder{uniqueValues: dictValues}, nil
 	default:
@@ -90,12 +95,14 @@ func getInt32ValuesDecoder(pageEncoding parquet.Encoding, typ *parquet.SchemaEle
 	}
 }
 
-func getInt64ValuesDecoder(pageEncoding parquet.Encoding, typ *parquet.SchemaElement, dictValues []interface{}) (valuesDecoder, error) {
+func getInt64ValuesDecoder(pageEncoding parquet.Encoding, typ *parquet.SchemaElement, dictValues valuesBuffer) (valuesDecoder, error) {
 	switch pageEncoding {
 	case parquet.Encoding_PLAIN:
 		return &int64PlainDecoder{}, nil
 	case parquet.Encoding_DELTA_BINARY_PACKED:
 		return &int64DeltaBPDecoder{}, nil
+	case parquet.Encoding_BYTE_STREAM_SPLIT:
+		return newInt64ByteStreamSplitDecoder(), nil
 	case parquet.Encoding_RLE_DICTIONARY:
 		return &dictDecoder{uniqueValues: dictValues}, nil
 	default:
@@ -103,7 +110,7 @@ func getInt64ValuesDecoder(pageEncoding parquet.Encoding, typ *parquet.SchemaEle
 	}
 }
 
-func getValuesDecoder(pageEncoding parquet.Encoding, typ *parquet.SchemaElement, dictValues []interface{}) (valuesDecoder, error) {
+func getValuesDecoder(pageEncoding parquet.Encoding, typ *parquet.SchemaElement, dictValues valuesBuffer) (valuesDecoder, error) {
 	// Change the deprecated value
 	if pageEncoding == parquet.Encoding_PLAIN_DICTIONARY {
 		pageEncoding = parquet.Encoding_RLE_DICTIONARY
@@ -125,6 +132,8 @@ func getValuesDecoder(pageEncoding parquet.Encoding, typ *parquet.SchemaElement,
 		switch pageEncoding {
 		case parquet.Encoding_PLAIN:
 			return &floatPlainDecoder{}, nil
+		case parquet.Encoding_BYTE_STREAM_SPLIT:
+			return newFloatByteStreamSplitDecoder(), nil
 		case parquet.Encoding_RLE_DICTIONARY:
 			return &dictDecoder{uniqueValues: dictValues}, nil
 		}
@@ -133,6 +142,8 @@ func getValuesDecoder(pageEncoding parquet.Encoding, typ *parquet.SchemaElement,
 		switch pageEncoding {
 		case parquet.Encoding_PLAIN:
 			return &doublePlainDecoder{}, nil
+		case parquet.Encoding_BYTE_STREAM_SPLIT:
+			return newDoubleByteStreamSplitDecoder(), nil
 		case parquet.Encoding_RLE_DICTIONARY:
 			return &dictDecoder{uniqueValues: dictValues}, nil
 		}
@@ -179,7 +190,7 @@ func readPageBlock(r io.Reader, codec parquet.CompressionCodec, compressedSize i
 	return dataPageBlock, nil
 }
 
-func (f *FileReader) readPages(ctx context.Context, r *offsetReader, col *Column, chunkMeta *parquet.ColumnMetaData, dDecoder, rDecoder getLevelDecoder) (pages []pageReader, useDict bool, err error) {
+func (f *FileReader) readPages(ctx context.Context, r *offsetReader, col *Column, chunkMeta *parquet.ColumnMetaData, cc *columnCrypto, dDecoder, rDecoder getLevelDecoder) (pages []pageReader, useDict bool, err error) {
 	var (
 		dictPage *dictPageReader
 	)
@@ -188,8 +199,18 @@ func (f *FileReader) readPages(ctx context.Context, r *offsetReader, col *Column
 		if chunkMeta.TotalCompressedSize-r.Count() <= 0 {
 			break
 		}
-		ph := &parquet.PageHeader{}
-		if err := readThrift(ctx, ph, r); err != nil {
+		var (
+			ph           = &parquet.PageHeader{}
+			pr io.Reader = r
+		)
+		if cc != nil {
+			// the dictionary page, if any, is the first page of the column chunk, and the
+			// data pages are numbered in order.
+			dictionary := dictPage == nil && len(pages) == 0 && chunkMeta.DictionaryPageOffset != nil
+			if ph, pr, err = f.readEncryptedPage(ctx, r, cc, dictionary, len(pages)); err != nil {
+				return nil, false, err
+			}
+		} else if err := readThrift(ctx, ph, r); err != nil {
 			return nil, false, err
 		}
 
@@ -198,6 +219,7 @@ func (f *FileReader) readPages(ctx context.Context, r *offsetReader, col *Column
 				return nil, false, errors.New("there should be only one dictionary")
 			}
 			p := &dictPageReader{
+				typ:         *col.Element().Type,
 				alloc:       f.allocTracker,
 				validateCRC: f.schemaReader.validateCRC,
 			}
@@ -209,7 +231,7 @@ func (f *FileReader) readPages(ctx context.Context, r *offsetReader, col *Column
 				return nil, false, err
 			}
 
-			if err := p.read(r, ph, chunkMeta.Codec); err != nil {
+			if err := p.read(pr, ph, chunkMeta.Codec); err != nil {
 				return nil, false, err
 			}
 
@@ -231,29 +253,32 @@ func (f *FileReader) readPages(ctx context.Context, r *offsetReader, col *Column
 		switch ph.Type {
 		case parquet.PageType_DATA_PAGE:
 			p = &dataPageReaderV1{
+				typ:   *col.Element().Type,
 				alloc: f.allocTracker,
 				ph:    ph,
 			}
 		case parquet.PageType_DATA_PAGE_V2:
 			p = &dataPageReaderV2{
+				typ:   *col.Element().Type,
 				alloc: f.allocTracker,
 				ph:    ph,
 			}
 		default:
 			return nil, false, fmt.Errorf("DATA_PAGE or DATA_PAGE_V2 type supported, but was %s", ph.Type)
 		}
-		var dictValue []interface{}
+		// the dictionary values are only read by the decoders, so all pages can share them.
+		var dictValue valuesBuffer
 		if dictPage != nil {
 			dictValue = dictPage.values
 		}
 		var fn = func(typ parquet.Encoding) (valuesDecoder, error) {
-			return getValuesDecoder(typ, col.Element(), clone(dictValue))
+			return getValuesDecoder(typ, col.Element(), dictValue)
 		}
 		if err := p.init(dDecoder, rDecoder, fn); err != nil {
 			return nil, false, err
 		}
 
-		if err := p.read(r, ph, chunkMeta.Codec, f.schemaReader.validateCRC); err != nil {
+		if err := p.read(pr, ph, chunkMeta.Codec, f.schemaReader.validateCRC); err != nil {
 			return nil, false, err
 		}
 		pages = append(pages, p)
@@ -262,15 +287,41 @@ func (f *FileReader) readPages(ctx context.Context, r *offsetReader, col *Column
 	return pages, dictPage != nil, nil
 }
 
-func clone(in []interface{}) []interface{} {
-	out := make([]interface{}, len(in))
-	copy(out, in)
-	return out
+// readEncryptedPage reads and decrypts the page header and the page that follow in r. It returns
+// the page header, with the compressed page size adjusted to the decrypted page, and a reader
+// for the decrypted page.
+func (f *FileReader) readEncryptedPage(ctx context.Context, r io.Reader, cc *columnCrypto, dictionary bool, pageOrdinal int) (*parquet.PageHeader, io.Reader, error) {
+	headerType, pageType := moduleDataPageHeader, moduleDataPage
+	if dictionary {
+		headerType, pageType, pageOrdinal = moduleDictionaryPageHeader, moduleDictionaryPage, 0
+	}
+
+	ph := &parquet.PageHeader{}
+	if err := cc.readThrift(ctx, headerType, pageOrdinal, ph, r, f.allocTracker); err != nil {
+		return nil, nil, fmt.Errorf("reading page header failed: %w", err)
+	}
+
+	module, err := readModule(r, f.allocTracker)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(module) != int(ph.CompressedPageSize) {
+		return nil, nil, fmt.Errorf("encrypted page has %d bytes, but the page header states %d bytes", len(module), ph.CompressedPageSize)
+	}
+
+	data, err := cc.decrypt(pageType, pageOrdinal, module)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decrypting page failed: %w", err)
+	}
+	ph.CompressedPageSize = int32(len(data))
+
+	return ph, bytes.NewReader(data), nil
 }
 
 func (f *FileReader) skipChunk(col *Column, chunk *parquet.ColumnChunk) error {
 	if chunk.FilePath != nil {
-		return fmt.Errorf("nyi: data is in another file: '%s'", *chunk.FilePath)
+		// the data is in another file, so there's nothing to skip in this one.
+		return nil
 	}
 
 	c := col.Index()
@@ -278,6 +329,10 @@ func (f *FileReader) skipChunk(col *Column, chunk *parquet.ColumnChunk) error {
 	// as we cannot read it from r
 	// see https://issues.apache.org/jira/browse/PARQUET-291
 	if chunk.MetaData == nil {
+		if chunk.CryptoMetadata != nil {
+			// the column is encrypted and its key isn't available, which is fine as long as it isn't read.
+			return nil
+		}
 		return fmt.Errorf("missing meta data for Column %c", c)
 	}
 
@@ -296,16 +351,15 @@ func (f *FileReader) skipChunk(col *Column, chunk *parquet.ColumnChunk) error {
 	return err
 }
 
-func (f *FileReader) readChunk(ctx context.Context, col *Column, chunk *parquet.ColumnChunk) (pages []pageReader, useDict bool, err error) {
-	if chunk.FilePath != nil {
-		return nil, false, fmt.Errorf("nyi: data is in another file: '%s'", *chunk.FilePath)
-	}
-
+func (f *FileReader) readChunk(ctx context.Context, col *Column, chunk *parquet.ColumnChunk, cc *columnCrypto) (pages []pageReader, useDict bool, err error) {
 	c := col.Index()
 	// chunk.FileOffset is useless so ChunkMetaData is required here
 	// as we cannot read it from r
 	// see https://issues.apache.org/jira/browse/PARQUET-291
 	if chunk.MetaData == nil {
+		if chunk.CryptoMetadata != nil {
+			return nil, false, fmt.Errorf("column %q is encrypted and its key is not available", col.path.flatName())
+		}
 		return nil, false, fmt.Errorf("missing meta data for Column %c", c)
 	}
 
@@ -318,13 +372,18 @@ func (f *FileReader) readChunk(ctx context.Context, col *Column, chunk *parquet.
 	if chunk.MetaData.DictionaryPageOffset != nil {
 		offset = *chunk.MetaData.DictionaryPageOffset
 	}
+	r, err := f.chunkReader(chunk)
+	if err != nil {
+		return nil, false, err
+	}
+
 	// Seek to the beginning of the first Page
-	if _, err := f.reader.Seek(offset, io.SeekStart); err != nil {
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
 		return nil, false, err
 	}
 
 	reader := &offsetReader{
-		inner:  f.reader,
+		inner:  r,
 		offset: offset,
 		count:  0,
 	}
@@ -358,14 +417,14 @@ func (f *FileReader) readChunk(ctx context.Context, col *Column, chunk *parquet.
 			return &levelDecoderWrapper{decoder: constDecoder(0), max: col.MaxDefinitionLevel()}, nil
 		}
 	}
-	return f.readPages(ctx, reader, col, chunk.MetaData, dDecoder, rDecoder)
+	return f.readPages(ctx, reader, col, chunk.MetaData, cc, dDecoder, rDecoder)
 }
 
 func readPageData(col *Column, pages []pageReader, useDict bool) error {
 	s := col.getColumnStore()
-	s.pageIdx, s.pages = 0, pages
+	s.pageIdx, s.pages, s.skippedRows = 0, pages, 0
 	s.useDict = useDict
-	if err := s.readNextPage(); err != nil {
+	if err := s.readNextPage(int32(col.maxD)); err != nil {
 		return nil
 	}
 
@@ -384,20 +443,26 @@ func (f *FileReader) readRowGroupData(ctx context.Context) error {
 			return fmt.Errorf("column index %d is out of bounds", idx)
 		}
 		chunk := rowGroup.Columns[c.Index()]
-		if !f.schemaReader.isSelectedByPath(c.path) {
+		selected := f.schemaReader.isSelectedByPath(c.path)
+		if !selected && !f.schemaReader.isFilterColumn(c.path) {
 			if err := f.skipChunk(c, chunk); err != nil {
 				return err
 			}
 			c.data.skipped = true
 			continue
 		}
-		pages, useDict, err := f.readChunk(ctx, c, chunk)
+		cc, err := f.columnCrypto(f.rowGroupPosition-1, chunk)
+		if err != nil {
+			return fmt.Errorf("column %q: %w", c.path.flatName(), err)
+		}
+		pages, useDict, err := f.readChunk(ctx, c, chunk, cc)
 		if err != nil {
 			return err
 		}
 		if err := readPageData(c, pages, useDict); err != nil {
 			return err
 		}
+		c.data.filterOnly = !selected
 	}
 
 	return nil
@@ -3,9 +3,10 @@ package goparquet
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
-	"math"
 	"sort"
+	"sync"
 
 	"github.com/fraugster/parquet-go/parquet"
 )
@@ -21,7 +22,7 @@ func getBooleanValuesEncoder(pageEncoding parquet.Encoding) (valuesEncoder, erro
 	}
 }
 
-func getByteArrayValuesEncoder(pageEncoding parquet.Encoding, dictValues []interface{}) (valuesEncoder, error) {
+func getByteArrayValuesEncoder(pageEncoding parquet.Encoding, dictValues valuesBuffer) (valuesEncoder, error) {
 	switch pageEncoding {
 	case parquet.Encoding_PLAIN:
 		return &byteArrayPlainEncoder{}, nil
@@ -34,18 +35,20 @@ func getByteArrayValuesEncoder(pageEncoding parquet.Encoding, dictValues []inter
 	}
 }
 
-func getFixedLenByteArrayValuesEncoder(pageEncoding parquet.Encoding, len int, dictValues []interface{}) (valuesEncoder, error) {
+func getFixedLenByteArrayValuesEncoder(pageEncoding parquet.Encoding, len int, dictValues valuesBuffer) (valuesEncoder, error) {
 	switch pageEncoding {
 	case parquet.Encoding_PLAIN:
 		return &byteArrayPlainEncoder{length: len}, nil
 	case parquet.Encoding_DELTA_BYTE_ARRAY:
 		return &byteArrayDeltaEncoder{}, nil
+	case parquet.Encoding_BYTE_STREAM_SPLIT:
+		return newFixedLenByteArrayByteStreamSplitEncoder(len), nil
 	default:
 		return nil, fmt.Errorf("unsupported encoding %s for fixed_len_byte_array(%d)", pageEncoding, len)
 	}
 }
 
-func getInt32ValuesEncoder(pageEncoding parquet.Encoding, typ *parquet.SchemaElement, dictValues []interface{}) (valuesEncoder, error) {
+func getInt32ValuesEncoder(pageEncoding parquet.Encoding, typ *parquet.SchemaElement, dictValues valuesBuffer) (valuesEncoder, error) {
 	switch pageEncoding {
 	case parquet.Encoding_PLAIN:
 		return &int32PlainEncoder{}, nil
@@ -56,12 +59,14 @@ func getInt32ValuesEncoder(pageEncoding parquet.Encoding, typ *parquet.SchemaEle
 				miniBlockCount: 4,
 			},
 		}, nil
+	case parquet.Encoding_BYTE_STREAM_SPLIT:
+		return newInt32ByteStreamSplitEncoder(), nil
 	default:
 		return nil, fmt.Errorf("unsupported encoding %s for int32", pageEncoding)
 	}
 }
 
-func getInt64ValuesEncoder(pageEncoding parquet.Encoding, typ *parquet.SchemaElement, dictValues []interface{}) (valuesEncoder, error) {
+func getInt64ValuesEncoder(pageEncoding parquet.Encoding, typ *parquet.SchemaElement, dictValues valuesBuffer) (valuesEncoder, error) {
 	switch pageEncoding {
 	case parquet.Encoding_PLAIN:
 		return &int64PlainEncoder{}, nil
@@ -72,12 +77,14 @@ func getInt64ValuesEncoder(pageEncoding parquet.Encoding, typ *parquet.SchemaEle
 				miniBlockCount: 4,
 			},
 		}, nil
+	case parquet.Encoding_BYTE_STREAM_SPLIT:
+		return newInt64ByteStreamSplitEncoder(), nil
 	default:
 		return nil, fmt.Errorf("unsupported encoding %s for int64", pageEncoding)
 	}
 }
 
-func getValuesEncoder(pageEncoding parquet.Encoding, typ *parquet.SchemaElement, dictValues []interface{}) (valuesEncoder, error) {
+func getValuesEncoder(pageEncoding parquet.Encoding, typ *parquet.SchemaElement, dictValues valuesBuffer) (valuesEncoder, error) {
 	// Change the deprecated value
 	if pageEncoding == parquet.Encoding_PLAIN_DICTIONARY {
 		pageEncoding = parquet.Encoding_RLE_DICTIONARY
@@ -100,12 +107,16 @@ func getValuesEncoder(pageEncoding parquet.Encoding, typ *parquet.SchemaElement,
 		switch pageEncoding {
 		case parquet.Encoding_PLAIN:
 			return &floatPlainEncoder{}, nil
+		case parquet.Encoding_BYTE_STREAM_SPLIT:
+			return newFloatByteStreamSplitEncoder(), nil
 		}
 
 	case parquet.Type_DOUBLE:
 		switch pageEncoding {
 		case parquet.Encoding_PLAIN:
 			return &doublePlainEncoder{}, nil
+		case parquet.Encoding_BYTE_STREAM_SPLIT:
+			return newDoubleByteStreamSplitEncoder(), nil
 		}
 
 	case parquet.Type_INT32:
@@ -151,7 +162,9 @@ func getDictValuesEncoder(typ *parquet.SchemaElement) (valuesEncoder, error) {
 	return nil, fmt.Errorf("type %s is not supported for dict value encoder", typ)
 }
 
-func writeChunk(ctx context.Context, w writePos, sch *schema, col *Column, codec parquet.CompressionCodec, pageFn newDataPageFunc, kvMetaData map[string]string) (*parquet.ColumnChunk, error) {
+func writeChunk(ctx context.Context, w writePos, sch *schema, col *Column, codec parquet.CompressionCodec, pageFn newDataPageFunc, kvMetaData map[string]string) (*parquet.ColumnChunk, *chunkIndex, error) {
+	codec = sch.compressionCodec(col, codec)
+
 	pos := w.Pos() // Save the position before writing data
 	chunkOffset := pos
 	var (
@@ -167,44 +180,25 @@ func writeChunk(ctx context.Context, w writePos, sch *schema, col *Column, codec
 	)
 
 	// flush final data page before writing dictionary page (if applicable) and all data pages.
-	if err := col.data.flushPage(sch, true); err != nil {
-		return nil, err
+	if err := sch.flushColumnPage(col, true); err != nil {
+		return nil, nil, err
 	}
 
-	dictValues := []interface{}{}
-	indices := map[interface{}]int32{}
-	useDict := true
-
-	if *col.Type() == parquet.Type_BOOLEAN { // never ever use dictionary encoding on booleans.
-		useDict = false
-	}
-	if !col.data.useDictionary() {
-		useDict = false
+	if col.data.spilled != nil {
+		return writeSpilledChunk(ctx, w, sch, col, codec, kvMetaData)
 	}
 
-	if useDict {
-	outerLoop:
-		for _, page := range col.data.dataPages {
-			if page.stats.DistinctCount != nil && *page.stats.DistinctCount > math.MaxInt16 {
-				useDict = false
-				break outerLoop
-			}
-			for _, v := range page.values {
-				k := mapKey(v)
-				if idx, ok := indices[k]; !ok {
-					idx = int32(len(dictValues))
-					indices[k] = idx
-					dictValues = append(dictValues, v)
-					page.indexList = append(page.indexList, idx)
-
-					if len(dictValues) > math.MaxInt16 {
-						useDict = false
-						break outerLoop
-					}
-				} else {
-					page.indexList = append(page.indexList, idx)
-				}
-			}
+	var (
+		dictValues valuesBuffer
+		useDict    bool
+		encodings  chunkEncodings
+	)
+
+	for _, page := range col.data.dataPages {
+		if page.dictionary {
+			dictValues = col.data.dict.values()
+			useDict = true
+			break
 		}
 	}
 
@@ -213,12 +207,13 @@ func writeChunk(ctx context.Context, w writePos, sch *schema, col *Column, codec
 		dictPageOffset = &tmp
 		dict := &dictPageWriter{}
 		if err := dict.init(sch, col, codec, dictValues); err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		compSize, unCompSize, err := dict.write(ctx, w)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
+		encodings.addPage(dict.header())
 		totalComp = w.Pos() - pos
 		// Header size plus the rLevel and dLevel size
 		headerSize := totalComp - int64(compSize)
@@ -229,104 +224,354 @@ func writeChunk(ctx context.Context, w writePos, sch *schema, col *Column, codec
 	var (
 		compSize, unCompSize  int
 		numValues, nullValues int64
+		firstRowIndex         int64
 	)
 
+	index := newChunkIndex(col.Element())
+
+	if fpp, ok := sch.bloomFilterFPP(col.path); ok && *col.Type() != parquet.Type_BOOLEAN {
+		bloomFilter, err := buildBloomFilter(*col.Type(), col.data.dataPages, fpp)
+		if err != nil {
+			return nil, nil, err
+		}
+		index.bloomFilter = bloomFilter
+	}
+
 	for _, page := range col.data.dataPages {
-		pw := pageFn(useDict, dictValues, page, sch.enableCRC)
+		pw := pageFn(page.dictionary, dictValues, page, sch.enableCRC)
 
 		if err := pw.init(col, codec); err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		var buf bytes.Buffer
 
 		compressed, uncompressed, err := pw.write(ctx, &buf)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
+		encodings.addPage(pw.header())
 
 		compSize += compressed
 		unCompSize += uncompressed
 		numValues += page.numValues
 		nullValues += page.nullValues
+
+		index.addPage(w.Pos(), int32(buf.Len()), firstRowIndex, page)
+		firstRowIndex += page.numRows
+
 		if _, err := w.Write(buf.Bytes()); err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
+	stats := &parquet.Statistics{
+		MinValue:      col.data.getStats().minValue(),
+		MaxValue:      col.data.getStats().maxValue(),
+		NullCount:     &nullValues,
+		DistinctCount: col.data.dict.distinctCount(),
+	}
+
 	col.data.dataPages = nil
+	col.data.dict = nil
 
 	totalComp += w.Pos() - pos
 	// Header size plus the rLevel and dLevel size
 	headerSize := totalComp - int64(compSize)
 	totalUnComp += int64(unCompSize) + headerSize
 
-	encodings := make([]parquet.Encoding, 0, 3)
-	encodings = append(encodings,
-		parquet.Encoding_RLE,
-		col.data.encoding(),
+	ch := &parquet.ColumnChunk{
+		FilePath:   nil, // No support for external
+		FileOffset: chunkOffset,
+		MetaData: &parquet.ColumnMetaData{
+			Type:                  col.data.parquetType(),
+			Encodings:             encodings.encodings,
+			PathInSchema:          col.path,
+			Codec:                 codec,
+			NumValues:             numValues + nullValues,
+			TotalUncompressedSize: totalUnComp,
+			TotalCompressedSize:   totalComp,
+			KeyValueMetadata:      keyValueMetaDataList(kvMetaData),
+			DataPageOffset:        pos,
+			IndexPageOffset:       nil,
+			DictionaryPageOffset:  dictPageOffset,
+			Statistics:            stats,
+			EncodingStats:         encodings.stats,
+		},
+		OffsetIndexOffset: nil,
+		OffsetIndexLength: nil,
+		ColumnIndexOffset: nil,
+		ColumnIndexLength: nil,
+	}
+	index.chunk = ch
+
+	return ch, index, nil
+}
+
+// writeSpilledChunk writes a column chunk whose data pages have been written to the page spill.
+// The dictionary page is written first, if any of the pages are dictionary-encoded, followed by
+// all data pages, which are copied from the page spill.
+func writeSpilledChunk(ctx context.Context, w writePos, sch *schema, col *Column, codec parquet.CompressionCodec, kvMetaData map[string]string) (*parquet.ColumnChunk, *chunkIndex, error) {
+	sc := col.data.spilled
+	chunkOffset := w.Pos()
+
+	var (
+		dictPageOffset        *int64
+		totalComp             int64
+		totalUnComp           int64
+		numValues, nullValues int64
+		firstRowIndex         int64
+		encodings             chunkEncodings
 	)
+
+	useDict := false
+	for _, sp := range sc.pages {
+		if sp.page.dictionary {
+			useDict = true
+			break
+		}
+	}
+
 	if useDict {
-		encodings[1] = parquet.Encoding_PLAIN // In dictionary we use PLAIN for the data, not the column encoding
-		encodings = append(encodings, parquet.Encoding_RLE_DICTIONARY)
+		dict := &dictPageWriter{}
+		if err := dict.init(sch, col, codec, col.data.dict.values()); err != nil {
+			return nil, nil, err
+		}
+		compSize, unCompSize, err := dict.write(ctx, w)
+		if err != nil {
+			return nil, nil, err
+		}
+		encodings.addPage(dict.header())
+		totalComp = w.Pos() - chunkOffset
+		totalUnComp = int64(unCompSize) + totalComp - int64(compSize)
+		dictPageOffset = &chunkOffset
 	}
 
-	keyValueMetaData := make([]*parquet.KeyValue, 0, len(kvMetaData))
-	for k, v := range kvMetaData {
-		value := v
-		keyValueMetaData = append(keyValueMetaData, &parquet.KeyValue{Key: k, Value: &value})
+	dataPageOffset := w.Pos()
+
+	index := newChunkIndex(col.Element())
+
+	if fpp, ok := sch.bloomFilterFPP(col.path); ok && sc.bloomFilterHashes != nil {
+		index.bloomFilter = newBloomFilterFromHashes(sc.bloomFilterHashes, fpp)
 	}
-	sort.Slice(keyValueMetaData, func(i, j int) bool {
-		return keyValueMetaData[i].Key < keyValueMetaData[j].Key
-	})
 
-	distinctCount := int64(len(dictValues))
+	for _, sp := range sc.pages {
+		index.addPage(w.Pos(), int32(sp.size), firstRowIndex, sp.page)
+		firstRowIndex += sp.page.numRows
+
+		if err := sch.pageSpill.copyTo(w, sp.offset, sp.size); err != nil {
+			return nil, nil, err
+		}
+		encodings.addPage(sp.header)
+
+		totalComp += sp.size
+		totalUnComp += int64(sp.unCompSize) + sp.size - int64(sp.compSize)
+		numValues += sp.page.numValues
+		nullValues += sp.page.nullValues
+	}
 
 	stats := &parquet.Statistics{
 		MinValue:      col.data.getStats().minValue(),
 		MaxValue:      col.data.getStats().maxValue(),
 		NullCount:     &nullValues,
-		DistinctCount: &distinctCount,
+		DistinctCount: col.data.dict.distinctCount(),
 	}
 
+	col.data.spilled = nil
+	col.data.dict = nil
+
 	ch := &parquet.ColumnChunk{
 		FilePath:   nil, // No support for external
 		FileOffset: chunkOffset,
 		MetaData: &parquet.ColumnMetaData{
 			Type:                  col.data.parquetType(),
-			Encodings:             encodings,
+			Encodings:             encodings.encodings,
 			PathInSchema:          col.path,
 			Codec:                 codec,
 			NumValues:             numValues + nullValues,
 			TotalUncompressedSize: totalUnComp,
 			TotalCompressedSize:   totalComp,
-			KeyValueMetadata:      keyValueMetaData,
-			DataPageOffset:        pos,
+			KeyValueMetadata:      keyValueMetaDataList(kvMetaData),
+			DataPageOffset:        dataPageOffset,
 			IndexPageOffset:       nil,
 			DictionaryPageOffset:  dictPageOffset,
 			Statistics:            stats,
-			EncodingStats:         nil,
+			EncodingStats:         encodings.stats,
 		},
-		OffsetIndexOffset: nil,
-		OffsetIndexLength: nil,
-		ColumnIndexOffset: nil,
-		ColumnIndexLength: nil,
 	}
+	index.chunk = ch
+
+	return ch, index, nil
+}
+
+// chunkEncodings collects the encodings of all pages of a column chunk as they are written.
+type chunkEncodings struct {
+	encodings []parquet.Encoding
+	stats     []*parquet.PageEncodingStats
+}
 
-	return ch, nil
+func (ce *chunkEncodings) addEncoding(enc parquet.Encoding) {
+	for _, e := range ce.encodings {
+		if e == enc {
+			return
+		}
+	}
+	ce.encodings = append(ce.encodings, enc)
+}
+
+// addPage records the encodings used by the page with the header ph, including the encodings
+// of its repetition and definition levels.
+func (ce *chunkEncodings) addPage(ph *parquet.PageHeader) {
+	var enc parquet.Encoding
+	switch ph.Type {
+	case parquet.PageType_DICTIONARY_PAGE:
+		enc = ph.DictionaryPageHeader.Encoding
+	case parquet.PageType_DATA_PAGE:
+		ce.addEncoding(ph.DataPageHeader.RepetitionLevelEncoding)
+		ce.addEncoding(ph.DataPageHeader.DefinitionLevelEncoding)
+		enc = ph.DataPageHeader.Encoding
+	case parquet.PageType_DATA_PAGE_V2:
+		ce.addEncoding(parquet.Encoding_RLE) // levels are always RLE-encoded in data pages v2.
+		enc = ph.DataPageHeaderV2.Encoding
+	default:
+		return
+	}
+	ce.addEncoding(enc)
+
+	for _, st := range ce.stats {
+		if st.PageType == ph.Type && st.Encoding == enc {
+			st.Count++
+			return
+		}
+	}
+	ce.stats = append(ce.stats, &parquet.PageEncodingStats{PageType: ph.Type, Encoding: enc, Count: 1})
+}
+
+// keyValueMetaDataList converts the key-value meta data of a column chunk into a list that is
+// sorted by key.
+func keyValueMetaDataList(kvMetaData map[string]string) []*parquet.KeyValue {
+	keyValueMetaData := make([]*parquet.KeyValue, 0, len(kvMetaData))
+	for k, v := range kvMetaData {
+		value := v
+		keyValueMetaData = append(keyValueMetaData, &parquet.KeyValue{Key: k, Value: &value})
+	}
+	sort.Slice(keyValueMetaData, func(i, j int) bool {
+		return keyValueMetaData[i].Key < keyValueMetaData[j].Key
+	})
+	return keyValueMetaData
 }
 
-func writeRowGroup(ctx context.Context, w writePos, sch *schema, codec parquet.CompressionCodec, pageFn newDataPageFunc, h *flushRowGroupOptionHandle) ([]*parquet.ColumnChunk, error) {
+func writeRowGroup(ctx context.Context, w writePos, sch *schema, codec parquet.CompressionCodec, pageFn newDataPageFunc, h *flushRowGroupOptionHandle, concurrency int) ([]*parquet.ColumnChunk, []*chunkIndex, error) {
 	dataCols := sch.Columns()
-	var res = make([]*parquet.ColumnChunk, 0, len(dataCols))
+	if concurrency > 1 && len(dataCols) > 1 {
+		return writeRowGroupConcurrently(ctx, w, sch, dataCols, codec, pageFn, h, concurrency)
+	}
+
+	var (
+		res     = make([]*parquet.ColumnChunk, 0, len(dataCols))
+		indexes = make([]*chunkIndex, 0, len(dataCols))
+	)
 	for _, ci := range dataCols {
-		ch, err := writeChunk(ctx, w, sch, ci, codec, pageFn, h.getMetaData(ci.Path()))
+		ch, index, err := writeChunk(ctx, w, sch, ci, codec, pageFn, h.getMetaData(ci.Path()))
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		res = append(res, ch)
+		indexes = append(indexes, index)
 	}
 
-	return res, nil
+	return res, indexes, nil
+}
+
+type chunkResult struct {
+	done  chan struct{}
+	buf   *bytes.Buffer
+	chunk *parquet.ColumnChunk
+	index *chunkIndex
+	err   error
+}
+
+// writeRowGroupConcurrently encodes and compresses the column chunks of a row group on
+// concurrency worker goroutines. Every column chunk is written to its own buffer, and
+// the buffers are written to w in schema order as soon as they are available, so that
+// the resulting file is identical to the one written by writeRowGroup.
+func writeRowGroupConcurrently(ctx context.Context, w writePos, sch *schema, dataCols []*Column, codec parquet.CompressionCodec, pageFn newDataPageFunc, h *flushRowGroupOptionHandle, concurrency int) ([]*parquet.ColumnChunk, []*chunkIndex, error) {
+	results := make([]*chunkResult, len(dataCols))
+	jobs := make(chan int, len(dataCols))
+	for i := range dataCols {
+		results[i] = &chunkResult{done: make(chan struct{})}
+		jobs <- i
+	}
+	close(jobs)
+
+	if concurrency > len(dataCols) {
+		concurrency = len(dataCols)
+	}
+
+	// if writing fails, the remaining jobs are skipped, and all workers are waited for
+	// before returning.
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				res := results[idx]
+				select {
+				case <-stop:
+					res.err = errors.New("row group write aborted")
+				default:
+					col := dataCols[idx]
+					res.buf = &bytes.Buffer{}
+					res.chunk, res.index, res.err = writeChunk(ctx, &writePosStruct{w: res.buf}, sch, col, codec, pageFn, h.getMetaData(col.Path()))
+				}
+				close(res.done)
+			}
+		}()
+	}
+
+	var (
+		chunks  = make([]*parquet.ColumnChunk, 0, len(dataCols))
+		indexes = make([]*chunkIndex, 0, len(dataCols))
+	)
+	for _, res := range results {
+		<-res.done
+		if res.err != nil {
+			return nil, nil, res.err
+		}
+
+		shiftChunkOffsets(res.chunk, res.index, w.Pos())
+
+		if _, err := w.Write(res.buf.Bytes()); err != nil {
+			return nil, nil, err
+		}
+		res.buf = nil
+
+		chunks = append(chunks, res.chunk)
+		indexes = append(indexes, res.index)
+	}
+
+	return chunks, indexes, nil
+}
+
+// shiftChunkOffsets moves all file offsets of a column chunk and its page index by delta bytes.
+func shiftChunkOffsets(ch *parquet.ColumnChunk, index *chunkIndex, delta int64) {
+	ch.FileOffset += delta
+	ch.MetaData.DataPageOffset += delta
+	if ch.MetaData.DictionaryPageOffset != nil {
+		offset := *ch.MetaData.DictionaryPageOffset + delta
+		ch.MetaData.DictionaryPageOffset = &offset
+	}
+
+	if index != nil && index.offsetIndex != nil {
+		for _, loc := range index.offsetIndex.PageLocations {
+			loc.Offset += delta
+		}
+	}
 }
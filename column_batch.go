@@ -0,0 +1,233 @@
+package goparquet
+
+import (
+	"errors"
+	"fmt"
+)
+
+// AddColumnValues adds a batch of values to the data column identified by path, without the
+// overhead of assembling a map[string]interface{} for every row as AddData does.
+//
+// values needs to be a slice of the column's type, i.e. []bool, []int32, []int64, [][12]byte,
+// []float32, []float64 or [][]byte, and only contains the values that are not null. dLevels and
+// rLevels are the definition and repetition levels of all values, including the null values.
+// They need to be nil if the column's maximum definition or repetition level is 0, in which
+// case every value is defined or starts a new row, respectively. A batch always contains
+// complete rows, i.e. the first repetition level needs to be 0.
+//
+// All columns need to be added using AddColumnValues, and need to contain the same number of
+// rows when the row group is flushed. Rows can't be added using AddData to the same row group,
+// and sorting columns aren't supported. The row group is flushed automatically only if all
+// columns contain the same number of rows after a batch was added.
+func (fw *FileWriter) AddColumnValues(path ColumnPath, values interface{}, dLevels, rLevels []int32) error {
+	if len(fw.sortingColumns) > 0 {
+		return errors.New("column values can't be added if sorting columns are configured")
+	}
+
+	if err := fw.schemaWriter.addColumnValues(path, values, dLevels, rLevels); err != nil {
+		return err
+	}
+
+	if fw.rowGroupFlushSize > 0 && fw.schemaWriter.DataSize() >= fw.rowGroupFlushSize {
+		if _, ok := fw.schemaWriter.columnBatchRecords(); ok {
+			return fw.FlushRowGroup()
+		}
+	}
+
+	return nil
+}
+
+func (r *schema) addColumnValues(path ColumnPath, values interface{}, dLevels, rLevels []int32) error {
+	if r.numRecords > 0 && !r.columnBatches {
+		return errors.New("column values can't be added to a row group that contains rows added using AddData")
+	}
+
+	r.readOnly = 1
+	r.ensureRoot()
+
+	col := r.GetColumnByPath(path)
+	if col == nil || col.data == nil {
+		return fmt.Errorf("path %s doesn't end on a data column", path.flatName())
+	}
+
+	r.columnBatches = true
+
+	return col.data.addBatch(r, col, values, dLevels, rLevels)
+}
+
+// columnBatchRecords returns the number of rows in the current row group if all columns contain
+// the same number of rows, which is always the case if rows are added using AddData.
+func (r *schema) columnBatchRecords() (int64, bool) {
+	if !r.columnBatches {
+		return r.numRecords, true
+	}
+
+	cols := r.Columns()
+	for _, col := range cols[1:] {
+		if col.data.batchRecords != cols[0].data.batchRecords {
+			return 0, false
+		}
+	}
+
+	return cols[0].data.batchRecords, true
+}
+
+// finishColumnBatches checks that all columns that were added using AddColumnValues contain
+// the same number of rows, and sets the number of rows of the row group accordingly.
+func (r *schema) finishColumnBatches() error {
+	if !r.columnBatches {
+		return nil
+	}
+
+	numRecords, ok := r.columnBatchRecords()
+	if !ok {
+		counts := make([]string, 0, len(r.Columns()))
+		for _, col := range r.Columns() {
+			counts = append(counts, fmt.Sprintf("%s: %d", col.FlatName(), col.data.batchRecords))
+		}
+		return fmt.Errorf("all columns need to contain the same number of rows, but got %v", counts)
+	}
+
+	r.numRecords = numRecords
+	return nil
+}
+
+// addBatch adds the values with their definition and repetition levels to the column store, and
+// flushes the current data page whenever it is full at the end of a row. The values and levels
+// are validated before anything is added, so an invalid batch leaves the column store unchanged.
+func (cs *ColumnStore) addBatch(sch *schema, col *Column, values interface{}, dLevels, rLevels []int32) error {
+	buf, setStats, err := cs.batchValues(values)
+	if err != nil {
+		return fmt.Errorf("column %s: %w", col.FlatName(), err)
+	}
+	numValues := buf.len()
+
+	numLevels := numValues
+	if col.maxD > 0 {
+		numLevels = len(dLevels)
+	} else if dLevels != nil {
+		return fmt.Errorf("column %s has no definition levels", col.FlatName())
+	}
+
+	if col.maxR > 0 {
+		if len(rLevels) != numLevels {
+			return fmt.Errorf("column %s: got %d repetition levels but %d definition levels", col.FlatName(), len(rLevels), numLevels)
+		}
+		if numLevels > 0 && rLevels[0] != 0 {
+			return fmt.Errorf("column %s: the first repetition level needs to be 0", col.FlatName())
+		}
+	} else if rLevels != nil {
+		return fmt.Errorf("column %s has no repetition levels", col.FlatName())
+	}
+
+	maxD, maxR := int32(col.maxD), int32(col.maxR)
+	defined := 0
+	for i := 0; i < numLevels; i++ {
+		if col.maxD > 0 {
+			if dLevels[i] < 0 || dLevels[i] > maxD {
+				return fmt.Errorf("column %s: definition level %d is out of range", col.FlatName(), dLevels[i])
+			}
+			if dLevels[i] == maxD {
+				defined++
+			}
+		}
+		if col.maxR > 0 && (rLevels[i] < 0 || rLevels[i] > maxR) {
+			return fmt.Errorf("column %s: repetition level %d is out of range", col.FlatName(), rLevels[i])
+		}
+	}
+	if col.maxD > 0 && defined != numValues {
+		return fmt.Errorf("column %s: got %d values but %d definition levels of defined values", col.FlatName(), numValues, defined)
+	}
+
+	// the values of a row are added at once when the end of the row is reached, starting at
+	// position start of values.
+	pos, start := 0, 0
+	for i := 0; i < numLevels; i++ {
+		var dl, rl int32 = maxD, 0
+		if col.maxD > 0 {
+			dl = dLevels[i]
+		}
+		if col.maxR > 0 {
+			rl = rLevels[i]
+		}
+
+		cs.rLevels.appendSingle(rl)
+		cs.dLevels.appendSingle(dl)
+
+		if dl == maxD {
+			setStats(pos)
+			pos++
+		} else {
+			cs.values.addNull()
+		}
+
+		if i+1 == numLevels || col.maxR == 0 || rLevels[i+1] == 0 {
+			if err := cs.values.addValuesFrom(buf, start, pos); err != nil {
+				return fmt.Errorf("column %s: %w", col.FlatName(), err)
+			}
+			start = pos
+			cs.batchRecords++
+			if err := sch.flushColumnPage(col, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// batchValues returns values as a values buffer without copying them, and a function that
+// updates the statistics of the column with the value at a position. values needs to be a
+// slice of the column's type, and all of them are validated.
+func (cs *ColumnStore) batchValues(values interface{}) (valuesBuffer, func(int), error) {
+	switch s := cs.typedColumnStore.(type) {
+	case *booleanStore:
+		if v, ok := values.([]bool); ok {
+			return booleanValues(v), func(int) {}, nil
+		}
+	case *int32Store:
+		if v, ok := values.([]int32); ok {
+			return int32Values(v), func(i int) {
+				s.setMinMax(v[i])
+			}, nil
+		}
+	case *int64Store:
+		if v, ok := values.([]int64); ok {
+			return int64Values(v), func(i int) {
+				s.setMinMax(v[i])
+			}, nil
+		}
+	case *int96Store:
+		if v, ok := values.([][12]byte); ok {
+			// int96 values always have the required length of 12 bytes.
+			return int96Values(v), func(i int) {
+				_ = s.setMinMax(v[i][:])
+			}, nil
+		}
+	case *floatStore:
+		if v, ok := values.([]float32); ok {
+			return floatValues(v), func(i int) {
+				s.setMinMax(v[i])
+			}, nil
+		}
+	case *doubleStore:
+		if v, ok := values.([]float64); ok {
+			return doubleValues(v), func(i int) {
+				s.setMinMax(v[i])
+			}, nil
+		}
+	case *byteArrayStore:
+		if v, ok := values.([][]byte); ok {
+			for _, b := range v {
+				if err := s.checkSize(b); err != nil {
+					return nil, nil, err
+				}
+			}
+			return byteArrayValues(v), func(i int) {
+				_ = s.setMinMax(v[i])
+			}, nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("unsupported type %T for values of type %s", values, cs.parquetType())
+}
@@ -0,0 +1,210 @@
+package goparquet
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+const columnBatchTestSchema = `message test {
+	required int64 id;
+	optional binary name (STRING);
+	optional group tags (LIST) {
+		repeated group list {
+			required binary element (STRING);
+		}
+	}
+	repeated int32 codes;
+	required double score;
+}`
+
+// columnBatchTestData returns the rows of the test data, and the same data as the values and
+// levels of the columns.
+func columnBatchTestData(numRows int) (rows []map[string]interface{}, ids []int64, names [][]byte, nameDL []int32, tags [][]byte, tagDL, tagRL []int32, codes []int32, codeDL, codeRL []int32, scores []float64) {
+	for i := 0; i < numRows; i++ {
+		row := map[string]interface{}{"id": int64(i), "score": float64(i) / 4}
+		ids = append(ids, int64(i))
+		scores = append(scores, float64(i)/4)
+
+		if i%3 != 0 {
+			name := []byte(fmt.Sprintf("name-%d", i%7))
+			row["name"] = name
+			names = append(names, name)
+			nameDL = append(nameDL, 1)
+		} else {
+			nameDL = append(nameDL, 0)
+		}
+
+		switch i % 4 {
+		case 0:
+			tagDL = append(tagDL, 0)
+			tagRL = append(tagRL, 0)
+		case 1:
+			row["tags"] = map[string]interface{}{}
+			tagDL = append(tagDL, 1)
+			tagRL = append(tagRL, 0)
+		default:
+			var list []map[string]interface{}
+			for j := 0; j < i%4; j++ {
+				tag := []byte(fmt.Sprintf("tag-%d", (i+j)%5))
+				list = append(list, map[string]interface{}{"element": tag})
+				tags = append(tags, tag)
+				tagDL = append(tagDL, 2)
+				tagRL = append(tagRL, int32(j))
+				if j > 0 {
+					tagRL[len(tagRL)-1] = 1
+				}
+			}
+			row["tags"] = map[string]interface{}{"list": list}
+		}
+
+		if i%2 == 0 {
+			row["codes"] = []int32{int32(i), int32(i + 1)}
+			codes = append(codes, int32(i), int32(i+1))
+			codeDL = append(codeDL, 1, 1)
+			codeRL = append(codeRL, 0, 1)
+		} else {
+			codeDL = append(codeDL, 0)
+			codeRL = append(codeRL, 0)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return
+}
+
+func TestAddColumnValues(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(columnBatchTestSchema)
+	require.NoError(t, err)
+
+	rows, ids, names, nameDL, tags, tagDL, tagRL, codes, codeDL, codeRL, scores := columnBatchTestData(1000)
+
+	expected := &bytes.Buffer{}
+	fw := NewFileWriter(expected, WithSchemaDefinition(sd), WithMaxPageSize(512), WithCompressionCodec(parquet.CompressionCodec_SNAPPY))
+	for i, row := range rows {
+		require.NoError(t, fw.AddData(row))
+		if i == 599 {
+			require.NoError(t, fw.FlushRowGroup())
+		}
+	}
+	require.NoError(t, fw.Close())
+
+	actual := &bytes.Buffer{}
+	fw = NewFileWriter(actual, WithSchemaDefinition(sd), WithMaxPageSize(512), WithCompressionCodec(parquet.CompressionCodec_SNAPPY))
+
+	// the first row group is written in several batches per column, the second in a single one.
+	for _, batch := range [][2]int{{0, 250}, {250, 600}} {
+		from, to := batch[0], batch[1]
+		require.NoError(t, fw.AddColumnValues(ColumnPath{"id"}, ids[from:to], nil, nil))
+		require.NoError(t, fw.AddColumnValues(ColumnPath{"score"}, scores[from:to], nil, nil))
+	}
+	require.NoError(t, fw.AddColumnValues(ColumnPath{"name"}, names[:400], nameDL[:600], nil))
+	tagLevels := 0
+	for i, numRows := 0, 0; i < len(tagRL); i++ {
+		if tagRL[i] == 0 {
+			if numRows == 600 {
+				break
+			}
+			numRows++
+		}
+		tagLevels++
+	}
+	numTags := 0
+	for _, dl := range tagDL[:tagLevels] {
+		if dl == 2 {
+			numTags++
+		}
+	}
+	require.NoError(t, fw.AddColumnValues(ColumnPath{"tags", "list", "element"}, tags[:numTags], tagDL[:tagLevels], tagRL[:tagLevels]))
+	require.NoError(t, fw.AddColumnValues(ColumnPath{"codes"}, codes[:600], codeDL[:900], codeRL[:900]))
+	require.NoError(t, fw.FlushRowGroup())
+
+	require.NoError(t, fw.AddColumnValues(ColumnPath{"id"}, ids[600:], nil, nil))
+	require.NoError(t, fw.AddColumnValues(ColumnPath{"score"}, scores[600:], nil, nil))
+	require.NoError(t, fw.AddColumnValues(ColumnPath{"name"}, names[400:], nameDL[600:], nil))
+	require.NoError(t, fw.AddColumnValues(ColumnPath{"tags", "list", "element"}, tags[numTags:], tagDL[tagLevels:], tagRL[tagLevels:]))
+	require.NoError(t, fw.AddColumnValues(ColumnPath{"codes"}, codes[600:], codeDL[900:], codeRL[900:]))
+	require.NoError(t, fw.Close())
+
+	require.Equal(t, expected.Bytes(), actual.Bytes())
+
+	r, err := NewFileReader(bytes.NewReader(actual.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), r.NumRows())
+	for i := range rows {
+		row, err := r.NextRow()
+		require.NoError(t, err)
+		require.Equal(t, rows[i]["id"], row["id"])
+		require.Equal(t, rows[i]["name"], row["name"])
+	}
+	_, err = r.NextRow()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestAddColumnValuesErrors(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(columnBatchTestSchema)
+	require.NoError(t, err)
+
+	fw := NewFileWriter(&bytes.Buffer{}, WithSchemaDefinition(sd))
+	require.Error(t, fw.AddColumnValues(ColumnPath{"foo"}, []int64{1}, nil, nil))
+	require.Error(t, fw.AddColumnValues(ColumnPath{"tags"}, []int64{1}, nil, nil))
+	require.Error(t, fw.AddColumnValues(ColumnPath{"id"}, []int32{1}, nil, nil))
+	require.Error(t, fw.AddColumnValues(ColumnPath{"id"}, []int64{1}, []int32{0}, nil))
+	require.Error(t, fw.AddColumnValues(ColumnPath{"name"}, [][]byte{[]byte("a")}, []int32{0, 2}, nil))
+	require.Error(t, fw.AddColumnValues(ColumnPath{"name"}, [][]byte{[]byte("a")}, []int32{0, 0}, nil))
+	require.Error(t, fw.AddColumnValues(ColumnPath{"name"}, [][]byte{[]byte("a")}, []int32{1}, []int32{0}))
+	require.Error(t, fw.AddColumnValues(ColumnPath{"codes"}, []int32{1, 2}, []int32{1, 1}, []int32{1, 1}))
+	require.Error(t, fw.AddColumnValues(ColumnPath{"codes"}, []int32{1, 2}, []int32{1, 1}, []int32{0}))
+
+	require.NoError(t, fw.AddColumnValues(ColumnPath{"id"}, []int64{1, 2}, nil, nil))
+	require.Error(t, fw.AddData(map[string]interface{}{"id": int64(3), "score": float64(3)}))
+	require.Error(t, fw.FlushRowGroup())
+	require.Error(t, fw.Close())
+
+	fw = NewFileWriter(&bytes.Buffer{}, WithSchemaDefinition(sd))
+	require.NoError(t, fw.AddData(map[string]interface{}{"id": int64(3), "score": float64(3)}))
+	require.Error(t, fw.AddColumnValues(ColumnPath{"id"}, []int64{1}, nil, nil))
+
+	fw = NewFileWriter(&bytes.Buffer{}, WithSchemaDefinition(sd), WithSortingColumns(SortingColumn{Path: ColumnPath{"id"}}))
+	require.Error(t, fw.AddColumnValues(ColumnPath{"id"}, []int64{1}, nil, nil))
+}
+
+func TestAddColumnValuesInvalidValues(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		optional fixed_len_byte_array(2) a;
+	}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	fw := NewFileWriter(buf, WithSchemaDefinition(sd))
+	require.Error(t, fw.AddColumnValues(ColumnPath{"a"}, [][]byte{{1, 2}, {3}}, []int32{1, 0, 1}, nil))
+
+	// the invalid batch must not have been added partially.
+	require.NoError(t, fw.AddColumnValues(ColumnPath{"a"}, [][]byte{{4, 5}}, []int32{0, 1}, nil))
+	require.NoError(t, fw.Close())
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, int64(2), r.NumRows())
+
+	row, err := r.NextRow()
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{}, row)
+	row, err = r.NextRow()
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"a": []byte{4, 5}}, row)
+	_, err = r.NextRow()
+	require.Equal(t, io.EOF, err)
+
+	meta, err := ReadFileMetaData(bytes.NewReader(buf.Bytes()), true)
+	require.NoError(t, err)
+	stats := meta.RowGroups[0].Columns[0].MetaData.Statistics
+	require.Equal(t, []byte{4, 5}, stats.MinValue)
+	require.Equal(t, []byte{4, 5}, stats.MaxValue)
+}
@@ -0,0 +1,173 @@
+package goparquet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ColumnReader reads the values of a column in the current row group in batches, together
+// with their definition and repetition levels, without assembling rows. The values and levels
+// have the same layout as the ones passed to FileWriter.AddColumnValues.
+type ColumnReader struct {
+	col *Column
+}
+
+// ColumnReader returns a ColumnReader for the selected data column identified by path in the
+// current row group, loading the row group first if required. Reading values from the column
+// reader consumes them, so rows can't be read from the current row group anymore. The row
+// filter configured with WithRowFilter isn't applied to the values.
+func (f *FileReader) ColumnReader(path ColumnPath) (*ColumnReader, error) {
+	return f.ColumnReaderWithContext(f.ctx, path)
+}
+
+// ColumnReaderWithContext returns a ColumnReader for the selected data column identified by
+// path in the current row group, loading the row group first if required. Reading values from
+// the column reader consumes them, so rows can't be read from the current row group anymore.
+// The row filter configured with WithRowFilter isn't applied to the values.
+func (f *FileReader) ColumnReaderWithContext(ctx context.Context, path ColumnPath) (cr *ColumnReader, err error) {
+	defer f.recover(&err)
+
+	if err := f.advanceIfNeeded(ctx); err != nil {
+		return nil, err
+	}
+
+	col := f.schemaReader.GetColumnByPath(path)
+	if col == nil || col.data == nil {
+		return nil, fmt.Errorf("path %s doesn't end on a data column", path.flatName())
+	}
+
+	if !f.schemaReader.isSelectedByPath(col.path) {
+		return nil, fmt.Errorf("column %s is not selected", path.flatName())
+	}
+
+	return &ColumnReader{col: col}, nil
+}
+
+// Column returns the column that is read.
+func (cr *ColumnReader) Column() *Column {
+	return cr.col
+}
+
+// ReadValues reads the next batch of values with their definition and repetition levels.
+// values needs to be a slice of the column's type, i.e. []bool, []int32, []int64, [][12]byte,
+// []float32, []float64 or [][]byte, and is filled with the values that are not null. dLevels
+// and rLevels are filled with the levels of all values, including the null values, unless the
+// column's maximum definition or repetition level is 0, in which case they may be nil.
+//
+// The batch size is the smallest length of values and the level slices that are used, so that
+// the values always fit. A batch doesn't necessarily end at the end of a row. ReadValues returns
+// the number of levels and the number of values that were read, and io.EOF if all values of the
+// column chunk have been read.
+func (cr *ColumnReader) ReadValues(values interface{}, dLevels, rLevels []int32) (numLevels, numValues int, err error) {
+	cs := cr.col.data
+
+	n, err := cs.batchValuesLen(values)
+	if err != nil {
+		return 0, 0, fmt.Errorf("column %s: %w", cr.col.FlatName(), err)
+	}
+
+	maxD, maxR := int32(cr.col.maxD), int32(cr.col.maxR)
+	if maxD > 0 && len(dLevels) < n {
+		n = len(dLevels)
+	}
+	if maxR > 0 && len(rLevels) < n {
+		n = len(rLevels)
+	}
+
+	// the values are copied from the page's values buffer once the end of the batch or the
+	// end of the page is reached, starting at position start of the page and position
+	// batchStart of values.
+	start, batchStart := cs.pageValuePos, 0
+	copyValues := func() error {
+		if numValues == batchStart {
+			return nil
+		}
+		if err := cs.pageValues.copyTo(values, batchStart, start, cs.pageValuePos); err != nil {
+			return err
+		}
+		batchStart = numValues
+		return nil
+	}
+
+	for numLevels < n {
+		rl, dl, last := cs.getRDLevelAt(cs.readPos)
+		if last {
+			if cs.pageIdx >= len(cs.pages) {
+				break
+			}
+			if err := copyValues(); err != nil {
+				return numLevels, numValues, err
+			}
+			if err := cs.readNextPage(maxD); err != nil {
+				return numLevels, numValues, err
+			}
+			start = cs.pageValuePos
+			continue
+		}
+		cs.readPos++
+
+		if maxD > 0 {
+			dLevels[numLevels] = dl
+		}
+		if maxR > 0 {
+			rLevels[numLevels] = rl
+		}
+		numLevels++
+
+		if dl == maxD {
+			if cs.pageValues == nil || cs.pageValuePos >= cs.pageValues.len() {
+				return numLevels, numValues, errors.New("out of range")
+			}
+			cs.pageValuePos++
+			numValues++
+		}
+	}
+
+	if err := copyValues(); err != nil {
+		return numLevels, numValues, err
+	}
+
+	if numLevels == 0 && n > 0 {
+		return 0, 0, io.EOF
+	}
+
+	return numLevels, numValues, nil
+}
+
+// batchValuesLen returns the length of values, which needs to be a slice of the column's type.
+func (cs *ColumnStore) batchValuesLen(values interface{}) (int, error) {
+	switch cs.typedColumnStore.(type) {
+	case *booleanStore:
+		if v, ok := values.([]bool); ok {
+			return len(v), nil
+		}
+	case *int32Store:
+		if v, ok := values.([]int32); ok {
+			return len(v), nil
+		}
+	case *int64Store:
+		if v, ok := values.([]int64); ok {
+			return len(v), nil
+		}
+	case *int96Store:
+		if v, ok := values.([][12]byte); ok {
+			return len(v), nil
+		}
+	case *floatStore:
+		if v, ok := values.([]float32); ok {
+			return len(v), nil
+		}
+	case *doubleStore:
+		if v, ok := values.([]float64); ok {
+			return len(v), nil
+		}
+	case *byteArrayStore:
+		if v, ok := values.([][]byte); ok {
+			return len(v), nil
+		}
+	}
+
+	return 0, fmt.Errorf("unsupported type %T for values of type %s", values, cs.parquetType())
+}
@@ -0,0 +1,133 @@
+package goparquet
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnReader(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(columnBatchTestSchema)
+	require.NoError(t, err)
+
+	rows, ids, names, nameDL, tags, tagDL, tagRL, codes, codeDL, codeRL, _ := columnBatchTestData(1000)
+
+	buf := &bytes.Buffer{}
+	fw := NewFileWriter(buf, WithSchemaDefinition(sd), WithMaxPageSize(512))
+	for i, row := range rows {
+		require.NoError(t, fw.AddData(row))
+		if i == 599 {
+			require.NoError(t, fw.FlushRowGroup())
+		}
+	}
+	require.NoError(t, fw.Close())
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()), "id", "name", "tags.list.element", "codes")
+	require.NoError(t, err)
+
+	var (
+		readIDs                                []int64
+		readNames, readTags                    [][]byte
+		readNameDL, readTagDL, readTagRL       []int32
+		readCodeValues, readCodeDL, readCodeRL []int32
+	)
+	for rg := 0; rg < 2; rg++ {
+		require.NoError(t, r.PreLoad())
+
+		cr, err := r.ColumnReader(ColumnPath{"id"})
+		require.NoError(t, err)
+		values := make([]int64, 64)
+		for {
+			numLevels, numValues, err := cr.ReadValues(values, nil, nil)
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			require.Equal(t, numLevels, numValues)
+			readIDs = append(readIDs, values[:numValues]...)
+		}
+
+		cr, err = r.ColumnReader(ColumnPath{"name"})
+		require.NoError(t, err)
+		nameValues, dLevels := make([][]byte, 10), make([]int32, 7)
+		for {
+			numLevels, numValues, err := cr.ReadValues(nameValues, dLevels, nil)
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			readNames = append(readNames, nameValues[:numValues]...)
+			readNameDL = append(readNameDL, dLevels[:numLevels]...)
+		}
+
+		cr, err = r.ColumnReader(ColumnPath{"tags", "list", "element"})
+		require.NoError(t, err)
+		require.Equal(t, uint16(2), cr.Column().MaxDefinitionLevel())
+		tagValues, dLevels, rLevels := make([][]byte, 13), make([]int32, 13), make([]int32, 13)
+		for {
+			numLevels, numValues, err := cr.ReadValues(tagValues, dLevels, rLevels)
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			readTags = append(readTags, tagValues[:numValues]...)
+			readTagDL = append(readTagDL, dLevels[:numLevels]...)
+			readTagRL = append(readTagRL, rLevels[:numLevels]...)
+		}
+
+		cr, err = r.ColumnReader(ColumnPath{"codes"})
+		require.NoError(t, err)
+		codeValues, dLevels, rLevels := make([]int32, 1000), make([]int32, 1000), make([]int32, 1000)
+		numLevels, numValues, err := cr.ReadValues(codeValues, dLevels, rLevels)
+		require.NoError(t, err)
+		readCodeValues = append(readCodeValues, codeValues[:numValues]...)
+		readCodeDL = append(readCodeDL, dLevels[:numLevels]...)
+		readCodeRL = append(readCodeRL, rLevels[:numLevels]...)
+		_, _, err = cr.ReadValues(codeValues, dLevels, rLevels)
+		require.Equal(t, io.EOF, err)
+
+		r.SkipRowGroup()
+	}
+	require.Equal(t, io.EOF, r.PreLoad())
+
+	require.Equal(t, ids, readIDs)
+	require.Equal(t, names, readNames)
+	require.Equal(t, nameDL, readNameDL)
+	require.Equal(t, tags, readTags)
+	require.Equal(t, tagDL, readTagDL)
+	require.Equal(t, tagRL, readTagRL)
+	require.Equal(t, codes, readCodeValues)
+	require.Equal(t, codeDL, readCodeDL)
+	require.Equal(t, codeRL, readCodeRL)
+}
+
+func TestColumnReaderErrors(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(columnBatchTestSchema)
+	require.NoError(t, err)
+
+	rows, _, _, _, _, _, _, _, _, _, _ := columnBatchTestData(10)
+
+	buf := &bytes.Buffer{}
+	fw := NewFileWriter(buf, WithSchemaDefinition(sd))
+	for _, row := range rows {
+		require.NoError(t, fw.AddData(row))
+	}
+	require.NoError(t, fw.Close())
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()), "id")
+	require.NoError(t, err)
+
+	_, err = r.ColumnReader(ColumnPath{"score"})
+	require.Error(t, err)
+
+	_, err = r.ColumnReader(ColumnPath{"tags"})
+	require.Error(t, err)
+
+	cr, err := r.ColumnReader(ColumnPath{"id"})
+	require.NoError(t, err)
+	_, _, err = cr.ReadValues(make([]int32, 10), nil, nil)
+	require.Error(t, err)
+}
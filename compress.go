@@ -123,8 +123,8 @@ func newBlockReader(buf []byte, codec parquet.CompressionCodec, compressedSize i
 }
 
 // RegisterBlockCompressor is a function to to register additional block compressors to the package. By default,
-// only UNCOMPRESSED, GZIP and SNAPPY are supported as parquet compression algorithms. The parquet file format
-// supports more compression algorithms, such as LZO, BROTLI, LZ4 and ZSTD. To limit the amount of external dependencies,
+// only UNCOMPRESSED, GZIP, SNAPPY, LZ4_RAW, LZ4 and ZSTD are supported as parquet compression algorithms. The parquet file
+// format supports more compression algorithms, such as LZO and BROTLI. To limit the amount of external dependencies,
 // the number of supported algorithms was reduced to a core set. If you want to use any of the other compression
 // algorithms, please provide your own implementation of it in a way that satisfies the BlockCompressor interface,
 // and register it using this function from your code.
@@ -154,4 +154,7 @@ func init() {
 	RegisterBlockCompressor(parquet.CompressionCodec_UNCOMPRESSED, plainCompressor{})
 	RegisterBlockCompressor(parquet.CompressionCodec_GZIP, gzipCompressor{})
 	RegisterBlockCompressor(parquet.CompressionCodec_SNAPPY, snappyCompressor{})
+	RegisterBlockCompressor(parquet.CompressionCodec_LZ4_RAW, lz4RawCompressor{})
+	RegisterBlockCompressor(parquet.CompressionCodec_LZ4, lz4HadoopCompressor{})
+	RegisterBlockCompressor(parquet.CompressionCodec_ZSTD, NewZSTDCompressor(DefaultZSTDCompressionLevel))
 }
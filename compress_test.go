@@ -1,6 +1,10 @@
 package goparquet
 
 import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
 	"testing"
 
 	"github.com/fraugster/parquet-go/parquet"
@@ -20,6 +24,9 @@ occaecat cupidatat non proident, sunt in culpa qui officia deserunt mollit anim
 		parquet.CompressionCodec_GZIP,
 		parquet.CompressionCodec_SNAPPY,
 		parquet.CompressionCodec_UNCOMPRESSED,
+		parquet.CompressionCodec_LZ4_RAW,
+		parquet.CompressionCodec_LZ4,
+		parquet.CompressionCodec_ZSTD,
 	}
 
 	for _, m := range methods {
@@ -30,3 +37,201 @@ occaecat cupidatat non proident, sunt in culpa qui officia deserunt mollit anim
 		assert.Equal(t, block, b2)
 	}
 }
+
+func TestLZ4RoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+
+	random := make([]byte, 100000)
+	rnd.Read(random)
+
+	words := [][]byte{[]byte("parquet"), []byte("column"), []byte("page"), []byte(" "), []byte("\n")}
+	var text []byte
+	for len(text) < 200000 {
+		text = append(text, words[rnd.Intn(len(words))]...)
+	}
+
+	inputs := [][]byte{
+		{},
+		[]byte("a"),
+		[]byte("abcdefghijkl"),
+		[]byte("abcdefghijklm"),
+		bytes.Repeat([]byte("a"), 1000),
+		bytes.Repeat([]byte("abc"), 100000),
+		random,
+		text,
+	}
+
+	for _, input := range inputs {
+		compressed := lz4CompressBlock(nil, input)
+		require.True(t, len(compressed) <= lz4CompressBound(len(input)))
+
+		decompressed, err := lz4DecompressBlock(nil, compressed)
+		require.NoError(t, err)
+		require.Equal(t, len(input), len(decompressed))
+		require.True(t, bytes.Equal(input, decompressed))
+	}
+}
+
+func TestLZ4DecompressReference(t *testing.T) {
+	// block produced by the lz4 command line tool.
+	block := []byte{
+		0xff, 0x0a, 0x50, 0x61, 0x72, 0x71, 0x75, 0x65, 0x74, 0x20, 0x4c, 0x5a, 0x34, 0x20, 0x74, 0x65,
+		0x73, 0x74, 0x20, 0x76, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x2e, 0x20, 0x19, 0x00, 0x1f, 0x1f, 0x61,
+		0x01, 0x00, 0x26, 0x50, 0x20, 0x65, 0x6e, 0x64, 0x2e,
+	}
+	expected := []byte("Parquet LZ4 test vector. Parquet LZ4 test vector. Parquet LZ4 test vector. " +
+		"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa end.")
+
+	res, err := decompressBlock(block, parquet.CompressionCodec_LZ4_RAW)
+	require.NoError(t, err)
+	require.Equal(t, expected, res)
+
+	// the legacy LZ4 codec falls back to plain LZ4 blocks.
+	res, err = decompressBlock(block, parquet.CompressionCodec_LZ4)
+	require.NoError(t, err)
+	require.Equal(t, expected, res)
+
+	// Hadoop framing with the data split into two blocks.
+	var framed []byte
+	for _, part := range [][]byte{expected[:50], expected[50:]} {
+		compressed := lz4CompressBlock(nil, part)
+		hdr := make([]byte, 8)
+		binary.BigEndian.PutUint32(hdr[0:4], uint32(len(part)))
+		binary.BigEndian.PutUint32(hdr[4:8], uint32(len(compressed)))
+		framed = append(framed, hdr...)
+		framed = append(framed, compressed...)
+	}
+	res, err = decompressBlock(framed, parquet.CompressionCodec_LZ4)
+	require.NoError(t, err)
+	require.Equal(t, expected, res)
+}
+
+func TestLZ4DecompressCorrupt(t *testing.T) {
+	inputs := [][]byte{
+		{},
+		{0xf0},
+		{0x10},
+		{0x11, 'a', 0x00, 0x00},
+		{0x11, 'a', 0x02, 0x00},
+		{0x1f, 'a', 0x01, 0x00, 0xff},
+	}
+
+	for _, input := range inputs {
+		_, err := lz4DecompressBlock(nil, input)
+		require.Error(t, err, "input %x", input)
+	}
+}
+
+func TestZSTDRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+
+	random := make([]byte, 100000)
+	rnd.Read(random)
+
+	words := [][]byte{[]byte("parquet"), []byte("column"), []byte("page"), []byte(" "), []byte("\n")}
+	var text []byte
+	for len(text) < 300000 {
+		text = append(text, words[rnd.Intn(len(words))]...)
+	}
+
+	skewed := make([]byte, 50000)
+	for i := range skewed {
+		if rnd.Intn(10) == 0 {
+			skewed[i] = byte(rnd.Intn(256))
+		} else {
+			skewed[i] = byte(rnd.Intn(3))
+		}
+	}
+
+	inputs := [][]byte{
+		{},
+		[]byte("a"),
+		[]byte("hello hello hello"),
+		bytes.Repeat([]byte("a"), 200000),
+		bytes.Repeat([]byte("abc"), 100000),
+		random,
+		text,
+		skewed,
+	}
+
+	for _, level := range []int{1, DefaultZSTDCompressionLevel, 9, 22} {
+		c := NewZSTDCompressor(level)
+		for _, input := range inputs {
+			compressed, err := c.CompressBlock(input)
+			require.NoError(t, err)
+
+			decompressed, err := c.DecompressBlock(compressed)
+			require.NoError(t, err, "level %d, input of %d bytes", level, len(input))
+			require.Equal(t, len(input), len(decompressed))
+			require.True(t, bytes.Equal(input, decompressed))
+		}
+	}
+
+	compressed, err := compressBlock(text, parquet.CompressionCodec_ZSTD)
+	require.NoError(t, err)
+	require.True(t, len(compressed) < len(text)/4, "text compressed to %d bytes", len(compressed))
+}
+
+func TestZSTDDecompressReference(t *testing.T) {
+	// frame produced by the zstd command line tool with level 19.
+	frame := []byte{
+		0x28, 0xb5, 0x2f, 0xfd, 0x60, 0x9a, 0x01, 0x55, 0x06, 0x00, 0x96, 0x50, 0x28, 0x17, 0x80, 0x4b,
+		0x5a, 0x0c, 0x40, 0x9a, 0x03, 0x9b, 0x38, 0x76, 0xa4, 0xb3, 0x4c, 0xd8, 0xdd, 0xdd, 0xdd, 0x9d,
+		0x49, 0x20, 0x06, 0x7a, 0x0f, 0x2a, 0x00, 0x1e, 0x00, 0x1e, 0x00, 0xa3, 0xaf, 0xe1, 0xba, 0x76,
+		0x62, 0x8d, 0x47, 0x1f, 0xce, 0xb6, 0x97, 0x4d, 0xf3, 0x94, 0x4b, 0xe2, 0x40, 0x18, 0x1e, 0x08,
+		0x60, 0x91, 0x68, 0x1c, 0x92, 0x07, 0xd2, 0x60, 0x24, 0x0d, 0x24, 0x40, 0x18, 0x14, 0x90, 0x46,
+		0xe2, 0x50, 0x2c, 0x0c, 0x82, 0x3b, 0x96, 0x7d, 0xb3, 0x9b, 0xd9, 0x85, 0x3b, 0xd5, 0x3d, 0x34,
+		0x17, 0x2a, 0xf9, 0x56, 0xad, 0xb7, 0x27, 0xe1, 0x38, 0xf5, 0xd1, 0x46, 0x79, 0xa5, 0x4d, 0x46,
+		0x4f, 0x5d, 0x26, 0x97, 0xce, 0x44, 0xf5, 0xa8, 0x5d, 0x0c, 0x5f, 0xd4, 0x75, 0xe6, 0xbc, 0x1a,
+		0x07, 0xbf, 0xd5, 0x8d, 0xe8, 0xb2, 0x9b, 0x54, 0x9f, 0xa4, 0x4b, 0x1d, 0xbe, 0xba, 0x96, 0x3b,
+		0x2d, 0xa8, 0x2f, 0xe2, 0xaa, 0x76, 0x52, 0x8d, 0x3a, 0x1f, 0x6e, 0xb5, 0xd7, 0xe9, 0xb4, 0xf9,
+		0x5c, 0x5e, 0x2e, 0xdf, 0xca, 0xf5, 0xf2, 0xdc, 0x1c, 0x4f, 0xbf, 0xda, 0x0d, 0x03, 0x3b, 0xa8,
+		0x11, 0xa8, 0xe8, 0xee, 0x7f, 0x06, 0xb0, 0x1b, 0x03, 0x10, 0x3c, 0x1f, 0xc0, 0xc6, 0x94, 0x22,
+		0x35, 0x2a, 0x05, 0x5b, 0xaa, 0x20, 0x08, 0x5b, 0x51, 0x3c, 0x33, 0x84, 0xed, 0x94, 0x49, 0xd2,
+		0xba, 0xc4, 0x99, 0xaa,
+	}
+
+	expected := "Parquet ZSTD test vector."
+	for i := 0; i < 60; i++ {
+		expected += fmt.Sprintf(" value%d=%d", i, i*i%97)
+	}
+
+	res, err := decompressBlock(frame, parquet.CompressionCodec_ZSTD)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(res))
+
+	// a skippable frame followed by two frames.
+	multi := []byte{0x50, 0x2a, 0x4d, 0x18, 0x03, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03}
+	multi = append(multi, frame...)
+	multi = append(multi, zstdCompress(nil, []byte(" end."), DefaultZSTDCompressionLevel)...)
+
+	res, err = decompressBlock(multi, parquet.CompressionCodec_ZSTD)
+	require.NoError(t, err)
+	require.Equal(t, expected+" end.", string(res))
+}
+
+func TestZSTDDecompressCorrupt(t *testing.T) {
+	valid := zstdCompress(nil, bytes.Repeat([]byte("parquet column page "), 100), DefaultZSTDCompressionLevel)
+
+	inputs := [][]byte{
+		{},
+		{0x28, 0xb5, 0x2f},
+		{0x28, 0xb5, 0x2f, 0xfe, 0x20, 0x00, 0x01, 0x00, 0x00},
+		{0x28, 0xb5, 0x2f, 0xfd, 0x20, 0x05, 0x01, 0x00, 0x00},
+		{0x28, 0xb5, 0x2f, 0xfd, 0x28, 0x00, 0x01, 0x00, 0x00},
+		{0x28, 0xb5, 0x2f, 0xfd, 0x20, 0x00, 0x07, 0x00, 0x00},
+		valid[:len(valid)-1],
+		valid[:len(valid)/2],
+	}
+
+	for i := 10; i < len(valid)-4; i++ {
+		corrupted := append([]byte(nil), valid...)
+		corrupted[i] ^= 0x55
+		inputs = append(inputs, corrupted)
+	}
+
+	for _, input := range inputs {
+		_, err := zstdDecompress(input)
+		require.Error(t, err, "input %x", input)
+	}
+}
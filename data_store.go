@@ -20,8 +20,18 @@ type ColumnStore struct {
 	pages   []pageReader
 	pageIdx int
 
+	// skippedRows is the number of rows that have been skipped after the end of the current
+	// data page. Data pages of which all rows are skipped aren't decoded at all, the remaining
+	// rows are skipped once the next data page is read.
+	skippedRows int
+
 	values *dictStore
 
+	// pageValues are the values of the data page that is currently read, and pageValuePos is
+	// the position of the next value in it.
+	pageValues   valuesBuffer
+	pageValuePos int
+
 	dLevels *packedArray
 	rLevels *packedArray
 
@@ -32,17 +42,40 @@ type ColumnStore struct {
 
 	skipped bool
 
+	// filterOnly is set if the column is only read to evaluate a row filter, but
+	// wasn't selected, so its values are never returned.
+	filterOnly bool
+
+	// peeked holds the value of the current row if it was read ahead of time to
+	// evaluate a row filter.
+	peeked *peekedValue
+
 	dataPages []*dataPage
 
+	// dict is the dictionary of the current column chunk, if the column uses dictionary encoding.
+	dict *chunkDictionary
+
+	// spilled holds the data pages that have already been written to the page spill.
+	spilled *spilledChunk
+
 	maxPageSize int64
 
 	prevNumRecords int64 // this is just for correctly calculating how many rows are in a data page.
 
+	// batchRecords is the number of rows that have been added to the current row group using
+	// AddColumnValues.
+	batchRecords int64
+
 	alloc *allocTracker
 }
 
+type peekedValue struct {
+	value  interface{}
+	dLevel int32
+}
+
 type dataPage struct {
-	values     []interface{}
+	values     valuesBuffer
 	indexList  []int32
 	rL         *packedArray
 	dL         *packedArray
@@ -50,6 +83,7 @@ type dataPage struct {
 	nullValues int64
 	numRows    int64
 	stats      *parquet.Statistics
+	dictionary bool // true if the page is dictionary-encoded.
 }
 
 // useDictionary is simply a function to decide to use dictionary or not.
@@ -71,16 +105,25 @@ func (cs *ColumnStore) reset(rep parquet.FieldRepetitionType, maxR, maxD uint16)
 	}
 	cs.repTyp = rep
 	if cs.values == nil {
-		cs.values = &dictStore{useDict: cs.useDict, alloc: cs.alloc}
+		cs.values = &dictStore{useDict: cs.useDict}
 		cs.rLevels = &packedArray{}
 		cs.dLevels = &packedArray{}
 	}
-	cs.values.init()
+	cs.values.init(cs.newValues())
 	cs.rLevels.reset(bits.Len16(maxR))
 	cs.dLevels.reset(bits.Len16(maxD))
 	cs.readPos = 0
+	cs.pageValues = nil
+	cs.pageValuePos = 0
+	cs.skippedRows = 0
 	cs.skipped = false
+	cs.filterOnly = false
+	cs.peeked = nil
 	cs.prevNumRecords = 0
+	cs.batchRecords = 0
+	cs.dataPages = nil
+	cs.dict = nil
+	cs.spilled = nil
 
 	cs.typedColumnStore.reset(rep)
 }
@@ -106,20 +149,19 @@ func (cs *ColumnStore) add(v interface{}, dL uint16, maxRL, rL uint16) error {
 	// level is one less
 	if v == nil {
 		cs.appendRDLevel(rL, dL)
-		cs.values.addValue(nil, 0)
+		cs.values.addNull()
 		return nil
 	}
-	vals, err := cs.getValues(v)
+	n, err := cs.values.addValues(cs.typedColumnStore, v)
 	if err != nil {
 		return err
 	}
-	if len(vals) == 0 {
+	if n == 0 {
 		// the MaxRl might be increased in the beginning and increased again in the next call but for nil its not important
 		return cs.add(nil, dL, maxRL, rL)
 	}
 
-	for i, j := range vals {
-		cs.values.addValue(j, cs.sizeOf(j))
+	for i := 0; i < n; i++ {
 		tmp := dL
 		if cs.repTyp != parquet.FieldRepetitionType_REQUIRED {
 			tmp++
@@ -137,7 +179,7 @@ func (cs *ColumnStore) add(v interface{}, dL uint16, maxRL, rL uint16) error {
 
 func (cs *ColumnStore) estimateSize() (total int64) {
 	dictSize, noDictSize := cs.values.sizes()
-	if cs.useDictionary() {
+	if cs.useDictionary() && (cs.dict == nil || !cs.dict.full) {
 		total += dictSize
 	} else {
 		total += noDictSize
@@ -160,8 +202,13 @@ func (cs *ColumnStore) flushPage(sch *schema, force bool) error {
 		return nil
 	}
 
-	numRows := sch.numRecords - cs.prevNumRecords
-	cs.prevNumRecords = sch.numRecords
+	numRecords := sch.numRecords
+	if sch.columnBatches {
+		numRecords = cs.batchRecords
+	}
+
+	numRows := numRecords - cs.prevNumRecords
+	cs.prevNumRecords = numRecords
 
 	cs.dataPages = append(cs.dataPages, &dataPage{
 		values:     cs.values.getValues(),
@@ -209,18 +256,40 @@ func (cs *ColumnStore) getRDLevelAt(pos int) (int32, int32, bool) {
 	return rl, dl, false
 }
 
-func (cs *ColumnStore) getNext() (v interface{}, err error) {
-	v, err = cs.values.getNextValue()
+func (cs *ColumnStore) getNext() (interface{}, error) {
+	values, pos := cs.readValues()
+	if *pos >= values.len() {
+		return nil, errors.New("out of range")
+	}
+	*pos++
+	return values.value(*pos - 1), nil
+}
+
+// readValues returns the values that are read and a pointer to the position of the next value
+// in them. These are the values of the current data page, or the values that have been added
+// to the store if no data page has been read.
+func (cs *ColumnStore) readValues() (valuesBuffer, *int) {
+	if cs.pageValues == nil {
+		return cs.values.values, &cs.values.readPos
+	}
+	return cs.pageValues, &cs.pageValuePos
+}
+
+// newValues returns an empty buffer for the values of the column.
+func (cs *ColumnStore) newValues() valuesBuffer {
+	values, err := newValuesBuffer(cs.parquetType(), 0)
 	if err != nil {
-		return nil, err
+		panic(err)
 	}
-	return v, nil
+	return values
 }
 
 func (cs *ColumnStore) resetData() {
 	cs.readPos = 0
-	cs.values = &dictStore{useDict: cs.useDict, alloc: cs.alloc}
-	cs.values.init()
+	cs.pageValues = nil
+	cs.pageValuePos = 0
+	cs.values = &dictStore{useDict: cs.useDict}
+	cs.values.init(cs.newValues())
 
 	rLevelBitWidth := cs.rLevels.bw
 	dLevelBitWidth := cs.dLevels.bw
@@ -233,7 +302,9 @@ func (cs *ColumnStore) resetData() {
 	cs.getPageStats().reset()
 }
 
-func (cs *ColumnStore) readNextPage() error {
+// readNextPage reads the next data page, and skips the rows that have been skipped after the
+// end of the previous data page.
+func (cs *ColumnStore) readNextPage(maxD int32) error {
 	if cs.pageIdx >= len(cs.pages) {
 		return fmt.Errorf("out of range: requested page index = %d total number of pages = %d", cs.pageIdx, len(cs.pages))
 	}
@@ -247,15 +318,31 @@ func (cs *ColumnStore) readNextPage() error {
 
 	cs.resetData()
 
-	cs.values.readPos = 0
-
-	for _, v := range data {
-		cs.values.addValue(v, cs.sizeOf(v))
+	cs.pageValues = data
+	if values, ok := data.(byteArrayValues); ok {
+		// the values of the other types are part of the page's values buffer, while every
+		// byte array is allocated separately.
+		for _, v := range values {
+			cs.alloc.register(v, uint64(len(v)))
+		}
 	}
 
 	cs.rLevels.appendArray(rl)
 	cs.dLevels.appendArray(dl)
 
+	// rows are only skipped across data pages if the column isn't repeated, so every level
+	// is a row.
+	for ; cs.skippedRows > 0; cs.skippedRows-- {
+		_, dl, last := cs.getRDLevelAt(cs.readPos)
+		if last {
+			return errors.New("out of range")
+		}
+		if dl == maxD {
+			cs.pageValuePos++
+		}
+		cs.readPos++
+	}
+
 	return nil
 }
 
@@ -264,8 +351,94 @@ func (cs *ColumnStore) get(maxD, maxR int32) (interface{}, int32, error) {
 		return nil, 0, nil
 	}
 
+	if cs.peeked != nil {
+		p := cs.peeked
+		cs.peeked = nil
+		if cs.filterOnly {
+			return nil, 0, nil
+		}
+		return p.value, p.dLevel, nil
+	}
+
+	if cs.filterOnly {
+		return nil, 0, cs.skipRow(maxD, maxR)
+	}
+
+	return cs.read(maxD, maxR)
+}
+
+// peek reads the value of the current row without consuming it, i.e. the next call to get
+// returns the same value.
+func (cs *ColumnStore) peek(maxD, maxR int32) (interface{}, error) {
+	if cs.peeked == nil {
+		v, dl, err := cs.read(maxD, maxR)
+		if err != nil {
+			return nil, err
+		}
+		cs.peeked = &peekedValue{value: v, dLevel: dl}
+	}
+
+	return cs.peeked.value, nil
+}
+
+// skipRow skips all values of the current row without assembling them.
+func (cs *ColumnStore) skipRow(maxD, maxR int32) error {
+	if cs.skipped {
+		return nil
+	}
+
+	if cs.peeked != nil {
+		cs.peeked = nil
+		return nil
+	}
+
 	if cs.readPos >= cs.rLevels.count || cs.readPos >= cs.dLevels.count {
-		if err := cs.readNextPage(); err != nil {
+		if maxR == 0 && cs.pages != nil {
+			return cs.skipPageRow()
+		}
+		if err := cs.readNextPage(maxD); err != nil {
+			return err
+		}
+	}
+
+	values, pos := cs.readValues()
+	for {
+		_, dl, _ := cs.getRDLevelAt(cs.readPos)
+		if dl == maxD {
+			if *pos >= values.len() {
+				return errors.New("out of range")
+			}
+			*pos++
+		}
+		cs.readPos++
+
+		rl, _, last := cs.getRDLevelAt(cs.readPos)
+		if last || rl == 0 {
+			return nil
+		}
+	}
+}
+
+// skipPageRow skips a row after the end of the current data page of a column that isn't
+// repeated, i.e. every value of a data page is a row. The data pages of which all rows are
+// skipped are dropped without decoding them.
+func (cs *ColumnStore) skipPageRow() error {
+	cs.skippedRows++
+	for cs.pageIdx < len(cs.pages) && cs.skippedRows >= int(cs.pages[cs.pageIdx].numValues()) {
+		cs.skippedRows -= int(cs.pages[cs.pageIdx].numValues())
+		cs.pageIdx++
+	}
+
+	if cs.skippedRows > 0 && cs.pageIdx >= len(cs.pages) {
+		return fmt.Errorf("out of range: skipped %d rows after the last page", cs.skippedRows)
+	}
+
+	return nil
+}
+
+func (cs *ColumnStore) read(maxD, maxR int32) (interface{}, int32, error) {
+	if cs.readPos >= cs.rLevels.count || cs.readPos >= cs.dLevels.count {
+		if err := cs.readNextPage(maxD); err != nil {
 			return nil, 0, err
 		}
 	}
@@ -291,20 +464,20 @@ func (cs *ColumnStore) get(maxD, maxR int32) (interface{}, int32, error) {
 	// the next data in this object, should have maxR as the rLevel. the first rLevel less than maxR means the value
 	// is from the next object and we should not touch it in this call
 
-	var ret = cs.typedColumnStore.append(nil, v)
+	// the values of a row are always in the same page, so they can be copied at once.
+	values, pos := cs.readValues()
+	start := *pos - 1
 	for {
 		cs.readPos++
 		rl, _, last := cs.getRDLevelAt(cs.readPos)
 		if last || rl < maxR {
 			// end of this object
-			return ret, maxD, nil
+			return values.slice(start, *pos), maxD, nil
 		}
-		v, err := cs.getNext()
-		if err != nil {
-			return nil, maxD, err
+		if *pos >= values.len() {
+			return nil, maxD, errors.New("out of range")
 		}
-
-		ret = cs.typedColumnStore.append(ret, v)
+		*pos++
 	}
 }
 
@@ -335,13 +508,13 @@ func getValuesStore(typ *parquet.SchemaElement, alloc *allocTracker) (*ColumnSto
 	case parquet.Type_BOOLEAN:
 		return newPlainStore(&booleanStore{ColumnParameters: params}, alloc), nil
 	case parquet.Type_BYTE_ARRAY:
-		return newPlainStore(&byteArrayStore{ColumnParameters: params}, alloc), nil
+		return newPlainStore(newByteArrayStore(parquet.Type_BYTE_ARRAY, params), alloc), nil
 	case parquet.Type_FIXED_LEN_BYTE_ARRAY:
 		if typ.TypeLength == nil {
 			return nil, fmt.Errorf("type %s with nil type length", typ.Type)
 		}
 
-		return newPlainStore(&byteArrayStore{ColumnParameters: params}, alloc), nil
+		return newPlainStore(newByteArrayStore(parquet.Type_FIXED_LEN_BYTE_ARRAY, params), alloc), nil
 
 	case parquet.Type_FLOAT:
 		return newPlainStore(&floatStore{ColumnParameters: params, stats: newFloatStats(), pageStats: newFloatStats()}, alloc), nil
@@ -349,13 +522,13 @@ func getValuesStore(typ *parquet.SchemaElement, alloc *allocTracker) (*ColumnSto
 		return newPlainStore(&doubleStore{ColumnParameters: params, stats: newDoubleStats(), pageStats: newDoubleStats()}, alloc), nil
 
 	case parquet.Type_INT32:
-		return newPlainStore(&int32Store{ColumnParameters: params, stats: newInt32Stats(), pageStats: newInt32Stats()}, alloc), nil
+		unsigned := isUnsignedParams(parquet.Type_INT32, params)
+		return newPlainStore(&int32Store{ColumnParameters: params, stats: newInt32Stats(unsigned), pageStats: newInt32Stats(unsigned)}, alloc), nil
 	case parquet.Type_INT64:
-		return newPlainStore(&int64Store{ColumnParameters: params, stats: newInt64Stats(), pageStats: newInt64Stats()}, alloc), nil
+		unsigned := isUnsignedParams(parquet.Type_INT64, params)
+		return newPlainStore(&int64Store{ColumnParameters: params, stats: newInt64Stats(unsigned), pageStats: newInt64Stats(unsigned)}, alloc), nil
 	case parquet.Type_INT96:
-		store := &int96Store{}
-		store.ColumnParameters = params
-		return newPlainStore(store, alloc), nil
+		return newPlainStore(newInt96Store(params), alloc), nil
 	default:
 		return nil, fmt.Errorf("unsupported type: %s", typ.Type)
 	}
@@ -375,22 +548,24 @@ func NewBooleanStore(enc parquet.Encoding, params *ColumnParameters) (*ColumnSto
 // then a dictionary is used, otherwise a dictionary will never be used to encode the data.
 func NewInt32Store(enc parquet.Encoding, useDict bool, params *ColumnParameters) (*ColumnStore, error) {
 	switch enc {
-	case parquet.Encoding_PLAIN, parquet.Encoding_DELTA_BINARY_PACKED:
+	case parquet.Encoding_PLAIN, parquet.Encoding_DELTA_BINARY_PACKED, parquet.Encoding_BYTE_STREAM_SPLIT:
 	default:
 		return nil, fmt.Errorf("encoding %q is not supported on this type", enc)
 	}
-	return newStore(&int32Store{ColumnParameters: params, stats: newInt32Stats(), pageStats: newInt32Stats()}, enc, useDict, nil), nil // allocTracker is set by recursiveFix
+	unsigned := isUnsignedParams(parquet.Type_INT32, params)
+	return newStore(&int32Store{ColumnParameters: params, stats: newInt32Stats(unsigned), pageStats: newInt32Stats(unsigned)}, enc, useDict, nil), nil // allocTracker is set by recursiveFix
 }
 
 // NewInt64Store creates a new column store to store int64 values. If useDict is true,
 // then a dictionary is used, otherwise a dictionary will never be used to encode the data.
 func NewInt64Store(enc parquet.Encoding, useDict bool, params *ColumnParameters) (*ColumnStore, error) {
 	switch enc {
-	case parquet.Encoding_PLAIN, parquet.Encoding_DELTA_BINARY_PACKED:
+	case parquet.Encoding_PLAIN, parquet.Encoding_DELTA_BINARY_PACKED, parquet.Encoding_BYTE_STREAM_SPLIT:
 	default:
 		return nil, fmt.Errorf("encoding %q is not supported on this type", enc)
 	}
-	return newStore(&int64Store{ColumnParameters: params, stats: newInt64Stats(), pageStats: newInt64Stats()}, enc, useDict, nil), nil // allocTracker is set by recursiveFix
+	unsigned := isUnsignedParams(parquet.Type_INT64, params)
+	return newStore(&int64Store{ColumnParameters: params, stats: newInt64Stats(unsigned), pageStats: newInt64Stats(unsigned)}, enc, useDict, nil), nil // allocTracker is set by recursiveFix
 }
 
 // NewInt96Store creates a new column store to store int96 values. If useDict is true,
@@ -401,16 +576,14 @@ func NewInt96Store(enc parquet.Encoding, useDict bool, params *ColumnParameters)
 	default:
 		return nil, fmt.Errorf("encoding %q is not supported on this type", enc)
 	}
-	store := &int96Store{}
-	store.ColumnParameters = params
-	return newStore(store, enc, useDict, nil), nil // allocTracker is set by recursiveFix
+	return newStore(newInt96Store(params), enc, useDict, nil), nil // allocTracker is set by recursiveFix
 }
 
 // NewFloatStore creates a new column store to store float (float32) values. If useDict is true,
 // then a dictionary is used, otherwise a dictionary will never be used to encode the data.
 func NewFloatStore(enc parquet.Encoding, useDict bool, params *ColumnParameters) (*ColumnStore, error) {
 	switch enc {
-	case parquet.Encoding_PLAIN:
+	case parquet.Encoding_PLAIN, parquet.Encoding_BYTE_STREAM_SPLIT:
 	default:
 		return nil, fmt.Errorf("encoding %q is not supported on this type", enc)
 	}
@@ -421,7 +594,7 @@ func NewFloatStore(enc parquet.Encoding, useDict bool, params *ColumnParameters)
 // then a dictionary is used, otherwise a dictionary will never be used to encode the data.
 func NewDoubleStore(enc parquet.Encoding, useDict bool, params *ColumnParameters) (*ColumnStore, error) {
 	switch enc {
-	case parquet.Encoding_PLAIN:
+	case parquet.Encoding_PLAIN, parquet.Encoding_BYTE_STREAM_SPLIT:
 	default:
 		return nil, fmt.Errorf("encoding %q is not supported on this type", enc)
 	}
@@ -436,14 +609,14 @@ func NewByteArrayStore(enc parquet.Encoding, useDict bool, params *ColumnParamet
 	default:
 		return nil, fmt.Errorf("encoding %q is not supported on this type", enc)
 	}
-	return newStore(&byteArrayStore{ColumnParameters: params}, enc, useDict, nil), nil // allocTracker is set by recursiveFix
+	return newStore(newByteArrayStore(parquet.Type_BYTE_ARRAY, params), enc, useDict, nil), nil // allocTracker is set by recursiveFix
 }
 
 // NewFixedByteArrayStore creates a new column store to store fixed size byte arrays. If useDict is true,
 // then a dictionary is used, otherwise a dictionary will never be used to encode the data.
 func NewFixedByteArrayStore(enc parquet.Encoding, useDict bool, params *ColumnParameters) (*ColumnStore, error) {
 	switch enc {
-	case parquet.Encoding_PLAIN, parquet.Encoding_DELTA_LENGTH_BYTE_ARRAY, parquet.Encoding_DELTA_BYTE_ARRAY:
+	case parquet.Encoding_PLAIN, parquet.Encoding_DELTA_LENGTH_BYTE_ARRAY, parquet.Encoding_DELTA_BYTE_ARRAY, parquet.Encoding_BYTE_STREAM_SPLIT:
 	default:
 		return nil, fmt.Errorf("encoding %q is not supported on this type", enc)
 	}
@@ -455,7 +628,5 @@ func NewFixedByteArrayStore(enc parquet.Encoding, useDict bool, params *ColumnPa
 		return nil, fmt.Errorf("fix length with len %d is not possible", *params.TypeLength)
 	}
 
-	return newStore(&byteArrayStore{
-		ColumnParameters: params,
-	}, enc, useDict, nil), nil // allocTracker is set by recursiveFix
+	return newStore(newByteArrayStore(parquet.Type_FIXED_LEN_BYTE_ARRAY, params), enc, useDict, nil), nil // allocTracker is set by recursiveFix
 }
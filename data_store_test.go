@@ -1,6 +1,7 @@
 package goparquet
 
 import (
+	"io"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -11,7 +12,7 @@ import (
 )
 
 func newIntStore() *ColumnStore {
-	d := newStore(&int32Store{ColumnParameters: &ColumnParameters{}, stats: newInt32Stats(), pageStats: newInt32Stats()}, parquet.Encoding_PLAIN, false, nil)
+	d := newStore(&int32Store{ColumnParameters: &ColumnParameters{}, stats: newInt32Stats(false), pageStats: newInt32Stats(false)}, parquet.Encoding_PLAIN, false, nil)
 	return d
 }
 
@@ -32,7 +33,7 @@ func TestOneColumn(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, uint16(0), d.MaxDefinitionLevel())
 	assert.Equal(t, uint16(0), d.MaxRepetitionLevel())
-	assert.Equal(t, []interface{}{int32(10), int32(20)}, d.data.values.getValues())
+	assert.Equal(t, []interface{}{int32(10), int32(20)}, fromTestValuesBuffer(d.data.values.getValues()))
 	assert.Equal(t, []int32{0, 0}, d.data.dLevels.toArray())
 	assert.Equal(t, []int32{0, 0}, d.data.rLevels.toArray())
 
@@ -62,7 +63,7 @@ func TestOneColumnOptional(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, uint16(1), d.MaxDefinitionLevel())
 	assert.Equal(t, uint16(0), d.MaxRepetitionLevel())
-	assert.Equal(t, []interface{}{int32(10)}, d.data.values.getValues())
+	assert.Equal(t, []interface{}{int32(10)}, fromTestValuesBuffer(d.data.values.getValues()))
 	assert.Equal(t, []int32{1, 0}, d.data.dLevels.toArray())
 	assert.Equal(t, []int32{0, 0}, d.data.rLevels.toArray())
 
@@ -90,7 +91,7 @@ func TestOneColumnRepeated(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, uint16(1), d.MaxDefinitionLevel())
 	assert.Equal(t, uint16(1), d.MaxRepetitionLevel())
-	assert.Equal(t, []interface{}{int32(10), int32(20)}, d.data.values.getValues())
+	assert.Equal(t, []interface{}{int32(10), int32(20)}, fromTestValuesBuffer(d.data.values.getValues()))
 	assert.Equal(t, []int32{1, 1, 0}, d.data.dLevels.toArray())
 	assert.Equal(t, []int32{0, 1, 0}, d.data.rLevels.toArray())
 
@@ -149,7 +150,7 @@ func TestComplexPart1(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, uint16(2), d.MaxDefinitionLevel())
 	assert.Equal(t, uint16(2), d.MaxRepetitionLevel())
-	assert.Equal(t, []interface{}{int32(1), int32(2), int32(3)}, d.data.values.getValues())
+	assert.Equal(t, []interface{}{int32(1), int32(2), int32(3)}, fromTestValuesBuffer(d.data.values.getValues()))
 	assert.Equal(t, []int32{2, 2, 1, 2}, d.data.dLevels.toArray())
 	assert.Equal(t, []int32{0, 2, 1, 1}, d.data.rLevels.toArray())
 
@@ -157,7 +158,7 @@ func TestComplexPart1(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, uint16(3), d.MaxDefinitionLevel())
 	assert.Equal(t, uint16(2), d.MaxRepetitionLevel())
-	assert.Equal(t, []interface{}{int32(100), int32(101)}, d.data.values.getValues())
+	assert.Equal(t, []interface{}{int32(100), int32(101)}, fromTestValuesBuffer(d.data.values.getValues()))
 	assert.Equal(t, []int32{3, 2, 1, 3}, d.data.dLevels.toArray())
 	assert.Equal(t, []int32{0, 2, 1, 1}, d.data.rLevels.toArray())
 
@@ -165,7 +166,7 @@ func TestComplexPart1(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, uint16(2), d.MaxDefinitionLevel())
 	assert.Equal(t, uint16(1), d.MaxRepetitionLevel())
-	assert.Equal(t, []interface{}{int32(10), int32(11)}, d.data.values.getValues())
+	assert.Equal(t, []interface{}{int32(10), int32(11)}, fromTestValuesBuffer(d.data.values.getValues()))
 	assert.Equal(t, []int32{2, 2, 1}, d.data.dLevels.toArray())
 	assert.Equal(t, []int32{0, 1, 1}, d.data.rLevels.toArray())
 
@@ -205,7 +206,7 @@ func TestComplexPart2(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, uint16(2), d.MaxDefinitionLevel())
 	assert.Equal(t, uint16(1), d.MaxRepetitionLevel())
-	assert.Equal(t, []interface{}{int32(20), int32(40), int32(60), int32(80)}, d.data.values.getValues())
+	assert.Equal(t, []interface{}{int32(20), int32(40), int32(60), int32(80)}, fromTestValuesBuffer(d.data.values.getValues()))
 	assert.Equal(t, []int32{2, 2, 2, 2}, d.data.dLevels.toArray())
 	assert.Equal(t, []int32{0, 1, 1, 0}, d.data.rLevels.toArray())
 
@@ -213,7 +214,7 @@ func TestComplexPart2(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, uint16(2), d.MaxDefinitionLevel())
 	assert.Equal(t, uint16(1), d.MaxRepetitionLevel())
-	assert.Equal(t, []interface{}{int32(10), int32(30)}, d.data.values.getValues())
+	assert.Equal(t, []interface{}{int32(10), int32(30)}, fromTestValuesBuffer(d.data.values.getValues()))
 	assert.Equal(t, []int32{1, 2, 2}, d.data.dLevels.toArray())
 	assert.Equal(t, []int32{0, 0, 1}, d.data.rLevels.toArray())
 
@@ -292,7 +293,7 @@ func TestComplex(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, uint16(0), d.MaxDefinitionLevel())
 	assert.Equal(t, uint16(0), d.MaxRepetitionLevel())
-	assert.Equal(t, []interface{}{int32(10), int32(20)}, d.data.values.getValues())
+	assert.Equal(t, []interface{}{int32(10), int32(20)}, fromTestValuesBuffer(d.data.values.getValues()))
 	assert.Equal(t, []int32{0, 0}, d.data.dLevels.toArray())
 	assert.Equal(t, []int32{0, 0}, d.data.rLevels.toArray())
 
@@ -300,7 +301,7 @@ func TestComplex(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, uint16(2), d.MaxDefinitionLevel())
 	assert.Equal(t, uint16(1), d.MaxRepetitionLevel())
-	assert.Equal(t, []interface{}{int32(10), int32(11), int32(12)}, d.data.values.getValues())
+	assert.Equal(t, []interface{}{int32(10), int32(11), int32(12)}, fromTestValuesBuffer(d.data.values.getValues()))
 	assert.Equal(t, []int32{2, 2, 1, 2}, d.data.dLevels.toArray())
 	assert.Equal(t, []int32{0, 1, 1, 0}, d.data.rLevels.toArray())
 
@@ -308,7 +309,7 @@ func TestComplex(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, uint16(2), d.MaxDefinitionLevel())
 	assert.Equal(t, uint16(1), d.MaxRepetitionLevel())
-	assert.Equal(t, []interface{}{int32(20), int32(40), int32(60), int32(80)}, d.data.values.getValues())
+	assert.Equal(t, []interface{}{int32(20), int32(40), int32(60), int32(80)}, fromTestValuesBuffer(d.data.values.getValues()))
 	assert.Equal(t, []int32{2, 2, 2, 2}, d.data.dLevels.toArray())
 	assert.Equal(t, []int32{0, 1, 1, 0}, d.data.rLevels.toArray())
 
@@ -316,7 +317,7 @@ func TestComplex(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, uint16(2), d.MaxDefinitionLevel())
 	assert.Equal(t, uint16(1), d.MaxRepetitionLevel())
-	assert.Equal(t, []interface{}{int32(10), int32(30)}, d.data.values.getValues())
+	assert.Equal(t, []interface{}{int32(10), int32(30)}, fromTestValuesBuffer(d.data.values.getValues()))
 	assert.Equal(t, []int32{1, 2, 2}, d.data.dLevels.toArray())
 	assert.Equal(t, []int32{0, 0, 1}, d.data.rLevels.toArray())
 
@@ -324,7 +325,7 @@ func TestComplex(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, uint16(3), d.MaxDefinitionLevel())
 	assert.Equal(t, uint16(2), d.MaxRepetitionLevel())
-	assert.Equal(t, []interface{}{int32(100), int32(101)}, d.data.values.getValues())
+	assert.Equal(t, []interface{}{int32(100), int32(101)}, fromTestValuesBuffer(d.data.values.getValues()))
 	assert.Equal(t, []int32{3, 2, 1, 3, 1}, d.data.dLevels.toArray())
 	assert.Equal(t, []int32{0, 2, 1, 1, 0}, d.data.rLevels.toArray())
 
@@ -332,7 +333,7 @@ func TestComplex(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, uint16(2), d.MaxDefinitionLevel())
 	assert.Equal(t, uint16(2), d.MaxRepetitionLevel())
-	assert.Equal(t, []interface{}{int32(1), int32(2), int32(3)}, d.data.values.getValues())
+	assert.Equal(t, []interface{}{int32(1), int32(2), int32(3)}, fromTestValuesBuffer(d.data.values.getValues()))
 	assert.Equal(t, []int32{2, 2, 1, 2, 1}, d.data.dLevels.toArray())
 	assert.Equal(t, []int32{0, 2, 1, 1, 0}, d.data.rLevels.toArray())
 
@@ -375,7 +376,7 @@ func TestTwitterBlog(t *testing.T) {
 	for i := 1; i < 11; i++ {
 		expected = append(expected, int32(i))
 	}
-	assert.Equal(t, expected, d.data.values.getValues())
+	assert.Equal(t, expected, fromTestValuesBuffer(d.data.values.getValues()))
 	assert.Equal(t, uint16(2), d.MaxDefinitionLevel())
 	assert.Equal(t, uint16(2), d.MaxRepetitionLevel())
 	assert.Equal(t, []int32{0, 2, 2, 1, 2, 2, 2, 0, 1, 2}, d.data.rLevels.toArray())
@@ -412,7 +413,7 @@ func TestEmptyParent(t *testing.T) {
 	col, err := row.findDataColumn("baz.list.element")
 	require.NoError(t, err)
 
-	assert.Equal(t, []interface{}(nil), col.data.values.getValues())
+	assert.Equal(t, 0, col.data.values.getValues().len())
 
 	assert.Equal(t, uint16(2), col.MaxDefinitionLevel())
 	assert.Equal(t, uint16(1), col.MaxRepetitionLevel())
@@ -465,7 +466,7 @@ func TestZeroRL(t *testing.T) {
 	d, err := row.findDataColumn("baz.list.element.quux")
 	require.NoError(t, err)
 	var expected = []interface{}{int32(23), int32(42)}
-	assert.Equal(t, expected, d.data.values.getValues())
+	assert.Equal(t, expected, fromTestValuesBuffer(d.data.values.getValues()))
 	assert.Equal(t, uint16(1), d.MaxDefinitionLevel())
 	assert.Equal(t, uint16(1), d.MaxRepetitionLevel())
 	assert.Equal(t, []int32{0, 1}, d.data.rLevels.toArray())
@@ -485,7 +486,7 @@ func TestZeroRL(t *testing.T) {
 
 	d, err = row.findDataColumn("baz.list.element.quux")
 	require.NoError(t, err)
-	assert.Equal(t, expected, d.data.values.getValues())
+	assert.Equal(t, expected, fromTestValuesBuffer(d.data.values.getValues()))
 	assert.Equal(t, uint16(2), d.MaxDefinitionLevel())
 	assert.Equal(t, uint16(1), d.MaxRepetitionLevel())
 	assert.Equal(t, []int32{0, 1}, d.data.rLevels.toArray())
@@ -495,3 +496,83 @@ func TestZeroRL(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, data, read)
 }
+
+// testPageReader is a data page of an optional int32 column with the values first..first+n-1,
+// where every third value is null. It counts how often it is decoded.
+type testPageReader struct {
+	first, n int32
+	decoded  int
+}
+
+func (p *testPageReader) init(dDecoder, rDecoder getLevelDecoder, values getValueDecoderFn) error {
+	return nil
+}
+
+func (p *testPageReader) read(r io.Reader, ph *parquet.PageHeader, codec parquet.CompressionCodec, validateCRC bool) error {
+	return nil
+}
+
+func (p *testPageReader) numValues() int32 {
+	return p.n
+}
+
+func (p *testPageReader) readValues(size int) (valuesBuffer, *packedArray, *packedArray, error) {
+	p.decoded++
+
+	var values int32Values
+	dLevels, rLevels := &packedArray{}, &packedArray{}
+	dLevels.reset(1)
+	rLevels.reset(0)
+	for v := p.first; v < p.first+p.n; v++ {
+		rLevels.appendSingle(0)
+		if v%3 == 0 {
+			dLevels.appendSingle(0)
+			continue
+		}
+		dLevels.appendSingle(1)
+		values = append(values, v)
+	}
+	dLevels.flush()
+	rLevels.flush()
+
+	return values, dLevels, rLevels, nil
+}
+
+func TestSkipRowSkipsPages(t *testing.T) {
+	var pages []*testPageReader
+	col := &Column{data: newIntStore(), maxD: 1}
+	col.data.reset(parquet.FieldRepetitionType_OPTIONAL, 0, 1)
+
+	var readers []pageReader
+	for i := int32(0); i < 4; i++ {
+		page := &testPageReader{first: i * 10, n: 10}
+		pages = append(pages, page)
+		readers = append(readers, page)
+	}
+	require.NoError(t, readPageData(col, readers, false))
+
+	read := func() interface{} {
+		v, _, err := col.data.get(1, 0)
+		require.NoError(t, err)
+		return v
+	}
+
+	require.Nil(t, read())
+	for i := 0; i < 24; i++ {
+		require.NoError(t, col.data.skipRow(1, 0))
+	}
+	require.Equal(t, int32(25), read())
+	require.Equal(t, int32(26), read())
+	require.Nil(t, read())
+	require.NoError(t, col.data.skipRow(1, 0))
+	require.Equal(t, int32(29), read())
+
+	// the second page has been skipped without decoding it.
+	require.Equal(t, []int{1, 0, 1, 0}, []int{pages[0].decoded, pages[1].decoded, pages[2].decoded, pages[3].decoded})
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, col.data.skipRow(1, 0))
+	}
+	require.Error(t, col.data.skipRow(1, 0))
+	require.Equal(t, 0, pages[3].decoded)
+}
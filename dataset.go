@@ -0,0 +1,649 @@
+package goparquet
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+)
+
+// hiveDefaultPartition is the partition value that Hive uses for null values.
+const hiveDefaultPartition = "__HIVE_DEFAULT_PARTITION__"
+
+// Dataset reads a collection of parquet files as if they were a single file. The files are
+// discovered by walking a directory tree, and Hive-style path segments of the form key=value,
+// e.g. table/date=2024-01-01/country=DE/part-0.parquet, are exposed as partition columns.
+//
+// Files and directories whose names start with "_" or "." are ignored, as they are used for
+// metadata and temporary files by most writers. All remaining files need to be parquet files
+// that are partitioned by the same keys in the same order.
+//
+// The schemas of all files are unified into a single schema: columns that don't exist in all
+// files or that are optional in any of them are optional, and columns that occur in several
+// files need to have the same type in all of them. The partition columns are appended as
+// optional columns. Their type is INT64 if all values of a partition key are integers, DATE
+// if they are all dates like 2024-01-01, TIMESTAMP(NANOS) if they are all timestamps like
+// 2024-01-01 12:30:00, and a STRING otherwise. The value __HIVE_DEFAULT_PARTITION__ denotes
+// null.
+type Dataset struct {
+	fsys          fs.FS
+	files         []*datasetFile
+	partitionKeys []string
+	schemaDef     *parquetschema.SchemaDefinition
+
+	filter        boundPredicate
+	readerOptions []FileReaderOption
+
+	fileIdx int
+	file    fs.File
+	reader  *FileReader
+}
+
+type datasetFile struct {
+	path string
+	meta *parquet.FileMetaData
+
+	// partitions contains the values of the partition keys in the order of the keys,
+	// as strings while discovering the files and converted to their type afterwards.
+	// Null values are nil.
+	partitions []interface{}
+}
+
+// DatasetOption is an option that can be passed on to NewDataset when creating a new dataset.
+type DatasetOption func(*datasetOptions) error
+
+type datasetOptions struct {
+	filter        Predicate
+	readerOptions []FileReaderOption
+}
+
+// WithDatasetFilter configures a predicate that is used to prune files and row groups that
+// can't contain any matching rows. Predicates on partition columns are evaluated against
+// the partition values of each file, so that whole partitions are skipped, and predicates
+// on all other columns are evaluated against the statistics of the row groups, just like
+// with WithRowGroupFilter.
+//
+// Please note that the filter only prunes whole files and row groups; row groups that are
+// read may still contain rows that don't match the predicate.
+func WithDatasetFilter(pred Predicate) DatasetOption {
+	return func(opts *datasetOptions) error {
+		opts.filter = pred
+		return nil
+	}
+}
+
+// WithDatasetReaderOptions configures the options that are used to open the files of the
+// dataset, such as WithColumnPaths or WithKeyRetriever. Options that refer to columns only
+// apply to the columns of the files, not to partition columns.
+func WithDatasetReaderOptions(options ...FileReaderOption) DatasetOption {
+	return func(opts *datasetOptions) error {
+		opts.readerOptions = append(opts.readerOptions, options...)
+		return nil
+	}
+}
+
+// NewDatasetFromDirectory creates a new Dataset from the files below the directory dir.
+func NewDatasetFromDirectory(dir string, options ...DatasetOption) (*Dataset, error) {
+	return NewDataset(os.DirFS(dir), options...)
+}
+
+// NewDataset creates a new Dataset from the files in fsys. The footers of all files are read
+// to determine the schema of the dataset, but no data is read until NextRow is called. The
+// files need to implement io.Seeker, which is the case for files opened by os.DirFS.
+func NewDataset(fsys fs.FS, options ...DatasetOption) (*Dataset, error) {
+	opts := &datasetOptions{}
+	for _, f := range options {
+		if err := f(opts); err != nil {
+			return nil, err
+		}
+	}
+
+	files, keys, err := discoverDatasetFiles(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Dataset{
+		fsys:          fsys,
+		partitionKeys: keys,
+		readerOptions: opts.readerOptions,
+	}
+
+	var schemaDefs []*parquetschema.SchemaDefinition
+	for _, file := range files {
+		sd, err := d.readFileMetaData(file)
+		if err != nil {
+			return nil, err
+		}
+		schemaDefs = append(schemaDefs, sd)
+	}
+
+	partitionDefs := convertPartitionValues(files, keys)
+
+	if d.schemaDef, err = unifySchemaDefinitions(schemaDefs, partitionDefs); err != nil {
+		return nil, err
+	}
+
+	if opts.filter != nil {
+		sch := &schema{}
+		if err := sch.SetSchemaDefinition(d.schemaDef); err != nil {
+			return nil, fmt.Errorf("creating schema of dataset failed: %w", err)
+		}
+		if d.filter, err = opts.filter.bind(sch, nil); err != nil {
+			return nil, fmt.Errorf("invalid dataset filter: %w", err)
+		}
+	}
+
+	for _, file := range files {
+		if d.filter != nil && d.filter.evalStats(d.partitionStats(file)) == filterNever {
+			continue
+		}
+		d.files = append(d.files, file)
+	}
+
+	return d, nil
+}
+
+// discoverDatasetFiles walks fsys and returns all files along with the partition keys.
+func discoverDatasetFiles(fsys fs.FS) ([]*datasetFile, []string, error) {
+	var (
+		files []*datasetFile
+		keys  []string
+	)
+
+	err := fs.WalkDir(fsys, ".", func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if p != "." && (strings.HasPrefix(entry.Name(), "_") || strings.HasPrefix(entry.Name(), ".")) {
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		var (
+			fileKeys   []string
+			partitions []interface{}
+		)
+		if dir := path.Dir(p); dir != "." {
+			for _, segment := range strings.Split(dir, "/") {
+				idx := strings.Index(segment, "=")
+				if idx < 0 {
+					continue
+				}
+				key, err := url.PathUnescape(segment[:idx])
+				if err != nil {
+					return fmt.Errorf("invalid partition key in %q: %w", p, err)
+				}
+				value, err := url.PathUnescape(segment[idx+1:])
+				if err != nil {
+					return fmt.Errorf("invalid partition value in %q: %w", p, err)
+				}
+				fileKeys = append(fileKeys, key)
+				if value == hiveDefaultPartition {
+					partitions = append(partitions, nil)
+				} else {
+					partitions = append(partitions, value)
+				}
+			}
+		}
+
+		if len(files) == 0 {
+			keys = fileKeys
+		} else if !equalStrings(keys, fileKeys) {
+			return fmt.Errorf("file %q is partitioned by %v instead of %v", p, fileKeys, keys)
+		}
+
+		files = append(files, &datasetFile{path: p, partitions: partitions})
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("discovering files failed: %w", err)
+	}
+
+	if len(files) == 0 {
+		return nil, nil, errors.New("no files found")
+	}
+
+	for i, key := range keys {
+		for _, k := range keys[:i] {
+			if k == key {
+				return nil, nil, fmt.Errorf("duplicate partition key %q", key)
+			}
+		}
+	}
+
+	return files, keys, nil
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// partitionType is a type that the values of a partition key are converted to if all of
+// them can be parsed as that type.
+type partitionType struct {
+	parse func(s string) (interface{}, error)
+	elem  func(elem *parquet.SchemaElement)
+}
+
+var partitionTypes = []partitionType{
+	{
+		parse: func(s string) (interface{}, error) {
+			return strconv.ParseInt(s, 10, 64)
+		},
+		elem: func(elem *parquet.SchemaElement) {
+			elem.Type = parquet.TypePtr(parquet.Type_INT64)
+		},
+	},
+	{
+		parse: func(s string) (interface{}, error) {
+			t, err := time.Parse(partitionDateLayout, s)
+			if err != nil {
+				return nil, err
+			}
+			return int32(t.Unix() / secPerDay), nil
+		},
+		elem: func(elem *parquet.SchemaElement) {
+			elem.Type = parquet.TypePtr(parquet.Type_INT32)
+			elem.LogicalType = &parquet.LogicalType{DATE: parquet.NewDateType()}
+			elem.ConvertedType = parquet.ConvertedTypePtr(parquet.ConvertedType_DATE)
+		},
+	},
+	{
+		parse: func(s string) (interface{}, error) {
+			t, err := time.Parse(partitionTimestampLayout, s)
+			if err != nil {
+				return nil, err
+			}
+			return t.UnixNano(), nil
+		},
+		elem: func(elem *parquet.SchemaElement) {
+			elem.Type = parquet.TypePtr(parquet.Type_INT64)
+			elem.LogicalType = &parquet.LogicalType{TIMESTAMP: &parquet.TimestampType{
+				IsAdjustedToUTC: true,
+				Unit:            &parquet.TimeUnit{NANOS: parquet.NewNanoSeconds()},
+			}}
+		},
+	},
+	{
+		parse: func(s string) (interface{}, error) {
+			return []byte(s), nil
+		},
+		elem: func(elem *parquet.SchemaElement) {
+			elem.Type = parquet.TypePtr(parquet.Type_BYTE_ARRAY)
+			elem.LogicalType = &parquet.LogicalType{STRING: parquet.NewStringType()}
+			elem.ConvertedType = parquet.ConvertedTypePtr(parquet.ConvertedType_UTF8)
+		},
+	},
+}
+
+// convertPartitionValues converts the partition values of files to the first of the
+// partitionTypes that all values of a key can be parsed as. It returns the definitions of
+// the partition columns.
+func convertPartitionValues(files []*datasetFile, keys []string) []*parquetschema.ColumnDefinition {
+	defs := make([]*parquetschema.ColumnDefinition, 0, len(keys))
+
+	for i, key := range keys {
+		var (
+			typ    partitionType
+			values []interface{}
+		)
+		for _, typ = range partitionTypes {
+			if values = parsePartitionValues(files, i, typ); values != nil {
+				break
+			}
+		}
+
+		elem := &parquet.SchemaElement{
+			Name:           key,
+			RepetitionType: parquet.FieldRepetitionTypePtr(parquet.FieldRepetitionType_OPTIONAL),
+		}
+		typ.elem(elem)
+		defs = append(defs, &parquetschema.ColumnDefinition{SchemaElement: elem})
+
+		for j, file := range files {
+			file.partitions[i] = values[j]
+		}
+	}
+
+	return defs
+}
+
+// parsePartitionValues parses the i-th partition value of all files as typ. It returns nil
+// if any of them can't be parsed.
+func parsePartitionValues(files []*datasetFile, i int, typ partitionType) []interface{} {
+	values := make([]interface{}, len(files))
+	for j, file := range files {
+		s, ok := file.partitions[i].(string)
+		if !ok {
+			continue
+		}
+		v, err := typ.parse(s)
+		if err != nil {
+			return nil
+		}
+		values[j] = v
+	}
+	return values
+}
+
+// unifySchemaDefinitions merges the schema definitions of all files and appends the
+// partition columns.
+func unifySchemaDefinitions(schemaDefs []*parquetschema.SchemaDefinition, partitionDefs []*parquetschema.ColumnDefinition) (*parquetschema.SchemaDefinition, error) {
+	var (
+		columns []*parquetschema.ColumnDefinition
+		counts  = make(map[string]int)
+	)
+
+	for _, sd := range schemaDefs {
+		for _, col := range sd.Clone().RootColumn.Children {
+			name := col.SchemaElement.GetName()
+
+			var existing *parquetschema.ColumnDefinition
+			for _, c := range columns {
+				if c.SchemaElement.GetName() == name {
+					existing = c
+					break
+				}
+			}
+			counts[name]++
+
+			if existing == nil {
+				columns = append(columns, col)
+				continue
+			}
+
+			rep, err := unifyColumnDefinitions(existing, col)
+			if err != nil {
+				return nil, err
+			}
+			existing.SchemaElement.RepetitionType = parquet.FieldRepetitionTypePtr(rep)
+		}
+	}
+
+	// columns that are missing in some of the files are null in their rows.
+	for _, col := range columns {
+		if counts[col.SchemaElement.GetName()] < len(schemaDefs) && col.SchemaElement.GetRepetitionType() == parquet.FieldRepetitionType_REQUIRED {
+			col.SchemaElement.RepetitionType = parquet.FieldRepetitionTypePtr(parquet.FieldRepetitionType_OPTIONAL)
+		}
+	}
+
+	for _, def := range partitionDefs {
+		for _, c := range columns {
+			if c.SchemaElement.GetName() == def.SchemaElement.GetName() {
+				return nil, fmt.Errorf("partition key %q collides with a column of the same name", def.SchemaElement.GetName())
+			}
+		}
+		columns = append(columns, def)
+	}
+
+	return parquetschema.SchemaDefinitionFromColumnDefinition(&parquetschema.ColumnDefinition{
+		Children:      columns,
+		SchemaElement: schemaDefs[0].RootColumn.SchemaElement,
+	}), nil
+}
+
+// unifyColumnDefinitions checks whether the top-level columns a and b only differ in whether
+// they are required or optional, and returns the repetition type of the unified column.
+func unifyColumnDefinitions(a, b *parquetschema.ColumnDefinition) (parquet.FieldRepetitionType, error) {
+	repA, repB := a.SchemaElement.GetRepetitionType(), b.SchemaElement.GetRepetitionType()
+
+	if (repA == parquet.FieldRepetitionType_REPEATED) != (repB == parquet.FieldRepetitionType_REPEATED) || columnDefinitionString(a, repA) != columnDefinitionString(b, repA) {
+		return 0, fmt.Errorf("column %q has different types in the files of the dataset: %s vs. %s",
+			a.SchemaElement.GetName(), strings.TrimSpace(columnDefinitionString(a, repA)), strings.TrimSpace(columnDefinitionString(b, repB)))
+	}
+
+	if repA == parquet.FieldRepetitionType_OPTIONAL || repB == parquet.FieldRepetitionType_OPTIONAL {
+		return parquet.FieldRepetitionType_OPTIONAL, nil
+	}
+	return repA, nil
+}
+
+// columnDefinitionString returns the textual representation of col, with its repetition
+// type replaced by rep.
+func columnDefinitionString(col *parquetschema.ColumnDefinition, rep parquet.FieldRepetitionType) string {
+	elem := *col.SchemaElement
+	elem.RepetitionType = &rep
+
+	sd := parquetschema.SchemaDefinitionFromColumnDefinition(&parquetschema.ColumnDefinition{
+		Children:      []*parquetschema.ColumnDefinition{{Children: col.Children, SchemaElement: &elem}},
+		SchemaElement: &parquet.SchemaElement{Name: "column"},
+	})
+
+	return strings.TrimPrefix(strings.TrimSuffix(sd.String(), "}\n"), "message column {\n")
+}
+
+// readFileMetaData reads the meta data of file and returns its schema definition.
+func (d *Dataset) readFileMetaData(file *datasetFile) (*parquetschema.SchemaDefinition, error) {
+	f, r, err := d.openFile(file.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fr, err := NewFileReaderWithOptions(r, d.readerOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("opening %q failed: %w", file.path, err)
+	}
+	file.meta = fr.meta
+
+	return fr.GetSchemaDefinition(), nil
+}
+
+func (d *Dataset) openFile(name string) (fs.File, io.ReadSeeker, error) {
+	f, err := d.fsys.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, ok := f.(io.ReadSeeker)
+	if !ok {
+		f.Close()
+		return nil, nil, fmt.Errorf("file %q doesn't support seeking", name)
+	}
+
+	return f, r, nil
+}
+
+// partitionStats returns the statistics of the partition columns for file, where every file
+// counts as a single value.
+func (d *Dataset) partitionStats(file *datasetFile) partitionStats {
+	stats := make(partitionStats, len(d.partitionKeys))
+
+	for i, key := range d.partitionKeys {
+		cs := &columnStats{numValues: 1, nullCount: new(int64)}
+		switch v := file.partitions[i].(type) {
+		case int32:
+			cs.min = make([]byte, 4)
+			binary.LittleEndian.PutUint32(cs.min, uint32(v))
+			cs.max = cs.min
+		case int64:
+			cs.min = make([]byte, 8)
+			binary.LittleEndian.PutUint64(cs.min, uint64(v))
+			cs.max = cs.min
+		case []byte:
+			cs.min, cs.max = v, v
+		default:
+			*cs.nullCount = 1
+		}
+		stats[key] = cs
+	}
+
+	return stats
+}
+
+// GetSchemaDefinition returns the unified schema definition of the dataset, including the
+// partition columns.
+func (d *Dataset) GetSchemaDefinition() *parquetschema.SchemaDefinition {
+	return d.schemaDef
+}
+
+// PartitionKeys returns the partition keys of the dataset in the order in which they appear
+// in the paths of the files.
+func (d *Dataset) PartitionKeys() []string {
+	return d.partitionKeys
+}
+
+// Files returns the paths of all files of the dataset that haven't been pruned by the
+// dataset filter.
+func (d *Dataset) Files() []string {
+	paths := make([]string, 0, len(d.files))
+	for _, file := range d.files {
+		paths = append(paths, file.path)
+	}
+	return paths
+}
+
+// NumRows returns the number of rows in all files of the dataset that haven't been pruned
+// by the dataset filter. This information is directly taken from the files' meta data.
+func (d *Dataset) NumRows() int64 {
+	var n int64
+	for _, file := range d.files {
+		n += file.meta.NumRows
+	}
+	return n
+}
+
+// NextRow reads the next row from the dataset, including the values of its partition
+// columns. When all files have been read, io.EOF is returned.
+func (d *Dataset) NextRow() (map[string]interface{}, error) {
+	return d.NextRowWithContext(context.Background())
+}
+
+// NextRowWithContext reads the next row from the dataset, including the values of its
+// partition columns. When all files have been read, io.EOF is returned.
+func (d *Dataset) NextRowWithContext(ctx context.Context) (map[string]interface{}, error) {
+	for {
+		if d.reader == nil {
+			if d.fileIdx >= len(d.files) {
+				return nil, io.EOF
+			}
+			if err := d.openReader(d.files[d.fileIdx]); err != nil {
+				return nil, err
+			}
+		}
+
+		file := d.files[d.fileIdx]
+
+		row, err := d.reader.NextRowWithContext(ctx)
+		if err == io.EOF {
+			if err := d.closeReader(); err != nil {
+				return nil, err
+			}
+			d.fileIdx++
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %q failed: %w", file.path, err)
+		}
+
+		for i, key := range d.partitionKeys {
+			if v := file.partitions[i]; v != nil {
+				row[key] = v
+			}
+		}
+
+		return row, nil
+	}
+}
+
+func (d *Dataset) openReader(file *datasetFile) error {
+	f, r, err := d.openFile(file.path)
+	if err != nil {
+		return err
+	}
+
+	fr, err := NewFileReaderWithOptions(r, append(append([]FileReaderOption(nil), d.readerOptions...), WithFileMetaData(file.meta))...)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("opening %q failed: %w", file.path, err)
+	}
+
+	if d.filter != nil {
+		filter := &datasetRowGroupFilter{boundPredicate: d.filter, partitions: d.partitionStats(file)}
+		if fr.rowGroupFilter != nil {
+			fr.rowGroupFilter = &boundAnd{preds: []boundPredicate{fr.rowGroupFilter, filter}}
+		} else {
+			fr.rowGroupFilter = filter
+		}
+	}
+
+	d.file, d.reader = f, fr
+	return nil
+}
+
+// Close closes the file of the dataset that is currently being read. Afterwards, NextRow
+// returns io.EOF.
+func (d *Dataset) Close() error {
+	d.fileIdx = len(d.files)
+	return d.closeReader()
+}
+
+func (d *Dataset) closeReader() error {
+	if d.file == nil {
+		return nil
+	}
+
+	err := d.file.Close()
+	d.file, d.reader = nil, nil
+	return err
+}
+
+// partitionStats provides the statistics of partition columns, keyed by partition key.
+type partitionStats map[string]*columnStats
+
+func (s partitionStats) columnStats(path ColumnPath) *columnStats {
+	if len(path) != 1 {
+		return nil
+	}
+	return s[path[0]]
+}
+
+// datasetRowGroupFilter evaluates the dataset filter against the statistics of a row
+// group, using the partition values of the row group's file for the partition columns.
+type datasetRowGroupFilter struct {
+	boundPredicate
+	partitions partitionStats
+}
+
+func (p *datasetRowGroupFilter) evalStats(sp statsProvider) filterResult {
+	return p.boundPredicate.evalStats(datasetStats{statsProvider: sp, partitions: p.partitions})
+}
+
+type datasetStats struct {
+	statsProvider
+	partitions partitionStats
+}
+
+func (s datasetStats) columnStats(path ColumnPath) *columnStats {
+	if cs := s.partitions.columnStats(path); cs != nil {
+		return cs
+	}
+	return s.statsProvider.columnStats(path)
+}
@@ -0,0 +1,258 @@
+package goparquet
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+func writeDatasetTestFile(t *testing.T, schemaText string, from, to int) *fstest.MapFile {
+	var rows []map[string]interface{}
+	for i := from; i < to; i++ {
+		rows = append(rows, map[string]interface{}{"id": int64(i), "score": float64(i) / 2})
+	}
+	return writeDatasetTestRows(t, schemaText, rows...)
+}
+
+// writeDatasetTestRows writes rows to a file with row groups of 10 rows each.
+func writeDatasetTestRows(t *testing.T, schemaText string, rows ...map[string]interface{}) *fstest.MapFile {
+	sd, err := parquetschema.ParseSchemaDefinition(schemaText)
+	require.NoError(t, err)
+
+	buf := &memReadWriteSeeker{}
+	fw := NewFileWriter(buf, WithSchemaDefinition(sd))
+	for i, row := range rows {
+		require.NoError(t, fw.AddData(row))
+		if i%10 == 9 {
+			require.NoError(t, fw.FlushRowGroup())
+		}
+	}
+	require.NoError(t, fw.Close())
+
+	return &fstest.MapFile{Data: buf.data}
+}
+
+func readDatasetIDs(t *testing.T, d *Dataset) ([]int64, []map[string]interface{}) {
+	var (
+		ids  []int64
+		rows []map[string]interface{}
+	)
+	for {
+		row, err := d.NextRow()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		ids = append(ids, row["id"].(int64))
+		rows = append(rows, row)
+	}
+	require.NoError(t, d.Close())
+	return ids, rows
+}
+
+func datasetTestFS(t *testing.T) fstest.MapFS {
+	const (
+		schemaV1 = `message test { required int64 id; }`
+		schemaV2 = `message test { optional int64 id; optional double score; }`
+	)
+
+	return fstest.MapFS{
+		"date=2024-01-01/country=DE/part-0.parquet":                         writeDatasetTestFile(t, schemaV1, 0, 20),
+		"date=2024-01-01/country=FR/part-0.parquet":                         writeDatasetTestFile(t, schemaV1, 20, 30),
+		"date=2024-01-02/country=DE/part-0.parquet":                         writeDatasetTestFile(t, schemaV2, 30, 40),
+		"date=2024-01-02/country=DE/part-1.parquet":                         writeDatasetTestFile(t, schemaV2, 40, 50),
+		"date=2024-01-02/country=__HIVE_DEFAULT_PARTITION__/part-0.parquet": writeDatasetTestFile(t, schemaV2, 50, 60),
+		"date=2024-01-02/country=DE/.part-1.parquet.crc":                    &fstest.MapFile{Data: []byte("crc")},
+		"_SUCCESS": &fstest.MapFile{},
+		"_temporary/date=2024-01-03/part-0.parquet": &fstest.MapFile{Data: []byte("incomplete")},
+	}
+}
+
+func TestDataset(t *testing.T) {
+	d, err := NewDataset(datasetTestFS(t))
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"date", "country"}, d.PartitionKeys())
+	require.Equal(t, int64(60), d.NumRows())
+	require.Len(t, d.Files(), 5)
+
+	expectedSchema, err := parquetschema.ParseSchemaDefinition(`message test {
+		optional int64 id;
+		optional double score;
+		optional int32 date (DATE);
+		optional binary country (STRING);
+	}`)
+	require.NoError(t, err)
+	require.Equal(t, expectedSchema.String(), d.GetSchemaDefinition().String())
+
+	ids, rows := readDatasetIDs(t, d)
+	require.Len(t, ids, 60)
+	for i, row := range rows {
+		// 2024-01-01 is the 19723rd day after the Unix epoch.
+		expected := map[string]interface{}{"id": int64(i), "date": int32(19723), "country": []byte("DE")}
+		switch {
+		case i >= 50:
+			expected["date"] = int32(19724)
+			delete(expected, "country")
+			expected["score"] = float64(i) / 2
+		case i >= 30:
+			expected["date"] = int32(19724)
+			expected["score"] = float64(i) / 2
+		case i >= 20:
+			expected["country"] = []byte("FR")
+		}
+		require.Equal(t, expected, row)
+	}
+
+	_, err = d.NextRow()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestDatasetFilter(t *testing.T) {
+	fsys := datasetTestFS(t)
+	jan1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testData := map[string]struct {
+		pred  Predicate
+		files []string
+		ids   []int64
+	}{
+		"date": {
+			pred:  Eq(ColumnPath{"date"}, jan1),
+			files: []string{"date=2024-01-01/country=DE/part-0.parquet", "date=2024-01-01/country=FR/part-0.parquet"},
+		},
+		"country": {
+			pred:  And(Gt(ColumnPath{"date"}, jan1), In(ColumnPath{"country"}, "DE", "FR")),
+			files: []string{"date=2024-01-02/country=DE/part-0.parquet", "date=2024-01-02/country=DE/part-1.parquet"},
+		},
+		"null": {
+			pred:  IsNull(ColumnPath{"country"}),
+			files: []string{"date=2024-01-02/country=__HIVE_DEFAULT_PARTITION__/part-0.parquet"},
+		},
+		"not-null": {
+			pred:  Not(Or(IsNull(ColumnPath{"country"}), Eq(ColumnPath{"country"}, "DE"))),
+			files: []string{"date=2024-01-01/country=FR/part-0.parquet"},
+		},
+		"row-groups": {
+			// the partition filter keeps a single file, and the row group filter skips
+			// its second row group.
+			pred:  And(Eq(ColumnPath{"country"}, "DE"), Eq(ColumnPath{"date"}, jan1), Lt(ColumnPath{"id"}, 5)),
+			files: []string{"date=2024-01-01/country=DE/part-0.parquet"},
+			ids:   []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		},
+		"missing-column": {
+			// the first files don't contain score and can't be skipped based on it.
+			pred:  Gt(ColumnPath{"score"}, 100),
+			files: []string{"date=2024-01-01/country=DE/part-0.parquet", "date=2024-01-01/country=FR/part-0.parquet", "date=2024-01-02/country=DE/part-0.parquet", "date=2024-01-02/country=DE/part-1.parquet", "date=2024-01-02/country=__HIVE_DEFAULT_PARTITION__/part-0.parquet"},
+			ids:   []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29},
+		},
+	}
+
+	for name, tt := range testData {
+		t.Run(name, func(t *testing.T) {
+			d, err := NewDataset(fsys, WithDatasetFilter(tt.pred))
+			require.NoError(t, err)
+			require.Equal(t, tt.files, d.Files())
+
+			ids, _ := readDatasetIDs(t, d)
+			if tt.ids == nil {
+				require.Equal(t, d.NumRows(), int64(len(ids)))
+				return
+			}
+			require.Equal(t, tt.ids, ids)
+		})
+	}
+
+	_, err := NewDataset(fsys, WithDatasetFilter(Eq(ColumnPath{"region"}, "EU")))
+	require.Error(t, err)
+}
+
+func TestDatasetIntegerPartitions(t *testing.T) {
+	const schemaText = `message test { required int64 id; }`
+
+	fsys := fstest.MapFS{
+		"year=2023/month=12/part-0.parquet": writeDatasetTestFile(t, schemaText, 0, 10),
+		"year=2024/month=01/part-0.parquet": writeDatasetTestFile(t, schemaText, 10, 20),
+		"year=2024/month=02/part-0.parquet": writeDatasetTestFile(t, schemaText, 20, 30),
+	}
+
+	d, err := NewDataset(fsys, WithDatasetFilter(And(Eq(ColumnPath{"year"}, 2024), Lt(ColumnPath{"month"}, 2))), WithDatasetReaderOptions(WithColumnPaths(ColumnPath{"id"})))
+	require.NoError(t, err)
+	require.Equal(t, []string{"year=2024/month=01/part-0.parquet"}, d.Files())
+
+	_, rows := readDatasetIDs(t, d)
+	require.Len(t, rows, 10)
+	require.Equal(t, map[string]interface{}{"id": int64(10), "year": int64(2024), "month": int64(1)}, rows[0])
+}
+
+func TestDatasetFromDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dataset")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fsys := datasetTestFS(t)
+	for name, file := range fsys {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, ioutil.WriteFile(p, file.Data, 0644))
+	}
+
+	d, err := NewDatasetFromDirectory(dir)
+	require.NoError(t, err)
+
+	ids, _ := readDatasetIDs(t, d)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	require.Len(t, ids, 60)
+	require.Equal(t, int64(59), ids[59])
+}
+
+func TestDatasetErrors(t *testing.T) {
+	const schemaText = `message test { required int64 id; }`
+
+	testData := map[string]fstest.MapFS{
+		"no-files": {
+			"_SUCCESS": &fstest.MapFile{},
+		},
+		"inconsistent-keys": {
+			"a=1/b=2/part-0.parquet": writeDatasetTestFile(t, schemaText, 0, 1),
+			"b=2/a=1/part-0.parquet": writeDatasetTestFile(t, schemaText, 0, 1),
+		},
+		"missing-keys": {
+			"a=1/part-0.parquet": writeDatasetTestFile(t, schemaText, 0, 1),
+			"part-0.parquet":     writeDatasetTestFile(t, schemaText, 0, 1),
+		},
+		"duplicate-keys": {
+			"a=1/a=2/part-0.parquet": writeDatasetTestFile(t, schemaText, 0, 1),
+		},
+		"different-types": {
+			"a=1/part-0.parquet": writeDatasetTestFile(t, schemaText, 0, 1),
+			"a=2/part-0.parquet": writeDatasetTestRows(t, `message test { required int32 id; }`, map[string]interface{}{"id": int32(1)}),
+		},
+		"repeated": {
+			"a=1/part-0.parquet": writeDatasetTestFile(t, `message test { optional int64 id; }`, 0, 1),
+			"a=2/part-0.parquet": writeDatasetTestRows(t, `message test { repeated int64 id; }`, map[string]interface{}{"id": []int64{1}}),
+		},
+		"partition-collision": {
+			"id=1/part-0.parquet": writeDatasetTestFile(t, schemaText, 0, 1),
+		},
+		"not-parquet": {
+			"a=1/part-0.parquet": writeDatasetTestFile(t, schemaText, 0, 1),
+			"a=1/README":         &fstest.MapFile{Data: []byte("not a parquet file")},
+		},
+	}
+
+	for name, fsys := range testData {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewDataset(fsys)
+			require.Error(t, err)
+		})
+	}
+}
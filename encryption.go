@@ -0,0 +1,800 @@
+package goparquet
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/fraugster/parquet-go/parquet"
+)
+
+// Parquet Modular Encryption encrypts every module of a file, i.e. every page header, page,
+// column index, offset index, bloom filter, the column meta data and the footer, separately.
+// Each encrypted module consists of its length as 4 byte little endian integer, a random
+// nonce and the encrypted data. Modules encrypted with AES-GCM additionally end with the
+// authentication tag, which also covers additional authenticated data (AAD) that identifies
+// the module within the file, so that modules can't be swapped without being noticed.
+
+var encryptedMagic = []byte{'P', 'A', 'R', 'E'}
+
+const (
+	encryptionLengthSize   = 4
+	encryptionNonceSize    = 12
+	encryptionTagSize      = 16
+	encryptionFileAADSize  = 8
+	footerSignatureSize    = encryptionNonceSize + encryptionTagSize
+	encryptionMaxOrdinal   = math.MaxInt16
+	encryptionCTRIVCounter = 1
+)
+
+// module types as used in the additional authenticated data of the modules.
+const (
+	moduleFooter byte = iota
+	moduleColumnMetaData
+	moduleDataPage
+	moduleDictionaryPage
+	moduleDataPageHeader
+	moduleDictionaryPageHeader
+	moduleColumnIndex
+	moduleOffsetIndex
+	moduleBloomFilterHeader
+	moduleBloomFilterBitset
+)
+
+// KeyRetriever retrieves the keys to decrypt an encrypted parquet file. The key metadata
+// is stored in the file alongside the data that was encrypted with the key. It is defined
+// by the writer of the file, e.g. as the ID of a key in a key management service, and must
+// not contain the key itself.
+type KeyRetriever interface {
+	RetrieveKey(keyMetadata []byte) ([]byte, error)
+}
+
+// InMemoryKeyRetriever is a KeyRetriever that holds all keys in memory, indexed by their
+// key metadata.
+type InMemoryKeyRetriever map[string][]byte
+
+// RetrieveKey returns the key stored for keyMetadata.
+func (kr InMemoryKeyRetriever) RetrieveKey(keyMetadata []byte) ([]byte, error) {
+	key, ok := kr[string(keyMetadata)]
+	if !ok {
+		return nil, fmt.Errorf("no key for key metadata %q", keyMetadata)
+	}
+	return key, nil
+}
+
+// EncryptionAlgorithm is the algorithm used to encrypt a parquet file.
+type EncryptionAlgorithm int
+
+const (
+	// EncryptionAESGCM encrypts all modules of the file with AES-GCM, which authenticates
+	// all data. This is the AES_GCM_V1 algorithm of the parquet specification.
+	EncryptionAESGCM EncryptionAlgorithm = iota
+	// EncryptionAESGCMCTR encrypts pages with AES-CTR and all other modules with AES-GCM,
+	// which is faster, but doesn't authenticate the content of the pages. This is the
+	// AES_GCM_CTR_V1 algorithm of the parquet specification.
+	EncryptionAESGCMCTR
+)
+
+// moduleCipher encrypts and decrypts modules with a single key.
+type moduleCipher struct {
+	block cipher.Block
+	gcm   cipher.AEAD
+}
+
+func newModuleCipher(key []byte) (*moduleCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &moduleCipher{block: block, gcm: gcm}, nil
+}
+
+// encrypt returns the module that holds data encrypted with AES-CTR if ctr is true, or
+// with AES-GCM and the additional authenticated data aad otherwise.
+func (c *moduleCipher) encrypt(data, aad []byte, ctr bool) ([]byte, error) {
+	size := encryptionNonceSize + len(data)
+	if !ctr {
+		size += encryptionTagSize
+	}
+	if size > math.MaxInt32 {
+		return nil, fmt.Errorf("module of %d bytes is too large to be encrypted", len(data))
+	}
+
+	module := make([]byte, encryptionLengthSize+encryptionNonceSize, encryptionLengthSize+size)
+	binary.LittleEndian.PutUint32(module, uint32(size))
+
+	nonce := module[encryptionLengthSize:]
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("generating nonce failed: %w", err)
+	}
+
+	if ctr {
+		module = module[:encryptionLengthSize+size]
+		cipher.NewCTR(c.block, ctrIV(nonce)).XORKeyStream(module[encryptionLengthSize+encryptionNonceSize:], data)
+		return module, nil
+	}
+
+	return c.gcm.Seal(module, nonce, data, aad), nil
+}
+
+// decrypt returns the data of module, which was encrypted with AES-CTR if ctr is true, or
+// with AES-GCM and the additional authenticated data aad otherwise.
+func (c *moduleCipher) decrypt(module, aad []byte, ctr bool) ([]byte, error) {
+	minSize := encryptionLengthSize + encryptionNonceSize
+	if !ctr {
+		minSize += encryptionTagSize
+	}
+	if len(module) < minSize || int(binary.LittleEndian.Uint32(module)) != len(module)-encryptionLengthSize {
+		return nil, errors.New("invalid encrypted module")
+	}
+
+	nonce := module[encryptionLengthSize : encryptionLengthSize+encryptionNonceSize]
+	data := module[encryptionLengthSize+encryptionNonceSize:]
+
+	if ctr {
+		out := make([]byte, len(data))
+		cipher.NewCTR(c.block, ctrIV(nonce)).XORKeyStream(out, data)
+		return out, nil
+	}
+
+	out, err := c.gcm.Open(nil, nonce, data, aad)
+	if err != nil {
+		return nil, errors.New("decrypting module failed, either the key is wrong or the data has been tampered with")
+	}
+
+	return out, nil
+}
+
+// sign returns the nonce and the authentication tag of data encrypted with AES-GCM and
+// the additional authenticated data aad, which are used to sign plaintext footers.
+func (c *moduleCipher) sign(data, aad []byte, nonce []byte) ([]byte, error) {
+	if nonce == nil {
+		nonce = make([]byte, encryptionNonceSize)
+		if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			return nil, fmt.Errorf("generating nonce failed: %w", err)
+		}
+	}
+
+	sealed := c.gcm.Seal(nil, nonce, data, aad)
+	return append(append([]byte(nil), nonce...), sealed[len(sealed)-encryptionTagSize:]...), nil
+}
+
+// ctrIV returns the initialization vector for AES-CTR, which consists of the nonce followed
+// by a 4 byte big endian counter that starts at 1.
+func ctrIV(nonce []byte) []byte {
+	iv := make([]byte, aes.BlockSize)
+	copy(iv, nonce)
+	binary.BigEndian.PutUint32(iv[encryptionNonceSize:], encryptionCTRIVCounter)
+	return iv
+}
+
+// readModule reads an encrypted module, including its length, from r.
+func readModule(r io.Reader, alloc *allocTracker) ([]byte, error) {
+	var lengthBuf [encryptionLengthSize]byte
+	if _, err := io.ReadFull(r, lengthBuf[:]); err != nil {
+		return nil, fmt.Errorf("reading module length failed: %w", err)
+	}
+
+	size := binary.LittleEndian.Uint32(lengthBuf[:])
+	if size < encryptionNonceSize || size > math.MaxInt32 {
+		return nil, fmt.Errorf("invalid module length %d", size)
+	}
+
+	alloc.test(uint64(size))
+	module := make([]byte, encryptionLengthSize+int(size))
+	alloc.register(module, uint64(size))
+
+	copy(module, lengthBuf[:])
+	if _, err := io.ReadFull(r, module[encryptionLengthSize:]); err != nil {
+		return nil, fmt.Errorf("reading module failed: %w", err)
+	}
+
+	return module, nil
+}
+
+// moduleAAD returns the additional authenticated data of a module. Besides the file AAD and
+// the module type, it contains the ordinals of the row group and the column, and for data
+// pages and their headers, the ordinal of the page within the column chunk.
+func moduleAAD(fileAAD []byte, moduleType byte, rowGroup, column, page int) []byte {
+	aad := make([]byte, 0, len(fileAAD)+7)
+	aad = append(append(aad, fileAAD...), moduleType)
+	if moduleType == moduleFooter {
+		return aad
+	}
+
+	aad = append(aad, byte(rowGroup), byte(rowGroup>>8), byte(column), byte(column>>8))
+	if moduleType == moduleDataPage || moduleType == moduleDataPageHeader {
+		aad = append(aad, byte(page), byte(page>>8))
+	}
+
+	return aad
+}
+
+// columnCrypto encrypts and decrypts the modules of a single column chunk.
+type columnCrypto struct {
+	cipher  *moduleCipher
+	fileAAD []byte
+	// ctr is true if pages are encrypted with AES-CTR.
+	ctr bool
+
+	rowGroup int
+	column   int
+}
+
+func newColumnCrypto(c *moduleCipher, fileAAD []byte, ctr bool, rowGroup, column int) (*columnCrypto, error) {
+	if rowGroup > encryptionMaxOrdinal {
+		return nil, fmt.Errorf("encrypted files can't have more than %d row groups", encryptionMaxOrdinal+1)
+	}
+	if column > encryptionMaxOrdinal {
+		return nil, fmt.Errorf("encrypted files can't have more than %d columns", encryptionMaxOrdinal+1)
+	}
+
+	return &columnCrypto{cipher: c, fileAAD: fileAAD, ctr: ctr, rowGroup: rowGroup, column: column}, nil
+}
+
+func (cc *columnCrypto) isCTR(moduleType byte) bool {
+	return cc.ctr && (moduleType == moduleDataPage || moduleType == moduleDictionaryPage)
+}
+
+func (cc *columnCrypto) encrypt(moduleType byte, page int, data []byte) ([]byte, error) {
+	if page > encryptionMaxOrdinal {
+		return nil, fmt.Errorf("encrypted column chunks can't have more than %d pages", encryptionMaxOrdinal+1)
+	}
+	return cc.cipher.encrypt(data, moduleAAD(cc.fileAAD, moduleType, cc.rowGroup, cc.column, page), cc.isCTR(moduleType))
+}
+
+func (cc *columnCrypto) decrypt(moduleType byte, page int, module []byte) ([]byte, error) {
+	return cc.cipher.decrypt(module, moduleAAD(cc.fileAAD, moduleType, cc.rowGroup, cc.column, page), cc.isCTR(moduleType))
+}
+
+// writeThrift writes tw to w as encrypted module.
+func (cc *columnCrypto) writeThrift(ctx context.Context, moduleType byte, page int, tw thriftWriter, w io.Writer) error {
+	var buf bytes.Buffer
+	if err := writeThrift(ctx, tw, &buf); err != nil {
+		return err
+	}
+
+	module, err := cc.encrypt(moduleType, page, buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	return writeFull(w, module)
+}
+
+// readThrift reads tr from the encrypted module read from r.
+func (cc *columnCrypto) readThrift(ctx context.Context, moduleType byte, page int, tr thriftReader, r io.Reader, alloc *allocTracker) error {
+	module, err := readModule(r, alloc)
+	if err != nil {
+		return err
+	}
+
+	data, err := cc.decrypt(moduleType, page, module)
+	if err != nil {
+		return err
+	}
+
+	return readThrift(ctx, tr, bytes.NewReader(data))
+}
+
+type columnEncryptionKey struct {
+	path        ColumnPath
+	key         []byte
+	keyMetadata []byte
+}
+
+// fileEncryption holds the encryption settings of a file writer.
+type fileEncryption struct {
+	algorithm         EncryptionAlgorithm
+	footerKey         []byte
+	footerKeyMetadata []byte
+	plaintextFooter   bool
+	columnKeys        []columnEncryptionKey
+
+	// the following fields are set up by init once the first data is written.
+	initialized   bool
+	aadFileUnique []byte
+	footerCipher  *moduleCipher
+	columnCiphers []*moduleCipher
+}
+
+func (e *fileEncryption) init() error {
+	if e.initialized {
+		return nil
+	}
+
+	if e.footerKey == nil {
+		return errors.New("encryption requires a footer key")
+	}
+
+	var err error
+	if e.footerCipher, err = newModuleCipher(e.footerKey); err != nil {
+		return fmt.Errorf("footer key: %w", err)
+	}
+
+	e.columnCiphers = make([]*moduleCipher, len(e.columnKeys))
+	for i, ck := range e.columnKeys {
+		if e.columnCiphers[i], err = newModuleCipher(ck.key); err != nil {
+			return fmt.Errorf("key of column %s: %w", ck.path.flatName(), err)
+		}
+	}
+
+	e.aadFileUnique = make([]byte, encryptionFileAADSize)
+	if _, err := io.ReadFull(rand.Reader, e.aadFileUnique); err != nil {
+		return fmt.Errorf("generating file AAD failed: %w", err)
+	}
+
+	e.initialized = true
+	return nil
+}
+
+func (e *fileEncryption) parquetAlgorithm() *parquet.EncryptionAlgorithm {
+	if e.algorithm == EncryptionAESGCMCTR {
+		return &parquet.EncryptionAlgorithm{AES_GCM_CTR_V1: &parquet.AesGcmCtrV1{AadFileUnique: e.aadFileUnique}}
+	}
+	return &parquet.EncryptionAlgorithm{AES_GCM_V1: &parquet.AesGcmV1{AadFileUnique: e.aadFileUnique}}
+}
+
+// columnCrypto returns the crypto of the column chunk of the column identified by path, along
+// with its crypto meta data. If the column isn't encrypted, nil is returned.
+func (e *fileEncryption) columnCrypto(path ColumnPath, rowGroup, column int) (*columnCrypto, *parquet.ColumnCryptoMetaData, error) {
+	ctr := e.algorithm == EncryptionAESGCMCTR
+
+	if len(e.columnKeys) == 0 {
+		cc, err := newColumnCrypto(e.footerCipher, e.aadFileUnique, ctr, rowGroup, column)
+		return cc, &parquet.ColumnCryptoMetaData{ENCRYPTION_WITH_FOOTER_KEY: parquet.NewEncryptionWithFooterKey()}, err
+	}
+
+	for i, ck := range e.columnKeys {
+		if !path.HasPrefix(ck.path) {
+			continue
+		}
+		cc, err := newColumnCrypto(e.columnCiphers[i], e.aadFileUnique, ctr, rowGroup, column)
+		return cc, &parquet.ColumnCryptoMetaData{
+			ENCRYPTION_WITH_COLUMN_KEY: &parquet.EncryptionWithColumnKey{
+				PathInSchema: path,
+				KeyMetadata:  ck.keyMetadata,
+			},
+		}, err
+	}
+
+	return nil, nil, nil
+}
+
+// writeRowGroup writes the column chunks of a row group to w. The column chunks were written
+// to data as if data started at the current position of w. The pages of encrypted columns are
+// encrypted along the way, and the offsets of all column chunks are adjusted to their final
+// position in w.
+func (e *fileEncryption) writeRowGroup(ctx context.Context, w writePos, data []byte, chunks []*parquet.ColumnChunk, indexes []*chunkIndex, rowGroup int) error {
+	base := w.Pos()
+	for column, ch := range chunks {
+		offset, size, err := chunkRange(ch)
+		if err != nil {
+			return err
+		}
+		if offset < base || offset-base+size > int64(len(data)) {
+			return fmt.Errorf("column chunk at offset %d with size %d is out of range", offset, size)
+		}
+		chunkData := data[offset-base : offset-base+size]
+
+		cc, cryptoMetaData, err := e.columnCrypto(ch.MetaData.PathInSchema, rowGroup, column)
+		if err != nil {
+			return err
+		}
+
+		if cc == nil {
+			shiftChunkOffsets(ch, indexes[column], w.Pos()-offset)
+			if err := writeFull(w, chunkData); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := encryptColumnChunk(ctx, w, chunkData, ch, indexes[column], cc); err != nil {
+			return fmt.Errorf("encrypting column %s failed: %w", ColumnPath(ch.MetaData.PathInSchema).flatName(), err)
+		}
+		ch.CryptoMetadata = cryptoMetaData
+		indexes[column].crypto = cc
+	}
+
+	return nil
+}
+
+// encryptColumnChunk writes the pages of the column chunk ch that are read from data to w,
+// with every page header and page encrypted as separate module.
+func encryptColumnChunk(ctx context.Context, w writePos, data []byte, ch *parquet.ColumnChunk, index *chunkIndex, cc *columnCrypto) error {
+	meta := ch.MetaData
+	ch.FileOffset = w.Pos()
+	meta.DictionaryPageOffset = nil
+
+	var locations []*parquet.PageLocation
+	if index.offsetIndex != nil {
+		locations = index.offsetIndex.PageLocations
+	}
+
+	r := bytes.NewReader(data)
+	page := 0
+	for r.Len() > 0 {
+		ph := &parquet.PageHeader{}
+		if err := readThrift(ctx, ph, r); err != nil {
+			return fmt.Errorf("reading page header failed: %w", err)
+		}
+
+		start := len(data) - r.Len()
+		if ph.CompressedPageSize < 0 || int(ph.CompressedPageSize) > r.Len() {
+			return fmt.Errorf("invalid compressed page size %d", ph.CompressedPageSize)
+		}
+		if _, err := r.Seek(int64(ph.CompressedPageSize), io.SeekCurrent); err != nil {
+			return err
+		}
+
+		headerType, pageType, ordinal := moduleDataPageHeader, moduleDataPage, page
+		pos := w.Pos()
+		if ph.Type == parquet.PageType_DICTIONARY_PAGE {
+			headerType, pageType, ordinal = moduleDictionaryPageHeader, moduleDictionaryPage, 0
+			meta.DictionaryPageOffset = &pos
+		} else if page == 0 {
+			meta.DataPageOffset = pos
+		}
+
+		module, err := cc.encrypt(pageType, ordinal, data[start:start+int(ph.CompressedPageSize)])
+		if err != nil {
+			return err
+		}
+		ph.CompressedPageSize = int32(len(module))
+
+		if err := cc.writeThrift(ctx, headerType, ordinal, ph, w); err != nil {
+			return err
+		}
+		if err := writeFull(w, module); err != nil {
+			return err
+		}
+
+		if ph.Type != parquet.PageType_DICTIONARY_PAGE {
+			if page < len(locations) {
+				locations[page].Offset = pos
+				locations[page].CompressedPageSize = int32(w.Pos() - pos)
+			}
+			page++
+		}
+	}
+
+	meta.TotalCompressedSize = w.Pos() - ch.FileOffset
+
+	return nil
+}
+
+// encryptColumnMetaData encrypts the column meta data of all encrypted column chunks. With an
+// encrypted footer, this is only necessary for columns that are encrypted with their own key,
+// as the footer key already protects the others. With a plaintext footer, a copy of the meta
+// data without statistics remains in the footer, so that readers without the keys can still
+// read the file's structure.
+func (e *fileEncryption) encryptColumnMetaData(ctx context.Context, rowGroups []*parquet.RowGroup) error {
+	for rowGroup, rg := range rowGroups {
+		for column, ch := range rg.Columns {
+			if ch.CryptoMetadata == nil || ch.MetaData == nil {
+				continue
+			}
+			if !e.plaintextFooter && ch.CryptoMetadata.ENCRYPTION_WITH_FOOTER_KEY != nil {
+				continue
+			}
+
+			cc, _, err := e.columnCrypto(ch.MetaData.PathInSchema, rowGroup, column)
+			if err != nil {
+				return err
+			}
+
+			var buf bytes.Buffer
+			if err := writeThrift(ctx, ch.MetaData, &buf); err != nil {
+				return err
+			}
+			if ch.EncryptedColumnMetadata, err = cc.encrypt(moduleColumnMetaData, 0, buf.Bytes()); err != nil {
+				return err
+			}
+
+			if e.plaintextFooter {
+				redacted := *ch.MetaData
+				redacted.Statistics = nil
+				redacted.EncodingStats = nil
+				ch.MetaData = &redacted
+			} else {
+				ch.MetaData = nil
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeFooter writes the file meta data meta to w. An encrypted footer is preceded by the
+// file crypto meta data, while a plaintext footer is followed by its signature.
+func (e *fileEncryption) writeFooter(ctx context.Context, w io.Writer, meta *parquet.FileMetaData) error {
+	if e.plaintextFooter {
+		meta.EncryptionAlgorithm = e.parquetAlgorithm()
+		meta.FooterSigningKeyMetadata = e.footerKeyMetadata
+	}
+
+	var buf bytes.Buffer
+	if err := writeThrift(ctx, meta, &buf); err != nil {
+		return err
+	}
+
+	aad := moduleAAD(e.aadFileUnique, moduleFooter, 0, 0, 0)
+
+	if e.plaintextFooter {
+		signature, err := e.footerCipher.sign(buf.Bytes(), aad, nil)
+		if err != nil {
+			return err
+		}
+		return writeFull(w, append(buf.Bytes(), signature...))
+	}
+
+	cryptoMetaData := &parquet.FileCryptoMetaData{
+		EncryptionAlgorithm: e.parquetAlgorithm(),
+		KeyMetadata:         e.footerKeyMetadata,
+	}
+	if err := writeThrift(ctx, cryptoMetaData, w); err != nil {
+		return err
+	}
+
+	module, err := e.footerCipher.encrypt(buf.Bytes(), aad, false)
+	if err != nil {
+		return err
+	}
+
+	return writeFull(w, module)
+}
+
+// fileDecryption holds everything needed to decrypt an encrypted file.
+type fileDecryption struct {
+	keyRetriever      KeyRetriever
+	fileAAD           []byte
+	ctr               bool
+	footerKeyMetadata []byte
+
+	ciphers map[string]*moduleCipher
+}
+
+func newFileDecryption(alg *parquet.EncryptionAlgorithm, footerKeyMetadata []byte, kr KeyRetriever, aadPrefix []byte) (*fileDecryption, error) {
+	var (
+		storedPrefix, aadFileUnique []byte
+		supplyPrefix                bool
+		ctr                         bool
+	)
+	switch {
+	case alg == nil:
+		return nil, errors.New("encryption algorithm is missing")
+	case alg.AES_GCM_V1 != nil:
+		storedPrefix, aadFileUnique, supplyPrefix = alg.AES_GCM_V1.AadPrefix, alg.AES_GCM_V1.AadFileUnique, alg.AES_GCM_V1.GetSupplyAadPrefix()
+	case alg.AES_GCM_CTR_V1 != nil:
+		storedPrefix, aadFileUnique, supplyPrefix = alg.AES_GCM_CTR_V1.AadPrefix, alg.AES_GCM_CTR_V1.AadFileUnique, alg.AES_GCM_CTR_V1.GetSupplyAadPrefix()
+		ctr = true
+	default:
+		return nil, errors.New("unsupported encryption algorithm")
+	}
+
+	prefix := storedPrefix
+	if aadPrefix != nil {
+		if storedPrefix != nil && !bytes.Equal(storedPrefix, aadPrefix) {
+			return nil, errors.New("AAD prefix doesn't match the AAD prefix stored in the file")
+		}
+		prefix = aadPrefix
+	} else if supplyPrefix {
+		return nil, errors.New("file requires an AAD prefix to be supplied")
+	}
+
+	return &fileDecryption{
+		keyRetriever:      kr,
+		fileAAD:           append(append([]byte(nil), prefix...), aadFileUnique...),
+		ctr:               ctr,
+		footerKeyMetadata: footerKeyMetadata,
+		ciphers:           make(map[string]*moduleCipher),
+	}, nil
+}
+
+// cipher returns the cipher for the key identified by keyMetadata.
+func (d *fileDecryption) cipher(keyMetadata []byte) (*moduleCipher, error) {
+	if c, ok := d.ciphers[string(keyMetadata)]; ok {
+		return c, nil
+	}
+
+	if d.keyRetriever == nil {
+		return nil, errors.New("file is encrypted, but no key retriever was provided")
+	}
+
+	key, err := d.keyRetriever.RetrieveKey(keyMetadata)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving key failed: %w", err)
+	}
+
+	c, err := newModuleCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	d.ciphers[string(keyMetadata)] = c
+
+	return c, nil
+}
+
+// columnCrypto returns the crypto of the column chunk chunk, or nil if it isn't encrypted.
+func (d *fileDecryption) columnCrypto(chunk *parquet.ColumnChunk, rowGroup, column int) (*columnCrypto, error) {
+	if chunk.CryptoMetadata == nil {
+		return nil, nil
+	}
+
+	keyMetadata := d.footerKeyMetadata
+	if ck := chunk.CryptoMetadata.ENCRYPTION_WITH_COLUMN_KEY; ck != nil {
+		keyMetadata = ck.KeyMetadata
+	} else if chunk.CryptoMetadata.ENCRYPTION_WITH_FOOTER_KEY == nil {
+		return nil, errors.New("unsupported column encryption")
+	}
+
+	c, err := d.cipher(keyMetadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return newColumnCrypto(c, d.fileAAD, d.ctr, rowGroup, column)
+}
+
+// decryptColumnMetaData decrypts the encrypted column meta data of all column chunks in meta
+// whose keys are available. The column meta data of the other column chunks is left as is,
+// so that the remaining columns can still be read.
+func (d *fileDecryption) decryptColumnMetaData(ctx context.Context, meta *parquet.FileMetaData) error {
+	for rowGroup, rg := range meta.RowGroups {
+		for column, chunk := range rg.Columns {
+			if chunk.EncryptedColumnMetadata == nil {
+				continue
+			}
+
+			cc, err := d.columnCrypto(chunk, rowGroup, column)
+			if err != nil {
+				continue // the key isn't available, so the column can't be read.
+			}
+
+			data, err := cc.decrypt(moduleColumnMetaData, 0, chunk.EncryptedColumnMetadata)
+			if err != nil {
+				return fmt.Errorf("column %d of row group %d: %w", column, rowGroup, err)
+			}
+
+			chunk.MetaData = &parquet.ColumnMetaData{}
+			if err := readThrift(ctx, chunk.MetaData, bytes.NewReader(data)); err != nil {
+				return fmt.Errorf("reading column meta data of column %d of row group %d failed: %w", column, rowGroup, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// readEncryptedFooter reads the file meta data from the footer of a file with an encrypted
+// footer, which consists of the file crypto meta data followed by the encrypted file meta data.
+func readEncryptedFooter(ctx context.Context, footer []byte, kr KeyRetriever, aadPrefix []byte) (*parquet.FileMetaData, *fileDecryption, error) {
+	r := bytes.NewReader(footer)
+
+	cryptoMetaData := &parquet.FileCryptoMetaData{}
+	if err := readThrift(ctx, cryptoMetaData, r); err != nil {
+		return nil, nil, fmt.Errorf("reading file crypto meta data failed: %w", err)
+	}
+
+	dec, err := newFileDecryption(cryptoMetaData.EncryptionAlgorithm, cryptoMetaData.KeyMetadata, kr, aadPrefix)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c, err := dec.cipher(cryptoMetaData.KeyMetadata)
+	if err != nil {
+		return nil, nil, fmt.Errorf("footer key: %w", err)
+	}
+
+	data, err := c.decrypt(footer[len(footer)-r.Len():], moduleAAD(dec.fileAAD, moduleFooter, 0, 0, 0), false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decrypting footer failed: %w", err)
+	}
+
+	meta := &parquet.FileMetaData{}
+	if err := readThrift(ctx, meta, bytes.NewReader(data)); err != nil {
+		return nil, nil, fmt.Errorf("read file meta failed: %w", err)
+	}
+
+	return meta, dec, nil
+}
+
+// verifyFooterSignature verifies the signature that follows the plaintext footer of
+// length footerLength in footer.
+func (d *fileDecryption) verifyFooterSignature(footer []byte, footerLength int) error {
+	if len(footer)-footerLength != footerSignatureSize {
+		return errors.New("footer signature is missing")
+	}
+
+	c, err := d.cipher(d.footerKeyMetadata)
+	if err != nil {
+		return fmt.Errorf("footer key: %w", err)
+	}
+
+	signature := footer[footerLength:]
+	expected, err := c.sign(footer[:footerLength], moduleAAD(d.fileAAD, moduleFooter, 0, 0, 0), signature[:encryptionNonceSize])
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(signature, expected) {
+		return errors.New("footer signature doesn't match, either the key is wrong or the footer has been tampered with")
+	}
+
+	return nil
+}
+
+// columnCrypto returns the crypto of the column chunk chunk in the row group with the index
+// rowGroupIdx, or nil if the column chunk isn't encrypted.
+func (f *FileReader) columnCrypto(rowGroupIdx int, chunk *parquet.ColumnChunk) (*columnCrypto, error) {
+	if chunk.CryptoMetadata == nil {
+		return nil, nil
+	}
+
+	if f.decryption == nil {
+		return nil, errors.New("column chunk is encrypted, but the file isn't")
+	}
+
+	for column, c := range f.meta.RowGroups[rowGroupIdx].Columns {
+		if c == chunk {
+			return f.decryption.columnCrypto(chunk, rowGroupIdx, column)
+		}
+	}
+
+	return nil, errors.New("column chunk not found")
+}
+
+// writeEncrypted writes the bloom filter to w, with its header and its bitset encrypted as
+// separate modules.
+func (f *splitBlockBloomFilter) writeEncrypted(ctx context.Context, w io.Writer, cc *columnCrypto) error {
+	if err := cc.writeThrift(ctx, moduleBloomFilterHeader, 0, f.header(), w); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.LittleEndian, f.bitset); err != nil {
+		return err
+	}
+
+	module, err := cc.encrypt(moduleBloomFilterBitset, 0, buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	return writeFull(w, module)
+}
+
+// readEncryptedSplitBlockBloomFilter reads a bloom filter whose header and bitset are
+// encrypted from r.
+func readEncryptedSplitBlockBloomFilter(ctx context.Context, r io.Reader, cc *columnCrypto, alloc *allocTracker) (*splitBlockBloomFilter, error) {
+	header := &parquet.BloomFilterHeader{}
+	if err := cc.readThrift(ctx, moduleBloomFilterHeader, 0, header, r, alloc); err != nil {
+		return nil, err
+	}
+
+	module, err := readModule(r, alloc)
+	if err != nil {
+		return nil, err
+	}
+
+	bitset, err := cc.decrypt(moduleBloomFilterBitset, 0, module)
+	if err != nil {
+		return nil, err
+	}
+
+	return newSplitBlockBloomFilterFromHeader(header, bytes.NewReader(bitset), alloc)
+}
@@ -0,0 +1,321 @@
+package goparquet
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	encryptionTestFooterKey = []byte("0123456789012345")
+	encryptionTestColumnKey = []byte("1234567890123450")
+	encryptionTestKeys      = InMemoryKeyRetriever{
+		"kf":  encryptionTestFooterKey,
+		"kc1": encryptionTestColumnKey,
+	}
+)
+
+func writeEncryptionTestFile(t *testing.T, numRows int, opts ...FileWriterOption) []byte {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 id;
+		optional binary name (STRING);
+		required binary category (STRING);
+	}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	fw := NewFileWriter(buf, append([]FileWriterOption{
+		WithSchemaDefinition(sd),
+		WithMaxPageSize(256),
+		WithCompressionCodec(parquet.CompressionCodec_SNAPPY),
+		WithBloomFilter(ColumnPath{"name"}, 0.01),
+		WithCRC(true),
+	}, opts...)...)
+
+	for i := 0; i < numRows; i++ {
+		row := map[string]interface{}{
+			"id":       int64(i),
+			"category": []byte(fmt.Sprintf("category-%d", i%5)),
+		}
+		if i%3 != 0 {
+			row["name"] = []byte(fmt.Sprintf("name-%d", i))
+		}
+		require.NoError(t, fw.AddData(row))
+		if i%100 == 99 {
+			require.NoError(t, fw.FlushRowGroup())
+		}
+	}
+	require.NoError(t, fw.Close())
+
+	return buf.Bytes()
+}
+
+func requireEncryptionTestRows(t *testing.T, r *FileReader, numRows int, columns ...string) {
+	for i := 0; i < numRows; i++ {
+		row, err := r.NextRow()
+		require.NoError(t, err)
+
+		expected := map[string]interface{}{
+			"id":       int64(i),
+			"category": []byte(fmt.Sprintf("category-%d", i%5)),
+		}
+		if i%3 != 0 {
+			expected["name"] = []byte(fmt.Sprintf("name-%d", i))
+		}
+		if len(columns) > 0 {
+			selected := make(map[string]interface{})
+			for _, c := range columns {
+				if v, ok := expected[c]; ok {
+					selected[c] = v
+				}
+			}
+			expected = selected
+		}
+		require.Equal(t, expected, row)
+	}
+}
+
+func TestEncryption(t *testing.T) {
+	testData := map[string]struct {
+		opts            []FileWriterOption
+		plaintextFooter bool
+		// encrypted lists the columns that are encrypted.
+		encrypted []string
+	}{
+		"uniform": {
+			opts:      []FileWriterOption{WithFooterKey(encryptionTestFooterKey, []byte("kf"))},
+			encrypted: []string{"id", "name", "category"},
+		},
+		"uniform-ctr": {
+			opts:      []FileWriterOption{WithFooterKey(encryptionTestFooterKey, []byte("kf")), WithEncryptionAlgorithm(EncryptionAESGCMCTR)},
+			encrypted: []string{"id", "name", "category"},
+		},
+		"column-key": {
+			opts: []FileWriterOption{
+				WithFooterKey(encryptionTestFooterKey, []byte("kf")),
+				WithColumnKey(ColumnPath{"name"}, encryptionTestColumnKey, []byte("kc1")),
+			},
+			encrypted: []string{"name"},
+		},
+		"plaintext-footer": {
+			opts: []FileWriterOption{
+				WithFooterKey(encryptionTestFooterKey, []byte("kf")),
+				WithColumnKey(ColumnPath{"name"}, encryptionTestColumnKey, []byte("kc1")),
+				WithPlaintextFooter(true),
+				WithDataPageV2(),
+			},
+			plaintextFooter: true,
+			encrypted:       []string{"name"},
+		},
+		"plaintext-footer-uniform-ctr": {
+			opts: []FileWriterOption{
+				WithFooterKey(encryptionTestFooterKey, []byte("kf")),
+				WithPlaintextFooter(true),
+				WithEncryptionAlgorithm(EncryptionAESGCMCTR),
+				WithWriterConcurrency(3),
+			},
+			plaintextFooter: true,
+			encrypted:       []string{"id", "name", "category"},
+		},
+	}
+
+	for name, tt := range testData {
+		t.Run(name, func(t *testing.T) {
+			data := writeEncryptionTestFile(t, 250, tt.opts...)
+
+			expectedMagic := encryptedMagic
+			if tt.plaintextFooter {
+				expectedMagic = magic
+			}
+			require.Equal(t, expectedMagic, data[:4])
+			require.Equal(t, expectedMagic, data[len(data)-4:])
+
+			r, err := NewFileReaderWithOptions(bytes.NewReader(data), WithKeyRetriever(encryptionTestKeys), WithCRC32Validation(true))
+			require.NoError(t, err)
+			require.Equal(t, int64(250), r.NumRows())
+			require.Equal(t, 3, r.RowGroupCount())
+
+			for rg := 0; rg < r.RowGroupCount(); rg++ {
+				for _, col := range []string{"id", "name", "category"} {
+					info, err := r.ColumnChunkInfo(rg, ColumnPath{col})
+					require.NoError(t, err)
+					require.NotNil(t, info.Statistics)
+
+					pages, err := r.ColumnChunkPages(rg, ColumnPath{col})
+					require.NoError(t, err)
+
+					offsetIndex, err := r.OffsetIndex(rg, ColumnPath{col})
+					require.NoError(t, err)
+					require.NotNil(t, offsetIndex)
+					dataPages := pages[len(pages)-len(offsetIndex.PageLocations):]
+					for i, loc := range offsetIndex.PageLocations {
+						require.Equal(t, dataPages[i].Offset, loc.Offset)
+					}
+
+					columnIndex, err := r.ColumnIndex(rg, ColumnPath{col})
+					require.NoError(t, err)
+					require.NotNil(t, columnIndex)
+					require.Len(t, columnIndex.NullPages, len(offsetIndex.PageLocations))
+				}
+
+				i := rg*100 + 1
+				if i%3 == 0 {
+					i++
+				}
+				ok, err := r.MightContain(rg, ColumnPath{"name"}, fmt.Sprintf("name-%d", i))
+				require.NoError(t, err)
+				require.True(t, ok)
+			}
+
+			requireEncryptionTestRows(t, r, 250)
+
+			// without keys, the file can't be read with an encrypted footer, and only the
+			// unencrypted columns can be read with a plaintext footer.
+			r, err = NewFileReader(bytes.NewReader(data))
+			if !tt.plaintextFooter {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			_, err = r.NextRow()
+			require.Error(t, err)
+
+			var plaintextColumns []string
+			for _, col := range []string{"id", "name", "category"} {
+				encrypted := false
+				for _, c := range tt.encrypted {
+					encrypted = encrypted || c == col
+				}
+				if !encrypted {
+					plaintextColumns = append(plaintextColumns, col)
+				}
+
+				chunk := r.meta.RowGroups[0].Columns[r.schemaReader.GetColumnByPath(ColumnPath{col}).Index()]
+				require.Equal(t, encrypted, chunk.CryptoMetadata != nil)
+				require.Equal(t, encrypted, chunk.MetaData.Statistics == nil)
+			}
+
+			if len(plaintextColumns) > 0 {
+				r, err = NewFileReader(bytes.NewReader(data), plaintextColumns...)
+				require.NoError(t, err)
+				requireEncryptionTestRows(t, r, 250, plaintextColumns...)
+			}
+		})
+	}
+}
+
+func TestEncryptionUnavailableColumnKey(t *testing.T) {
+	data := writeEncryptionTestFile(t, 150,
+		WithFooterKey(encryptionTestFooterKey, []byte("kf")),
+		WithColumnKey(ColumnPath{"name"}, encryptionTestColumnKey, []byte("kc1")),
+	)
+
+	kr := InMemoryKeyRetriever{"kf": encryptionTestFooterKey}
+
+	r, err := NewFileReaderWithOptions(bytes.NewReader(data), WithKeyRetriever(kr), WithColumnPaths(ColumnPath{"id"}, ColumnPath{"category"}))
+	require.NoError(t, err)
+	requireEncryptionTestRows(t, r, 150, "id", "category")
+
+	r, err = NewFileReaderWithOptions(bytes.NewReader(data), WithKeyRetriever(kr))
+	require.NoError(t, err)
+	_, err = r.NextRow()
+	require.Error(t, err)
+
+	_, err = r.ColumnChunkInfo(0, ColumnPath{"name"})
+	require.EqualError(t, err, `column "name" is encrypted and its key is not available`)
+	_, err = r.ColumnChunkPages(0, ColumnPath{"name"})
+	require.EqualError(t, err, `column "name" is encrypted and its key is not available`)
+	_, err = r.ColumnIndex(0, ColumnPath{"name"})
+	require.EqualError(t, err, `column "name" is encrypted and its key is not available`)
+	_, err = r.ColumnChunkInfo(0, ColumnPath{"foo"})
+	require.EqualError(t, err, `column "foo" not found`)
+
+	info, err := r.ColumnChunkInfo(0, ColumnPath{"id"})
+	require.NoError(t, err)
+	require.Equal(t, ColumnPath{"id"}, info.Path)
+}
+
+func TestEncryptionWrongKeyAndTampering(t *testing.T) {
+	data := writeEncryptionTestFile(t, 100, WithFooterKey(encryptionTestFooterKey, []byte("kf")))
+
+	_, err := NewFileReaderWithOptions(bytes.NewReader(data), WithKeyRetriever(InMemoryKeyRetriever{"kf": encryptionTestColumnKey}))
+	require.Error(t, err)
+
+	r, err := NewFileReaderWithOptions(bytes.NewReader(data), WithKeyRetriever(encryptionTestKeys))
+	require.NoError(t, err)
+	pages, err := r.ColumnChunkPages(0, ColumnPath{"id"})
+	require.NoError(t, err)
+
+	// modifying a single byte of a page must be detected.
+	tampered := append([]byte(nil), data...)
+	tampered[pages[0].Offset+encryptionLengthSize+encryptionNonceSize]++ // within the page header.
+	r, err = NewFileReaderWithOptions(bytes.NewReader(tampered), WithKeyRetriever(encryptionTestKeys))
+	require.NoError(t, err)
+	_, err = r.NextRow()
+	require.Error(t, err)
+
+	// the signature of a plaintext footer is verified if keys are provided.
+	data = writeEncryptionTestFile(t, 100, WithFooterKey(encryptionTestFooterKey, []byte("kf")), WithPlaintextFooter(true))
+	_, err = NewFileReaderWithOptions(bytes.NewReader(data), WithKeyRetriever(encryptionTestKeys))
+	require.NoError(t, err)
+
+	tampered = append([]byte(nil), data...)
+	tampered[len(tampered)-8-footerSignatureSize-1]++
+	_, err = NewFileReaderWithOptions(bytes.NewReader(tampered), WithKeyRetriever(encryptionTestKeys))
+	require.Error(t, err)
+}
+
+func TestEncryptionInvalidConfiguration(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(appendTestSchema)
+	require.NoError(t, err)
+
+	fw := NewFileWriter(&bytes.Buffer{}, WithSchemaDefinition(sd), WithColumnKey(ColumnPath{"name"}, encryptionTestColumnKey, []byte("kc1")))
+	require.NoError(t, fw.AddData(map[string]interface{}{"id": int64(1)}))
+	require.Error(t, fw.Close())
+
+	fw = NewFileWriter(&bytes.Buffer{}, WithSchemaDefinition(sd), WithFooterKey([]byte("too short"), nil))
+	require.NoError(t, fw.AddData(map[string]interface{}{"id": int64(1)}))
+	require.Error(t, fw.Close())
+
+	data := writeEncryptionTestFile(t, 10, WithFooterKey(encryptionTestFooterKey, []byte("kf")), WithPlaintextFooter(true))
+	require.Error(t, MergeFiles(&bytes.Buffer{}, []io.ReadSeeker{bytes.NewReader(data)}))
+}
+
+func TestModuleCipher(t *testing.T) {
+	c, err := newModuleCipher(encryptionTestFooterKey)
+	require.NoError(t, err)
+
+	aad := moduleAAD([]byte("file"), moduleDataPage, 1, 2, 3)
+	require.Equal(t, []byte{'f', 'i', 'l', 'e', moduleDataPage, 1, 0, 2, 0, 3, 0}, aad)
+	require.Equal(t, []byte{'f', 'i', 'l', 'e', moduleFooter}, moduleAAD([]byte("file"), moduleFooter, 1, 2, 3))
+	require.Equal(t, []byte{'f', 'i', 'l', 'e', moduleColumnIndex, 1, 0, 2, 0}, moduleAAD([]byte("file"), moduleColumnIndex, 1, 2, 3))
+
+	for _, ctr := range []bool{false, true} {
+		module, err := c.encrypt([]byte("hello world"), aad, ctr)
+		require.NoError(t, err)
+
+		size := encryptionLengthSize + encryptionNonceSize + len("hello world")
+		if !ctr {
+			size += encryptionTagSize
+		}
+		require.Len(t, module, size)
+
+		data, err := c.decrypt(module, aad, ctr)
+		require.NoError(t, err)
+		require.Equal(t, []byte("hello world"), data)
+
+		read, err := readModule(bytes.NewReader(append(append([]byte(nil), module...), 1, 2, 3)), nil)
+		require.NoError(t, err)
+		require.Equal(t, module, read)
+	}
+
+	module, err := c.encrypt([]byte("hello world"), aad, false)
+	require.NoError(t, err)
+	_, err = c.decrypt(module, moduleAAD([]byte("file"), moduleDataPage, 1, 2, 4), false)
+	require.Error(t, err)
+}
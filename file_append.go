@@ -0,0 +1,163 @@
+package goparquet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/fraugster/parquet-go/parquet"
+)
+
+// truncater is implemented by files that can be truncated, such as *os.File.
+type truncater interface {
+	Truncate(size int64) error
+}
+
+// OpenFileWriterForAppend opens the existing parquet file rws to append further row groups
+// to it. The meta data of the file is read, and the writer is positioned after the last
+// column chunk of the file, as well as after the page indexes and bloom filters that belong
+// to the existing row groups, so that they remain valid. The existing row groups are kept,
+// and when the file writer is closed, a footer is written that contains both the existing
+// and the new row groups.
+//
+// The schema, the key-value meta data, the creator and the version of the file are taken
+// over from the existing file. If a schema definition is provided using WithSchemaDefinition,
+// it needs to match the schema of the file, otherwise an error is returned. Columns must not
+// be added to the returned file writer.
+//
+// The footer of the file is overwritten when the file writer is closed. If the resulting
+// file is smaller than the original one, rws needs to implement a Truncate(int64) error
+// method, like *os.File does, so that the file can be truncated to its new size.
+//
+// The statistics of the existing row groups are kept, so the column orders of the file are
+// kept as well: if the file doesn't declare the type defined order for all columns, the new
+// file doesn't declare any column orders.
+func OpenFileWriterForAppend(rws io.ReadWriteSeeker, options ...FileWriterOption) (*FileWriter, error) {
+	return OpenFileWriterForAppendWithContext(context.Background(), rws, options...)
+}
+
+// OpenFileWriterForAppendWithContext opens the existing parquet file rws to append further row
+// groups to it, like OpenFileWriterForAppend does. The provided context.Context is used to read
+// the meta data of the file, and overrides the default context of the returned file writer,
+// unless a different one is provided using WithWriterContext.
+func OpenFileWriterForAppendWithContext(ctx context.Context, rws io.ReadWriteSeeker, options ...FileWriterOption) (*FileWriter, error) {
+	meta, err := ReadFileMetaDataWithContext(ctx, rws, true)
+	if err != nil {
+		return nil, fmt.Errorf("reading file meta data failed: %w", err)
+	}
+
+	if meta.EncryptionAlgorithm != nil {
+		return nil, errors.New("appending to encrypted files is not supported")
+	}
+
+	fileSize, err := rws.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	fileSchema, err := makeSchema(meta, false, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating schema from file meta data failed: %w", err)
+	}
+	schemaDef := fileSchema.GetSchemaDefinition()
+
+	offset, err := appendOffset(ctx, rws, meta)
+	if err != nil {
+		return nil, err
+	}
+	if offset > fileSize-8 {
+		return nil, fmt.Errorf("column chunks end at offset %d, which is beyond the footer", offset)
+	}
+
+	if _, err := rws.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	kv := make(map[string]string, len(meta.KeyValueMetadata))
+	for _, e := range meta.KeyValueMetadata {
+		kv[e.Key] = e.GetValue()
+	}
+
+	fileOptions := []FileWriterOption{
+		WithWriterContext(ctx),
+		WithSchemaDefinition(schemaDef),
+		WithMetaData(kv),
+		FileVersion(meta.Version),
+	}
+	if meta.CreatedBy != nil {
+		fileOptions = append(fileOptions, WithCreator(*meta.CreatedBy))
+	}
+
+	fw := newFileWriter(rws, offset, append(fileOptions, options...)...)
+
+	if fw.encryption != nil {
+		return nil, errors.New("appending to encrypted files is not supported")
+	}
+
+	if got := fw.GetSchemaDefinition().String(); got != schemaDef.String() {
+		return nil, fmt.Errorf("schema definition doesn't match the schema of the file, expected:\n%s\ngot:\n%s", schemaDef, got)
+	}
+
+	if len(meta.RowGroups) > 0 {
+		for idx := range fw.schemaWriter.Columns() {
+			fw.addCopiedColumnOrder(idx, fileColumnOrder(meta, idx))
+		}
+	}
+	fw.rowGroups = meta.RowGroups
+	fw.totalNumRecords = meta.NumRows
+	fw.appendTarget = rws
+	fw.appendFileSize = fileSize
+
+	return fw, nil
+}
+
+// appendOffset returns the offset after the last column chunk of the file described by meta,
+// including the page indexes and the bloom filters of the column chunks.
+func appendOffset(ctx context.Context, r io.ReadSeeker, meta *parquet.FileMetaData) (int64, error) {
+	offset := int64(len(magic))
+
+	extend := func(start, length int64) {
+		if end := start + length; end > offset {
+			offset = end
+		}
+	}
+
+	for _, rg := range meta.RowGroups {
+		for _, chunk := range rg.Columns {
+			if chunk.FilePath != nil {
+				return 0, fmt.Errorf("nyi: data is in another file: '%s'", *chunk.FilePath)
+			}
+			if chunk.MetaData == nil {
+				return 0, errors.New("missing meta data for column chunk")
+			}
+
+			start := chunk.MetaData.DataPageOffset
+			if chunk.MetaData.DictionaryPageOffset != nil && *chunk.MetaData.DictionaryPageOffset > 0 && *chunk.MetaData.DictionaryPageOffset < start {
+				start = *chunk.MetaData.DictionaryPageOffset
+			}
+			extend(start, chunk.MetaData.TotalCompressedSize)
+
+			if chunk.ColumnIndexOffset != nil && chunk.ColumnIndexLength != nil {
+				extend(*chunk.ColumnIndexOffset, int64(*chunk.ColumnIndexLength))
+			}
+			if chunk.OffsetIndexOffset != nil && chunk.OffsetIndexLength != nil {
+				extend(*chunk.OffsetIndexOffset, int64(*chunk.OffsetIndexLength))
+			}
+
+			if bloomFilterOffset := chunk.MetaData.BloomFilterOffset; bloomFilterOffset != nil {
+				if _, err := r.Seek(*bloomFilterOffset, io.SeekStart); err != nil {
+					return 0, err
+				}
+				or := &offsetReader{inner: r, offset: *bloomFilterOffset}
+				header := &parquet.BloomFilterHeader{}
+				if err := readThrift(ctx, header, or); err != nil {
+					return 0, fmt.Errorf("reading bloom filter header failed: %w", err)
+				}
+				extend(*bloomFilterOffset, or.Count()+int64(header.NumBytes))
+			}
+		}
+	}
+
+	return offset, nil
+}
@@ -0,0 +1,194 @@
+package goparquet
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+const appendTestSchema = `message test {
+	required int64 id;
+	optional binary name (STRING);
+}`
+
+func appendTestRows(t *testing.T, fw *FileWriter, from, to int) {
+	for i := from; i < to; i++ {
+		row := map[string]interface{}{"id": int64(i)}
+		if i%3 != 0 {
+			row["name"] = []byte(fmt.Sprintf("name-%d", i))
+		}
+		require.NoError(t, fw.AddData(row))
+	}
+	require.NoError(t, fw.FlushRowGroup())
+}
+
+func TestOpenFileWriterForAppend(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(appendTestSchema)
+	require.NoError(t, err)
+
+	f, err := ioutil.TempFile("", "parquet-append")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	fw := NewFileWriter(f,
+		WithSchemaDefinition(sd),
+		WithCompressionCodec(parquet.CompressionCodec_SNAPPY),
+		WithBloomFilter(ColumnPath{"name"}, 0.01),
+		WithMetaData(map[string]string{"foo": "bar"}),
+		WithCreator("ingestion"),
+	)
+	appendTestRows(t, fw, 0, 100)
+	appendTestRows(t, fw, 100, 200)
+	require.NoError(t, fw.Close())
+
+	for i := 0; i < 2; i++ {
+		fw, err = OpenFileWriterForAppend(f, WithCompressionCodec(parquet.CompressionCodec_GZIP), WithBloomFilter(ColumnPath{"name"}, 0.01))
+		require.NoError(t, err)
+		appendTestRows(t, fw, 200+i*100, 300+i*100)
+		require.NoError(t, fw.Close())
+	}
+
+	r, err := NewFileReader(f)
+	require.NoError(t, err)
+	require.Equal(t, 4, r.RowGroupCount())
+	require.Equal(t, int64(400), r.NumRows())
+	require.Equal(t, map[string]string{"foo": "bar"}, r.MetaData())
+	require.Equal(t, "ingestion", r.meta.GetCreatedBy())
+	require.Equal(t, parquet.CompressionCodec_SNAPPY, r.meta.RowGroups[1].Columns[0].MetaData.Codec)
+	require.Equal(t, parquet.CompressionCodec_GZIP, r.meta.RowGroups[2].Columns[0].MetaData.Codec)
+
+	for rg := 0; rg < 4; rg++ {
+		name := rg*100 + 1
+		if name%3 == 0 {
+			name++
+		}
+		ok, err := r.MightContain(rg, ColumnPath{"name"}, fmt.Sprintf("name-%d", name))
+		require.NoError(t, err)
+		require.True(t, ok, "row group %d", rg)
+
+		columnIndex, err := r.ColumnIndex(rg, ColumnPath{"id"})
+		require.NoError(t, err)
+		require.NotNil(t, columnIndex)
+		require.Equal(t, int64(rg*100), int64(binary.LittleEndian.Uint64(columnIndex.MinValues[0])))
+	}
+
+	for i := 0; i < 400; i++ {
+		row, err := r.NextRow()
+		require.NoError(t, err)
+		require.Equal(t, int64(i), row["id"])
+		if i%3 != 0 {
+			require.Equal(t, []byte(fmt.Sprintf("name-%d", i)), row["name"])
+		}
+	}
+}
+
+func TestOpenFileWriterForAppendTruncate(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(appendTestSchema)
+	require.NoError(t, err)
+
+	f, err := ioutil.TempFile("", "parquet-append")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	fw := NewFileWriter(f, WithSchemaDefinition(sd), WithMetaData(map[string]string{"large": string(make([]byte, 1000))}))
+	appendTestRows(t, fw, 0, 10)
+	require.NoError(t, fw.Close())
+
+	// dropping the large key-value meta data makes the footer smaller than before.
+	fw, err = OpenFileWriterForAppend(f, WithMetaData(nil))
+	require.NoError(t, err)
+	require.NoError(t, fw.Close())
+
+	st, err := f.Stat()
+	require.NoError(t, err)
+	require.Equal(t, fw.CurrentFileSize(), st.Size())
+
+	r, err := NewFileReader(f)
+	require.NoError(t, err)
+	require.Equal(t, int64(10), r.NumRows())
+	require.Empty(t, r.MetaData())
+
+	// in-memory files can't be truncated.
+	buf := &bytes.Buffer{}
+	fw = NewFileWriter(buf, WithSchemaDefinition(sd), WithMetaData(map[string]string{"large": string(make([]byte, 1000))}))
+	appendTestRows(t, fw, 0, 10)
+	require.NoError(t, fw.Close())
+
+	fw, err = OpenFileWriterForAppend(&memReadWriteSeeker{data: buf.Bytes()}, WithMetaData(nil))
+	require.NoError(t, err)
+	require.Error(t, fw.Close())
+}
+
+func TestOpenFileWriterForAppendSchemaMismatch(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(appendTestSchema)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	fw := NewFileWriter(buf, WithSchemaDefinition(sd))
+	appendTestRows(t, fw, 0, 10)
+	require.NoError(t, fw.Close())
+
+	_, err = OpenFileWriterForAppend(&memReadWriteSeeker{data: buf.Bytes()}, WithSchemaDefinition(sd))
+	require.NoError(t, err)
+
+	otherSD, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 id;
+		optional binary name (STRING);
+		optional double score;
+	}`)
+	require.NoError(t, err)
+
+	_, err = OpenFileWriterForAppend(&memReadWriteSeeker{data: buf.Bytes()}, WithSchemaDefinition(otherSD))
+	require.Error(t, err)
+
+	_, err = OpenFileWriterForAppend(&memReadWriteSeeker{data: []byte("PAR1 not a parquet file PAR1")})
+	require.Error(t, err)
+}
+
+func TestOpenFileWriterForAppendWithoutColumnOrders(t *testing.T) {
+	f := &memReadWriteSeeker{data: writeLegacyUnsignedTestFile(t)}
+
+	fw, err := OpenFileWriterForAppend(f)
+	require.NoError(t, err)
+	require.NoError(t, fw.AddData(map[string]interface{}{"a": int32(1)}))
+	require.NoError(t, fw.Close())
+
+	r, err := NewFileReader(bytes.NewReader(f.data))
+	require.NoError(t, err)
+	require.Nil(t, r.meta.ColumnOrders)
+	require.Equal(t, 3, countFilteredRows(t, f.data, Eq(ColumnPath{"a"}, uint32(1))))
+}
+
+func TestOpenFileWriterForAppendWithContext(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(appendTestSchema)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	fw := NewFileWriter(buf, WithSchemaDefinition(sd))
+	appendTestRows(t, fw, 0, 10)
+	require.NoError(t, fw.Close())
+
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "append")
+
+	f := &memReadWriteSeeker{data: buf.Bytes()}
+	fw, err = OpenFileWriterForAppendWithContext(ctx, f)
+	require.NoError(t, err)
+	require.Equal(t, ctx, fw.ctx)
+	appendTestRows(t, fw, 10, 20)
+	require.NoError(t, fw.Close())
+
+	r, err := NewFileReader(bytes.NewReader(f.data))
+	require.NoError(t, err)
+	require.Equal(t, int64(20), r.NumRows())
+}
@@ -21,54 +21,99 @@ func ReadFileMetaData(r io.ReadSeeker, extraValidation bool) (*parquet.FileMetaD
 
 // ReadFileMetaDataWithContext reads and returns the meta data of a parquet file. You can use this function
 // to read and inspect the meta data before starting to read the whole parquet file.
+//
+// Files with an encrypted footer can't be read by this function. For encrypted files with a plaintext
+// footer, the meta data of encrypted columns is returned without statistics.
 func ReadFileMetaDataWithContext(ctx context.Context, r io.ReadSeeker, extraValidation bool) (*parquet.FileMetaData, error) {
+	meta, _, err := readFileMetaData(ctx, r, extraValidation, nil, nil)
+	return meta, err
+}
+
+// readFileMetaData reads the meta data of a parquet file. If the file is encrypted, the meta data
+// is decrypted using the keys retrieved from kr, and the returned file decryption can be used to
+// decrypt the remaining modules of the file.
+func readFileMetaData(ctx context.Context, r io.ReadSeeker, extraValidation bool, kr KeyRetriever, aadPrefix []byte) (*parquet.FileMetaData, *fileDecryption, error) {
+	// read footer length and the file magic footer
+	footerPos, err := r.Seek(-8, io.SeekEnd)
+	if err != nil {
+		return nil, nil, fmt.Errorf("seek for the footer len failed: %w", err)
+	}
+	var fl int32
+	if err := binary.Read(r, binary.LittleEndian, &fl); err != nil {
+		return nil, nil, fmt.Errorf("read the footer len failed: %w", err)
+	}
+
+	footerMagic := make([]byte, 4)
+	if _, err := io.ReadFull(r, footerMagic); err != nil {
+		return nil, nil, fmt.Errorf("read the file magic footer failed: %w", err)
+	}
+	encryptedFooter := bytes.Equal(footerMagic, encryptedMagic)
+
 	if extraValidation {
+		if !bytes.Equal(footerMagic, magic) && !encryptedFooter {
+			return nil, nil, errors.New("invalid parquet file footer")
+		}
+
 		if _, err := r.Seek(0, io.SeekStart); err != nil {
-			return nil, fmt.Errorf("seek for the file magic header failed: %w", err)
+			return nil, nil, fmt.Errorf("seek for the file magic header failed: %w", err)
 		}
 
 		buf := make([]byte, 4)
 		// read and validate header
 		if _, err := io.ReadFull(r, buf); err != nil {
-			return nil, fmt.Errorf("read the file magic header failed: %w", err)
-		}
-		if !bytes.Equal(buf, magic) {
-			return nil, errors.New("invalid parquet file header")
-		}
-
-		// read and validate footer
-		if _, err := r.Seek(-4, io.SeekEnd); err != nil {
-			return nil, fmt.Errorf("seek for the file magic footer failed: %w", err)
-		}
-
-		if _, err := io.ReadFull(r, buf); err != nil {
-			return nil, fmt.Errorf("read the file magic header failed: %w", err)
+			return nil, nil, fmt.Errorf("read the file magic header failed: %w", err)
 		}
-		if !bytes.Equal(buf, magic) {
-			return nil, errors.New("invalid parquet file footer")
+		if !bytes.Equal(buf, footerMagic) {
+			return nil, nil, errors.New("invalid parquet file header")
 		}
 	}
 
-	// read footer length
-	if _, err := r.Seek(-8, io.SeekEnd); err != nil {
-		return nil, fmt.Errorf("seek for the footer len failed: %w", err)
-	}
-	var fl int32
-	if err := binary.Read(r, binary.LittleEndian, &fl); err != nil {
-		return nil, fmt.Errorf("read the footer len failed: %w", err)
-	}
-	if fl <= 0 {
-		return nil, fmt.Errorf("invalid footer len %d", fl)
+	if fl <= 0 || int64(fl) > footerPos {
+		return nil, nil, fmt.Errorf("invalid footer len %d", fl)
 	}
 
 	// read file metadata
 	if _, err := r.Seek(-8-int64(fl), io.SeekEnd); err != nil {
-		return nil, fmt.Errorf("seek file meta data failed: %w", err)
+		return nil, nil, fmt.Errorf("seek file meta data failed: %w", err)
 	}
-	meta := &parquet.FileMetaData{}
-	if err := readThrift(ctx, meta, io.LimitReader(r, int64(fl))); err != nil {
-		return nil, fmt.Errorf("read file meta failed: %w", err)
+	footer := make([]byte, fl)
+	if _, err := io.ReadFull(r, footer); err != nil {
+		return nil, nil, fmt.Errorf("read file meta failed: %w", err)
+	}
+
+	var (
+		meta = &parquet.FileMetaData{}
+		dec  *fileDecryption
+	)
+	if encryptedFooter {
+		if meta, dec, err = readEncryptedFooter(ctx, footer, kr, aadPrefix); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		fr := bytes.NewReader(footer)
+		if err := readThrift(ctx, meta, fr); err != nil {
+			return nil, nil, fmt.Errorf("read file meta failed: %w", err)
+		}
+
+		if meta.EncryptionAlgorithm != nil {
+			if dec, err = newFileDecryption(meta.EncryptionAlgorithm, meta.FooterSigningKeyMetadata, kr, aadPrefix); err != nil {
+				return nil, nil, err
+			}
+			// without a key retriever, the file can still be read, as long as no encrypted
+			// columns are read.
+			if kr != nil {
+				if err := dec.verifyFooterSignature(footer, len(footer)-fr.Len()); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+	}
+
+	if dec != nil {
+		if err := dec.decryptColumnMetaData(ctx, meta); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	return meta, nil
+	return meta, dec, nil
 }
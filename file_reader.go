@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/fs"
 	"runtime"
 
 	"github.com/fraugster/parquet-go/parquet"
@@ -24,6 +25,18 @@ type FileReader struct {
 	ctx context.Context
 
 	allocTracker *allocTracker
+
+	bloomFilters map[*parquet.ColumnChunk]*splitBlockBloomFilter
+
+	rowGroupFilter boundPredicate
+
+	rowFilter        boundPredicate
+	rowFilterColumns filterColumns
+
+	decryption *fileDecryption
+
+	fileOpener    FileOpener
+	externalFiles map[string]io.ReadSeeker
 }
 
 // NewFileReaderWithOptions creates a new FileReader. You can provide a list of FileReaderOptions to configure
@@ -35,12 +48,20 @@ func NewFileReaderWithOptions(r io.ReadSeeker, readerOptions ...FileReaderOption
 		return nil, err
 	}
 
-	var err error
+	var (
+		err        error
+		decryption *fileDecryption
+	)
 	if opts.metaData == nil {
-		opts.metaData, err = ReadFileMetaData(r, true)
+		opts.metaData, decryption, err = readFileMetaData(opts.ctx, r, true, opts.keyRetriever, opts.aadPrefix)
 		if err != nil {
 			return nil, fmt.Errorf("reading file meta data failed: %w", err)
 		}
+	} else if opts.metaData.EncryptionAlgorithm != nil {
+		decryption, err = newFileDecryption(opts.metaData.EncryptionAlgorithm, opts.metaData.FooterSigningKeyMetadata, opts.keyRetriever, opts.aadPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file meta data: %w", err)
+		}
 	}
 
 	schema, err := makeSchema(opts.metaData, opts.validateCRC, opts.allocTracker)
@@ -48,17 +69,50 @@ func NewFileReaderWithOptions(r io.ReadSeeker, readerOptions ...FileReaderOption
 		return nil, fmt.Errorf("creating schema failed: %w", err)
 	}
 
+	var rowGroupFilter boundPredicate
+	if opts.rowGroupFilter != nil {
+		rowGroupFilter, err = opts.rowGroupFilter.bind(schema, opts.metaData)
+		if err != nil {
+			return nil, fmt.Errorf("invalid row group filter: %w", err)
+		}
+	}
+
+	var (
+		rowFilter        boundPredicate
+		rowFilterColumns filterColumns
+	)
+	if opts.rowFilter != nil {
+		rowFilter, err = opts.rowFilter.bind(schema, opts.metaData)
+		if err != nil {
+			return nil, fmt.Errorf("invalid row filter: %w", err)
+		}
+
+		for _, path := range rowFilter.columns() {
+			col := schema.GetColumnByPath(path)
+			if col.MaxRepetitionLevel() > 1 || (col.MaxRepetitionLevel() == 1 && col.rep != parquet.FieldRepetitionType_REPEATED) {
+				return nil, fmt.Errorf("invalid row filter: column %q is nested within a repeated group", path.flatName())
+			}
+			rowFilterColumns = append(rowFilterColumns, col)
+			schema.filterColumns = append(schema.filterColumns, path)
+		}
+	}
+
 	schema.SetSelectedColumns(opts.columns...)
 	// Reset the reader to the beginning of the file
 	if _, err := r.Seek(4, io.SeekStart); err != nil {
 		return nil, err
 	}
 	return &FileReader{
-		meta:         opts.metaData,
-		schemaReader: schema,
-		reader:       r,
-		ctx:          opts.ctx,
-		allocTracker: opts.allocTracker,
+		meta:             opts.metaData,
+		schemaReader:     schema,
+		reader:           r,
+		ctx:              opts.ctx,
+		allocTracker:     opts.allocTracker,
+		rowGroupFilter:   rowGroupFilter,
+		rowFilter:        rowFilter,
+		rowFilterColumns: rowFilterColumns,
+		decryption:       decryption,
+		fileOpener:       opts.fileOpener,
 	}, nil
 }
 
@@ -71,6 +125,14 @@ type fileReaderOptions struct {
 	columns      []ColumnPath
 	validateCRC  bool
 	allocTracker *allocTracker
+
+	rowGroupFilter Predicate
+	rowFilter      Predicate
+
+	keyRetriever KeyRetriever
+	aadPrefix    []byte
+
+	fileOpener FileOpener
 }
 
 func newFileReaderOptions() *fileReaderOptions {
@@ -138,6 +200,40 @@ func WithCRC32Validation(enable bool) FileReaderOption {
 	}
 }
 
+// WithRowGroupFilter configures a predicate that is evaluated against the statistics
+// of each row group before it is loaded. Row groups whose statistics show that none
+// of their rows can match the predicate are skipped. This also applies to row groups
+// selected using SeekToRowGroup, in which case the reader moves on to the next row
+// group that may contain matching rows.
+//
+// Please note that the filter only prunes whole row groups; row groups that are read
+// may still contain rows that don't match the predicate.
+func WithRowGroupFilter(pred Predicate) FileReaderOption {
+	return func(opts *fileReaderOptions) error {
+		opts.rowGroupFilter = pred
+		return nil
+	}
+}
+
+// WithRowFilter configures a predicate that is evaluated for each row. Rows that don't
+// match the predicate are skipped by NextRow. To evaluate the predicate, only the columns
+// used by the predicate are decoded first, and the remaining selected columns are only
+// assembled for rows that match. Columns that are used by the predicate but haven't been
+// selected are read, but not returned. The predicate is also evaluated against the
+// statistics of each row group to skip row groups that can't contain matching rows.
+//
+// Columns used in a row filter must not be nested within repeated groups. If a repeated
+// column is used, the predicate matches if it matches any of the column's values in a row.
+//
+// Please note that RowGroupNumRows still returns the number of rows in the row group
+// before filtering.
+func WithRowFilter(pred Predicate) FileReaderOption {
+	return func(opts *fileReaderOptions) error {
+		opts.rowFilter = pred
+		return nil
+	}
+}
+
 // WithMaximumMemorySize allows you to configure a maximum limit in terms of memory
 // that shall be allocated when reading this file. If the amount of memory gets over
 // this limit, further function calls will fail.
@@ -148,6 +244,62 @@ func WithMaximumMemorySize(maxSizeBytes uint64) FileReaderOption {
 	}
 }
 
+// WithKeyRetriever configures the KeyRetriever that provides the keys to read encrypted
+// files. Columns whose keys can't be retrieved can't be read, but all other columns of
+// the file can. For files with a plaintext footer, the signature of the footer is
+// verified if a key retriever is configured.
+func WithKeyRetriever(kr KeyRetriever) FileReaderOption {
+	return func(opts *fileReaderOptions) error {
+		opts.keyRetriever = kr
+		return nil
+	}
+}
+
+// WithAADPrefix supplies the AAD prefix of an encrypted file, which is required to read
+// files whose writer didn't store the AAD prefix in the file.
+func WithAADPrefix(prefix []byte) FileReaderOption {
+	return func(opts *fileReaderOptions) error {
+		opts.aadPrefix = prefix
+		return nil
+	}
+}
+
+// FileOpener opens the file identified by the path of a column chunk whose data is stored in
+// another file, such as the column chunks of a _metadata summary file. The path is relative to
+// the directory of the file that is being read. If the returned io.ReadSeeker implements
+// io.Closer as well, it is closed once no longer needed, i.e. when the FileReader moves on to
+// a row group that doesn't refer to the file, or by FileReader.Close.
+type FileOpener func(path string) (io.ReadSeeker, error)
+
+// NewFSFileOpener returns a FileOpener that opens files from fsys, which needs to contain the
+// files relative to the directory of the file that is being read. The files need to implement
+// io.Seeker, which is the case for files opened by os.DirFS.
+func NewFSFileOpener(fsys fs.FS) FileOpener {
+	return func(path string) (io.ReadSeeker, error) {
+		f, err := fsys.Open(path)
+		if err != nil {
+			return nil, err
+		}
+
+		r, ok := f.(io.ReadSeeker)
+		if !ok {
+			f.Close()
+			return nil, fmt.Errorf("file %q doesn't support seeking", path)
+		}
+
+		return r, nil
+	}
+}
+
+// WithFileOpener configures the FileOpener that is used to open the files that contain the data of
+// column chunks whose FilePath is set. Without a file opener, such column chunks can't be read.
+func WithFileOpener(opener FileOpener) FileReaderOption {
+	return func(opts *fileReaderOptions) error {
+		opts.fileOpener = opener
+		return nil
+	}
+}
+
 // NewFileReader creates a new FileReader. You can limit the columns that are read by providing
 // the names of the specific columns to read using dotted notation. If no columns are provided,
 // then all columns are read.
@@ -174,6 +326,68 @@ func NewFileReaderWithMetaData(r io.ReadSeeker, meta *parquet.FileMetaData, colu
 	return NewFileReaderWithOptions(r, WithFileMetaData(meta), WithColumns(columns...))
 }
 
+// Close closes all files that have been opened using the FileOpener configured with WithFileOpener.
+// The reader that was passed to NewFileReaderWithOptions is not closed.
+func (f *FileReader) Close() error {
+	return f.closeExternalFiles(nil)
+}
+
+// closeExternalFiles closes all files opened by the file opener that don't contain any column
+// chunks of the row group rg, which may be nil.
+func (f *FileReader) closeExternalFiles(rg *parquet.RowGroup) error {
+	used := make(map[string]bool)
+	if rg != nil {
+		for _, chunk := range rg.Columns {
+			if chunk.FilePath != nil {
+				used[*chunk.FilePath] = true
+			}
+		}
+	}
+
+	var firstErr error
+	for path, r := range f.externalFiles {
+		if used[path] {
+			continue
+		}
+		delete(f.externalFiles, path)
+		if c, ok := r.(io.Closer); ok {
+			if err := c.Close(); err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("closing %q failed: %w", path, err)
+			}
+		}
+	}
+	return firstErr
+}
+
+// chunkReader returns the reader for the file that contains the data of chunk, which is the file
+// that is being read unless the chunk's FilePath is set.
+func (f *FileReader) chunkReader(chunk *parquet.ColumnChunk) (io.ReadSeeker, error) {
+	if chunk.FilePath == nil {
+		return f.reader, nil
+	}
+
+	path := *chunk.FilePath
+	if r, ok := f.externalFiles[path]; ok {
+		return r, nil
+	}
+
+	if f.fileOpener == nil {
+		return nil, fmt.Errorf("data is in another file %q, but no file opener is configured", path)
+	}
+
+	r, err := f.fileOpener(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening %q failed: %w", path, err)
+	}
+
+	if f.externalFiles == nil {
+		f.externalFiles = make(map[string]io.ReadSeeker)
+	}
+	f.externalFiles[path] = r
+
+	return r, nil
+}
+
 func (*FileReader) recover(errp *error) {
 	if e := recover(); e != nil {
 		if _, ok := e.(runtime.Error); ok {
@@ -197,13 +411,34 @@ func (f *FileReader) SeekToRowGroupWithContext(ctx context.Context, rowGroupPosi
 	return f.readRowGroup(ctx)
 }
 
-// readRowGroup read the next row group into memory
+// readRowGroup read the next row group into memory. Row groups that can't match
+// the row group filter are skipped. Files opened by the file opener that the next row
+// group doesn't refer to are closed, so that reading a summary file of many files
+// doesn't keep all of them open.
 func (f *FileReader) readRowGroup(ctx context.Context) error {
-	if len(f.meta.RowGroups) <= f.rowGroupPosition {
-		return io.EOF
+	for {
+		if len(f.meta.RowGroups) <= f.rowGroupPosition {
+			if err := f.closeExternalFiles(nil); err != nil {
+				return err
+			}
+			return io.EOF
+		}
+		f.rowGroupPosition++
+
+		stats := rowGroupStats{f.meta.RowGroups[f.rowGroupPosition-1]}
+		if f.rowGroupFilter != nil && f.rowGroupFilter.evalStats(stats) == filterNever {
+			continue
+		}
+		if f.rowFilter != nil && f.rowFilter.evalStats(stats) == filterNever {
+			continue
+		}
+
+		if err := f.closeExternalFiles(f.meta.RowGroups[f.rowGroupPosition-1]); err != nil {
+			return err
+		}
+
+		return f.readRowGroupData(ctx) //, f.reader, f.schemaReader, f.meta.RowGroups[f.rowGroupPosition-1])
 	}
-	f.rowGroupPosition++
-	return f.readRowGroupData(ctx) //, f.reader, f.schemaReader, f.meta.RowGroups[f.rowGroupPosition-1])
 }
 
 // CurrentRowGroup returns information about the current row group.
@@ -263,12 +498,40 @@ func (f *FileReader) NextRow() (map[string]interface{}, error) {
 func (f *FileReader) NextRowWithContext(ctx context.Context) (row map[string]interface{}, err error) {
 	defer f.recover(&err)
 
-	if err := f.advanceIfNeeded(ctx); err != nil {
-		return nil, err
+	for {
+		if err := f.advanceIfNeeded(ctx); err != nil {
+			return nil, err
+		}
+
+		f.currentRecord++
+
+		if f.rowFilter != nil {
+			match, err := f.rowFilter.evalRow(f.rowFilterColumns)
+			if err != nil {
+				return nil, err
+			}
+			if !match {
+				if err := f.schemaReader.skipRow(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+
+		return f.schemaReader.getData()
 	}
+}
 
-	f.currentRecord++
-	return f.schemaReader.getData()
+// filterColumns provides the values of the current row of the columns used by a row filter.
+type filterColumns []*Column
+
+func (cols filterColumns) columnValue(path ColumnPath) (interface{}, error) {
+	for _, col := range cols {
+		if col.path.Equal(path) {
+			return col.data.peek(int32(col.maxD), int32(col.maxR))
+		}
+	}
+	return nil, fmt.Errorf("column %q is not used by the row filter", path.flatName())
 }
 
 // SkipRowGroup skips the currently loaded row group and advances to the next row group.
@@ -305,7 +568,7 @@ func (f *FileReader) ColumnMetaData(colName string) (map[string]string, error) {
 func (f *FileReader) ColumnMetaDataByPath(path ColumnPath) (metaData map[string]string, err error) {
 	defer f.recover(&err)
 	for _, col := range f.CurrentRowGroup().Columns {
-		if path.Equal(ColumnPath(col.MetaData.PathInSchema)) {
+		if col.MetaData != nil && path.Equal(ColumnPath(col.MetaData.PathInSchema)) {
 			return keyValueMetaDataToMap(col.MetaData.KeyValueMetadata), nil
 		}
 	}
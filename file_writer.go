@@ -2,8 +2,11 @@ package goparquet
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/binary"
+	"errors"
+	"fmt"
 	"io"
 
 	"github.com/fraugster/parquet-go/parquet"
@@ -29,10 +32,34 @@ type FileWriter struct {
 
 	rowGroups []*parquet.RowGroup
 
+	pageIndexDisabled bool
+	chunkIndexes      []*chunkIndex
+
+	sortingColumns []SortingColumn
+	sortRows       bool
+	sorter         *rowSorter
+	prevSortKey    []interface{}
+	bufferedRows   []map[string]interface{}
+	bufferedSize   int64 // the estimated size of the buffered rows.
+
 	codec parquet.CompressionCodec
 
+	concurrency int
+
 	newPageFunc newDataPageFunc
 
+	// appendTarget and appendFileSize are set if the writer appends to an existing file, which
+	// needs to be truncated if it ends up smaller than before.
+	appendTarget   io.Writer
+	appendFileSize int64
+
+	encryption *fileEncryption
+
+	// copiedTypeOrder records for the leaf columns whose column chunks were copied from other
+	// files, keyed by the index of the leaf column, whether all of those files declared the
+	// type defined order for the column.
+	copiedTypeOrder map[int]bool
+
 	ctx context.Context
 
 	schemaDef *parquetschema.SchemaDefinition
@@ -44,11 +71,17 @@ type FileWriterOption func(fw *FileWriter)
 // NewFileWriter creates a new FileWriter. You can provide FileWriterOptions to influence the
 // file writer's behaviour.
 func NewFileWriter(w io.Writer, options ...FileWriterOption) *FileWriter {
+	return newFileWriter(w, 0, options...)
+}
+
+// newFileWriter creates a new FileWriter that writes to w, which is positioned at offset pos
+// of the file.
+func newFileWriter(w io.Writer, pos int64, options ...FileWriterOption) *FileWriter {
 	bw := bufio.NewWriter(w)
 	fw := &FileWriter{
 		w: &writePosStruct{
 			w:   bw,
-			pos: 0,
+			pos: pos,
 		},
 		bw:           bw,
 		version:      1,
@@ -64,6 +97,12 @@ func NewFileWriter(w io.Writer, options ...FileWriterOption) *FileWriter {
 		opt(fw)
 	}
 
+	if fw.schemaWriter.pageSpill != nil {
+		fw.schemaWriter.pageSpill.ctx = fw.ctx
+		fw.schemaWriter.pageSpill.codec = fw.codec
+		fw.schemaWriter.pageSpill.newPageFunc = fw.newPageFunc
+	}
+
 	// if a WithSchemaDefinition option was provided, the schema needs to be set after everything else
 	// as other options can change settings on the schemaWriter (such as the maximum page size).
 	if fw.schemaDef != nil {
@@ -96,6 +135,38 @@ func WithCompressionCodec(codec parquet.CompressionCodec) FileWriterOption {
 	}
 }
 
+// WithColumnCompressionCodec sets the compression codec used for the column identified by path,
+// overriding the codec set using WithCompressionCodec and the codec in the column's parameters.
+func WithColumnCompressionCodec(path ColumnPath, codec parquet.CompressionCodec) FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.schemaWriter.columnCodecs = append(fw.schemaWriter.columnCodecs, columnCodec{path: path, codec: codec})
+	}
+}
+
+// WithDictionaryLimits sets the maximum number of entries and the maximum size in bytes of the
+// dictionary page of every column chunk that uses dictionary encoding. The dictionary of a column
+// chunk is built page by page. Once adding a page would exceed one of the limits, the pages that
+// have already been dictionary-encoded are kept, and that page and all subsequent pages of the
+// column chunk are written using the encoding of the column's store instead. A maxEntries of 0
+// or less means the default of 32767 entries, a maxSize of 0 or less means that the size of the
+// dictionary page is not limited, which is the default.
+func WithDictionaryLimits(maxEntries int, maxSize int64) FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.schemaWriter.dictLimits = dictionaryLimits{maxEntries: maxEntries, maxSize: maxSize}
+	}
+}
+
+// WithColumnDictionaryLimits sets the dictionary limits for the column identified by path,
+// overriding the limits set using WithDictionaryLimits. See WithDictionaryLimits for details.
+func WithColumnDictionaryLimits(path ColumnPath, maxEntries int, maxSize int64) FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.schemaWriter.columnDictLimits = append(fw.schemaWriter.columnDictLimits, columnDictionaryLimits{
+			path:   path,
+			limits: dictionaryLimits{maxEntries: maxEntries, maxSize: maxSize},
+		})
+	}
+}
+
 // WithMetaData sets the key-value meta data on the file.
 func WithMetaData(data map[string]string) FileWriterOption {
 	return func(fw *FileWriter) {
@@ -107,6 +178,38 @@ func WithMetaData(data map[string]string) FileWriterOption {
 	}
 }
 
+// WithWriterConcurrency sets the number of goroutines that are used to encode and compress
+// the column chunks of a row group in parallel. Every column chunk is encoded into its own
+// buffer, and the buffers are written in schema order, so the resulting file is the same
+// regardless of the concurrency. Values of 1 or less disable concurrent encoding, which is
+// the default. Block compressors registered using RegisterBlockCompressor must be safe for
+// concurrent use if concurrency is enabled.
+func WithWriterConcurrency(n int) FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.concurrency = n
+	}
+}
+
+// WithPageSpill enables streaming mode, in which data pages are encoded and compressed as
+// soon as they are full, and written to sink. When a row group is flushed, its pages are copied
+// from sink to the file. This bounds the memory required to write a row group by the page size
+// rather than the row group size. The sink is reused for every row group, and is not closed by
+// the file writer.
+func WithPageSpill(sink io.ReadWriteSeeker) FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.schemaWriter.pageSpill = &pageSpill{rws: sink}
+	}
+}
+
+// WithTempFilePageSpill enables streaming mode like WithPageSpill, but writes the pages to
+// a temporary file that is created in dir. If dir is empty, the default directory for
+// temporary files is used. The temporary file is removed when the file writer is closed.
+func WithTempFilePageSpill(dir string) FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.schemaWriter.pageSpill = &pageSpill{tempDir: dir}
+	}
+}
+
 // WithMaxRowGroupSize sets the rough maximum size of a row group before it shall
 // be flushed automatically. Please note that enabling auto-flush will not allow
 // you to set per-column-chunk meta-data upon calling FlushRowGroup. If you
@@ -145,6 +248,106 @@ func WithCRC(enableCRC bool) FileWriterOption {
 	}
 }
 
+// WithPageIndex enables or disables writing the column index and the offset index
+// of all column chunks (also known as page index). Page indexes allow readers to
+// locate individual data pages and skip them based on their min and max values.
+// By default, page indexes are written.
+func WithPageIndex(enable bool) FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.pageIndexDisabled = !enable
+	}
+}
+
+// WithBloomFilter enables writing a split-block bloom filter for the column identified
+// by path. The bloom filter is sized according to the number of distinct values of each
+// column chunk so that the false positive probability fpp is met. fpp needs to be greater
+// than 0 and less than 1, otherwise a false positive probability of 0.01 is used.
+// Bloom filters are not supported for boolean columns, so this option has no effect on them.
+func WithBloomFilter(path ColumnPath, fpp float64) FileWriterOption {
+	return func(fw *FileWriter) {
+		if fpp <= 0 || fpp >= 1 {
+			fpp = defaultBloomFilterFPP
+		}
+		fw.schemaWriter.bloomFilters = append(fw.schemaWriter.bloomFilters, bloomFilterColumn{
+			path: path,
+			fpp:  fpp,
+		})
+	}
+}
+
+// WithSortingColumns declares the columns by which the rows of each row group are sorted.
+// The sorting columns are recorded in the row group meta data, so that readers and query
+// engines can make use of the sort order. By default, AddData returns an error if a row is
+// added out of order. To let the writer sort the rows of each row group instead, use
+// WithRowSorting.
+func WithSortingColumns(cols ...SortingColumn) FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.sortingColumns = cols
+	}
+}
+
+// WithRowSorting enables sorting the rows of each row group according to the sorting columns
+// declared using WithSortingColumns before the row group is written. The rows are buffered
+// until the row group is flushed, so they must not be modified after they have been passed
+// to AddData. They are only encoded when the row group is flushed, so rows that can't be
+// stored in the columns are reported by FlushRowGroup rather than AddData, and the maximum
+// row group size is compared against an estimate of the size of the buffered rows.
+func WithRowSorting(enable bool) FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.sortRows = enable
+	}
+}
+
+// WithFooterKey enables Parquet Modular Encryption, and encrypts the footer of the file with
+// key, which needs to be 16, 24 or 32 bytes long to use AES-128, AES-192 or AES-256. The
+// key metadata is stored in the file, so that readers can retrieve the key using their
+// KeyRetriever; it must not contain the key itself. Unless keys for individual columns are
+// configured using WithColumnKey, all columns are encrypted with the footer key as well.
+func WithFooterKey(key, keyMetadata []byte) FileWriterOption {
+	return func(fw *FileWriter) {
+		e := fw.fileEncryption()
+		e.footerKey = key
+		e.footerKeyMetadata = keyMetadata
+	}
+}
+
+// WithColumnKey encrypts the column identified by path with its own key. If path refers to
+// a group, all columns within the group are encrypted with the key. Once column keys are
+// configured, only columns with a column key are encrypted, while all other columns are
+// stored in plaintext. Encryption requires a footer key to be configured using WithFooterKey.
+func WithColumnKey(path ColumnPath, key, keyMetadata []byte) FileWriterOption {
+	return func(fw *FileWriter) {
+		e := fw.fileEncryption()
+		e.columnKeys = append(e.columnKeys, columnEncryptionKey{path: path, key: key, keyMetadata: keyMetadata})
+	}
+}
+
+// WithPlaintextFooter keeps the footer of an encrypted file in plaintext, so that readers
+// without the keys, or without support for encryption, can read the file's meta data and
+// its unencrypted columns. The footer is signed with the footer key, though, so that readers
+// with the key can detect whether it has been tampered with. The statistics of encrypted
+// columns are removed from the plaintext footer.
+func WithPlaintextFooter(enable bool) FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.fileEncryption().plaintextFooter = enable
+	}
+}
+
+// WithEncryptionAlgorithm sets the algorithm that is used to encrypt the file. The default is
+// EncryptionAESGCM.
+func WithEncryptionAlgorithm(alg EncryptionAlgorithm) FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.fileEncryption().algorithm = alg
+	}
+}
+
+func (fw *FileWriter) fileEncryption() *fileEncryption {
+	if fw.encryption == nil {
+		fw.encryption = &fileEncryption{}
+	}
+	return fw.encryption
+}
+
 // WithWriterContext overrides the default context (which is a context.Background())
 // in the FileWriter with the provided context.Context object.
 func WithWriterContext(ctx context.Context) FileWriterOption {
@@ -232,13 +435,27 @@ func (fw *FileWriter) FlushRowGroup(opts ...FlushRowGroupOption) error {
 
 // FlushRowGroupWithContext writes the current row group to the parquet file.
 func (fw *FileWriter) FlushRowGroupWithContext(ctx context.Context, opts ...FlushRowGroupOption) error {
+	if err := fw.schemaWriter.finishColumnBatches(); err != nil {
+		return err
+	}
+
 	// Write the entire row group
+	if err := fw.addBufferedRows(); err != nil {
+		return err
+	}
+
 	if fw.schemaWriter.rowGroupNumRecords() == 0 {
 		return nil
 	}
 
+	if fw.encryption != nil {
+		if err := fw.encryption.init(); err != nil {
+			return err
+		}
+	}
+
 	if fw.w.Pos() == 0 {
-		if err := writeFull(fw.w, magic); err != nil {
+		if err := writeFull(fw.w, fw.fileMagic()); err != nil {
 			return err
 		}
 	}
@@ -249,11 +466,46 @@ func (fw *FileWriter) FlushRowGroupWithContext(ctx context.Context, opts ...Flus
 		o(h)
 	}
 
-	cc, err := writeRowGroup(ctx, fw.w, fw.schemaWriter, fw.codec, fw.newPageFunc, h)
+	sorter, err := fw.rowSorter()
+	if err != nil {
+		return err
+	}
+
+	var sortingColumns []*parquet.SortingColumn
+	if sorter != nil {
+		sortingColumns = sorter.parquetSortingColumns()
+	}
+
+	concurrency := fw.concurrency
+	if fw.schemaWriter.pageSpill != nil {
+		// all pages have already been encoded, they only need to be copied from the page spill.
+		concurrency = 1
+	}
+
+	// the column chunks of encrypted files are written to a buffer first, from which they
+	// are encrypted page by page.
+	var (
+		w   = fw.w
+		buf *bytes.Buffer
+	)
+	if fw.encryption != nil {
+		buf = &bytes.Buffer{}
+		w = &writePosStruct{w: buf, pos: fw.w.Pos()}
+	}
+
+	cc, indexes, err := writeRowGroup(ctx, w, fw.schemaWriter, fw.codec, fw.newPageFunc, h, concurrency)
 	if err != nil {
 		return err
 	}
 
+	if fw.encryption != nil {
+		if err := fw.encryption.writeRowGroup(ctx, fw.w, buf.Bytes(), cc, indexes, len(fw.rowGroups)); err != nil {
+			return err
+		}
+	}
+
+	fw.chunkIndexes = append(fw.chunkIndexes, indexes...)
+
 	var totalCompressedSize, totalUncompressedSize int64
 
 	for _, c := range cc {
@@ -266,11 +518,12 @@ func (fw *FileWriter) FlushRowGroupWithContext(ctx context.Context, opts ...Flus
 		TotalByteSize:       totalUncompressedSize,
 		TotalCompressedSize: &totalCompressedSize,
 		NumRows:             fw.schemaWriter.rowGroupNumRecords(),
-		SortingColumns:      nil,
+		SortingColumns:      sortingColumns,
 	})
 	fw.totalNumRecords += fw.schemaWriter.rowGroupNumRecords()
 	// flush the schema
 	fw.schemaWriter.resetData()
+	fw.prevSortKey = nil
 
 	return nil
 }
@@ -278,17 +531,121 @@ func (fw *FileWriter) FlushRowGroupWithContext(ctx context.Context, opts ...Flus
 // AddData adds a new record to the current row group and flushes it if auto-flush is enabled and the size
 // is equal to or greater than the configured maximum row group size.
 func (fw *FileWriter) AddData(m map[string]interface{}) error {
-	if err := fw.schemaWriter.AddData(m); err != nil {
+	sorter, err := fw.rowSorter()
+	if err != nil {
 		return err
 	}
 
-	if fw.rowGroupFlushSize > 0 && fw.schemaWriter.DataSize() >= fw.rowGroupFlushSize {
+	switch {
+	case sorter != nil && fw.sortRows:
+		// the rows are only encoded once they have been sorted.
+		fw.bufferedRows = append(fw.bufferedRows, m)
+		fw.bufferedSize += estimateSize(m)
+	case sorter != nil:
+		sortKey := sorter.sortKey(m)
+		if fw.prevSortKey != nil && sorter.compare(fw.prevSortKey, sortKey) > 0 {
+			return errors.New("row is out of order according to the sorting columns")
+		}
+		if err := fw.schemaWriter.AddData(m); err != nil {
+			return err
+		}
+		fw.prevSortKey = sortKey
+	default:
+		if err := fw.schemaWriter.AddData(m); err != nil {
+			return err
+		}
+	}
+
+	if fw.rowGroupFlushSize > 0 && fw.CurrentRowGroupSize() >= fw.rowGroupFlushSize {
 		return fw.FlushRowGroup()
 	}
 
 	return nil
 }
 
+// addBufferedRows sorts the rows that have been buffered using WithRowSorting, and adds them
+// to the current row group.
+func (fw *FileWriter) addBufferedRows() error {
+	if len(fw.bufferedRows) == 0 {
+		return nil
+	}
+
+	sorter, err := fw.rowSorter()
+	if err != nil {
+		return err
+	}
+
+	rows := fw.bufferedRows
+	fw.bufferedRows, fw.bufferedSize = nil, 0
+
+	sorter.sort(rows)
+	for _, row := range rows {
+		if err := fw.schemaWriter.AddData(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// columnOrders returns the column orders of all leaf columns. The statistics of all columns are
+// computed according to the type defined order, but the statistics of copied column chunks are
+// taken over from their files. If any of them didn't declare the type defined order, no column
+// orders are returned, as they can only be declared for all columns or none of them, and
+// statistics of files without column orders may have been computed using signed comparisons.
+func (fw *FileWriter) columnOrders() []*parquet.ColumnOrder {
+	cols := fw.schemaWriter.Columns()
+	orders := make([]*parquet.ColumnOrder, 0, len(cols))
+	for idx := range cols {
+		if typeOrder, ok := fw.copiedTypeOrder[idx]; ok && !typeOrder {
+			return nil
+		}
+		orders = append(orders, &parquet.ColumnOrder{TYPE_ORDER: parquet.NewTypeDefinedOrder()})
+	}
+	return orders
+}
+
+// addCopiedColumnOrder records the column order of the leaf column idx that is declared by a
+// file whose column chunks are copied, as their statistics are taken over.
+func (fw *FileWriter) addCopiedColumnOrder(idx int, order *parquet.ColumnOrder) {
+	if fw.copiedTypeOrder == nil {
+		fw.copiedTypeOrder = make(map[int]bool)
+	}
+
+	typeOrder := order != nil && order.IsSetTYPE_ORDER()
+	if prev, ok := fw.copiedTypeOrder[idx]; ok {
+		typeOrder = typeOrder && prev
+	}
+	fw.copiedTypeOrder[idx] = typeOrder
+}
+
+// fileColumnOrder returns the column order of the leaf column idx that the file described by
+// meta declares, or nil if it doesn't declare one.
+func fileColumnOrder(meta *parquet.FileMetaData, idx int) *parquet.ColumnOrder {
+	if idx < len(meta.ColumnOrders) {
+		return meta.ColumnOrders[idx]
+	}
+	return nil
+}
+
+// rowSorter returns the row sorter for the configured sorting columns, or nil if no sorting
+// columns were configured.
+func (fw *FileWriter) rowSorter() (*rowSorter, error) {
+	if len(fw.sortingColumns) == 0 {
+		return nil, nil
+	}
+
+	if fw.sorter == nil {
+		sorter, err := newRowSorter(fw.schemaWriter, fw.sortingColumns)
+		if err != nil {
+			return nil, err
+		}
+		fw.sorter = sorter
+	}
+
+	return fw.sorter, nil
+}
+
 // Close flushes the current row group if necessary, taking the provided
 // options into account, and writes the meta data footer to the file.
 // Please be aware that this only finalizes the writing process. If you
@@ -303,13 +660,42 @@ func (fw *FileWriter) Close(opts ...FlushRowGroupOption) error {
 // Please be aware that this only finalizes the writing process. If you
 // provided a file as io.Writer when creating the FileWriter, you still need
 // to Close that file handle separately.
-func (fw *FileWriter) CloseWithContext(ctx context.Context, opts ...FlushRowGroupOption) error {
-	if fw.schemaWriter.rowGroupNumRecords() > 0 {
+func (fw *FileWriter) CloseWithContext(ctx context.Context, opts ...FlushRowGroupOption) (err error) {
+	if spill := fw.schemaWriter.pageSpill; spill != nil {
+		defer func() {
+			if closeErr := spill.close(); err == nil {
+				err = closeErr
+			}
+		}()
+	}
+
+	if err := fw.schemaWriter.finishColumnBatches(); err != nil {
+		return err
+	}
+
+	if fw.schemaWriter.rowGroupNumRecords() > 0 || len(fw.bufferedRows) > 0 {
 		if err := fw.FlushRowGroup(opts...); err != nil {
 			return err
 		}
 	}
 
+	if fw.encryption != nil {
+		if err := fw.encryption.init(); err != nil {
+			return err
+		}
+	}
+
+	if err := writeBloomFilters(ctx, fw.w, fw.chunkIndexes); err != nil {
+		return err
+	}
+
+	if !fw.pageIndexDisabled {
+		if err := writePageIndexes(ctx, fw.w, fw.chunkIndexes); err != nil {
+			return err
+		}
+	}
+	fw.chunkIndexes = nil
+
 	kv := make([]*parquet.KeyValue, 0, len(fw.kvStore))
 	for i := range fw.kvStore {
 		v := fw.kvStore[i]
@@ -329,11 +715,18 @@ func (fw *FileWriter) CloseWithContext(ctx context.Context, opts ...FlushRowGrou
 		RowGroups:        fw.rowGroups,
 		KeyValueMetadata: kv,
 		CreatedBy:        &fw.createdBy,
-		ColumnOrders:     nil,
+		ColumnOrders:     fw.columnOrders(),
 	}
 
 	pos := fw.w.Pos()
-	if err := writeThrift(ctx, meta, fw.w); err != nil {
+	if fw.encryption != nil {
+		if err := fw.encryption.encryptColumnMetaData(ctx, meta.RowGroups); err != nil {
+			return err
+		}
+		if err := fw.encryption.writeFooter(ctx, fw.w, meta); err != nil {
+			return err
+		}
+	} else if err := writeThrift(ctx, meta, fw.w); err != nil {
 		return err
 	}
 
@@ -342,18 +735,39 @@ func (fw *FileWriter) CloseWithContext(ctx context.Context, opts ...FlushRowGrou
 		return err
 	}
 
-	if err := writeFull(fw.w, magic); err != nil {
+	if err := writeFull(fw.w, fw.fileMagic()); err != nil {
 		return err
 	}
 
-	return fw.bw.Flush()
+	if err := fw.bw.Flush(); err != nil {
+		return err
+	}
+
+	if size := fw.w.Pos(); fw.appendTarget != nil && size < fw.appendFileSize {
+		t, ok := fw.appendTarget.(truncater)
+		if !ok {
+			return fmt.Errorf("file needs to be truncated from %d to %d bytes, but it doesn't support truncation", fw.appendFileSize, size)
+		}
+		return t.Truncate(size)
+	}
+
+	return nil
+}
+
+// fileMagic returns the magic bytes at the start and at the end of the file, which
+// differ for files with an encrypted footer.
+func (fw *FileWriter) fileMagic() []byte {
+	if fw.encryption != nil && !fw.encryption.plaintextFooter {
+		return encryptedMagic
+	}
+	return magic
 }
 
 // CurrentRowGroupSize returns a rough estimation of the uncompressed size of the current row group data. If you selected
 // a compression format other than UNCOMPRESSED, the final size will most likely be smaller and will dpeend on how well
 // your data can be compressed.
 func (fw *FileWriter) CurrentRowGroupSize() int64 {
-	return fw.schemaWriter.DataSize()
+	return fw.schemaWriter.DataSize() + fw.bufferedSize
 }
 
 // CurrentFileSize returns the amount of data written to the file so far. This does not include data that is in the
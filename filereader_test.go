@@ -2,10 +2,12 @@ package goparquet
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"math/rand"
 	"testing"
 
+	"github.com/fraugster/parquet-go/parquet"
 	"github.com/fraugster/parquet-go/parquetschema"
 	"github.com/stretchr/testify/require"
 )
@@ -245,3 +247,86 @@ func TestIssue60(t *testing.T) {
 
 	t.Logf("row = %#v", row)
 }
+
+func BenchmarkNextRow(b *testing.B) {
+	testData := map[string]struct {
+		schema string
+		// store creates the store of the required column a, if the schema isn't set.
+		store func() (*ColumnStore, error)
+		row   func(i int) map[string]interface{}
+	}{
+		"int64-plain": {
+			schema: `message test { required int64 a; required int64 b; }`,
+			row: func(i int) map[string]interface{} {
+				return map[string]interface{}{"a": int64(i), "b": rand.Int63()}
+			},
+		},
+		"int64-delta": {
+			store: func() (*ColumnStore, error) {
+				return NewInt64Store(parquet.Encoding_DELTA_BINARY_PACKED, false, &ColumnParameters{})
+			},
+			row: func(i int) map[string]interface{} {
+				return map[string]interface{}{"a": int64(i)}
+			},
+		},
+		"double-optional": {
+			schema: `message test { optional double a; optional double b; }`,
+			row: func(i int) map[string]interface{} {
+				row := map[string]interface{}{"a": rand.Float64()}
+				if i%4 != 0 {
+					row["b"] = float64(i)
+				}
+				return row
+			},
+		},
+		"string-dictionary": {
+			schema: `message test { required binary a (STRING); }`,
+			row: func(i int) map[string]interface{} {
+				return map[string]interface{}{"a": []byte(fmt.Sprintf("value-%d", i%100))}
+			},
+		},
+		"int32-repeated": {
+			schema: `message test { repeated int32 a; }`,
+			row: func(i int) map[string]interface{} {
+				return map[string]interface{}{"a": []int32{int32(i), int32(i + 1), int32(i + 2)}}
+			},
+		},
+	}
+
+	const numRows = 100000
+
+	for name, tt := range testData {
+		b.Run(name, func(b *testing.B) {
+			buf := &bytes.Buffer{}
+			fw := NewFileWriter(buf)
+			if tt.store != nil {
+				store, err := tt.store()
+				require.NoError(b, err)
+				require.NoError(b, fw.AddColumnByPath(ColumnPath{"a"}, NewDataColumn(store, parquet.FieldRepetitionType_REQUIRED)))
+			} else {
+				sd, err := parquetschema.ParseSchemaDefinition(tt.schema)
+				require.NoError(b, err)
+				require.NoError(b, fw.SetSchemaDefinition(sd))
+			}
+			for i := 0; i < numRows; i++ {
+				require.NoError(b, fw.AddData(tt.row(i)))
+			}
+			require.NoError(b, fw.Close())
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+				require.NoError(b, err)
+				for {
+					_, err := r.NextRow()
+					if err == io.EOF {
+						break
+					}
+					require.NoError(b, err)
+				}
+			}
+			b.ReportMetric(float64(b.N*numRows)/b.Elapsed().Seconds(), "rows/s")
+		})
+	}
+}
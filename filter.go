@@ -0,0 +1,1000 @@
+package goparquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/fraugster/parquet-go/parquet"
+)
+
+// Predicate is a filter expression over one or more columns. Predicates are
+// created using the functions Eq, Lt, Gt, In, IsNull, And, Or and Not, and can
+// be used to skip row groups that cannot contain matching rows, see
+// WithRowGroupFilter, or to skip individual rows, see WithRowFilter.
+//
+// Comparisons against null values never match, i.e. a row where a column is null
+// matches neither Eq, Lt, Gt nor In on that column, but only IsNull. For repeated
+// columns, a predicate matches if it matches any of the values.
+type Predicate interface {
+	bind(sch *schema, meta *parquet.FileMetaData) (boundPredicate, error)
+}
+
+// filterResult is the result of evaluating a predicate against statistics.
+type filterResult int
+
+const (
+	// filterMaybe means that some of the rows may match.
+	filterMaybe filterResult = iota
+	// filterNever means that none of the rows match.
+	filterNever
+	// filterAlways means that all of the rows match.
+	filterAlways
+)
+
+func (r filterResult) not() filterResult {
+	switch r {
+	case filterNever:
+		return filterAlways
+	case filterAlways:
+		return filterNever
+	default:
+		return filterMaybe
+	}
+}
+
+type boundPredicate interface {
+	evalStats(sp statsProvider) filterResult
+	evalRow(rv rowValues) (bool, error)
+	columns() []ColumnPath
+}
+
+type rowValues interface {
+	// columnValue returns the value of the column identified by path in the current row.
+	// For repeated columns, a slice of all values is returned. Null values are returned
+	// as nil.
+	columnValue(path ColumnPath) (interface{}, error)
+}
+
+// columnStats contains the statistics of a column chunk. min and max are plain-encoded.
+type columnStats struct {
+	min, max  []byte
+	nullCount *int64
+	numValues int64
+
+	// legacy is set if min and max were taken from the deprecated min and max
+	// fields, which are only valid for columns with signed sort order.
+	legacy bool
+}
+
+type statsProvider interface {
+	// columnStats returns the statistics of the column identified by path, or nil if
+	// no statistics are available.
+	columnStats(path ColumnPath) *columnStats
+}
+
+type rowGroupStats struct {
+	rowGroup *parquet.RowGroup
+}
+
+func (s rowGroupStats) columnStats(path ColumnPath) *columnStats {
+	for _, chunk := range s.rowGroup.Columns {
+		if chunk.MetaData == nil || !path.Equal(ColumnPath(chunk.MetaData.PathInSchema)) {
+			continue
+		}
+
+		cs := &columnStats{numValues: chunk.MetaData.NumValues}
+
+		stats := chunk.MetaData.Statistics
+		if stats == nil {
+			return cs
+		}
+
+		cs.nullCount = stats.NullCount
+		switch {
+		case stats.MinValue != nil && stats.MaxValue != nil:
+			cs.min, cs.max = stats.MinValue, stats.MaxValue
+		case stats.Min != nil && stats.Max != nil:
+			cs.min, cs.max = stats.Min, stats.Max
+			cs.legacy = true
+		}
+
+		return cs
+	}
+
+	return nil
+}
+
+// Eq returns a predicate that matches rows where the column identified by path equals value.
+//
+// The value needs to be compatible with the column's type: any integer type for INT32 and
+// INT64 columns, time.Time for DATE and TIMESTAMP columns, float32 or float64 for FLOAT and
+// DOUBLE columns, bool for BOOLEAN columns, and []byte, string or byte arrays for BYTE_ARRAY
+// and FIXED_LEN_BYTE_ARRAY columns. Decimals stored as binary are provided as their
+// big-endian two's complement representation.
+func Eq(path ColumnPath, value interface{}) Predicate {
+	return &comparisonPredicate{path: path, op: opEq, values: []interface{}{value}}
+}
+
+// Lt returns a predicate that matches rows where the column identified by path is less than value.
+func Lt(path ColumnPath, value interface{}) Predicate {
+	return &comparisonPredicate{path: path, op: opLt, values: []interface{}{value}}
+}
+
+// Gt returns a predicate that matches rows where the column identified by path is greater than value.
+func Gt(path ColumnPath, value interface{}) Predicate {
+	return &comparisonPredicate{path: path, op: opGt, values: []interface{}{value}}
+}
+
+// In returns a predicate that matches rows where the column identified by path equals any of values.
+func In(path ColumnPath, values ...interface{}) Predicate {
+	return &comparisonPredicate{path: path, op: opIn, values: values}
+}
+
+// IsNull returns a predicate that matches rows where the column identified by path is null.
+func IsNull(path ColumnPath) Predicate {
+	return &isNullPredicate{path: path}
+}
+
+// And returns a predicate that matches rows that match all of preds.
+func And(preds ...Predicate) Predicate {
+	return &andPredicate{preds: preds}
+}
+
+// Or returns a predicate that matches rows that match any of preds.
+func Or(preds ...Predicate) Predicate {
+	return &orPredicate{preds: preds}
+}
+
+// Not returns a predicate that matches rows that don't match pred.
+func Not(pred Predicate) Predicate {
+	return &notPredicate{pred: pred}
+}
+
+type compareOp int
+
+const (
+	opEq compareOp = iota
+	opLt
+	opGt
+	opIn
+)
+
+type comparisonPredicate struct {
+	path   ColumnPath
+	op     compareOp
+	values []interface{}
+}
+
+func (p *comparisonPredicate) bind(sch *schema, meta *parquet.FileMetaData) (boundPredicate, error) {
+	col, order, err := bindColumn(sch, meta, p.path)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.op != opIn && len(p.values) != 1 {
+		return nil, fmt.Errorf("comparison on column %q requires exactly one value", p.path.flatName())
+	}
+
+	values := make([]interface{}, 0, len(p.values))
+	for _, v := range p.values {
+		nv, err := order.normalize(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for column %q: %w", p.path.flatName(), err)
+		}
+		values = append(values, nv)
+	}
+
+	rowOrder := newValueOrder(col.Element(), true)
+	if rowOrder.kind == compareUndefined {
+		// values without a defined sort order (INT96 and INTERVAL) are compared by their
+		// byte representation when filtering rows.
+		rowOrder.kind = compareBytes
+	}
+
+	return &boundComparison{
+		path:     p.path,
+		op:       p.op,
+		values:   values,
+		order:    order,
+		rowOrder: rowOrder,
+		repeated: col.MaxRepetitionLevel() > 0,
+	}, nil
+}
+
+type boundComparison struct {
+	path     ColumnPath
+	op       compareOp
+	values   []interface{}
+	order    valueOrder // the order used to compare with statistics.
+	rowOrder valueOrder // the order used to compare with values of individual rows.
+	repeated bool
+}
+
+func (p *boundComparison) columns() []ColumnPath {
+	return []ColumnPath{p.path}
+}
+
+func (p *boundComparison) evalRow(rv rowValues) (bool, error) {
+	v, err := rv.columnValue(p.path)
+	if err != nil || v == nil {
+		return false, err
+	}
+
+	if !p.repeated {
+		return p.matchValue(v), nil
+	}
+
+	values := reflect.ValueOf(v)
+	if values.Kind() != reflect.Slice {
+		return false, fmt.Errorf("expected slice for repeated column %q, got %T", p.path.flatName(), v)
+	}
+	for i := 0; i < values.Len(); i++ {
+		if p.matchValue(values.Index(i).Interface()) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (p *boundComparison) matchValue(v interface{}) bool {
+	x, ok := p.rowOrder.fromStored(v)
+	if !ok {
+		return false
+	}
+
+	switch p.op {
+	case opEq, opIn:
+		for _, value := range p.values {
+			if p.rowOrder.compare(x, value) == 0 {
+				return true
+			}
+		}
+	case opLt:
+		return p.rowOrder.compare(x, p.values[0]) < 0
+	case opGt:
+		return p.rowOrder.compare(x, p.values[0]) > 0
+	}
+
+	return false
+}
+
+func (p *boundComparison) evalStats(sp statsProvider) filterResult {
+	cs := sp.columnStats(p.path)
+	if cs == nil {
+		return filterMaybe
+	}
+
+	if cs.numValues == 0 || (cs.nullCount != nil && *cs.nullCount == cs.numValues) {
+		// null values never match a comparison.
+		return filterNever
+	}
+
+	min, max, ok := p.order.decodeStats(cs)
+	if !ok {
+		return filterMaybe
+	}
+
+	// all rows can only match if there is exactly one non-null value per row. NaN values
+	// are left out of the statistics of floating point columns, so they might not match.
+	allMatch := !p.repeated && cs.nullCount != nil && *cs.nullCount == 0 && p.order.kind != compareFloat
+
+	if p.op == opIn {
+		res := filterNever
+		for _, v := range p.values {
+			switch p.evalEq(v, min, max, allMatch) {
+			case filterAlways:
+				return filterAlways
+			case filterMaybe:
+				res = filterMaybe
+			}
+		}
+		return res
+	}
+
+	v := p.values[0]
+
+	switch p.op {
+	case opEq:
+		return p.evalEq(v, min, max, allMatch)
+	case opLt:
+		if p.order.compare(min, v) >= 0 {
+			return filterNever
+		}
+		if allMatch && p.order.compare(max, v) < 0 {
+			return filterAlways
+		}
+	case opGt:
+		if p.order.compare(max, v) <= 0 {
+			return filterNever
+		}
+		if allMatch && p.order.compare(min, v) > 0 {
+			return filterAlways
+		}
+	}
+
+	return filterMaybe
+}
+
+func (p *boundComparison) evalEq(v, min, max interface{}, allMatch bool) filterResult {
+	minCmp, maxCmp := p.order.compare(v, min), p.order.compare(v, max)
+	if minCmp < 0 || maxCmp > 0 {
+		return filterNever
+	}
+	if allMatch && minCmp == 0 && maxCmp == 0 {
+		return filterAlways
+	}
+	return filterMaybe
+}
+
+type isNullPredicate struct {
+	path ColumnPath
+}
+
+func (p *isNullPredicate) bind(sch *schema, meta *parquet.FileMetaData) (boundPredicate, error) {
+	col, _, err := bindColumn(sch, meta, p.path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &boundIsNull{path: p.path, repeated: col.MaxRepetitionLevel() > 0}, nil
+}
+
+type boundIsNull struct {
+	path     ColumnPath
+	repeated bool
+}
+
+func (p *boundIsNull) columns() []ColumnPath {
+	return []ColumnPath{p.path}
+}
+
+func (p *boundIsNull) evalRow(rv rowValues) (bool, error) {
+	v, err := rv.columnValue(p.path)
+	if err != nil {
+		return false, err
+	}
+
+	if v == nil {
+		return true, nil
+	}
+
+	if p.repeated {
+		values := reflect.ValueOf(v)
+		return values.Kind() == reflect.Slice && values.Len() == 0, nil
+	}
+
+	return false, nil
+}
+
+func (p *boundIsNull) evalStats(sp statsProvider) filterResult {
+	cs := sp.columnStats(p.path)
+	if cs == nil || cs.nullCount == nil {
+		return filterMaybe
+	}
+
+	if *cs.nullCount == 0 {
+		return filterNever
+	}
+	if !p.repeated && *cs.nullCount == cs.numValues {
+		return filterAlways
+	}
+
+	return filterMaybe
+}
+
+type andPredicate struct {
+	preds []Predicate
+}
+
+func (p *andPredicate) bind(sch *schema, meta *parquet.FileMetaData) (boundPredicate, error) {
+	preds, err := bindPredicates(sch, meta, p.preds)
+	if err != nil {
+		return nil, err
+	}
+	return &boundAnd{preds: preds}, nil
+}
+
+type boundAnd struct {
+	preds []boundPredicate
+}
+
+func (p *boundAnd) columns() []ColumnPath {
+	return collectColumns(p.preds)
+}
+
+func (p *boundAnd) evalRow(rv rowValues) (bool, error) {
+	for _, pred := range p.preds {
+		ok, err := pred.evalRow(rv)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (p *boundAnd) evalStats(sp statsProvider) filterResult {
+	res := filterAlways
+	for _, pred := range p.preds {
+		switch pred.evalStats(sp) {
+		case filterNever:
+			return filterNever
+		case filterMaybe:
+			res = filterMaybe
+		}
+	}
+	return res
+}
+
+type orPredicate struct {
+	preds []Predicate
+}
+
+func (p *orPredicate) bind(sch *schema, meta *parquet.FileMetaData) (boundPredicate, error) {
+	preds, err := bindPredicates(sch, meta, p.preds)
+	if err != nil {
+		return nil, err
+	}
+	return &boundOr{preds: preds}, nil
+}
+
+type boundOr struct {
+	preds []boundPredicate
+}
+
+func (p *boundOr) columns() []ColumnPath {
+	return collectColumns(p.preds)
+}
+
+func (p *boundOr) evalRow(rv rowValues) (bool, error) {
+	for _, pred := range p.preds {
+		ok, err := pred.evalRow(rv)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+func (p *boundOr) evalStats(sp statsProvider) filterResult {
+	res := filterNever
+	for _, pred := range p.preds {
+		switch pred.evalStats(sp) {
+		case filterAlways:
+			return filterAlways
+		case filterMaybe:
+			res = filterMaybe
+		}
+	}
+	return res
+}
+
+type notPredicate struct {
+	pred Predicate
+}
+
+func (p *notPredicate) bind(sch *schema, meta *parquet.FileMetaData) (boundPredicate, error) {
+	if p.pred == nil {
+		return nil, errors.New("missing predicate in Not")
+	}
+	pred, err := p.pred.bind(sch, meta)
+	if err != nil {
+		return nil, err
+	}
+	return &boundNot{pred: pred}, nil
+}
+
+type boundNot struct {
+	pred boundPredicate
+}
+
+func (p *boundNot) columns() []ColumnPath {
+	return p.pred.columns()
+}
+
+func (p *boundNot) evalRow(rv rowValues) (bool, error) {
+	ok, err := p.pred.evalRow(rv)
+	return !ok, err
+}
+
+func (p *boundNot) evalStats(sp statsProvider) filterResult {
+	return p.pred.evalStats(sp).not()
+}
+
+func bindPredicates(sch *schema, meta *parquet.FileMetaData, preds []Predicate) ([]boundPredicate, error) {
+	bound := make([]boundPredicate, 0, len(preds))
+	for _, pred := range preds {
+		if pred == nil {
+			return nil, errors.New("missing predicate")
+		}
+		bp, err := pred.bind(sch, meta)
+		if err != nil {
+			return nil, err
+		}
+		bound = append(bound, bp)
+	}
+	return bound, nil
+}
+
+// collectColumns returns the distinct columns used by preds.
+func collectColumns(preds []boundPredicate) []ColumnPath {
+	var cols []ColumnPath
+	for _, pred := range preds {
+	outer:
+		for _, path := range pred.columns() {
+			for _, c := range cols {
+				if c.Equal(path) {
+					continue outer
+				}
+			}
+			cols = append(cols, path)
+		}
+	}
+	return cols
+}
+
+func bindColumn(sch *schema, meta *parquet.FileMetaData, path ColumnPath) (*Column, valueOrder, error) {
+	col := sch.GetColumnByPath(path)
+	if col == nil {
+		return nil, valueOrder{}, fmt.Errorf("column %q not found", path.flatName())
+	}
+	if !col.DataColumn() {
+		return nil, valueOrder{}, fmt.Errorf("column %q is not a leaf column", path.flatName())
+	}
+
+	typeDefinedOrder := false
+	if meta != nil {
+		for idx, c := range sch.Columns() {
+			if c.Path().Equal(path) {
+				typeDefinedOrder = idx < len(meta.ColumnOrders) && meta.ColumnOrders[idx].IsSetTYPE_ORDER()
+				break
+			}
+		}
+	}
+
+	return col, newValueOrder(col.Element(), typeDefinedOrder), nil
+}
+
+type compareKind int
+
+const (
+	compareUndefined compareKind = iota
+	compareSigned
+	compareUnsigned
+	compareFloat
+	compareBool
+	compareBytes
+	compareDecimalBytes
+)
+
+// valueOrder describes how values of a column are decoded and compared, according to its
+// physical and logical type.
+type valueOrder struct {
+	elem *parquet.SchemaElement
+	kind compareKind
+}
+
+func isUnsignedElement(elem *parquet.SchemaElement) bool {
+	if elem.LogicalType != nil && elem.LogicalType.IsSetINTEGER() {
+		return !elem.LogicalType.INTEGER.IsSigned
+	}
+	if elem.ConvertedType != nil {
+		switch *elem.ConvertedType {
+		case parquet.ConvertedType_UINT_8, parquet.ConvertedType_UINT_16, parquet.ConvertedType_UINT_32, parquet.ConvertedType_UINT_64:
+			return true
+		}
+	}
+	return false
+}
+
+func isDecimalElement(elem *parquet.SchemaElement) bool {
+	if elem.LogicalType != nil && elem.LogicalType.IsSetDECIMAL() {
+		return true
+	}
+	return elem.ConvertedType != nil && *elem.ConvertedType == parquet.ConvertedType_DECIMAL
+}
+
+// newValueOrder determines the sort order of a column. Statistics of columns whose sort order
+// is not signed are only trusted if the file declares the type defined order for the column,
+// as older writers computed them using signed comparisons.
+func newValueOrder(elem *parquet.SchemaElement, typeDefinedOrder bool) valueOrder {
+	o := valueOrder{elem: elem, kind: compareUndefined}
+
+	switch elem.GetType() {
+	case parquet.Type_BOOLEAN:
+		o.kind = compareBool
+	case parquet.Type_INT32, parquet.Type_INT64:
+		o.kind = compareSigned
+		if isUnsignedElement(elem) {
+			o.kind = compareUndefined
+			if typeDefinedOrder {
+				o.kind = compareUnsigned
+			}
+		}
+	case parquet.Type_FLOAT, parquet.Type_DOUBLE:
+		o.kind = compareFloat
+	case parquet.Type_BYTE_ARRAY, parquet.Type_FIXED_LEN_BYTE_ARRAY:
+		switch {
+		case isDecimalElement(elem):
+			if typeDefinedOrder {
+				o.kind = compareDecimalBytes
+			}
+		case elem.ConvertedType != nil && *elem.ConvertedType == parquet.ConvertedType_INTERVAL:
+			// the sort order of INTERVAL is undefined.
+		default:
+			o.kind = compareBytes
+		}
+	}
+
+	return o
+}
+
+func (o valueOrder) typ() parquet.Type {
+	return o.elem.GetType()
+}
+
+// decodeStats decodes the min and max values from the statistics. It returns false
+// if the statistics are missing or must not be used.
+func (o valueOrder) decodeStats(cs *columnStats) (min, max interface{}, ok bool) {
+	if o.kind == compareUndefined || cs.min == nil || cs.max == nil {
+		return nil, nil, false
+	}
+
+	if cs.legacy && o.kind != compareSigned && o.kind != compareFloat && o.kind != compareBool {
+		return nil, nil, false
+	}
+
+	min, err := o.decode(cs.min)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	max, err = o.decode(cs.max)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	if o.kind == compareFloat && (math.IsNaN(min.(float64)) || math.IsNaN(max.(float64))) {
+		return nil, nil, false
+	}
+
+	return min, max, true
+}
+
+func (o valueOrder) decode(data []byte) (interface{}, error) {
+	switch o.kind {
+	case compareBool:
+		if len(data) != 1 {
+			return nil, fmt.Errorf("invalid size %d for boolean value", len(data))
+		}
+		return data[0] != 0, nil
+	case compareSigned, compareUnsigned:
+		var u uint64
+		switch o.typ() {
+		case parquet.Type_INT32:
+			if len(data) != 4 {
+				return nil, fmt.Errorf("invalid size %d for int32 value", len(data))
+			}
+			if o.kind == compareSigned {
+				return int64(int32(binary.LittleEndian.Uint32(data))), nil
+			}
+			u = uint64(binary.LittleEndian.Uint32(data))
+		default:
+			if len(data) != 8 {
+				return nil, fmt.Errorf("invalid size %d for int64 value", len(data))
+			}
+			if o.kind == compareSigned {
+				return int64(binary.LittleEndian.Uint64(data)), nil
+			}
+			u = binary.LittleEndian.Uint64(data)
+		}
+		return u, nil
+	case compareFloat:
+		if o.typ() == parquet.Type_FLOAT {
+			if len(data) != 4 {
+				return nil, fmt.Errorf("invalid size %d for float value", len(data))
+			}
+			return float64(math.Float32frombits(binary.LittleEndian.Uint32(data))), nil
+		}
+		if len(data) != 8 {
+			return nil, fmt.Errorf("invalid size %d for double value", len(data))
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(data)), nil
+	case compareBytes, compareDecimalBytes:
+		return data, nil
+	}
+
+	return nil, errors.New("undefined sort order")
+}
+
+// fromStored converts a value as it is stored in a column into the representation that is
+// used for comparisons. It returns false if the value can't be compared, e.g. because it
+// is NaN.
+func (o valueOrder) fromStored(v interface{}) (interface{}, bool) {
+	switch x := v.(type) {
+	case bool:
+		return x, true
+	case int32:
+		if o.kind == compareUnsigned {
+			return uint64(uint32(x)), true
+		}
+		return int64(x), true
+	case int64:
+		if o.kind == compareUnsigned {
+			return uint64(x), true
+		}
+		return x, true
+	case float32:
+		return float64(x), !math.IsNaN(float64(x))
+	case float64:
+		return x, !math.IsNaN(x)
+	case []byte:
+		return x, true
+	case [12]byte:
+		return x[:], true
+	}
+
+	return nil, false
+}
+
+// normalize converts a value provided by the user into the representation that is
+// used for comparisons.
+func (o valueOrder) normalize(v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, errors.New("nil values can't be compared, use IsNull instead")
+	}
+
+	switch o.typ() {
+	case parquet.Type_BOOLEAN:
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("unsupported type %T for boolean column", v)
+		}
+		return b, nil
+	case parquet.Type_INT32, parquet.Type_INT64:
+		if t, ok := v.(time.Time); ok {
+			return o.normalizeTime(t)
+		}
+		if isUnsignedElement(o.elem) {
+			return toUint64(v)
+		}
+		return toInt64(v)
+	case parquet.Type_FLOAT, parquet.Type_DOUBLE:
+		var f float64
+		switch x := v.(type) {
+		case float32:
+			f = float64(x)
+		case float64:
+			f = x
+		default:
+			i, err := toInt64(v)
+			if err != nil {
+				return nil, fmt.Errorf("unsupported type %T for %s column", v, o.typ())
+			}
+			f = float64(i)
+		}
+		if math.IsNaN(f) {
+			return nil, errors.New("NaN can't be compared")
+		}
+		return f, nil
+	case parquet.Type_BYTE_ARRAY, parquet.Type_FIXED_LEN_BYTE_ARRAY:
+		switch x := v.(type) {
+		case []byte:
+			return x, nil
+		case string:
+			return []byte(x), nil
+		}
+		rv := reflect.ValueOf(v)
+		if rv.Kind() == reflect.Array && rv.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return b, nil
+		}
+		return nil, fmt.Errorf("unsupported type %T for %s column", v, o.typ())
+	case parquet.Type_INT96:
+		b, ok := v.([12]byte)
+		if !ok {
+			return nil, fmt.Errorf("unsupported type %T for int96 column", v)
+		}
+		return b[:], nil
+	}
+
+	return nil, fmt.Errorf("unsupported column type %s", o.typ())
+}
+
+func (o valueOrder) normalizeTime(t time.Time) (interface{}, error) {
+	elem := o.elem
+
+	if (elem.LogicalType != nil && elem.LogicalType.IsSetDATE()) || (elem.ConvertedType != nil && *elem.ConvertedType == parquet.ConvertedType_DATE) {
+		secs := t.Unix()
+		days := secs / 86400
+		if secs < 0 && secs%86400 != 0 {
+			days--
+		}
+		return days, nil
+	}
+
+	if elem.LogicalType != nil && elem.LogicalType.IsSetTIMESTAMP() {
+		unit := elem.LogicalType.TIMESTAMP.Unit
+		switch {
+		case unit.IsSetMILLIS():
+			return unixMillis(t), nil
+		case unit.IsSetMICROS():
+			return unixMicros(t), nil
+		case unit.IsSetNANOS():
+			return unixNanos(t)
+		}
+	}
+
+	if elem.ConvertedType != nil {
+		switch *elem.ConvertedType {
+		case parquet.ConvertedType_TIMESTAMP_MILLIS:
+			return unixMillis(t), nil
+		case parquet.ConvertedType_TIMESTAMP_MICROS:
+			return unixMicros(t), nil
+		}
+	}
+
+	return nil, errors.New("time.Time values are only supported for DATE and TIMESTAMP columns")
+}
+
+// unixMillis returns the number of milliseconds since the Unix epoch at t, rounded down. Unlike
+// t.UnixNano, it doesn't overflow for times before 1678 or after 2262.
+func unixMillis(t time.Time) int64 {
+	return t.Unix()*1e3 + int64(t.Nanosecond())/1e6
+}
+
+// unixMicros returns the number of microseconds since the Unix epoch at t, rounded down.
+func unixMicros(t time.Time) int64 {
+	return t.Unix()*1e6 + int64(t.Nanosecond())/1e3
+}
+
+// unixNanos returns the number of nanoseconds since the Unix epoch at t, or an error if t is
+// outside the range of nanosecond timestamps.
+func unixNanos(t time.Time) (int64, error) {
+	ns := t.UnixNano()
+	if (ns-int64(t.Nanosecond()))/1e9 != t.Unix() {
+		return 0, fmt.Errorf("time %s is out of range for a TIMESTAMP(NANOS) column", t.Format(time.RFC3339))
+	}
+	return ns, nil
+}
+
+func toInt64(v interface{}) (int64, error) {
+	switch x := v.(type) {
+	case int:
+		return int64(x), nil
+	case int8:
+		return int64(x), nil
+	case int16:
+		return int64(x), nil
+	case int32:
+		return int64(x), nil
+	case int64:
+		return x, nil
+	case uint8:
+		return int64(x), nil
+	case uint16:
+		return int64(x), nil
+	case uint32:
+		return int64(x), nil
+	case uint:
+		if uint64(x) > math.MaxInt64 {
+			return 0, fmt.Errorf("value %d is out of range", x)
+		}
+		return int64(x), nil
+	case uint64:
+		if x > math.MaxInt64 {
+			return 0, fmt.Errorf("value %d is out of range", x)
+		}
+		return int64(x), nil
+	}
+	return 0, fmt.Errorf("unsupported type %T for integer column", v)
+}
+
+func toUint64(v interface{}) (uint64, error) {
+	switch x := v.(type) {
+	case uint:
+		return uint64(x), nil
+	case uint8:
+		return uint64(x), nil
+	case uint16:
+		return uint64(x), nil
+	case uint32:
+		return uint64(x), nil
+	case uint64:
+		return x, nil
+	}
+
+	i, err := toInt64(v)
+	if err != nil {
+		return 0, err
+	}
+	if i < 0 {
+		return 0, fmt.Errorf("negative value %d for unsigned column", i)
+	}
+	return uint64(i), nil
+}
+
+// compare compares two normalized values. It returns a negative number if a < b,
+// 0 if a == b, and a positive number if a > b.
+func (o valueOrder) compare(a, b interface{}) int {
+	switch o.kind {
+	case compareBool:
+		x, y := a.(bool), b.(bool)
+		switch {
+		case x == y:
+			return 0
+		case !x:
+			return -1
+		default:
+			return 1
+		}
+	case compareSigned:
+		return compareInt64(a.(int64), b.(int64))
+	case compareUnsigned:
+		x, y := a.(uint64), b.(uint64)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	case compareFloat:
+		return compareFloat64(a.(float64), b.(float64))
+	case compareBytes:
+		return bytes.Compare(a.([]byte), b.([]byte))
+	case compareDecimalBytes:
+		return compareDecimal(a.([]byte), b.([]byte))
+	}
+
+	panic("values with undefined sort order can't be compared")
+}
+
+// compareDecimal compares two decimals in their big-endian two's complement representation.
+func compareDecimal(a, b []byte) int {
+	aNeg := len(a) > 0 && a[0]&0x80 != 0
+	bNeg := len(b) > 0 && b[0]&0x80 != 0
+
+	switch {
+	case aNeg && !bNeg:
+		return -1
+	case !aNeg && bNeg:
+		return 1
+	}
+
+	// both values have the same sign, so they can be compared byte by byte after
+	// sign-extending them to the same length.
+	var ext byte
+	if aNeg {
+		ext = 0xFF
+	}
+
+	l := len(a)
+	if len(b) > l {
+		l = len(b)
+	}
+
+	for i := 0; i < l; i++ {
+		x, y := ext, ext
+		if j := i - (l - len(a)); j >= 0 {
+			x = a[j]
+		}
+		if j := i - (l - len(b)); j >= 0 {
+			y = b[j]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return 0
+}
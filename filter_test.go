@@ -0,0 +1,479 @@
+package goparquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+func buildFilterTestFile(t *testing.T) []byte {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 id;
+		optional double score;
+		required int32 u (INT(32, false));
+		repeated int64 tags;
+	}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	fw := NewFileWriter(buf, WithSchemaDefinition(sd))
+
+	for i := 0; i < 500; i++ {
+		data := map[string]interface{}{
+			"id":   int64(i),
+			"u":    int32(i),
+			"tags": []int64{int64(i), int64(i + 1000)},
+		}
+		if i/100 != 2 {
+			data["score"] = float64(i) / 10
+		}
+		require.NoError(t, fw.AddData(data))
+		if (i+1)%100 == 0 {
+			require.NoError(t, fw.FlushRowGroup())
+		}
+	}
+	require.NoError(t, fw.Close())
+
+	return buf.Bytes()
+}
+
+func readFilteredRowGroups(t *testing.T, data []byte, pred Predicate) []int {
+	r, err := NewFileReaderWithOptions(bytes.NewReader(data), WithRowGroupFilter(pred))
+	require.NoError(t, err)
+
+	rowGroups := map[int]bool{}
+	for {
+		row, err := r.NextRow()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		rowGroups[int(row["id"].(int64)/100)] = true
+	}
+
+	result := []int{}
+	for rg := range rowGroups {
+		result = append(result, rg)
+	}
+	sort.Ints(result)
+	return result
+}
+
+func TestRowGroupFilter(t *testing.T) {
+	data := buildFilterTestFile(t)
+
+	id := ColumnPath{"id"}
+	score := ColumnPath{"score"}
+	tags := ColumnPath{"tags"}
+
+	tests := []struct {
+		name     string
+		pred     Predicate
+		expected []int
+	}{
+		{"eq", Eq(id, int64(150)), []int{1}},
+		{"eq_int_conversion", Eq(id, 150), []int{1}},
+		{"eq_no_match", Eq(id, 1000), []int{}},
+		{"lt", Lt(id, 100), []int{0}},
+		{"lt_boundary", Lt(id, 101), []int{0, 1}},
+		{"gt", Gt(id, 399), []int{4}},
+		{"in", In(id, 5, 450), []int{0, 4}},
+		{"is_null", IsNull(score), []int{2}},
+		{"not_is_null", Not(IsNull(score)), []int{0, 1, 3, 4}},
+		{"and", And(Gt(id, 50), Lt(id, 250)), []int{0, 1, 2}},
+		{"or", Or(Eq(id, 10), Eq(id, 310)), []int{0, 3}},
+		{"not", Not(Lt(id, 200)), []int{2, 3, 4}},
+		{"double", Gt(score, 45.0), []int{4}},
+		{"double_null_group", Lt(score, 25.0), []int{0, 1}},
+		{"repeated", Eq(tags, 1250), []int{2, 3, 4}},
+		{"repeated_no_match", Eq(tags, 2000), []int{}},
+		{"not_repeated", Not(Eq(tags, 1250)), []int{0, 1, 2, 3, 4}},
+		{"unsigned", Eq(ColumnPath{"u"}, uint32(150)), []int{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, readFilteredRowGroups(t, data, tt.pred))
+		})
+	}
+}
+
+func TestRowGroupFilterSeek(t *testing.T) {
+	data := buildFilterTestFile(t)
+
+	r, err := NewFileReaderWithOptions(bytes.NewReader(data), WithRowGroupFilter(Gt(ColumnPath{"id"}, 350)))
+	require.NoError(t, err)
+
+	require.NoError(t, r.SeekToRowGroup(1))
+	require.Equal(t, int64(300), int64(binary.LittleEndian.Uint64(r.CurrentRowGroup().Columns[0].MetaData.Statistics.MinValue)))
+
+	row, err := r.NextRow()
+	require.NoError(t, err)
+	require.Equal(t, int64(300), row["id"])
+}
+
+func TestRowGroupFilterInvalid(t *testing.T) {
+	data := buildFilterTestFile(t)
+
+	preds := []Predicate{
+		Eq(ColumnPath{"does_not_exist"}, 1),
+		Eq(ColumnPath{"id"}, "foo"),
+		Eq(ColumnPath{"id"}, nil),
+		Eq(ColumnPath{"score"}, math.NaN()),
+		Eq(ColumnPath{"u"}, -1),
+		And(Eq(ColumnPath{"id"}, 1), nil),
+		Not(nil),
+	}
+
+	for _, pred := range preds {
+		_, err := NewFileReaderWithOptions(bytes.NewReader(data), WithRowGroupFilter(pred))
+		require.Error(t, err)
+	}
+}
+
+type testStatsProvider map[string]*columnStats
+
+func (p testStatsProvider) columnStats(path ColumnPath) *columnStats {
+	return p[path.flatName()]
+}
+
+func TestPredicateEvalStats(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		optional int32 u (INT(32, false));
+		optional fixed_len_byte_array(2) dec (DECIMAL(4, 2));
+		optional binary s (STRING);
+		optional int96 ts;
+	}`)
+	require.NoError(t, err)
+
+	sch := &schema{}
+	require.NoError(t, sch.SetSchemaDefinition(sd))
+
+	withOrders := &parquet.FileMetaData{}
+	for range sch.Columns() {
+		withOrders.ColumnOrders = append(withOrders.ColumnOrders, &parquet.ColumnOrder{TYPE_ORDER: parquet.NewTypeDefinedOrder()})
+	}
+
+	uint32Bytes := func(v uint32) []byte {
+		b := make([]byte, 4)
+		binary.LittleEndian.PutUint32(b, v)
+		return b
+	}
+
+	stats := testStatsProvider{
+		"u":   {min: uint32Bytes(10), max: uint32Bytes(math.MaxUint32 - 10), nullCount: int64Ptr(0), numValues: 10},
+		"dec": {min: []byte{0xFF, 0x00}, max: []byte{0x01, 0x00}, nullCount: int64Ptr(1), numValues: 10},
+		"s":   {min: []byte("bar"), max: []byte("foo"), nullCount: int64Ptr(0), numValues: 10},
+		"ts":  {min: make([]byte, 12), max: make([]byte, 12), nullCount: int64Ptr(0), numValues: 10},
+	}
+
+	tests := []struct {
+		pred     Predicate
+		meta     *parquet.FileMetaData
+		expected filterResult
+	}{
+		{Eq(ColumnPath{"u"}, uint32(5)), withOrders, filterNever},
+		{Eq(ColumnPath{"u"}, uint32(math.MaxUint32-5)), withOrders, filterNever},
+		{Eq(ColumnPath{"u"}, uint32(math.MaxUint32-20)), withOrders, filterMaybe},
+		{Eq(ColumnPath{"u"}, uint32(5)), nil, filterMaybe},
+		{Lt(ColumnPath{"u"}, uint32(math.MaxUint32)), withOrders, filterAlways},
+		{Gt(ColumnPath{"dec"}, []byte{0x02, 0x00}), withOrders, filterNever},
+		{Lt(ColumnPath{"dec"}, []byte{0xFE, 0x00}), withOrders, filterNever},
+		{Lt(ColumnPath{"dec"}, []byte{0x00, 0x01}), withOrders, filterMaybe},
+		{Lt(ColumnPath{"dec"}, []byte{0xFE, 0x00}), nil, filterMaybe},
+		{Eq(ColumnPath{"s"}, "baz"), nil, filterMaybe},
+		{Eq(ColumnPath{"s"}, "zzz"), nil, filterNever},
+		{In(ColumnPath{"s"}, "aaa", "zzz"), nil, filterNever},
+		{Not(In(ColumnPath{"s"}, "aaa", "zzz")), nil, filterAlways},
+		{Lt(ColumnPath{"s"}, "zzz"), nil, filterAlways},
+		{IsNull(ColumnPath{"s"}), nil, filterNever},
+		{IsNull(ColumnPath{"dec"}), nil, filterMaybe},
+		{Eq(ColumnPath{"ts"}, [12]byte{}), withOrders, filterMaybe},
+		{Or(Eq(ColumnPath{"s"}, "zzz"), Eq(ColumnPath{"s"}, "baz")), nil, filterMaybe},
+		{And(Lt(ColumnPath{"s"}, "zzz"), Gt(ColumnPath{"s"}, "aaa")), nil, filterAlways},
+	}
+
+	for idx, tt := range tests {
+		bp, err := tt.pred.bind(sch, tt.meta)
+		require.NoError(t, err, "%d", idx)
+		require.Equal(t, tt.expected, bp.evalStats(stats), "%d", idx)
+	}
+}
+
+func TestCompareDecimal(t *testing.T) {
+	tests := []struct {
+		a, b     []byte
+		expected int
+	}{
+		{[]byte{0x01}, []byte{0x01}, 0},
+		{[]byte{0x00, 0x01}, []byte{0x01}, 0},
+		{[]byte{0xFF}, []byte{0xFF, 0xFF}, 0},
+		{[]byte{0xFF}, []byte{0x01}, -1},
+		{[]byte{0x01, 0x00}, []byte{0x7F}, 1},
+		{[]byte{0xFE}, []byte{0xFF, 0x00}, 1},
+		{[]byte{}, []byte{0x00}, 0},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, compareDecimal(tt.a, tt.b), "%x <=> %x", tt.a, tt.b)
+	}
+}
+
+func buildRowFilterTestFile(t *testing.T) []byte {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 id;
+		required int32 u (INT(32, false));
+		optional binary name (STRING);
+		optional group x {
+			required int64 c;
+			repeated group items {
+				required int32 v;
+			}
+		}
+		repeated int64 tags;
+	}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	fw := NewFileWriter(buf, WithSchemaDefinition(sd), WithMaxPageSize(128))
+
+	for i := 0; i < 500; i++ {
+		data := map[string]interface{}{
+			"id":   int64(i),
+			"u":    int32(uint32(i) + 1<<31),
+			"tags": []int64{int64(i), int64(i + 1000)},
+		}
+		if i%5 != 0 {
+			data["name"] = []byte(fmt.Sprintf("name-%03d", i))
+		}
+		if i%2 == 0 {
+			data["x"] = map[string]interface{}{
+				"c": int64(i * 2),
+				"items": []map[string]interface{}{
+					{"v": int32(i)},
+					{"v": int32(i + 1)},
+				},
+			}
+		}
+		require.NoError(t, fw.AddData(data))
+		if (i+1)%200 == 0 {
+			require.NoError(t, fw.FlushRowGroup())
+		}
+	}
+	require.NoError(t, fw.Close())
+
+	return buf.Bytes()
+}
+
+func readRowFilteredIDs(t *testing.T, data []byte, opts ...FileReaderOption) ([]int64, []map[string]interface{}) {
+	r, err := NewFileReaderWithOptions(bytes.NewReader(data), opts...)
+	require.NoError(t, err)
+
+	var (
+		ids  []int64
+		rows []map[string]interface{}
+	)
+	for {
+		row, err := r.NextRow()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		rows = append(rows, row)
+		if id, ok := row["id"]; ok {
+			ids = append(ids, id.(int64))
+		}
+	}
+	return ids, rows
+}
+
+func TestRowFilter(t *testing.T) {
+	data := buildRowFilterTestFile(t)
+
+	_, allRows := readRowFilteredIDs(t, data)
+	require.Len(t, allRows, 500)
+
+	id := ColumnPath{"id"}
+	name := ColumnPath{"name"}
+
+	tests := []struct {
+		name     string
+		pred     Predicate
+		expected func(i int64) bool
+	}{
+		{"eq", Eq(id, 150), func(i int64) bool { return i == 150 }},
+		{"not_eq", Not(Eq(id, 5)), func(i int64) bool { return i != 5 }},
+		{"lt", Lt(id, 10), func(i int64) bool { return i < 10 }},
+		{"in", In(id, 3, 250, 499, 1000), func(i int64) bool { return i == 3 || i == 250 || i == 499 }},
+		{"is_null", IsNull(name), func(i int64) bool { return i%5 == 0 }},
+		{"string", Gt(name, "name-490"), func(i int64) bool { return i > 490 && i%5 != 0 }},
+		{"nested", Gt(ColumnPath{"x", "c"}, 700), func(i int64) bool { return i%2 == 0 && i*2 > 700 }},
+		{"nested_null", IsNull(ColumnPath{"x", "c"}), func(i int64) bool { return i%2 != 0 }},
+		{"repeated", Eq(ColumnPath{"tags"}, 1250), func(i int64) bool { return i == 250 }},
+		{"unsigned", Gt(ColumnPath{"u"}, uint32(1<<31+400)), func(i int64) bool { return i > 400 }},
+		{"and_or", Or(And(Gt(id, 100), Lt(id, 110)), Eq(name, "name-333")), func(i int64) bool { return (i > 100 && i < 110) || i == 333 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, rows := readRowFilteredIDs(t, data, WithRowFilter(tt.pred))
+
+			var expected []int64
+			for i := int64(0); i < 500; i++ {
+				if tt.expected(i) {
+					expected = append(expected, i)
+				}
+			}
+			require.Equal(t, expected, ids)
+
+			for idx, row := range rows {
+				require.Equal(t, allRows[ids[idx]], row)
+			}
+		})
+	}
+}
+
+func TestFilterNaN(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required double x;
+	}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	fw := NewFileWriter(buf, WithSchemaDefinition(sd))
+	require.NoError(t, fw.AddData(map[string]interface{}{"x": 1.0}))
+	require.NoError(t, fw.AddData(map[string]interface{}{"x": math.NaN()}))
+	require.NoError(t, fw.Close())
+
+	readValues := func(opt FileReaderOption) []float64 {
+		r, err := NewFileReaderWithOptions(bytes.NewReader(buf.Bytes()), opt)
+		require.NoError(t, err)
+
+		var values []float64
+		for {
+			row, err := r.NextRow()
+			if err == io.EOF {
+				return values
+			}
+			require.NoError(t, err)
+			values = append(values, row["x"].(float64))
+		}
+	}
+
+	// NaN values aren't part of the statistics, so the row group may still contain
+	// matching rows.
+	pred := Not(Eq(ColumnPath{"x"}, 1.0))
+	require.Len(t, readValues(WithRowGroupFilter(pred)), 2)
+
+	values := readValues(WithRowFilter(pred))
+	require.Len(t, values, 1)
+	require.True(t, math.IsNaN(values[0]))
+}
+
+func TestFilterTimestampRange(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 ms (TIMESTAMP(MILLIS, true));
+		required int64 us (TIMESTAMP(MICROS, true));
+		required int64 ns (TIMESTAMP(NANOS, true));
+	}`)
+	require.NoError(t, err)
+
+	// the timestamps are outside of the range of nanosecond timestamps, except for the
+	// ones of the nanosecond column, which are all set to the Unix epoch.
+	times := []time.Time{
+		time.Date(1500, 6, 1, 12, 0, 0, 123456789, time.UTC),
+		time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(3000, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	buf := &bytes.Buffer{}
+	fw := NewFileWriter(buf, WithSchemaDefinition(sd))
+	for _, ts := range times {
+		require.NoError(t, fw.AddData(map[string]interface{}{
+			"ms": ts.Unix()*1e3 + int64(ts.Nanosecond())/1e6,
+			"us": ts.Unix()*1e6 + int64(ts.Nanosecond())/1e3,
+			"ns": int64(0),
+		}))
+	}
+	require.NoError(t, fw.Close())
+
+	countRows := func(opt FileReaderOption) int {
+		r, err := NewFileReaderWithOptions(bytes.NewReader(buf.Bytes()), opt)
+		require.NoError(t, err)
+
+		n := 0
+		for {
+			_, err := r.NextRow()
+			if err == io.EOF {
+				return n
+			}
+			require.NoError(t, err)
+			n++
+		}
+	}
+
+	farFuture := time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+	farPast := time.Date(1000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, col := range []string{"ms", "us"} {
+		testData := []struct {
+			pred     Predicate
+			expected int
+		}{
+			{pred: Lt(ColumnPath{col}, farFuture), expected: 3},
+			{pred: Gt(ColumnPath{col}, farPast), expected: 3},
+			{pred: Lt(ColumnPath{col}, time.Date(1600, 1, 1, 0, 0, 0, 0, time.UTC)), expected: 1},
+			{pred: Eq(ColumnPath{col}, times[2]), expected: 1},
+			{pred: Gt(ColumnPath{col}, times[0]), expected: 2},
+		}
+
+		for _, tt := range testData {
+			require.Equal(t, tt.expected, countRows(WithRowFilter(tt.pred)), "%s: %v", col, tt.pred)
+			if tt.expected > 0 {
+				require.Equal(t, 3, countRows(WithRowGroupFilter(tt.pred)), "%s: %v", col, tt.pred)
+			}
+		}
+	}
+
+	_, err = NewFileReaderWithOptions(bytes.NewReader(buf.Bytes()), WithRowGroupFilter(Lt(ColumnPath{"ns"}, farFuture)))
+	require.Error(t, err)
+}
+
+func TestRowFilterFilterOnlyColumns(t *testing.T) {
+	data := buildRowFilterTestFile(t)
+
+	ids, rows := readRowFilteredIDs(t, data,
+		WithColumnPaths(ColumnPath{"id"}, ColumnPath{"x", "items"}),
+		WithRowFilter(And(IsNull(ColumnPath{"name"}), Lt(ColumnPath{"x", "c"}, 100))),
+	)
+
+	require.Equal(t, []int64{0, 10, 20, 30, 40}, ids)
+	for _, row := range rows {
+		_, ok := row["name"]
+		require.False(t, ok)
+		x, ok := row["x"].(map[string]interface{})
+		require.True(t, ok)
+		_, ok = x["c"]
+		require.False(t, ok)
+		require.Len(t, x["items"], 2)
+	}
+}
+
+func TestRowFilterInvalid(t *testing.T) {
+	data := buildRowFilterTestFile(t)
+
+	_, err := NewFileReaderWithOptions(bytes.NewReader(data), WithRowFilter(Eq(ColumnPath{"x", "items", "v"}, 1)))
+	require.Error(t, err)
+
+	_, err = NewFileReaderWithOptions(bytes.NewReader(data), WithRowFilter(Eq(ColumnPath{"x"}, 1)))
+	require.Error(t, err)
+}
@@ -0,0 +1,45 @@
+package floor
+
+import (
+	goparquet "github.com/fraugster/parquet-go"
+	"github.com/fraugster/parquet-go/parquetschema"
+)
+
+// NewPartitionedWriter creates a new high-level writer for partitioned datasets
+// that writes objects using w.
+func NewPartitionedWriter(w *goparquet.PartitionedWriter) *PartitionedWriter {
+	return &PartitionedWriter{
+		w:         w,
+		schemaDef: w.GetSchemaDefinition(),
+	}
+}
+
+// PartitionedWriter represents a high-level writer for partitioned datasets. Objects
+// are marshalled according to the schema definition including the partition columns,
+// and each object is written to the file of its partition.
+type PartitionedWriter struct {
+	w         *goparquet.PartitionedWriter
+	schemaDef *parquetschema.SchemaDefinition
+}
+
+// Write adds a new object to be written to the dataset. If obj implements the
+// floor.Marshaller object, then obj.(Marshaller).Marshal will be called to
+// determine the data, otherwise reflection will be used.
+func (w *PartitionedWriter) Write(obj interface{}) error {
+	data, err := marshalObject(obj, w.schemaDef)
+	if err != nil {
+		return err
+	}
+
+	return w.w.AddData(data)
+}
+
+// Files returns the paths of all files that have been created so far.
+func (w *PartitionedWriter) Files() []string {
+	return w.w.Files()
+}
+
+// Close closes all open files of the dataset.
+func (w *PartitionedWriter) Close() error {
+	return w.w.Close()
+}
@@ -0,0 +1,111 @@
+package floor
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	goparquet "github.com/fraugster/parquet-go"
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionedWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "floor-partitioned")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 id;
+		required binary country (STRING);
+		required int32 year;
+	}`)
+	require.NoError(t, err)
+
+	pw, err := goparquet.NewPartitionedWriter(goparquet.NewDirectoryFileCreator(dir), sd, []string{"country", "year"})
+	require.NoError(t, err)
+
+	w := NewPartitionedWriter(pw)
+
+	type record struct {
+		ID      int64
+		Country string
+		Year    int32
+	}
+
+	for i := 0; i < 30; i++ {
+		require.NoError(t, w.Write(record{ID: int64(i), Country: []string{"DE", "FR", "US"}[i%3], Year: 2024}))
+	}
+	require.NoError(t, w.Close())
+
+	require.Equal(t, []string{
+		"country=DE/year=2024/part-00000.parquet",
+		"country=FR/year=2024/part-00000.parquet",
+		"country=US/year=2024/part-00000.parquet",
+	}, w.Files())
+
+	d, err := goparquet.NewDatasetFromDirectory(dir)
+	require.NoError(t, err)
+	require.Equal(t, int64(30), d.NumRows())
+
+	for i := 0; ; i++ {
+		row, err := d.NextRow()
+		if err == io.EOF {
+			require.Equal(t, 30, i)
+			break
+		}
+		require.NoError(t, err)
+		require.Equal(t, []byte([]string{"DE", "FR", "US"}[i/10]), row["country"])
+		require.Equal(t, int64(2024), row["year"])
+		require.Equal(t, int64(i/10+3*(i%10)), row["id"])
+	}
+}
+
+func TestPartitionedWriterDate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "floor-partitioned")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 id;
+		required int32 date (DATE);
+	}`)
+	require.NoError(t, err)
+
+	pw, err := goparquet.NewPartitionedWriter(goparquet.NewDirectoryFileCreator(dir), sd, []string{"date"})
+	require.NoError(t, err)
+
+	w := NewPartitionedWriter(pw)
+
+	type record struct {
+		ID   int64
+		Date time.Time
+	}
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, w.Write(record{ID: int64(i), Date: time.Date(2024, 1, 1+i%2, 0, 0, 0, 0, time.UTC)}))
+	}
+	require.NoError(t, w.Close())
+
+	require.Equal(t, []string{
+		"date=2024-01-01/part-00000.parquet",
+		"date=2024-01-02/part-00000.parquet",
+	}, w.Files())
+
+	d, err := goparquet.NewDatasetFromDirectory(dir)
+	require.NoError(t, err)
+	require.Equal(t, "message test {\n  required int64 id;\n  optional int32 date (DATE);\n}\n", d.GetSchemaDefinition().String())
+
+	for i := 0; ; i++ {
+		row, err := d.NextRow()
+		if err == io.EOF {
+			require.Equal(t, 4, i)
+			break
+		}
+		require.NoError(t, err)
+		// 2024-01-01 is the 19723rd day after the Unix epoch.
+		require.Equal(t, int32(19723+i/2), row["date"])
+	}
+}
@@ -52,23 +52,34 @@ type Writer struct {
 // obj implements the floor.Marshaller object, then obj.(Marshaller).Marshal
 // will be called to determine the data, otherwise reflection will be used.
 func (w *Writer) Write(obj interface{}) error {
-	m, ok := obj.(interfaces.Marshaller)
-	if !ok {
-		m = &reflectMarshaller{obj: obj, schemaDef: w.schemaDef}
-	}
-
-	data := interfaces.NewMarshallObjectWithSchema(nil, w.schemaDef)
-	if err := m.MarshalParquet(data); err != nil {
+	data, err := marshalObject(obj, w.schemaDef)
+	if err != nil {
 		return err
 	}
 
-	if err := w.w.AddData(data.GetData()); err != nil {
+	if err := w.w.AddData(data); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// marshalObject turns obj into a row according to schemaDef, either using obj's
+// MarshalParquet method or reflection.
+func marshalObject(obj interface{}, schemaDef *parquetschema.SchemaDefinition) (map[string]interface{}, error) {
+	m, ok := obj.(interfaces.Marshaller)
+	if !ok {
+		m = &reflectMarshaller{obj: obj, schemaDef: schemaDef}
+	}
+
+	data := interfaces.NewMarshallObjectWithSchema(nil, schemaDef)
+	if err := m.MarshalParquet(data); err != nil {
+		return nil, err
+	}
+
+	return data.GetData(), nil
+}
+
 type reflectMarshaller struct {
 	obj       interface{}
 	schemaDef *parquetschema.SchemaDefinition
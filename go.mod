@@ -1,6 +1,6 @@
 module github.com/fraugster/parquet-go
 
-go 1.13
+go 1.16
 
 require (
 	github.com/apache/thrift v0.16.0
@@ -17,6 +17,9 @@ import (
 // The function has to return any type that can be used as a map key. In particular, the
 // result can not be a slice. The default implementation used the fnv hash function as
 // implemented in Go's standard library.
+//
+// Deprecated: duplicate values are detected by comparing the values themselves, so the
+// function isn't used anymore.
 var DefaultHashFunc func([]byte) interface{}
 
 func init() {
@@ -80,6 +83,17 @@ func decodeRLEValue(bytes []byte) int32 {
 	}
 }
 
+// readFixedWidth reads up to len(buf)/width values of width bytes each from r into buf, and
+// returns the number of complete values that were read. Like reading the values one by one, the
+// error is io.EOF if r ends between two values, and io.ErrUnexpectedEOF if it ends within one.
+func readFixedWidth(r io.Reader, buf []byte, width int) (int, error) {
+	n, err := io.ReadFull(r, buf)
+	if err == io.ErrUnexpectedEOF && n%width == 0 {
+		err = io.EOF
+	}
+	return n / width, err
+}
+
 func writeFull(w io.Writer, buf []byte) error {
 	if len(buf) == 0 {
 		return nil
@@ -245,7 +259,7 @@ func prefix(b1, b2 []byte) int {
 	return l
 }
 
-func encodeValue(w io.Writer, enc valuesEncoder, all []interface{}) error {
+func encodeValue(w io.Writer, enc valuesEncoder, all valuesBuffer) error {
 	if err := enc.init(w); err != nil {
 		return err
 	}
@@ -291,23 +305,6 @@ func encodeLevelsV2(w io.Writer, max uint16, values *packedArray) error {
 	return nil
 }
 
-func mapKey(a interface{}) interface{} {
-	switch v := a.(type) {
-	case int, int32, int64, string, bool:
-		return a
-	case float64:
-		return math.Float64bits(v)
-	case float32:
-		return math.Float32bits(v)
-	case []byte:
-		return DefaultHashFunc(v)
-	case [12]byte:
-		return DefaultHashFunc(v[:])
-	default:
-		panic("not supported type")
-	}
-}
-
 func fnvHashFunc(in []byte) interface{} {
 	hash := fnv.New64()
 	if err := writeFull(hash, in); err != nil {
@@ -12,7 +12,7 @@ type pageReader interface {
 	init(dDecoder, rDecoder getLevelDecoder, values getValueDecoderFn) error
 	read(r io.Reader, ph *parquet.PageHeader, codec parquet.CompressionCodec, validateCRC bool) error
 
-	readValues(size int) (values []interface{}, dLevel *packedArray, rLevel *packedArray, err error)
+	readValues(size int) (values valuesBuffer, dLevel *packedArray, rLevel *packedArray, err error)
 
 	numValues() int32
 }
@@ -22,25 +22,28 @@ type pageWriter interface {
 	init(col *Column, codec parquet.CompressionCodec) error
 
 	write(ctx context.Context, w io.Writer) (int, int, error)
+
+	// header returns the header of the page once it has been written.
+	header() *parquet.PageHeader
 }
 
-type newDataPageFunc func(useDict bool, dictValues []interface{}, page *dataPage, enableCRC bool) pageWriter
+type newDataPageFunc func(useDict bool, dictValues valuesBuffer, page *dataPage, enableCRC bool) pageWriter
 
 type valuesDecoder interface {
 	init(io.Reader) error
 	// the error io.EOF with the less value is acceptable, any other error is not
-	decodeValues([]interface{}) (int, error)
+	decodeValues(valuesBuffer) (int, error)
 }
 
 type dictValuesDecoder interface {
 	valuesDecoder
 
-	setValues([]interface{})
+	setValues(valuesBuffer)
 }
 
 type valuesEncoder interface {
 	init(io.Writer) error
-	encodeValues([]interface{}) error
+	encodeValues(valuesBuffer) error
 
 	io.Closer
 }
@@ -48,7 +51,7 @@ type valuesEncoder interface {
 type dictValuesEncoder interface {
 	valuesEncoder
 
-	getValues() []interface{}
+	getValues() valuesBuffer
 }
 
 // parquetColumn is to convert a store to a parquet.SchemaElement
@@ -71,11 +74,9 @@ type typedColumnStore interface {
 	getStats() minMaxValues
 	getPageStats() minMaxValues
 
-	// Should extract the value, turn it into an array and check for min and max on all values in this
-	getValues(v interface{}) ([]interface{}, error)
+	// appendValues extracts the values from v, which is a single value or an array of values if
+	// the column is repeated, checks for min and max on all of them and appends them to dst, which
+	// is a buffer of the store's type. dst is returned unchanged if the values can't be stored.
+	appendValues(dst valuesBuffer, v interface{}) (valuesBuffer, error)
 	sizeOf(v interface{}) int
-	// the tricky append. this is a way of creating new "typed" array. the first interface is nil or an []T (T is the type,
-	// not the interface) and value is from that type. the result should be always []T (array of that type)
-	// exactly like the builtin append
-	append(arrayIn interface{}, value interface{}) interface{}
 }
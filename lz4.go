@@ -0,0 +1,266 @@
+package goparquet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// This file contains a dependency-free implementation of the LZ4 block format, as
+// used by the LZ4_RAW compression codec, and of the Hadoop framing around LZ4 blocks
+// that is used by the deprecated LZ4 compression codec.
+
+const (
+	lz4MinMatch     = 4
+	lz4LastLiterals = 5  // the last 5 bytes of a block are always literals.
+	lz4MFLimit      = 12 // the last match must start at least 12 bytes before the end of a block.
+	lz4MaxOffset    = 65535
+	lz4HashLog      = 14
+)
+
+type (
+	lz4RawCompressor    struct{}
+	lz4HadoopCompressor struct{}
+)
+
+func (lz4RawCompressor) CompressBlock(block []byte) ([]byte, error) {
+	return lz4CompressBlock(nil, block), nil
+}
+
+func (lz4RawCompressor) DecompressBlock(block []byte) ([]byte, error) {
+	return lz4DecompressBlock(nil, block)
+}
+
+// CompressBlock compresses block as a single LZ4 block with Hadoop framing, which
+// is what other implementations expect for the LZ4 compression codec.
+func (lz4HadoopCompressor) CompressBlock(block []byte) ([]byte, error) {
+	dst := make([]byte, 8, 8+lz4CompressBound(len(block)))
+	dst = lz4CompressBlock(dst, block)
+	binary.BigEndian.PutUint32(dst[0:4], uint32(len(block)))
+	binary.BigEndian.PutUint32(dst[4:8], uint32(len(dst)-8))
+	return dst, nil
+}
+
+// DecompressBlock decompresses block that was compressed with the LZ4 compression
+// codec. Unfortunately, writers didn't agree on what the LZ4 codec means, so the
+// Hadoop framing is tried first, with a fallback to a plain LZ4 block.
+func (lz4HadoopCompressor) DecompressBlock(block []byte) ([]byte, error) {
+	if res, err := lz4HadoopDecompress(block); err == nil {
+		return res, nil
+	}
+	return lz4DecompressBlock(nil, block)
+}
+
+// lz4HadoopDecompress decompresses data that consists of one or more LZ4 blocks, each
+// prefixed with its big-endian 32 bit decompressed and compressed size.
+func lz4HadoopDecompress(src []byte) ([]byte, error) {
+	if len(src) == 0 {
+		return nil, errors.New("lz4: empty hadoop frame")
+	}
+
+	var dst []byte
+	for len(src) > 0 {
+		if len(src) < 8 {
+			return nil, errors.New("lz4: truncated hadoop frame header")
+		}
+		rawLen := binary.BigEndian.Uint32(src[0:4])
+		compLen := binary.BigEndian.Uint32(src[4:8])
+		src = src[8:]
+
+		if uint64(compLen) > uint64(len(src)) {
+			return nil, fmt.Errorf("lz4: hadoop frame of %d bytes exceeds remaining %d bytes", compLen, len(src))
+		}
+
+		start := len(dst)
+		var err error
+		dst, err = lz4DecompressBlock(dst, src[:compLen])
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(dst)-start) != uint64(rawLen) {
+			return nil, fmt.Errorf("lz4: hadoop frame decompressed to %d bytes instead of %d bytes", len(dst)-start, rawLen)
+		}
+		src = src[compLen:]
+	}
+
+	return dst, nil
+}
+
+// lz4CompressBound returns the maximum size of a compressed LZ4 block for n bytes of input.
+func lz4CompressBound(n int) int {
+	return n + n/255 + 16
+}
+
+func lz4Hash(v uint32) uint32 {
+	return (v * 2654435761) >> (32 - lz4HashLog)
+}
+
+// lz4CompressBlock compresses src into a single LZ4 block that is appended to dst.
+func lz4CompressBlock(dst, src []byte) []byte {
+	if len(src) <= lz4MFLimit {
+		return lz4AppendLiterals(dst, src)
+	}
+
+	// the table holds the position + 1 of the last occurrence of a hash, 0 means no occurrence.
+	table := make([]int32, 1<<lz4HashLog)
+
+	var (
+		anchor   = 0
+		ip       = 0
+		mfLimit  = len(src) - lz4MFLimit
+		matchEnd = len(src) - lz4LastLiterals
+	)
+
+	for ip < mfLimit {
+		seq := binary.LittleEndian.Uint32(src[ip:])
+		h := lz4Hash(seq)
+		ref := int(table[h]) - 1
+		table[h] = int32(ip + 1)
+
+		if ref < 0 || ip-ref > lz4MaxOffset || binary.LittleEndian.Uint32(src[ref:]) != seq {
+			// skip faster over incompressible data.
+			ip += 1 + (ip-anchor)>>6
+			continue
+		}
+
+		// extend the match backwards as far as possible.
+		for ip > anchor && ref > 0 && src[ip-1] == src[ref-1] {
+			ip--
+			ref--
+		}
+
+		matchLen := lz4MinMatch
+		for ip+matchLen < matchEnd && src[ip+matchLen] == src[ref+matchLen] {
+			matchLen++
+		}
+
+		dst = lz4AppendSequence(dst, src[anchor:ip], ip-ref, matchLen)
+
+		ip += matchLen
+		anchor = ip
+	}
+
+	return lz4AppendLiterals(dst, src[anchor:])
+}
+
+func lz4AppendLength(dst []byte, n int) []byte {
+	for n >= 255 {
+		dst = append(dst, 255)
+		n -= 255
+	}
+	return append(dst, byte(n))
+}
+
+func lz4AppendSequence(dst, literals []byte, offset, matchLen int) []byte {
+	litLen, ml := len(literals), matchLen-lz4MinMatch
+
+	token := byte(15 << 4)
+	if litLen < 15 {
+		token = byte(litLen) << 4
+	}
+	if ml >= 15 {
+		token |= 15
+	} else {
+		token |= byte(ml)
+	}
+
+	dst = append(dst, token)
+	if litLen >= 15 {
+		dst = lz4AppendLength(dst, litLen-15)
+	}
+	dst = append(dst, literals...)
+	dst = append(dst, byte(offset), byte(offset>>8))
+	if ml >= 15 {
+		dst = lz4AppendLength(dst, ml-15)
+	}
+
+	return dst
+}
+
+// lz4AppendLiterals appends the last sequence of a block, which only consists of literals.
+func lz4AppendLiterals(dst, literals []byte) []byte {
+	litLen := len(literals)
+	if litLen >= 15 {
+		dst = append(dst, 15<<4)
+		dst = lz4AppendLength(dst, litLen-15)
+	} else {
+		dst = append(dst, byte(litLen)<<4)
+	}
+	return append(dst, literals...)
+}
+
+var errLZ4Corrupt = errors.New("lz4: corrupt input")
+
+// lz4DecompressBlock decompresses a single LZ4 block and appends the result to dst. Matches
+// must not refer to data in dst that was present before.
+func lz4DecompressBlock(dst, src []byte) ([]byte, error) {
+	base := len(dst)
+
+	readLength := func(i int, n int) (int, int, error) {
+		for {
+			if i >= len(src) {
+				return 0, 0, errLZ4Corrupt
+			}
+			b := src[i]
+			i++
+			n += int(b)
+			if b != 255 {
+				return i, n, nil
+			}
+		}
+	}
+
+	for i := 0; ; {
+		if i >= len(src) {
+			return nil, errLZ4Corrupt
+		}
+		token := src[i]
+		i++
+
+		litLen := int(token >> 4)
+		if litLen == 15 {
+			var err error
+			if i, litLen, err = readLength(i, litLen); err != nil {
+				return nil, err
+			}
+		}
+		if litLen > len(src)-i {
+			return nil, errLZ4Corrupt
+		}
+		dst = append(dst, src[i:i+litLen]...)
+		i += litLen
+
+		if i == len(src) {
+			// the last sequence of a block only consists of literals.
+			return dst, nil
+		}
+
+		if i+2 > len(src) {
+			return nil, errLZ4Corrupt
+		}
+		offset := int(src[i]) | int(src[i+1])<<8
+		i += 2
+		if offset == 0 || offset > len(dst)-base {
+			return nil, errLZ4Corrupt
+		}
+
+		matchLen := int(token & 15)
+		if matchLen == 15 {
+			var err error
+			if i, matchLen, err = readLength(i, matchLen); err != nil {
+				return nil, err
+			}
+		}
+		matchLen += lz4MinMatch
+
+		start := len(dst) - offset
+		if offset >= matchLen {
+			dst = append(dst, dst[start:start+matchLen]...)
+		} else {
+			// the match overlaps with the data it produces, so it needs to be copied byte by byte.
+			for j := 0; j < matchLen; j++ {
+				dst = append(dst, dst[start+j])
+			}
+		}
+	}
+}
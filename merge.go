@@ -0,0 +1,73 @@
+package goparquet
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/fraugster/parquet-go/parquet"
+)
+
+// MergeFiles writes a new parquet file to w that contains all row groups of the parquet
+// files read from inputs, in the order of the inputs. The column chunks are copied byte for
+// byte without decoding and re-encoding them, only their offsets in the meta data are
+// adjusted. Page indexes and bloom filters are copied along with the column chunks.
+//
+// All inputs need to have the same schema. The key-value meta data of all inputs is merged,
+// where the meta data of later inputs takes precedence. It can be overridden using the
+// WithMetaData option. Options that affect how data is encoded, such as the compression
+// codec or the page size, have no effect on the copied column chunks. The column orders are
+// only declared if all inputs declare them, as the statistics of the column chunks are
+// copied as well.
+func MergeFiles(w io.Writer, inputs []io.ReadSeeker, options ...FileWriterOption) error {
+	if len(inputs) == 0 {
+		return errors.New("no files to merge")
+	}
+
+	metas := make([]*parquet.FileMetaData, 0, len(inputs))
+	kv := make(map[string]string)
+
+	var schemaDef string
+	for idx, r := range inputs {
+		meta, err := ReadFileMetaData(r, true)
+		if err != nil {
+			return fmt.Errorf("reading meta data of file %d failed: %w", idx, err)
+		}
+
+		sch, err := makeSchema(meta, false, nil)
+		if err != nil {
+			return fmt.Errorf("creating schema of file %d failed: %w", idx, err)
+		}
+
+		if idx == 0 {
+			schemaDef = sch.GetSchemaDefinition().String()
+			options = append([]FileWriterOption{WithSchemaDefinition(sch.GetSchemaDefinition()), WithMetaData(kv)}, options...)
+		} else if got := sch.GetSchemaDefinition().String(); got != schemaDef {
+			return fmt.Errorf("schema of file %d doesn't match the schema of the first file, expected:\n%s\ngot:\n%s", idx, schemaDef, got)
+		}
+
+		for _, e := range meta.KeyValueMetadata {
+			kv[e.Key] = e.GetValue()
+		}
+
+		metas = append(metas, meta)
+	}
+
+	fw := NewFileWriter(w, options...)
+
+	for idx, meta := range metas {
+		if len(meta.RowGroups) > 0 {
+			for colIdx := range fw.schemaWriter.Columns() {
+				fw.addCopiedColumnOrder(colIdx, fileColumnOrder(meta, colIdx))
+			}
+		}
+
+		for _, rg := range meta.RowGroups {
+			if err := fw.copyRowGroup(fw.ctx, inputs[idx], rg); err != nil {
+				return fmt.Errorf("copying row group of file %d failed: %w", idx, err)
+			}
+		}
+	}
+
+	return fw.Close()
+}
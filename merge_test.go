@@ -0,0 +1,187 @@
+package goparquet
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+func writeMergeTestFile(t *testing.T, from, to int, opts ...FileWriterOption) []byte {
+	sd, err := parquetschema.ParseSchemaDefinition(appendTestSchema)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	fw := NewFileWriter(buf, append([]FileWriterOption{WithSchemaDefinition(sd), WithMaxPageSize(256)}, opts...)...)
+	appendTestRows(t, fw, from, (from+to)/2)
+	appendTestRows(t, fw, (from+to)/2, to)
+	require.NoError(t, fw.Close())
+
+	return buf.Bytes()
+}
+
+func TestMergeFiles(t *testing.T) {
+	inputs := []io.ReadSeeker{
+		bytes.NewReader(writeMergeTestFile(t, 0, 100, WithMetaData(map[string]string{"a": "1", "b": "1"}))),
+		bytes.NewReader(writeMergeTestFile(t, 100, 300, WithCompressionCodec(parquet.CompressionCodec_SNAPPY), WithBloomFilter(ColumnPath{"name"}, 0.01), WithMetaData(map[string]string{"b": "2"}))),
+		bytes.NewReader(writeMergeTestFile(t, 300, 400, WithDataPageV2(), WithPageIndex(false))),
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, MergeFiles(buf, inputs, WithCreator("merger")))
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, 6, r.RowGroupCount())
+	require.Equal(t, int64(400), r.NumRows())
+	require.Equal(t, map[string]string{"a": "1", "b": "2"}, r.MetaData())
+	require.Equal(t, "merger", r.meta.GetCreatedBy())
+	require.Equal(t, parquet.CompressionCodec_SNAPPY, r.meta.RowGroups[2].Columns[0].MetaData.Codec)
+
+	for rg := 0; rg < r.RowGroupCount(); rg++ {
+		columnIndex, err := r.ColumnIndex(rg, ColumnPath{"id"})
+		require.NoError(t, err)
+		offsetIndex, err := r.OffsetIndex(rg, ColumnPath{"id"})
+		require.NoError(t, err)
+
+		if rg >= 4 {
+			require.Nil(t, columnIndex)
+			require.Nil(t, offsetIndex)
+			continue
+		}
+
+		require.NotNil(t, columnIndex)
+		require.NotNil(t, offsetIndex)
+
+		pages, err := r.ColumnChunkPages(rg, ColumnPath{"id"})
+		require.NoError(t, err)
+		require.Len(t, offsetIndex.PageLocations, len(pages)-1) // excluding the dictionary page.
+		for i, loc := range offsetIndex.PageLocations {
+			require.Equal(t, pages[i+1].Offset, loc.Offset)
+		}
+
+		ok, err := r.MightContain(rg, ColumnPath{"name"}, "name-1000")
+		require.NoError(t, err)
+		require.Equal(t, rg < 2 || rg >= 4, ok)
+	}
+
+	for i := 0; i < 400; i++ {
+		row, err := r.NextRow()
+		require.NoError(t, err)
+		require.Equal(t, int64(i), row["id"])
+		if i%3 != 0 {
+			require.Equal(t, []byte(fmt.Sprintf("name-%d", i)), row["name"])
+		} else {
+			require.NotContains(t, row, "name")
+		}
+	}
+}
+
+func TestMergeFilesSchemaMismatch(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 id;
+	}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	fw := NewFileWriter(buf, WithSchemaDefinition(sd))
+	require.NoError(t, fw.AddData(map[string]interface{}{"id": int64(1)}))
+	require.NoError(t, fw.Close())
+
+	inputs := []io.ReadSeeker{
+		bytes.NewReader(writeMergeTestFile(t, 0, 10)),
+		bytes.NewReader(buf.Bytes()),
+	}
+
+	require.Error(t, MergeFiles(&bytes.Buffer{}, inputs))
+	require.Error(t, MergeFiles(&bytes.Buffer{}, nil))
+}
+
+// writeLegacyUnsignedTestFile writes a file like writers did before statistics followed the
+// type defined order: the statistics of the UINT_32 column a, which contains 1 and 0xFFFFFFFF,
+// are computed using signed comparisons, and the file doesn't declare any column orders.
+func writeLegacyUnsignedTestFile(t *testing.T) []byte {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int32 a (UINT_32);
+	}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	fw := NewFileWriter(buf, WithSchemaDefinition(sd), WithPageIndex(false))
+	require.NoError(t, fw.AddData(map[string]interface{}{"a": int32(1)}))
+	require.NoError(t, fw.AddData(map[string]interface{}{"a": int32(-1)}))
+	require.NoError(t, fw.Close())
+
+	data := buf.Bytes()
+	meta, err := ReadFileMetaData(bytes.NewReader(data), true)
+	require.NoError(t, err)
+
+	meta.ColumnOrders = nil
+	stats := meta.RowGroups[0].Columns[0].MetaData.Statistics
+	stats.MinValue, stats.MaxValue = []byte{0xFF, 0xFF, 0xFF, 0xFF}, []byte{1, 0, 0, 0}
+
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	out := bytes.NewBuffer(append([]byte(nil), data[:len(data)-8-footerLen]...))
+	footer := &bytes.Buffer{}
+	require.NoError(t, writeThrift(context.Background(), meta, footer))
+	out.Write(footer.Bytes())
+	require.NoError(t, binary.Write(out, binary.LittleEndian, uint32(footer.Len())))
+	out.Write(magic)
+
+	return out.Bytes()
+}
+
+// countFilteredRows returns the number of rows of data that are read using the row group
+// filter pred.
+func countFilteredRows(t *testing.T, data []byte, pred Predicate) int {
+	r, err := NewFileReaderWithOptions(bytes.NewReader(data), WithRowGroupFilter(pred))
+	require.NoError(t, err)
+
+	n := 0
+	for {
+		_, err := r.NextRow()
+		if err == io.EOF {
+			return n
+		}
+		require.NoError(t, err)
+		n++
+	}
+}
+
+func TestMergeFilesWithoutColumnOrders(t *testing.T) {
+	legacy := writeLegacyUnsignedTestFile(t)
+	pred := Eq(ColumnPath{"a"}, uint32(1))
+	require.Equal(t, 2, countFilteredRows(t, legacy, pred))
+
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int32 a (UINT_32);
+	}`)
+	require.NoError(t, err)
+
+	current := &bytes.Buffer{}
+	fw := NewFileWriter(current, WithSchemaDefinition(sd))
+	require.NoError(t, fw.AddData(map[string]interface{}{"a": int32(1)}))
+	require.NoError(t, fw.Close())
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, MergeFiles(buf, []io.ReadSeeker{bytes.NewReader(current.Bytes()), bytes.NewReader(legacy)}))
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Nil(t, r.meta.ColumnOrders)
+	require.Equal(t, 3, countFilteredRows(t, buf.Bytes(), pred))
+
+	// files that declare the type defined order keep it.
+	buf.Reset()
+	require.NoError(t, MergeFiles(buf, []io.ReadSeeker{bytes.NewReader(current.Bytes()), bytes.NewReader(current.Bytes())}))
+	r, err = NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Len(t, r.meta.ColumnOrders, 1)
+	require.True(t, r.meta.ColumnOrders[0].IsSetTYPE_ORDER())
+}
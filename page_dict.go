@@ -13,7 +13,8 @@ import (
 
 // dictionaryPage is not a real data page, so there is no need to implement the page interface
 type dictPageReader struct {
-	values []interface{}
+	typ    parquet.Type
+	values valuesBuffer
 	enc    valuesDecoder
 	ph     *parquet.PageHeader
 
@@ -57,7 +58,9 @@ func (dp *dictPageReader) read(r io.Reader, ph *parquet.PageHeader, codec parque
 		return err
 	}
 
-	dp.values = make([]interface{}, dp.numValues)
+	if dp.values, err = newValuesBuffer(dp.typ, int(dp.numValues)); err != nil {
+		return err
+	}
 
 	if err := dp.enc.init(reader); err != nil {
 		return err
@@ -75,10 +78,12 @@ type dictPageWriter struct {
 	sch        *schema
 	col        *Column
 	codec      parquet.CompressionCodec
-	dictValues []interface{}
+	dictValues valuesBuffer
+
+	ph *parquet.PageHeader // the header of the page, once it has been written.
 }
 
-func (dp *dictPageWriter) init(sch *schema, col *Column, codec parquet.CompressionCodec, dictValues []interface{}) error {
+func (dp *dictPageWriter) init(sch *schema, col *Column, codec parquet.CompressionCodec, dictValues valuesBuffer) error {
 	dp.sch = sch
 	dp.col = col
 	dp.codec = codec
@@ -93,7 +98,7 @@ func (dp *dictPageWriter) getHeader(comp, unComp int, crc32Checksum *int32) *par
 		CompressedPageSize:   int32(comp),
 		Crc:                  crc32Checksum,
 		DictionaryPageHeader: &parquet.DictionaryPageHeader{
-			NumValues: int32(len(dp.dictValues)),
+			NumValues: int32(dp.dictValues.len()),
 			Encoding:  parquet.Encoding_PLAIN, // PLAIN_DICTIONARY is deprecated in the Parquet 2.0 specification
 			IsSorted:  nil,
 		},
@@ -127,10 +132,14 @@ func (dp *dictPageWriter) write(ctx context.Context, w io.Writer) (int, int, err
 		crc32Checksum = &sum
 	}
 
-	header := dp.getHeader(compSize, unCompSize, crc32Checksum)
-	if err := writeThrift(ctx, header, w); err != nil {
+	dp.ph = dp.getHeader(compSize, unCompSize, crc32Checksum)
+	if err := writeThrift(ctx, dp.ph, w); err != nil {
 		return 0, 0, err
 	}
 
 	return compSize, unCompSize, writeFull(w, comp)
 }
+
+func (dp *dictPageWriter) header() *parquet.PageHeader {
+	return dp.ph
+}
@@ -0,0 +1,286 @@
+package goparquet
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/fraugster/parquet-go/parquet"
+)
+
+// chunkIndex holds the page index structures and the bloom filter of a single
+// column chunk. They are collected while the column chunk is written and
+// serialized right before the file footer.
+type chunkIndex struct {
+	chunk *parquet.ColumnChunk
+	order valueOrder
+
+	columnIndex *parquet.ColumnIndex
+	offsetIndex *parquet.OffsetIndex
+	bloomFilter *splitBlockBloomFilter
+
+	// invalid is set if at least one of the pages with non-null values doesn't
+	// have min and max values, in which case no column index can be written.
+	invalid bool
+
+	// copied is set if the column chunk was copied from another file along with
+	// its column index, which is then written as is.
+	copied bool
+
+	// crypto is set if the column chunk is encrypted, in which case the page index
+	// and the bloom filter are encrypted as well.
+	crypto *columnCrypto
+}
+
+func newChunkIndex(elem *parquet.SchemaElement) *chunkIndex {
+	return &chunkIndex{
+		order: newValueOrder(elem, true),
+		columnIndex: &parquet.ColumnIndex{
+			NullPages:  []bool{},
+			MinValues:  [][]byte{},
+			MaxValues:  [][]byte{},
+			NullCounts: []int64{},
+		},
+		offsetIndex: &parquet.OffsetIndex{
+			PageLocations: []*parquet.PageLocation{},
+		},
+	}
+}
+
+// addPage registers a data page that has been written at offset with the
+// compressed size (including the page header) size.
+func (ci *chunkIndex) addPage(offset int64, size int32, firstRowIndex int64, page *dataPage) {
+	ci.offsetIndex.PageLocations = append(ci.offsetIndex.PageLocations, &parquet.PageLocation{
+		Offset:             offset,
+		CompressedPageSize: size,
+		FirstRowIndex:      firstRowIndex,
+	})
+
+	nullPage := page.numValues == 0
+	minValue, maxValue := []byte{}, []byte{}
+	if !nullPage {
+		if page.stats.MinValue == nil || page.stats.MaxValue == nil {
+			ci.invalid = true
+		}
+		minValue, maxValue = page.stats.MinValue, page.stats.MaxValue
+	}
+
+	ci.columnIndex.NullPages = append(ci.columnIndex.NullPages, nullPage)
+	ci.columnIndex.MinValues = append(ci.columnIndex.MinValues, minValue)
+	ci.columnIndex.MaxValues = append(ci.columnIndex.MaxValues, maxValue)
+	ci.columnIndex.NullCounts = append(ci.columnIndex.NullCounts, page.nullValues)
+}
+
+// finish computes the boundary order of the column index. It returns the final column index,
+// or nil if no column index can be provided for this column chunk.
+func (ci *chunkIndex) finish() (*parquet.ColumnIndex, error) {
+	if ci.invalid {
+		return nil, nil
+	}
+
+	if ci.copied {
+		return ci.columnIndex, nil
+	}
+
+	if ci.order.kind == compareUndefined {
+		ci.columnIndex.BoundaryOrder = parquet.BoundaryOrder_UNORDERED
+		return ci.columnIndex, nil
+	}
+
+	var (
+		prevMin, prevMax interface{}
+		asc, desc        = true, true
+	)
+
+	for i, nullPage := range ci.columnIndex.NullPages {
+		if nullPage {
+			continue
+		}
+
+		minValue, err := ci.order.decode(ci.columnIndex.MinValues[i])
+		if err != nil {
+			return nil, err
+		}
+		maxValue, err := ci.order.decode(ci.columnIndex.MaxValues[i])
+		if err != nil {
+			return nil, err
+		}
+
+		if prevMin != nil {
+			minCmp, maxCmp := ci.order.compare(prevMin, minValue), ci.order.compare(prevMax, maxValue)
+			if minCmp > 0 || maxCmp > 0 {
+				asc = false
+			}
+			if minCmp < 0 || maxCmp < 0 {
+				desc = false
+			}
+		}
+		prevMin, prevMax = minValue, maxValue
+	}
+
+	switch {
+	case asc:
+		ci.columnIndex.BoundaryOrder = parquet.BoundaryOrder_ASCENDING
+	case desc:
+		ci.columnIndex.BoundaryOrder = parquet.BoundaryOrder_DESCENDING
+	default:
+		ci.columnIndex.BoundaryOrder = parquet.BoundaryOrder_UNORDERED
+	}
+
+	return ci.columnIndex, nil
+}
+
+// writePageIndexes writes the column indexes and offset indexes of all column chunks
+// written so far, and updates the column chunks' meta data accordingly.
+func writePageIndexes(ctx context.Context, w writePos, indexes []*chunkIndex) error {
+	for _, ci := range indexes {
+		columnIndex, err := ci.finish()
+		if err != nil {
+			return err
+		}
+		if columnIndex == nil {
+			continue
+		}
+
+		pos := w.Pos()
+		if err := ci.writeThrift(ctx, moduleColumnIndex, columnIndex, w); err != nil {
+			return err
+		}
+		length := int32(w.Pos() - pos)
+		ci.chunk.ColumnIndexOffset = &pos
+		ci.chunk.ColumnIndexLength = &length
+	}
+
+	for _, ci := range indexes {
+		if ci.offsetIndex == nil {
+			continue
+		}
+
+		pos := w.Pos()
+		if err := ci.writeThrift(ctx, moduleOffsetIndex, ci.offsetIndex, w); err != nil {
+			return err
+		}
+		length := int32(w.Pos() - pos)
+		ci.chunk.OffsetIndexOffset = &pos
+		ci.chunk.OffsetIndexLength = &length
+	}
+
+	return nil
+}
+
+// writeThrift writes tw to w, and encrypts it as module of type moduleType if the column
+// chunk is encrypted.
+func (ci *chunkIndex) writeThrift(ctx context.Context, moduleType byte, tw thriftWriter, w io.Writer) error {
+	if ci.crypto != nil {
+		return ci.crypto.writeThrift(ctx, moduleType, 0, tw, w)
+	}
+	return writeThrift(ctx, tw, w)
+}
+
+func (f *FileReader) columnChunkByPath(rowGroupIdx int, path ColumnPath) (*parquet.ColumnChunk, error) {
+	if rowGroupIdx < 0 || rowGroupIdx >= len(f.meta.RowGroups) {
+		return nil, fmt.Errorf("row group index %d is out of range", rowGroupIdx)
+	}
+
+	columns := f.meta.RowGroups[rowGroupIdx].Columns
+	for _, chunk := range columns {
+		if chunk.MetaData != nil && path.Equal(ColumnPath(chunk.MetaData.PathInSchema)) {
+			return chunk, nil
+		}
+	}
+
+	// the meta data of column chunks encrypted with a column key is only available if the key
+	// is, so they are identified by the path in their crypto meta data instead.
+	for _, chunk := range columns {
+		if chunk.MetaData == nil && chunk.CryptoMetadata != nil && chunk.CryptoMetadata.ENCRYPTION_WITH_COLUMN_KEY != nil &&
+			path.Equal(ColumnPath(chunk.CryptoMetadata.ENCRYPTION_WITH_COLUMN_KEY.PathInSchema)) {
+			return nil, fmt.Errorf("column %q is encrypted and its key is not available", path.flatName())
+		}
+	}
+
+	return nil, fmt.Errorf("column %q not found", path.flatName())
+}
+
+// readIndexAt reads the page index structure tr of the column chunk chunk in the row group with
+// the index rowGroupIdx from the file at offset, and decrypts it if the column chunk is encrypted.
+func (f *FileReader) readIndexAt(ctx context.Context, rowGroupIdx int, chunk *parquet.ColumnChunk, moduleType byte, tr thriftReader, offset int64, length int32) error {
+	r, err := f.chunkReader(chunk)
+	if err != nil {
+		return err
+	}
+
+	cc, err := f.columnCrypto(rowGroupIdx, chunk)
+	if err != nil {
+		return err
+	}
+	if cc == nil {
+		return readThriftAt(ctx, r, tr, offset, length)
+	}
+
+	if offset < 0 || length <= 0 {
+		return fmt.Errorf("invalid offset %d or length %d", offset, length)
+	}
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	return cc.readThrift(ctx, moduleType, 0, tr, io.LimitReader(r, int64(length)), f.allocTracker)
+}
+
+// ColumnIndex returns the column index of the column identified by path in the row group
+// with the index rowGroupIdx. The column index contains the min and max values as well
+// as the null counts of all data pages of the column chunk. If the file doesn't contain
+// a column index for the column chunk, nil is returned.
+func (f *FileReader) ColumnIndex(rowGroupIdx int, path ColumnPath) (*parquet.ColumnIndex, error) {
+	return f.ColumnIndexWithContext(f.ctx, rowGroupIdx, path)
+}
+
+// ColumnIndexWithContext returns the column index of the column identified by path in the row group
+// with the index rowGroupIdx. If the file doesn't contain a column index for the column chunk, nil is
+// returned.
+func (f *FileReader) ColumnIndexWithContext(ctx context.Context, rowGroupIdx int, path ColumnPath) (*parquet.ColumnIndex, error) {
+	chunk, err := f.columnChunkByPath(rowGroupIdx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if chunk.ColumnIndexOffset == nil || chunk.ColumnIndexLength == nil {
+		return nil, nil
+	}
+
+	columnIndex := &parquet.ColumnIndex{}
+	if err := f.readIndexAt(ctx, rowGroupIdx, chunk, moduleColumnIndex, columnIndex, *chunk.ColumnIndexOffset, *chunk.ColumnIndexLength); err != nil {
+		return nil, fmt.Errorf("reading column index failed: %w", err)
+	}
+
+	return columnIndex, nil
+}
+
+// OffsetIndex returns the offset index of the column identified by path in the row group
+// with the index rowGroupIdx. The offset index contains the location and the index of the
+// first row of all data pages of the column chunk. If the file doesn't contain an offset
+// index for the column chunk, nil is returned.
+func (f *FileReader) OffsetIndex(rowGroupIdx int, path ColumnPath) (*parquet.OffsetIndex, error) {
+	return f.OffsetIndexWithContext(f.ctx, rowGroupIdx, path)
+}
+
+// OffsetIndexWithContext returns the offset index of the column identified by path in the row group
+// with the index rowGroupIdx. If the file doesn't contain an offset index for the column chunk, nil
+// is returned.
+func (f *FileReader) OffsetIndexWithContext(ctx context.Context, rowGroupIdx int, path ColumnPath) (*parquet.OffsetIndex, error) {
+	chunk, err := f.columnChunkByPath(rowGroupIdx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if chunk.OffsetIndexOffset == nil || chunk.OffsetIndexLength == nil {
+		return nil, nil
+	}
+
+	offsetIndex := &parquet.OffsetIndex{}
+	if err := f.readIndexAt(ctx, rowGroupIdx, chunk, moduleOffsetIndex, offsetIndex, *chunk.OffsetIndexOffset, *chunk.OffsetIndexLength); err != nil {
+		return nil, fmt.Errorf("reading offset index failed: %w", err)
+	}
+
+	return offsetIndex, nil
+}
@@ -0,0 +1,146 @@
+package goparquet
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+func buildPageIndexTestFile(t *testing.T, numRows int, opts ...FileWriterOption) []byte {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 id;
+		optional double score;
+		required boolean flag;
+	}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	fw := NewFileWriter(buf, append([]FileWriterOption{WithSchemaDefinition(sd), WithMaxPageSize(256)}, opts...)...)
+
+	for i := 0; i < numRows; i++ {
+		data := map[string]interface{}{
+			"id":   int64(i),
+			"flag": i%2 == 0,
+		}
+		if i%3 != 0 {
+			data["score"] = float64(i) / 2
+		}
+		require.NoError(t, fw.AddData(data))
+		if i == numRows/2 {
+			require.NoError(t, fw.FlushRowGroup())
+		}
+	}
+	require.NoError(t, fw.Close())
+
+	return buf.Bytes()
+}
+
+func TestPageIndexWriteAndRead(t *testing.T) {
+	const numRows = 1000
+	data := buildPageIndexTestFile(t, numRows)
+
+	r, err := NewFileReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, 2, r.RowGroupCount())
+
+	var firstID int64
+	for rg := 0; rg < r.RowGroupCount(); rg++ {
+		rowGroup := r.meta.RowGroups[rg]
+
+		offsetIndex, err := r.OffsetIndex(rg, ColumnPath{"id"})
+		require.NoError(t, err)
+		require.NotNil(t, offsetIndex)
+		require.True(t, len(offsetIndex.PageLocations) > 1, "expected more than one page")
+
+		columnIndex, err := r.ColumnIndex(rg, ColumnPath{"id"})
+		require.NoError(t, err)
+		require.NotNil(t, columnIndex)
+		require.Equal(t, parquet.BoundaryOrder_ASCENDING, columnIndex.BoundaryOrder)
+		require.Len(t, columnIndex.NullPages, len(offsetIndex.PageLocations))
+		require.Len(t, columnIndex.MinValues, len(offsetIndex.PageLocations))
+		require.Len(t, columnIndex.MaxValues, len(offsetIndex.PageLocations))
+
+		require.Equal(t, int64(0), offsetIndex.PageLocations[0].FirstRowIndex)
+
+		var totalRows int64
+		for i, loc := range offsetIndex.PageLocations {
+			header := &parquet.PageHeader{}
+			_, err := r.reader.Seek(loc.Offset, io.SeekStart)
+			require.NoError(t, err)
+			require.NoError(t, readThrift(context.Background(), header, r.reader))
+			require.Equal(t, parquet.PageType_DATA_PAGE, header.Type)
+
+			numValues := int64(header.DataPageHeader.NumValues)
+			if i+1 < len(offsetIndex.PageLocations) {
+				require.Equal(t, numValues, offsetIndex.PageLocations[i+1].FirstRowIndex-loc.FirstRowIndex)
+			}
+			totalRows += numValues
+
+			minValue := int64(binary.LittleEndian.Uint64(columnIndex.MinValues[i]))
+			maxValue := int64(binary.LittleEndian.Uint64(columnIndex.MaxValues[i]))
+			require.Equal(t, firstID+loc.FirstRowIndex, minValue)
+			require.Equal(t, numValues-1, maxValue-minValue)
+			require.False(t, columnIndex.NullPages[i])
+			require.Equal(t, int64(0), columnIndex.NullCounts[i])
+		}
+		require.Equal(t, rowGroup.NumRows, totalRows)
+		firstID += rowGroup.NumRows
+
+		scoreIndex, err := r.ColumnIndex(rg, ColumnPath{"score"})
+		require.NoError(t, err)
+		require.NotNil(t, scoreIndex)
+		require.Equal(t, parquet.BoundaryOrder_ASCENDING, scoreIndex.BoundaryOrder)
+
+		var nullCount int64
+		for _, n := range scoreIndex.NullCounts {
+			nullCount += n
+		}
+		require.Equal(t, *rowGroup.Columns[1].MetaData.Statistics.NullCount, nullCount)
+
+		flagIndex, err := r.ColumnIndex(rg, ColumnPath{"flag"})
+		require.NoError(t, err)
+		require.Nil(t, flagIndex, "boolean columns have no statistics and therefore no column index")
+
+		flagOffsetIndex, err := r.OffsetIndex(rg, ColumnPath{"flag"})
+		require.NoError(t, err)
+		require.NotNil(t, flagOffsetIndex)
+	}
+
+	_, err = r.ColumnIndex(0, ColumnPath{"does_not_exist"})
+	require.Error(t, err)
+
+	_, err = r.OffsetIndex(2, ColumnPath{"id"})
+	require.Error(t, err)
+
+	var rows int
+	for {
+		_, err := r.NextRow()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		rows++
+	}
+	require.Equal(t, numRows, rows)
+}
+
+func TestPageIndexDisabled(t *testing.T) {
+	data := buildPageIndexTestFile(t, 100, WithPageIndex(false))
+
+	r, err := NewFileReader(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	columnIndex, err := r.ColumnIndex(0, ColumnPath{"id"})
+	require.NoError(t, err)
+	require.Nil(t, columnIndex)
+
+	offsetIndex, err := r.OffsetIndex(0, ColumnPath{"id"})
+	require.NoError(t, err)
+	require.Nil(t, offsetIndex)
+}
@@ -0,0 +1,183 @@
+package goparquet
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/fraugster/parquet-go/parquet"
+)
+
+// pageSpill receives the encoded and compressed data pages of the current row group
+// when the file writer operates in streaming mode. Pages are written to the spill
+// as soon as they are full, so that only the current page of every column needs
+// to be kept in memory. When the row group is flushed, the pages are copied from
+// the spill to the actual file.
+type pageSpill struct {
+	rws     io.ReadWriteSeeker
+	tempDir string
+	file    *os.File
+	pos     int64
+
+	ctx         context.Context
+	codec       parquet.CompressionCodec
+	newPageFunc newDataPageFunc
+}
+
+// spilledChunk holds the state of a column chunk whose pages have been written to
+// the page spill.
+type spilledChunk struct {
+	pages []*spilledPage
+	size  int64
+
+	bloomFilterHashes map[uint64]struct{}
+}
+
+// spilledPage describes a single data page in the page spill. The values of the page
+// have been dropped, only its meta data is retained.
+type spilledPage struct {
+	page       *dataPage
+	offset     int64
+	size       int64
+	compSize   int
+	unCompSize int
+	header     *parquet.PageHeader
+}
+
+// sink returns the underlying io.ReadWriteSeeker of the spill. If no sink was provided,
+// a temporary file is created.
+func (s *pageSpill) sink() (io.ReadWriteSeeker, error) {
+	if s.rws == nil {
+		f, err := ioutil.TempFile(s.tempDir, "parquet-go-pages-")
+		if err != nil {
+			return nil, fmt.Errorf("creating temporary file for pages failed: %w", err)
+		}
+		s.file = f
+		s.rws = f
+	}
+	return s.rws, nil
+}
+
+// write appends data to the spill and returns the offset at which it was written.
+func (s *pageSpill) write(data []byte) (int64, error) {
+	rws, err := s.sink()
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := rws.Seek(s.pos, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	if err := writeFull(rws, data); err != nil {
+		return 0, fmt.Errorf("writing page to spill failed: %w", err)
+	}
+
+	offset := s.pos
+	s.pos += int64(len(data))
+	return offset, nil
+}
+
+// copyTo copies size bytes starting at offset from the spill to w.
+func (s *pageSpill) copyTo(w io.Writer, offset, size int64) error {
+	if _, err := s.rws.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	n, err := io.CopyN(w, s.rws, size)
+	if err != nil {
+		return fmt.Errorf("copying page from spill failed after %d of %d bytes: %w", n, size, err)
+	}
+
+	return nil
+}
+
+// reset discards the content of the spill, so that it can be reused for the next row group.
+func (s *pageSpill) reset() {
+	s.pos = 0
+}
+
+// close closes and removes the temporary file, if one was created.
+func (s *pageSpill) close() error {
+	if s.file == nil {
+		return nil
+	}
+
+	name := s.file.Name()
+	err := s.file.Close()
+	if rmErr := os.Remove(name); err == nil {
+		err = rmErr
+	}
+	s.file, s.rws = nil, nil
+
+	return err
+}
+
+// spillPages encodes and compresses all pending data pages of col and writes them to
+// the page spill.
+func (s *pageSpill) spillPages(sch *schema, col *Column) error {
+	if col.data.spilled == nil {
+		col.data.spilled = &spilledChunk{}
+	}
+	sc := col.data.spilled
+
+	codec := sch.compressionCodec(col, s.codec)
+
+	_, bloomFilter := sch.bloomFilterFPP(col.path)
+	bloomFilter = bloomFilter && *col.Type() != parquet.Type_BOOLEAN
+
+	var dictValues valuesBuffer
+	if col.data.dict != nil {
+		dictValues = col.data.dict.values()
+	}
+
+	for _, page := range col.data.dataPages {
+		if bloomFilter {
+			if sc.bloomFilterHashes == nil {
+				sc.bloomFilterHashes = make(map[uint64]struct{})
+			}
+			if err := addBloomFilterHashes(sc.bloomFilterHashes, *col.Type(), page); err != nil {
+				return err
+			}
+		}
+
+		pw := s.newPageFunc(page.dictionary, dictValues, page, sch.enableCRC)
+		if err := pw.init(col, codec); err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		compressed, uncompressed, err := pw.write(s.ctx, &buf)
+		if err != nil {
+			return err
+		}
+
+		offset, err := s.write(buf.Bytes())
+		if err != nil {
+			return err
+		}
+
+		sc.pages = append(sc.pages, &spilledPage{
+			page: &dataPage{
+				numValues:  page.numValues,
+				nullValues: page.nullValues,
+				numRows:    page.numRows,
+				stats:      page.stats,
+				dictionary: page.dictionary,
+			},
+			offset:     offset,
+			size:       int64(buf.Len()),
+			compSize:   compressed,
+			unCompSize: uncompressed,
+			header:     pw.header(),
+		})
+		sc.size += int64(buf.Len())
+	}
+
+	col.data.dataPages = nil
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package goparquet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+// memReadWriteSeeker is an in-memory io.ReadWriteSeeker.
+type memReadWriteSeeker struct {
+	data []byte
+	pos  int64
+}
+
+func (m *memReadWriteSeeker) Read(p []byte) (int, error) {
+	if m.pos >= int64(len(m.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, m.data[m.pos:])
+	m.pos += int64(n)
+	return n, nil
+}
+
+func (m *memReadWriteSeeker) Write(p []byte) (int, error) {
+	if end := m.pos + int64(len(p)); end > int64(len(m.data)) {
+		m.data = append(m.data, make([]byte, end-int64(len(m.data)))...)
+	}
+	n := copy(m.data[m.pos:], p)
+	m.pos += int64(n)
+	return n, nil
+}
+
+func (m *memReadWriteSeeker) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += m.pos
+	case io.SeekEnd:
+		offset += int64(len(m.data))
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	m.pos = offset
+	return offset, nil
+}
+
+func writePageSpillTestFile(t *testing.T, numRows int, opts ...FileWriterOption) []byte {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 id;
+		optional binary name (STRING);
+		repeated int32 tags;
+		required boolean flag;
+	}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	opts = append([]FileWriterOption{
+		WithSchemaDefinition(sd),
+		WithCompressionCodec(parquet.CompressionCodec_SNAPPY),
+		WithMaxPageSize(512),
+		WithBloomFilter(ColumnPath{"name"}, 0.01),
+	}, opts...)
+	fw := NewFileWriter(buf, opts...)
+
+	for i := 0; i < numRows; i++ {
+		data := map[string]interface{}{
+			"id":   int64(i),
+			"tags": []int32{int32(i % 3), int32(i % 11)},
+			"flag": i%2 == 0,
+		}
+		if i%5 != 0 {
+			data["name"] = []byte(fmt.Sprintf("name-%d", i%100))
+		}
+		require.NoError(t, fw.AddData(data))
+		if (i+1)%(numRows/2) == 0 {
+			require.NoError(t, fw.FlushRowGroup())
+		}
+	}
+	require.NoError(t, fw.Close())
+
+	return buf.Bytes()
+}
+
+func readAllRows(t *testing.T, data []byte) []map[string]interface{} {
+	r, err := NewFileReader(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	var rows []map[string]interface{}
+	for {
+		row, err := r.NextRow()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func TestPageSpill(t *testing.T) {
+	sink := &memReadWriteSeeker{}
+
+	expected := writePageSpillTestFile(t, 2000)
+	data := writePageSpillTestFile(t, 2000, WithPageSpill(sink))
+
+	require.NotEmpty(t, sink.data)
+	require.Equal(t, readAllRows(t, expected), readAllRows(t, data))
+
+	expectedReader, err := NewFileReader(bytes.NewReader(expected))
+	require.NoError(t, err)
+	r, err := NewFileReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, 2, r.RowGroupCount())
+
+	for rg := range r.meta.RowGroups {
+		for i, ch := range r.meta.RowGroups[rg].Columns {
+			expectedChunk := expectedReader.meta.RowGroups[rg].Columns[i]
+			require.Equal(t, expectedChunk.MetaData.NumValues, ch.MetaData.NumValues)
+			require.Equal(t, expectedChunk.MetaData.Encodings, ch.MetaData.Encodings)
+			require.Equal(t, expectedChunk.MetaData.Statistics, ch.MetaData.Statistics)
+		}
+
+		columnIndex, err := r.ColumnIndex(rg, ColumnPath{"id"})
+		require.NoError(t, err)
+		require.NotNil(t, columnIndex)
+		require.True(t, len(columnIndex.MinValues) > 1)
+
+		ok, err := r.MightContain(rg, ColumnPath{"name"}, "name-42")
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+}
+
+func TestTempFilePageSpill(t *testing.T) {
+	dir, err := ioutil.TempDir("", "page-spill")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	expected := writePageSpillTestFile(t, 1000)
+	data := writePageSpillTestFile(t, 1000, WithTempFilePageSpill(dir))
+	require.Equal(t, readAllRows(t, expected), readAllRows(t, data))
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, files, "temporary file wasn't removed")
+}
+
+func TestPageSpillDictionaryFallback(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 id;
+	}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	fw := NewFileWriter(buf,
+		WithSchemaDefinition(sd),
+		WithMaxPageSize(8*1024),
+		WithPageSpill(&memReadWriteSeeker{}),
+	)
+
+	const numRows = 40000
+	for i := 0; i < numRows; i++ {
+		require.NoError(t, fw.AddData(map[string]interface{}{"id": int64(i)}))
+	}
+	require.NoError(t, fw.Close())
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	meta := r.meta.RowGroups[0].Columns[0].MetaData
+	require.NotNil(t, meta.DictionaryPageOffset)
+	require.Contains(t, meta.Encodings, parquet.Encoding_RLE_DICTIONARY)
+	require.Contains(t, meta.Encodings, parquet.Encoding_PLAIN)
+	require.Nil(t, meta.Statistics.DistinctCount)
+
+	for i := 0; i < numRows; i++ {
+		row, err := r.NextRow()
+		require.NoError(t, err)
+		require.Equal(t, int64(i), row["id"])
+	}
+}
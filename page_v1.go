@@ -13,7 +13,8 @@ import (
 )
 
 type dataPageReaderV1 struct {
-	ph *parquet.PageHeader
+	ph  *parquet.PageHeader
+	typ parquet.Type
 
 	valuesCount        int32
 	encoding           parquet.Encoding
@@ -30,7 +31,7 @@ func (dp *dataPageReaderV1) numValues() int32 {
 	return dp.valuesCount
 }
 
-func (dp *dataPageReaderV1) readValues(size int) (values []interface{}, dLevel *packedArray, rLevel *packedArray, err error) {
+func (dp *dataPageReaderV1) readValues(size int) (values valuesBuffer, dLevel *packedArray, rLevel *packedArray, err error) {
 	if rem := int(dp.valuesCount) - dp.position; rem < size {
 		size = rem
 	}
@@ -50,7 +51,10 @@ func (dp *dataPageReaderV1) readValues(size int) (values []interface{}, dLevel *
 		return nil, nil, nil, fmt.Errorf("read definition levels failed: %w", err)
 	}
 
-	val := make([]interface{}, notNull)
+	val, err := newValuesBuffer(dp.typ, notNull)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 
 	if notNull != 0 {
 		if n, err := dp.valuesDecoder.decodeValues(val); err != nil {
@@ -122,13 +126,15 @@ func (dp *dataPageReaderV1) read(r io.Reader, ph *parquet.PageHeader, codec parq
 }
 
 type dataPageWriterV1 struct {
-	dictValues []interface{}
+	dictValues valuesBuffer
 	col        *Column
 	codec      parquet.CompressionCodec
 	page       *dataPage
 
 	dictionary bool
 	enableCRC  bool
+
+	ph *parquet.PageHeader // the header of the page, once it has been written.
 }
 
 func (dp *dataPageWriterV1) init(col *Column, codec parquet.CompressionCodec) error {
@@ -182,7 +188,7 @@ func (dp *dataPageWriterV1) write(ctx context.Context, w io.Writer) (int, int, e
 	}
 
 	if dp.dictionary {
-		encoder := newDictEncoder(dataBuf, bits.Len(uint(len(dp.dictValues))))
+		encoder := newDictEncoder(dataBuf, bits.Len(uint(dp.dictValues.len())))
 		if err := encoder.encodeIndices(dp.page.indexList); err != nil {
 			return 0, 0, err
 		}
@@ -213,15 +219,19 @@ func (dp *dataPageWriterV1) write(ctx context.Context, w io.Writer) (int, int, e
 		crc32Checksum = &v
 	}
 
-	header := dp.getHeader(compSize, unCompSize, dp.page.stats, crc32Checksum)
-	if err := writeThrift(ctx, header, w); err != nil {
+	dp.ph = dp.getHeader(compSize, unCompSize, dp.page.stats, crc32Checksum)
+	if err := writeThrift(ctx, dp.ph, w); err != nil {
 		return 0, 0, err
 	}
 
 	return compSize, unCompSize, writeFull(w, comp)
 }
 
-func newDataPageV1Writer(useDict bool, dictValues []interface{}, page *dataPage, enableCRC bool) pageWriter {
+func (dp *dataPageWriterV1) header() *parquet.PageHeader {
+	return dp.ph
+}
+
+func newDataPageV1Writer(useDict bool, dictValues valuesBuffer, page *dataPage, enableCRC bool) pageWriter {
 	return &dataPageWriterV1{
 		dictionary: useDict,
 		dictValues: dictValues,
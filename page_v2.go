@@ -12,7 +12,8 @@ import (
 )
 
 type dataPageReaderV2 struct {
-	ph *parquet.PageHeader
+	ph  *parquet.PageHeader
+	typ parquet.Type
 
 	valuesCount        int32
 	encoding           parquet.Encoding
@@ -28,7 +29,7 @@ func (dp *dataPageReaderV2) numValues() int32 {
 	return dp.valuesCount
 }
 
-func (dp *dataPageReaderV2) readValues(size int) (values []interface{}, dLevel *packedArray, rLevel *packedArray, err error) {
+func (dp *dataPageReaderV2) readValues(size int) (values valuesBuffer, dLevel *packedArray, rLevel *packedArray, err error) {
 	if rem := int(dp.valuesCount) - dp.position; rem < size {
 		size = rem
 	}
@@ -48,7 +49,10 @@ func (dp *dataPageReaderV2) readValues(size int) (values []interface{}, dLevel *
 		return nil, nil, nil, fmt.Errorf("read definition levels failed: %w", err)
 	}
 
-	val := make([]interface{}, notNull)
+	val, err := newValuesBuffer(dp.typ, notNull)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 
 	if notNull != 0 {
 		if n, err := dp.valuesDecoder.decodeValues(val); err != nil {
@@ -131,13 +135,15 @@ func (dp *dataPageReaderV2) read(r io.Reader, ph *parquet.PageHeader, codec parq
 }
 
 type dataPageWriterV2 struct {
-	dictValues []interface{}
+	dictValues valuesBuffer
 	col        *Column
 	codec      parquet.CompressionCodec
 	page       *dataPage
 
 	dictionary bool
 	enableCRC  bool
+
+	ph *parquet.PageHeader // the header of the page, once it has been written.
 }
 
 func (dp *dataPageWriterV2) init(col *Column, codec parquet.CompressionCodec) error {
@@ -197,7 +203,7 @@ func (dp *dataPageWriterV2) write(ctx context.Context, w io.Writer) (int, int, e
 	}
 
 	if dp.dictionary {
-		encoder := newDictEncoder(dataBuf, bits.Len(uint(len(dp.dictValues))))
+		encoder := newDictEncoder(dataBuf, bits.Len(uint(dp.dictValues.len())))
 		if err := encoder.encodeIndices(dp.page.indexList); err != nil {
 			return 0, 0, err
 		}
@@ -229,8 +235,8 @@ func (dp *dataPageWriterV2) write(ctx context.Context, w io.Writer) (int, int, e
 
 	compSize, unCompSize := len(comp), len(dataBuf.Bytes())
 	defLen, repLen := def.Len(), rep.Len()
-	header := dp.getHeader(compSize, unCompSize, defLen, repLen, dp.codec != parquet.CompressionCodec_UNCOMPRESSED, dp.page.stats, int32(dp.page.numRows), crc32Checksum)
-	if err := writeThrift(ctx, header, w); err != nil {
+	dp.ph = dp.getHeader(compSize, unCompSize, defLen, repLen, dp.codec != parquet.CompressionCodec_UNCOMPRESSED, dp.page.stats, int32(dp.page.numRows), crc32Checksum)
+	if err := writeThrift(ctx, dp.ph, w); err != nil {
 		return 0, 0, err
 	}
 
@@ -245,7 +251,11 @@ func (dp *dataPageWriterV2) write(ctx context.Context, w io.Writer) (int, int, e
 	return compSize + defLen + repLen, unCompSize + defLen + repLen, writeFull(w, comp)
 }
 
-func newDataPageV2Writer(useDict bool, dictValues []interface{}, page *dataPage, enableCRC bool) pageWriter {
+func (dp *dataPageWriterV2) header() *parquet.PageHeader {
+	return dp.ph
+}
+
+func newDataPageV2Writer(useDict bool, dictValues valuesBuffer, page *dataPage, enableCRC bool) pageWriter {
 	return &dataPageWriterV2{
 		dictionary: useDict,
 		dictValues: dictValues,
@@ -20,6 +20,8 @@ func TestParquetTesting(t *testing.T) {
 		"data/alltypes_plain.snappy.parquet",
 		"data/binary.parquet",
 		"data/byte_array_decimal.parquet",
+		"data/byte_stream_split.zstd.parquet",
+		"data/byte_stream_split_extended.gzip.parquet",
 		"data/datapage_v2.snappy.parquet",
 		"data/delta_binary_packed.parquet",
 		//"data/delta_byte_array.parquet",
@@ -28,14 +30,14 @@ func TestParquetTesting(t *testing.T) {
 		// "data/dict-page-offset-zero.parquet",
 		"data/fixed_length_decimal.parquet",
 		"data/fixed_length_decimal_legacy.parquet",
-		// "data/hadoop_lz4_compressed.parquet", // LZ4 is currently unsupported out of the box.
-		// "data/hadoop_lz4_compressed_larger.parquet",
+		"data/hadoop_lz4_compressed.parquet",
+		"data/hadoop_lz4_compressed_larger.parquet",
 		"data/int32_decimal.parquet",
 		"data/int64_decimal.parquet",
 		"data/list_columns.parquet",
 		"data/nested_lists.snappy.parquet",
 		"data/nested_maps.snappy.parquet",
-		// "data/nested_structs.rust.parquet", // uses ZSTD which is currently unsupported out of the box.
+		"data/nested_structs.rust.parquet",
 		"data/nonnullable.impala.parquet",
 		"data/nullable.impala.parquet",
 		"data/nulls.snappy.parquet",
@@ -0,0 +1,466 @@
+package goparquet
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+)
+
+// defaultMaxOpenFiles is the default maximum number of files a PartitionedWriter keeps open.
+const defaultMaxOpenFiles = 100
+
+// The layouts of DATE, TIMESTAMP and TIME partition values, which match the ones used by Hive.
+const (
+	partitionDateLayout      = "2006-01-02"
+	partitionTimestampLayout = "2006-01-02 15:04:05.999999999"
+	partitionTimeLayout      = "15:04:05.999999999"
+)
+
+// FileCreator creates the file identified by the slash-separated path name, including all
+// of its parent directories, and returns it for writing.
+type FileCreator func(name string) (io.WriteCloser, error)
+
+// NewDirectoryFileCreator returns a FileCreator that creates files below the directory dir.
+func NewDirectoryFileCreator(dir string) FileCreator {
+	return func(name string) (io.WriteCloser, error) {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return nil, err
+		}
+		return os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	}
+}
+
+// PartitionedWriter writes rows to a dataset that is partitioned by the values of one or
+// more columns, with the Hive-style directory layout that is read by Dataset: each row is
+// written to a file in the directory key1=value1/key2=value2/, with the keys in the order
+// of the partition columns. Null and empty values are written as __HIVE_DEFAULT_PARTITION__.
+//
+// The partition columns are removed from the rows and from the schema of the files, as
+// their values are encoded in the paths of the files. Within each partition directory, the
+// files are named part-00000.parquet, part-00001.parquet and so on, and a new file is started
+// once the current one reaches the configured maximum number of rows or size.
+type PartitionedWriter struct {
+	create           FileCreator
+	schemaDef        *parquetschema.SchemaDefinition
+	fileSchemaDef    *parquetschema.SchemaDefinition
+	partitionColumns []string
+	partitionElems   []*parquet.SchemaElement
+
+	writerOptions []FileWriterOption
+	maxOpenFiles  int
+	maxRows       int64
+	maxSize       int64
+	prefix        string
+
+	open     map[string]*partitionFile
+	sequence map[string]int
+	useCount uint64
+	files    []string
+}
+
+type partitionFile struct {
+	w        *FileWriter
+	closer   io.Closer
+	numRows  int64
+	lastUsed uint64
+}
+
+// PartitionedWriterOption is an option that can be passed on to NewPartitionedWriter when
+// creating a new partitioned writer.
+type PartitionedWriterOption func(*PartitionedWriter) error
+
+// WithPartitionFileWriterOptions configures the options that are used to create the writers of
+// all files, e.g. the compression codec. The schema definition of the files is set by the
+// PartitionedWriter and must not be provided as an option.
+func WithPartitionFileWriterOptions(options ...FileWriterOption) PartitionedWriterOption {
+	return func(pw *PartitionedWriter) error {
+		pw.writerOptions = append(pw.writerOptions, options...)
+		return nil
+	}
+}
+
+// WithMaxOpenFiles sets the maximum number of files that are open at the same time. If a
+// row needs to be written to a partition without an open file while the maximum number of
+// files is open, the least recently used file is closed, and the next row of its partition
+// is written to a new file. The default is 100.
+func WithMaxOpenFiles(n int) PartitionedWriterOption {
+	return func(pw *PartitionedWriter) error {
+		if n < 1 {
+			return errors.New("maximum number of open files must be at least 1")
+		}
+		pw.maxOpenFiles = n
+		return nil
+	}
+}
+
+// WithMaxRowsPerFile sets the maximum number of rows per file. Once a file contains that
+// many rows, it is closed and the next row of its partition is written to a new file. By
+// default, the number of rows per file is unlimited.
+func WithMaxRowsPerFile(n int64) PartitionedWriterOption {
+	return func(pw *PartitionedWriter) error {
+		pw.maxRows = n
+		return nil
+	}
+}
+
+// WithMaxFileSize sets the size in bytes after which a file is closed and the next row of its
+// partition is written to a new file. The size of a file is estimated as the size of all row
+// groups that have been flushed plus the uncompressed size of the current row group, so files
+// end up smaller than the limit if their data is compressed. By default, the size of files is
+// unlimited.
+func WithMaxFileSize(size int64) PartitionedWriterOption {
+	return func(pw *PartitionedWriter) error {
+		pw.maxSize = size
+		return nil
+	}
+}
+
+// WithFileNamePrefix sets the prefix of the names of all files, which is "part" by default.
+// Using a unique prefix avoids overwriting files when writing to an existing dataset.
+func WithFileNamePrefix(prefix string) PartitionedWriterOption {
+	return func(pw *PartitionedWriter) error {
+		if prefix == "" || strings.Contains(prefix, "/") {
+			return fmt.Errorf("invalid file name prefix %q", prefix)
+		}
+		pw.prefix = prefix
+		return nil
+	}
+}
+
+// NewPartitionedWriter creates a new PartitionedWriter that creates its files using create.
+// The schema definition sd describes the rows including the partition columns, which need
+// to be top-level columns that are either required or optional.
+func NewPartitionedWriter(create FileCreator, sd *parquetschema.SchemaDefinition, partitionColumns []string, options ...PartitionedWriterOption) (*PartitionedWriter, error) {
+	if sd == nil || sd.RootColumn == nil {
+		return nil, errors.New("schema definition is missing")
+	}
+
+	if len(partitionColumns) == 0 {
+		return nil, errors.New("at least one partition column is required")
+	}
+
+	pw := &PartitionedWriter{
+		create:           create,
+		schemaDef:        sd,
+		partitionColumns: partitionColumns,
+		maxOpenFiles:     defaultMaxOpenFiles,
+		prefix:           "part",
+		open:             make(map[string]*partitionFile),
+		sequence:         make(map[string]int),
+	}
+
+	for _, opt := range options {
+		if err := opt(pw); err != nil {
+			return nil, err
+		}
+	}
+
+	dropped := make(map[*parquetschema.ColumnDefinition]bool)
+	pw.fileSchemaDef = sd.Clone()
+	for i, name := range partitionColumns {
+		for _, n := range partitionColumns[:i] {
+			if n == name {
+				return nil, fmt.Errorf("duplicate partition column %q", name)
+			}
+		}
+
+		col := findColumnDefinition(pw.fileSchemaDef.RootColumn, ColumnPath{name})
+		if col == nil {
+			return nil, fmt.Errorf("partition column %q doesn't exist", name)
+		}
+		if col.SchemaElement.Type == nil || col.SchemaElement.GetRepetitionType() == parquet.FieldRepetitionType_REPEATED {
+			return nil, fmt.Errorf("partition column %q needs to be a required or optional leaf column", name)
+		}
+		dropped[col] = true
+		pw.partitionElems = append(pw.partitionElems, col.SchemaElement)
+	}
+
+	if removeColumnDefinitions(pw.fileSchemaDef.RootColumn, dropped) {
+		return nil, errors.New("schema doesn't contain any columns besides the partition columns")
+	}
+
+	return pw, nil
+}
+
+// GetSchemaDefinition returns the schema definition of the rows, including the partition columns.
+func (pw *PartitionedWriter) GetSchemaDefinition() *parquetschema.SchemaDefinition {
+	return pw.schemaDef
+}
+
+// AddData writes the row m to the file of its partition. m itself is not modified.
+func (pw *PartitionedWriter) AddData(m map[string]interface{}) error {
+	row := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		row[k] = v
+	}
+
+	segments := make([]string, 0, len(pw.partitionColumns))
+	for i, name := range pw.partitionColumns {
+		value, err := partitionValueString(row[name], pw.partitionElems[i])
+		if err != nil {
+			return fmt.Errorf("invalid value for partition column %q: %w", name, err)
+		}
+		segments = append(segments, escapePartitionPathSegment(name)+"="+escapePartitionPathSegment(value))
+		delete(row, name)
+	}
+	dir := strings.Join(segments, "/")
+
+	pf, err := pw.partitionFile(dir)
+	if err != nil {
+		return err
+	}
+
+	if err := pf.w.AddData(row); err != nil {
+		return err
+	}
+	pf.numRows++
+
+	if (pw.maxRows > 0 && pf.numRows >= pw.maxRows) || (pw.maxSize > 0 && pf.w.CurrentFileSize()+pf.w.CurrentRowGroupSize() >= pw.maxSize) {
+		return pw.closeFile(dir)
+	}
+
+	return nil
+}
+
+// partitionFile returns the open file of the partition dir, and creates a new one if there
+// is none.
+func (pw *PartitionedWriter) partitionFile(dir string) (*partitionFile, error) {
+	pw.useCount++
+
+	if pf, ok := pw.open[dir]; ok {
+		pf.lastUsed = pw.useCount
+		return pf, nil
+	}
+
+	if len(pw.open) >= pw.maxOpenFiles {
+		var (
+			lruDir string
+			lru    *partitionFile
+		)
+		for d, pf := range pw.open {
+			if lru == nil || pf.lastUsed < lru.lastUsed {
+				lruDir, lru = d, pf
+			}
+		}
+		if err := pw.closeFile(lruDir); err != nil {
+			return nil, err
+		}
+	}
+
+	name := path.Join(dir, fmt.Sprintf("%s-%05d.parquet", pw.prefix, pw.sequence[dir]))
+	pw.sequence[dir]++
+
+	f, err := pw.create(name)
+	if err != nil {
+		return nil, fmt.Errorf("creating file %q failed: %w", name, err)
+	}
+
+	options := append(append([]FileWriterOption(nil), pw.writerOptions...), WithSchemaDefinition(pw.fileSchemaDef.Clone()))
+	pf := &partitionFile{
+		w:        NewFileWriter(f, options...),
+		closer:   f,
+		lastUsed: pw.useCount,
+	}
+	pw.open[dir] = pf
+	pw.files = append(pw.files, name)
+
+	return pf, nil
+}
+
+func (pw *PartitionedWriter) closeFile(dir string) error {
+	pf := pw.open[dir]
+	delete(pw.open, dir)
+
+	if err := pf.w.Close(); err != nil {
+		pf.closer.Close()
+		return err
+	}
+
+	return pf.closer.Close()
+}
+
+// Files returns the slash-separated paths of all files that have been created so far, in the
+// order in which they were created.
+func (pw *PartitionedWriter) Files() []string {
+	return append([]string(nil), pw.files...)
+}
+
+// Close closes all open files. It needs to be called after all rows have been written,
+// otherwise the files are incomplete.
+func (pw *PartitionedWriter) Close() error {
+	var firstErr error
+	for dir := range pw.open {
+		if err := pw.closeFile(dir); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// partitionValueString returns the textual representation of the partition value v of the
+// column elem. Dates, timestamps and times are formatted like Hive does, and decimals are
+// formatted using the scale of the column.
+func partitionValueString(v interface{}, elem *parquet.SchemaElement) (string, error) {
+	if v != nil {
+		switch {
+		case isDecimalElement(elem):
+			return decimalValueString(v, elem)
+		case isDateElement(elem):
+			if d, ok := v.(int32); ok {
+				return time.Unix(int64(d)*secPerDay, 0).UTC().Format(partitionDateLayout), nil
+			}
+		case elem.GetType() == parquet.Type_INT96:
+			if b, ok := v.([12]byte); ok {
+				return Int96ToTime(b).UTC().Format(partitionTimestampLayout), nil
+			}
+		}
+
+		if unit, ok := timestampUnit(elem); ok {
+			if ts, ok := v.(int64); ok {
+				return unixTime(ts, unit).Format(partitionTimestampLayout), nil
+			}
+		}
+		if unit, ok := timeUnit(elem); ok {
+			switch t := v.(type) {
+			case int32:
+				return unixTime(int64(t), unit).Format(partitionTimeLayout), nil
+			case int64:
+				return unixTime(t, unit).Format(partitionTimeLayout), nil
+			}
+		}
+	}
+
+	switch x := v.(type) {
+	case nil:
+		return hiveDefaultPartition, nil
+	case []byte:
+		if len(x) == 0 {
+			return hiveDefaultPartition, nil
+		}
+		return string(x), nil
+	case string:
+		if x == "" {
+			return hiveDefaultPartition, nil
+		}
+		return x, nil
+	case bool:
+		return strconv.FormatBool(x), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%d", x), nil
+	case float32:
+		return strconv.FormatFloat(float64(x), 'g', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64), nil
+	}
+
+	return "", fmt.Errorf("unsupported type %T", v)
+}
+
+// decimalValueString formats the unscaled decimal value v using the scale of the column elem.
+func decimalValueString(v interface{}, elem *parquet.SchemaElement) (string, error) {
+	unscaled := new(big.Int)
+	switch x := v.(type) {
+	case int32:
+		unscaled.SetInt64(int64(x))
+	case int64:
+		unscaled.SetInt64(x)
+	case []byte:
+		// the value is a big-endian two's complement integer.
+		unscaled.SetBytes(x)
+		if len(x) > 0 && x[0]&0x80 != 0 {
+			unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(len(x))*8))
+		}
+	default:
+		return "", fmt.Errorf("unsupported type %T for decimal", v)
+	}
+
+	scale := elem.GetScale()
+	if elem.LogicalType != nil && elem.LogicalType.IsSetDECIMAL() {
+		scale = elem.LogicalType.DECIMAL.GetScale()
+	}
+
+	sign := ""
+	if unscaled.Sign() < 0 {
+		sign = "-"
+		unscaled.Neg(unscaled)
+	}
+
+	digits := unscaled.String()
+	if scale <= 0 {
+		return sign + digits, nil
+	}
+	if n := int(scale) + 1 - len(digits); n > 0 {
+		digits = strings.Repeat("0", n) + digits
+	}
+	return sign + digits[:len(digits)-int(scale)] + "." + digits[len(digits)-int(scale):], nil
+}
+
+// isDateElement returns true if the column elem contains dates.
+func isDateElement(elem *parquet.SchemaElement) bool {
+	if elem.LogicalType != nil && elem.LogicalType.IsSetDATE() {
+		return true
+	}
+	return elem.ConvertedType != nil && *elem.ConvertedType == parquet.ConvertedType_DATE
+}
+
+// timestampUnit returns the unit of the values of the column elem if it contains timestamps.
+func timestampUnit(elem *parquet.SchemaElement) (time.Duration, bool) {
+	if elem.LogicalType != nil && elem.LogicalType.IsSetTIMESTAMP() {
+		return timeUnitDuration(elem.LogicalType.TIMESTAMP.Unit), true
+	}
+	switch elem.GetConvertedType() {
+	case parquet.ConvertedType_TIMESTAMP_MILLIS:
+		return time.Millisecond, true
+	case parquet.ConvertedType_TIMESTAMP_MICROS:
+		return time.Microsecond, true
+	}
+	return 0, false
+}
+
+// timeUnit returns the unit of the values of the column elem if it contains times of day.
+func timeUnit(elem *parquet.SchemaElement) (time.Duration, bool) {
+	if elem.LogicalType != nil && elem.LogicalType.IsSetTIME() {
+		return timeUnitDuration(elem.LogicalType.TIME.Unit), true
+	}
+	switch elem.GetConvertedType() {
+	case parquet.ConvertedType_TIME_MILLIS:
+		return time.Millisecond, true
+	case parquet.ConvertedType_TIME_MICROS:
+		return time.Microsecond, true
+	}
+	return 0, false
+}
+
+// unixTime returns the UTC time that is v units after the Unix epoch.
+func unixTime(v int64, unit time.Duration) time.Time {
+	perSecond := int64(time.Second / unit)
+	return time.Unix(v/perSecond, v%perSecond*int64(unit)).UTC()
+}
+
+func timeUnitDuration(unit *parquet.TimeUnit) time.Duration {
+	switch {
+	case unit.IsSetMILLIS():
+		return time.Millisecond
+	case unit.IsSetMICROS():
+		return time.Microsecond
+	}
+	return time.Nanosecond
+}
+
+// escapePartitionPathSegment escapes s so that it can be used as key or value in a path
+// segment of the form key=value.
+func escapePartitionPathSegment(s string) string {
+	return strings.Replace(url.PathEscape(s), "=", "%3D", -1)
+}
@@ -0,0 +1,260 @@
+package goparquet
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+type mapFSFile struct {
+	bytes.Buffer
+	fsys fstest.MapFS
+	name string
+}
+
+func (f *mapFSFile) Close() error {
+	f.fsys[f.name] = &fstest.MapFile{Data: f.Bytes()}
+	return nil
+}
+
+// mapFSFileCreator returns a FileCreator that adds the files to fsys when they are closed.
+func mapFSFileCreator(fsys fstest.MapFS) FileCreator {
+	return func(name string) (io.WriteCloser, error) {
+		return &mapFSFile{fsys: fsys, name: name}, nil
+	}
+}
+
+const partitionedWriterTestSchema = `message test {
+	required int64 id;
+	required int32 year;
+	optional binary country (STRING);
+	optional binary name (STRING);
+}`
+
+func TestPartitionedWriter(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(partitionedWriterTestSchema)
+	require.NoError(t, err)
+
+	fsys := fstest.MapFS{}
+	pw, err := NewPartitionedWriter(mapFSFileCreator(fsys), sd, []string{"year", "country"},
+		WithMaxOpenFiles(3),
+		WithMaxRowsPerFile(10),
+	)
+	require.NoError(t, err)
+
+	countries := []interface{}{[]byte("DE"), []byte("FR"), nil, []byte("a/b=c")}
+	for i := 0; i < 200; i++ {
+		row := map[string]interface{}{
+			"id":   int64(i),
+			"year": int32(2023 + i%2),
+			"name": []byte(fmt.Sprintf("name-%d", i)),
+		}
+		if c := countries[(i/10)%len(countries)]; c != nil {
+			row["country"] = c
+		}
+		require.NoError(t, pw.AddData(row))
+		require.Equal(t, int32(2023+i%2), row["year"])
+	}
+	require.NoError(t, pw.Close())
+
+	files := pw.Files()
+	require.Len(t, fsys, len(files))
+	for _, name := range files {
+		require.Contains(t, fsys, name)
+	}
+	require.Contains(t, files, "year=2023/country=DE/part-00000.parquet")
+	require.Contains(t, files, "year=2024/country=__HIVE_DEFAULT_PARTITION__/part-00000.parquet")
+	require.Contains(t, files, "year=2024/country=a%2Fb%3Dc/part-00000.parquet")
+
+	// the country changes every 10 rows, and as only 3 files are open at the same time,
+	// the files of both years are closed every time, before they contain 10 rows.
+	require.Len(t, files, 40)
+	require.Contains(t, files, "year=2024/country=FR/part-00004.parquet")
+
+	r, err := NewFileReader(bytes.NewReader(fsys[files[0]].Data))
+	require.NoError(t, err)
+	require.Equal(t, "message test {\n  required int64 id;\n  optional binary name (STRING);\n}\n", r.GetSchemaDefinition().String())
+
+	d, err := NewDataset(fsys)
+	require.NoError(t, err)
+	require.Equal(t, []string{"year", "country"}, d.PartitionKeys())
+
+	var ids []int
+	for {
+		row, err := d.NextRow()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		i := int(row["id"].(int64))
+		ids = append(ids, i)
+
+		expected := map[string]interface{}{
+			"id":   int64(i),
+			"year": int64(2023 + i%2),
+			"name": []byte(fmt.Sprintf("name-%d", i)),
+		}
+		if c := countries[(i/10)%len(countries)]; c != nil {
+			expected["country"] = c
+		}
+		require.Equal(t, expected, row)
+	}
+
+	sort.Ints(ids)
+	require.Len(t, ids, 200)
+	require.Equal(t, 199, ids[199])
+}
+
+func TestPartitionedWriterLogicalTypes(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 id;
+		required int32 date (DATE);
+		required int64 ts (TIMESTAMP(MICROS, true));
+		required int32 tod (TIME(MILLIS, true));
+		required int64 price (DECIMAL(10, 2));
+		required fixed_len_byte_array(4) amount (DECIMAL(9, 3));
+	}`)
+	require.NoError(t, err)
+
+	fsys := fstest.MapFS{}
+	pw, err := NewPartitionedWriter(mapFSFileCreator(fsys), sd, []string{"date", "ts", "tod", "price", "amount"})
+	require.NoError(t, err)
+
+	ts := time.Date(2024, 1, 1, 12, 30, 0, 500000000, time.UTC)
+	require.NoError(t, pw.AddData(map[string]interface{}{
+		"id":     int64(1),
+		"date":   int32(19723),
+		"ts":     ts.UnixNano() / int64(time.Microsecond),
+		"tod":    int32((12*time.Hour + 30*time.Minute + 5*time.Millisecond) / time.Millisecond),
+		"price":  int64(-1999),
+		"amount": []byte{0, 0, 0, 42},
+	}))
+	require.NoError(t, pw.Close())
+
+	require.Equal(t, []string{"date=2024-01-01/ts=2024-01-01%2012:30:00.5/tod=12:30:00.005/price=-19.99/amount=0.042/part-00000.parquet"}, pw.Files())
+
+	d, err := NewDataset(fsys)
+	require.NoError(t, err)
+
+	expectedSchema, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 id;
+		optional int32 date (DATE);
+		optional int64 ts (TIMESTAMP(NANOS, true));
+		optional binary tod (STRING);
+		optional binary price (STRING);
+		optional binary amount (STRING);
+	}`)
+	require.NoError(t, err)
+	require.Equal(t, expectedSchema.String(), d.GetSchemaDefinition().String())
+
+	row, err := d.NextRow()
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"id":     int64(1),
+		"date":   int32(19723),
+		"ts":     ts.UnixNano(),
+		"tod":    []byte("12:30:00.005"),
+		"price":  []byte("-19.99"),
+		"amount": []byte("0.042"),
+	}, row)
+	require.NoError(t, d.Close())
+}
+
+func TestPartitionedWriterRolling(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(partitionedWriterTestSchema)
+	require.NoError(t, err)
+
+	fsys := fstest.MapFS{}
+	pw, err := NewPartitionedWriter(mapFSFileCreator(fsys), sd, []string{"year"}, WithMaxRowsPerFile(30), WithFileNamePrefix("batch-1"))
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, pw.AddData(map[string]interface{}{"id": int64(i), "year": int32(2024)}))
+	}
+	require.NoError(t, pw.Close())
+
+	require.Equal(t, []string{
+		"year=2024/batch-1-00000.parquet",
+		"year=2024/batch-1-00001.parquet",
+		"year=2024/batch-1-00002.parquet",
+		"year=2024/batch-1-00003.parquet",
+	}, pw.Files())
+
+	for idx, name := range pw.Files() {
+		r, err := NewFileReader(bytes.NewReader(fsys[name].Data))
+		require.NoError(t, err)
+		if idx < 3 {
+			require.Equal(t, int64(30), r.NumRows())
+		} else {
+			require.Equal(t, int64(10), r.NumRows())
+		}
+	}
+
+	fsys = fstest.MapFS{}
+	pw, err = NewPartitionedWriter(mapFSFileCreator(fsys), sd, []string{"year"}, WithMaxFileSize(200))
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, pw.AddData(map[string]interface{}{"id": int64(i), "year": int32(2024), "name": []byte(strings.Repeat("x", 20))}))
+	}
+	require.NoError(t, pw.Close())
+	require.Greater(t, len(pw.Files()), 5)
+
+	d, err := NewDataset(fsys)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), d.NumRows())
+}
+
+func TestPartitionedWriterErrors(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(partitionedWriterTestSchema)
+	require.NoError(t, err)
+
+	create := mapFSFileCreator(fstest.MapFS{})
+
+	_, err = NewPartitionedWriter(create, sd, nil)
+	require.Error(t, err)
+
+	_, err = NewPartitionedWriter(create, sd, []string{"foo"})
+	require.Error(t, err)
+
+	_, err = NewPartitionedWriter(create, sd, []string{"year", "year"})
+	require.Error(t, err)
+
+	_, err = NewPartitionedWriter(create, sd, []string{"id", "year", "country", "name"})
+	require.Error(t, err)
+
+	_, err = NewPartitionedWriter(create, sd, []string{"year"}, WithMaxOpenFiles(0))
+	require.Error(t, err)
+
+	_, err = NewPartitionedWriter(create, sd, []string{"year"}, WithFileNamePrefix("a/b"))
+	require.Error(t, err)
+
+	nested, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 id;
+		repeated int64 tags;
+		optional group address {
+			required binary city (STRING);
+		}
+	}`)
+	require.NoError(t, err)
+
+	_, err = NewPartitionedWriter(create, nested, []string{"tags"})
+	require.Error(t, err)
+
+	_, err = NewPartitionedWriter(create, nested, []string{"address"})
+	require.Error(t, err)
+
+	pw, err := NewPartitionedWriter(create, sd, []string{"name"})
+	require.NoError(t, err)
+	require.Error(t, pw.AddData(map[string]interface{}{"id": int64(1), "year": int32(2024), "name": struct{}{}}))
+	require.NoError(t, pw.Close())
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"math"
 	"math/rand"
 	"os"
@@ -132,6 +133,23 @@ func TestWriteThenReadFile(t *testing.T) {
 			},
 			ReadOpts: []FileReaderOption{WithCRC32Validation(true)},
 		},
+		{
+			Name: "datapagev2_zstd",
+			WriteOpts: []FileWriterOption{
+				WithCompressionCodec(parquet.CompressionCodec_ZSTD),
+				WithCreator("parquet-go-unittest"),
+				WithDataPageV2(),
+			},
+			ReadOpts: []FileReaderOption{},
+		},
+		{
+			Name: "datapagev1_lz4_raw",
+			WriteOpts: []FileWriterOption{
+				WithCompressionCodec(parquet.CompressionCodec_LZ4_RAW),
+				WithCreator("parquet-go-unittest"),
+			},
+			ReadOpts: []FileReaderOption{},
+		},
 	}
 
 	for _, tt := range tests {
@@ -972,6 +990,7 @@ func TestReadWriteFixedLenByteArrayEncodings(t *testing.T) {
 		{name: "delta_byte_array_with_dict", enc: parquet.Encoding_DELTA_BYTE_ARRAY, useDict: true, input: []byte{1, 3, 2, 14, 99, 42}},
 		{name: "delta_byte_array_no_dict", enc: parquet.Encoding_DELTA_BYTE_ARRAY, useDict: false, input: []byte{7, 5, 254, 127, 42, 23}},
 		{name: "plain_no_dict", enc: parquet.Encoding_PLAIN, useDict: false, input: []byte{9, 8, 7, 6, 5, 4}},
+		{name: "byte_stream_split_no_dict", enc: parquet.Encoding_BYTE_STREAM_SPLIT, useDict: false, input: []byte{3, 1, 4, 1, 5, 9}},
 	}
 
 	for _, tt := range testData {
@@ -1502,3 +1521,225 @@ func TestRepeatedInt32(t *testing.T) {
 		int32(9001),
 	}, row["foo"])
 }
+
+func TestWriteWithConcurrency(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 id;
+		optional binary name (STRING);
+		required double score;
+		repeated int32 tags;
+		required boolean flag;
+	}`)
+	require.NoError(t, err)
+
+	writeFile := func(concurrency int) []byte {
+		buf := &bytes.Buffer{}
+		fw := NewFileWriter(buf,
+			WithSchemaDefinition(sd),
+			WithCompressionCodec(parquet.CompressionCodec_SNAPPY),
+			WithBloomFilter(ColumnPath{"id"}, 0.01),
+			WithMaxPageSize(1024),
+			WithWriterConcurrency(concurrency),
+		)
+
+		for i := 0; i < 3000; i++ {
+			data := map[string]interface{}{
+				"id":    int64(i),
+				"score": float64(i%17) / 3,
+				"tags":  []int32{int32(i % 5), int32(i % 7)},
+				"flag":  i%3 == 0,
+			}
+			if i%4 != 0 {
+				data["name"] = []byte(fmt.Sprintf("name-%d", i%50))
+			}
+			require.NoError(t, fw.AddData(data))
+			if (i+1)%1000 == 0 {
+				require.NoError(t, fw.FlushRowGroup())
+			}
+		}
+		require.NoError(t, fw.Close())
+
+		return buf.Bytes()
+	}
+
+	expected := writeFile(1)
+	for _, concurrency := range []int{2, 4, 16} {
+		data := writeFile(concurrency)
+		require.Equal(t, expected, data, "file written with concurrency %d differs", concurrency)
+	}
+
+	r, err := NewFileReader(bytes.NewReader(expected))
+	require.NoError(t, err)
+	require.Equal(t, 3, r.RowGroupCount())
+
+	columnIndex, err := r.ColumnIndex(1, ColumnPath{"id"})
+	require.NoError(t, err)
+	require.NotNil(t, columnIndex)
+
+	ok, err := r.MightContain(2, ColumnPath{"id"}, int64(2500))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	for i := 0; i < 3000; i++ {
+		row, err := r.NextRow()
+		require.NoError(t, err)
+		require.Equal(t, int64(i), row["id"])
+	}
+}
+
+func TestWritePerColumnCompressionCodec(t *testing.T) {
+	gzip := parquet.CompressionCodec_GZIP
+
+	idStore, err := NewInt64Store(parquet.Encoding_PLAIN, true, &ColumnParameters{})
+	require.NoError(t, err)
+	blobStore, err := NewByteArrayStore(parquet.Encoding_PLAIN, true, &ColumnParameters{Codec: &gzip})
+	require.NoError(t, err)
+	nameStore, err := NewByteArrayStore(parquet.Encoding_PLAIN, true, &ColumnParameters{Codec: &gzip})
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	w := NewFileWriter(buf,
+		WithCompressionCodec(parquet.CompressionCodec_SNAPPY),
+		WithColumnCompressionCodec(ColumnPath{"name"}, parquet.CompressionCodec_UNCOMPRESSED),
+	)
+	require.NoError(t, w.AddColumnByPath(ColumnPath{"id"}, NewDataColumn(idStore, parquet.FieldRepetitionType_REQUIRED)))
+	require.NoError(t, w.AddColumnByPath(ColumnPath{"blob"}, NewDataColumn(blobStore, parquet.FieldRepetitionType_REQUIRED)))
+	require.NoError(t, w.AddColumnByPath(ColumnPath{"name"}, NewDataColumn(nameStore, parquet.FieldRepetitionType_OPTIONAL)))
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, w.AddData(map[string]interface{}{
+			"id":   int64(i),
+			"blob": []byte(fmt.Sprintf(`{"id": %d, "payload": "some repetitive payload"}`, i)),
+			"name": []byte(fmt.Sprintf("name-%d", i%10)),
+		}))
+	}
+	require.NoError(t, w.Close())
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	columns := r.meta.RowGroups[0].Columns
+	require.Equal(t, parquet.CompressionCodec_SNAPPY, columns[0].MetaData.Codec)
+	require.Equal(t, parquet.CompressionCodec_GZIP, columns[1].MetaData.Codec)
+	require.Equal(t, parquet.CompressionCodec_UNCOMPRESSED, columns[2].MetaData.Codec)
+
+	for i := 0; i < 100; i++ {
+		row, err := r.NextRow()
+		require.NoError(t, err)
+		require.Equal(t, int64(i), row["id"])
+		require.Equal(t, []byte(fmt.Sprintf(`{"id": %d, "payload": "some repetitive payload"}`, i)), row["blob"])
+		require.Equal(t, []byte(fmt.Sprintf("name-%d", i%10)), row["name"])
+	}
+}
+
+func TestReadWriteByteStreamSplit(t *testing.T) {
+	var buf bytes.Buffer
+	wr := NewFileWriter(&buf, WithCompressionCodec(parquet.CompressionCodec_ZSTD))
+
+	floatStore, err := NewFloatStore(parquet.Encoding_BYTE_STREAM_SPLIT, false, &ColumnParameters{})
+	require.NoError(t, err)
+	doubleStore, err := NewDoubleStore(parquet.Encoding_BYTE_STREAM_SPLIT, false, &ColumnParameters{})
+	require.NoError(t, err)
+	int32Store, err := NewInt32Store(parquet.Encoding_BYTE_STREAM_SPLIT, false, &ColumnParameters{})
+	require.NoError(t, err)
+	int64Store, err := NewInt64Store(parquet.Encoding_BYTE_STREAM_SPLIT, false, &ColumnParameters{})
+	require.NoError(t, err)
+
+	require.NoError(t, wr.AddColumn("f", NewDataColumn(floatStore, parquet.FieldRepetitionType_REQUIRED)))
+	require.NoError(t, wr.AddColumn("d", NewDataColumn(doubleStore, parquet.FieldRepetitionType_OPTIONAL)))
+	require.NoError(t, wr.AddColumn("i32", NewDataColumn(int32Store, parquet.FieldRepetitionType_REQUIRED)))
+	require.NoError(t, wr.AddColumn("i64", NewDataColumn(int64Store, parquet.FieldRepetitionType_REQUIRED)))
+
+	var rows []map[string]interface{}
+	for i := 0; i < 1000; i++ {
+		row := map[string]interface{}{
+			"f":   float32(i) * 1.5,
+			"i32": int32(i * 7),
+			"i64": int64(i) << 33,
+		}
+		if i%3 != 0 {
+			row["d"] = float64(i) / 3
+		}
+		rows = append(rows, row)
+		require.NoError(t, wr.AddData(row))
+	}
+
+	require.NoError(t, wr.Close())
+
+	rd, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	for _, col := range rd.meta.RowGroups[0].Columns {
+		require.Contains(t, col.MetaData.Encodings, parquet.Encoding_BYTE_STREAM_SPLIT)
+	}
+
+	for _, row := range rows {
+		data, err := rd.NextRow()
+		require.NoError(t, err)
+		require.Equal(t, row, data)
+	}
+
+	_, err = rd.NextRow()
+	require.True(t, errors.Is(err, io.EOF))
+}
+
+func BenchmarkAddData(b *testing.B) {
+	testData := map[string]struct {
+		schema string
+		row    func(i int) map[string]interface{}
+	}{
+		"int64-plain": {
+			schema: `message test { required int64 a; required int64 b; }`,
+			row: func(i int) map[string]interface{} {
+				return map[string]interface{}{"a": int64(i), "b": rand.Int63()}
+			},
+		},
+		"double-optional": {
+			schema: `message test { optional double a; optional double b; }`,
+			row: func(i int) map[string]interface{} {
+				row := map[string]interface{}{"a": rand.Float64()}
+				if i%4 != 0 {
+					row["b"] = float64(i)
+				}
+				return row
+			},
+		},
+		"string-dictionary": {
+			schema: `message test { required binary a (STRING); }`,
+			row: func(i int) map[string]interface{} {
+				return map[string]interface{}{"a": []byte(fmt.Sprintf("value-%d", i%100))}
+			},
+		},
+		"int32-repeated": {
+			schema: `message test { repeated int32 a; }`,
+			row: func(i int) map[string]interface{} {
+				return map[string]interface{}{"a": []int32{int32(i), int32(i + 1), int32(i + 2)}}
+			},
+		},
+	}
+
+	const numRows = 100000
+
+	for name, tt := range testData {
+		b.Run(name, func(b *testing.B) {
+			sd, err := parquetschema.ParseSchemaDefinition(tt.schema)
+			require.NoError(b, err)
+
+			rows := make([]map[string]interface{}, numRows)
+			for i := range rows {
+				rows[i] = tt.row(i)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				fw := NewFileWriter(ioutil.Discard, WithSchemaDefinition(sd))
+				for _, row := range rows {
+					require.NoError(b, fw.AddData(row))
+				}
+				require.NoError(b, fw.Close())
+			}
+			b.ReportMetric(float64(b.N*numRows)/b.Elapsed().Seconds(), "rows/s")
+		})
+	}
+}
@@ -0,0 +1,421 @@
+package goparquet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+)
+
+// ColumnFunc computes the value of a column added by a Rewriter from a row of the source
+// file. The row only contains the input columns of the added columns. A nil value is
+// written as null, which requires the added column to be optional.
+type ColumnFunc func(row map[string]interface{}) (interface{}, error)
+
+// Rewriter writes a copy of a parquet file with some of its columns dropped, renamed or
+// added. The column chunks of all columns that are kept are copied byte for byte without
+// decoding and re-encoding them, only the values of added columns are encoded. The row
+// groups of the source file are kept as they are.
+//
+// All column paths passed to the methods of Rewriter refer to the columns of the source
+// file, regardless of any renames.
+type Rewriter struct {
+	r         io.ReadSeeker
+	meta      *parquet.FileMetaData
+	schemaDef *parquetschema.SchemaDefinition
+
+	drops   []ColumnPath
+	renames []columnRename
+	adds    []addedColumn
+}
+
+type columnRename struct {
+	path ColumnPath
+	name string
+}
+
+type addedColumn struct {
+	def    *parquetschema.ColumnDefinition
+	inputs []ColumnPath
+	fn     ColumnFunc
+}
+
+// NewRewriter creates a new Rewriter for the parquet file read from r.
+func NewRewriter(r io.ReadSeeker) (*Rewriter, error) {
+	meta, err := ReadFileMetaData(r, true)
+	if err != nil {
+		return nil, fmt.Errorf("reading file meta data failed: %w", err)
+	}
+
+	sch, err := makeSchema(meta, false, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating schema from file meta data failed: %w", err)
+	}
+
+	return &Rewriter{
+		r:         r,
+		meta:      meta,
+		schemaDef: sch.GetSchemaDefinition(),
+	}, nil
+}
+
+// DropColumn removes the column identified by path from the rewritten file. The path can
+// refer to a leaf column or to a group, in which case all columns within the group are
+// dropped. Groups that end up without any columns are dropped as well.
+func (rw *Rewriter) DropColumn(path ColumnPath) error {
+	if findColumnDefinition(rw.schemaDef.RootColumn, path) == nil {
+		return fmt.Errorf("column %s doesn't exist", path.flatName())
+	}
+
+	rw.drops = append(rw.drops, path)
+	return nil
+}
+
+// RenameColumn changes the name of the column identified by path to name. Only the last
+// element of the path is renamed; to move a column into a different group, it needs to be
+// dropped and added instead. If path refers to a group, the paths of all columns within the
+// group change accordingly.
+func (rw *Rewriter) RenameColumn(path ColumnPath, name string) error {
+	if findColumnDefinition(rw.schemaDef.RootColumn, path) == nil {
+		return fmt.Errorf("column %s doesn't exist", path.flatName())
+	}
+
+	if name == "" {
+		return errors.New("column name must not be empty")
+	}
+
+	rw.renames = append(rw.renames, columnRename{path: path, name: name})
+	return nil
+}
+
+// AddColumn adds the column described by def as a top-level column after all existing
+// columns. The column can be a group with further columns. Its values are computed by fn,
+// which is called once per row of the source file, in order, with a row that contains the
+// columns identified by inputs. Inputs of all added columns are read together, so the row
+// may contain columns that were requested by other added columns as well.
+func (rw *Rewriter) AddColumn(def *parquetschema.ColumnDefinition, inputs []ColumnPath, fn ColumnFunc) error {
+	if def == nil || def.SchemaElement == nil {
+		return errors.New("column definition is missing")
+	}
+
+	if fn == nil {
+		return errors.New("column function is missing")
+	}
+
+	for _, path := range inputs {
+		if findColumnDefinition(rw.schemaDef.RootColumn, path) == nil {
+			return fmt.Errorf("input column %s doesn't exist", path.flatName())
+		}
+	}
+
+	rw.adds = append(rw.adds, addedColumn{def: def, inputs: inputs, fn: fn})
+	return nil
+}
+
+// Write writes the rewritten file to w. The key-value meta data of the source file is
+// kept and can be overridden using the WithMetaData option. Options that affect how data
+// is encoded, such as the compression codec or the page size, only apply to added columns.
+// The statistics of copied columns are kept, so the rewritten file only declares the type
+// defined order if the source file declares it for all copied columns.
+func (rw *Rewriter) Write(w io.Writer, options ...FileWriterOption) error {
+	outDef, leaves, err := rw.outputSchema()
+	if err != nil {
+		return err
+	}
+
+	if err := (&schema{}).SetSchemaDefinition(outDef); err != nil {
+		return fmt.Errorf("invalid schema for rewritten file: %w", err)
+	}
+
+	kv := make(map[string]string, len(rw.meta.KeyValueMetadata))
+	for _, e := range rw.meta.KeyValueMetadata {
+		kv[e.Key] = e.GetValue()
+	}
+
+	fw := NewFileWriter(w, append([]FileWriterOption{WithSchemaDefinition(outDef), WithMetaData(kv)}, options...)...)
+	if fw.encryption != nil {
+		return errors.New("rewriting into encrypted files is not supported")
+	}
+
+	var (
+		addSch *schema
+		fr     *FileReader
+	)
+	if len(rw.adds) > 0 {
+		if addSch, err = rw.addedColumnsSchema(fw.schemaWriter); err != nil {
+			return err
+		}
+
+		var inputs []ColumnPath
+		for _, ac := range rw.adds {
+			inputs = append(inputs, ac.inputs...)
+		}
+
+		// without any inputs, the rows don't need to be read at all.
+		if len(inputs) > 0 {
+			if fr, err = NewFileReaderWithOptions(rw.r, WithColumnPaths(inputs...), WithFileMetaData(rw.meta)); err != nil {
+				return fmt.Errorf("opening file for reading input columns failed: %w", err)
+			}
+		}
+	}
+
+	if err := writeFull(fw.w, magic); err != nil {
+		return err
+	}
+
+	for idx, rg := range rw.meta.RowGroups {
+		if err := rw.writeRowGroup(fw.ctx, fw, rg, leaves, addSch, fr); err != nil {
+			return fmt.Errorf("rewriting row group %d failed: %w", idx, err)
+		}
+	}
+
+	return fw.Close()
+}
+
+// rewrittenLeaf is a leaf column of the rewritten file. If it is copied from the source
+// file, source is the column's path in the source file, otherwise it is an added column.
+type rewrittenLeaf struct {
+	path   ColumnPath
+	source ColumnPath
+}
+
+// outputSchema applies all changes to a copy of the source file's schema definition, and
+// returns it along with its leaf columns in the order of the schema.
+func (rw *Rewriter) outputSchema() (*parquetschema.SchemaDefinition, []rewrittenLeaf, error) {
+	outDef := rw.schemaDef.Clone()
+
+	// the columns are looked up before changing anything, as renames change the paths.
+	sources := make(map[*parquetschema.ColumnDefinition]ColumnPath)
+	collectLeafDefinitions(outDef.RootColumn, nil, func(def *parquetschema.ColumnDefinition, path ColumnPath) {
+		sources[def] = path
+	})
+
+	dropped := make(map[*parquetschema.ColumnDefinition]bool)
+	for _, path := range rw.drops {
+		dropped[findColumnDefinition(outDef.RootColumn, path)] = true
+	}
+
+	renamed := make(map[*parquetschema.ColumnDefinition]string)
+	for _, r := range rw.renames {
+		renamed[findColumnDefinition(outDef.RootColumn, r.path)] = r.name
+	}
+
+	for def, name := range renamed {
+		def.SchemaElement.Name = name
+	}
+
+	removeColumnDefinitions(outDef.RootColumn, dropped)
+
+	for _, ac := range rw.adds {
+		outDef.RootColumn.Children = append(outDef.RootColumn.Children, ac.def)
+	}
+
+	names := make(map[string]bool)
+	for _, col := range outDef.RootColumn.Children {
+		if names[col.SchemaElement.Name] {
+			return nil, nil, fmt.Errorf("duplicate column %s in rewritten file", col.SchemaElement.Name)
+		}
+		names[col.SchemaElement.Name] = true
+	}
+
+	if len(outDef.RootColumn.Children) == 0 {
+		return nil, nil, errors.New("rewritten file has no columns")
+	}
+
+	var leaves []rewrittenLeaf
+	collectLeafDefinitions(outDef.RootColumn, nil, func(def *parquetschema.ColumnDefinition, path ColumnPath) {
+		leaves = append(leaves, rewrittenLeaf{path: path, source: sources[def]})
+	})
+
+	return outDef, leaves, nil
+}
+
+// addedColumnsSchema creates a schema that only consists of the added columns, which is used
+// to encode them. It takes over the settings of the file writer's schema.
+func (rw *Rewriter) addedColumnsSchema(writerSchema *schema) (*schema, error) {
+	def := &parquetschema.SchemaDefinition{
+		RootColumn: &parquetschema.ColumnDefinition{
+			SchemaElement: &parquet.SchemaElement{Name: rw.schemaDef.RootColumn.SchemaElement.Name},
+		},
+	}
+	for _, ac := range rw.adds {
+		def.RootColumn.Children = append(def.RootColumn.Children, ac.def)
+	}
+
+	sch := &schema{
+		maxPageSize:      writerSchema.maxPageSize,
+		enableCRC:        writerSchema.enableCRC,
+		bloomFilters:     writerSchema.bloomFilters,
+		columnCodecs:     writerSchema.columnCodecs,
+		dictLimits:       writerSchema.dictLimits,
+		columnDictLimits: writerSchema.columnDictLimits,
+	}
+	if err := sch.SetSchemaDefinition(def.Clone()); err != nil {
+		return nil, fmt.Errorf("creating schema for added columns failed: %w", err)
+	}
+
+	return sch, nil
+}
+
+func (rw *Rewriter) writeRowGroup(ctx context.Context, fw *FileWriter, rg *parquet.RowGroup, leaves []rewrittenLeaf, addSch *schema, fr *FileReader) error {
+	if addSch != nil {
+		defer addSch.resetData()
+
+		for i := int64(0); i < rg.NumRows; i++ {
+			row := map[string]interface{}{}
+			if fr != nil {
+				var err error
+				if row, err = fr.NextRowWithContext(ctx); err != nil {
+					return fmt.Errorf("reading row failed: %w", err)
+				}
+			}
+
+			data := make(map[string]interface{}, len(rw.adds))
+			for _, ac := range rw.adds {
+				v, err := ac.fn(row)
+				if err != nil {
+					return fmt.Errorf("computing column %s failed: %w", ac.def.SchemaElement.Name, err)
+				}
+				if v != nil {
+					data[ac.def.SchemaElement.Name] = v
+				}
+			}
+
+			if err := addSch.AddData(data); err != nil {
+				return err
+			}
+		}
+	}
+
+	var (
+		chunks              = make([]*parquet.ColumnChunk, 0, len(leaves))
+		columnIndexes       = make(map[int]int, len(leaves))
+		totalCompressedSize int64
+		totalByteSize       int64
+	)
+	for leafIdx, leaf := range leaves {
+		var (
+			ch    *parquet.ColumnChunk
+			index *chunkIndex
+			err   error
+		)
+
+		if leaf.source != nil {
+			sourceIdx, chunk := findColumnChunk(rg, leaf.source)
+			if chunk == nil {
+				return fmt.Errorf("column chunk of column %s not found", leaf.source.flatName())
+			}
+			if ch, index, err = copyColumnChunk(ctx, fw.w, rw.r, chunk); err != nil {
+				return fmt.Errorf("copying column %s failed: %w", leaf.source.flatName(), err)
+			}
+			ch.MetaData.PathInSchema = leaf.path
+			columnIndexes[sourceIdx] = leafIdx
+			fw.addCopiedColumnOrder(leafIdx, fileColumnOrder(rw.meta, sourceIdx))
+		} else {
+			col := addSch.GetColumnByPath(leaf.path)
+			if col == nil {
+				return fmt.Errorf("added column %s not found", leaf.path.flatName())
+			}
+			if ch, index, err = writeChunk(ctx, fw.w, addSch, col, fw.codec, fw.newPageFunc, nil); err != nil {
+				return fmt.Errorf("writing column %s failed: %w", leaf.path.flatName(), err)
+			}
+		}
+
+		chunks = append(chunks, ch)
+		fw.chunkIndexes = append(fw.chunkIndexes, index)
+		totalCompressedSize += ch.MetaData.TotalCompressedSize
+		totalByteSize += ch.MetaData.TotalUncompressedSize
+	}
+
+	// the sort order remains valid up to the first sorting column that was dropped.
+	var sortingColumns []*parquet.SortingColumn
+	for _, sc := range rg.SortingColumns {
+		idx, ok := columnIndexes[int(sc.ColumnIdx)]
+		if !ok {
+			break
+		}
+		sortingColumns = append(sortingColumns, &parquet.SortingColumn{
+			ColumnIdx:  int32(idx),
+			Descending: sc.Descending,
+			NullsFirst: sc.NullsFirst,
+		})
+	}
+
+	fw.rowGroups = append(fw.rowGroups, &parquet.RowGroup{
+		Columns:             chunks,
+		TotalByteSize:       totalByteSize,
+		TotalCompressedSize: &totalCompressedSize,
+		NumRows:             rg.NumRows,
+		SortingColumns:      sortingColumns,
+	})
+	fw.totalNumRecords += rg.NumRows
+
+	return nil
+}
+
+func findColumnChunk(rg *parquet.RowGroup, path ColumnPath) (int, *parquet.ColumnChunk) {
+	for idx, chunk := range rg.Columns {
+		if chunk.MetaData != nil && ColumnPath(chunk.MetaData.PathInSchema).Equal(path) {
+			return idx, chunk
+		}
+	}
+	return -1, nil
+}
+
+// findColumnDefinition returns the column definition identified by path below root, or nil
+// if there is no such column.
+func findColumnDefinition(root *parquetschema.ColumnDefinition, path ColumnPath) *parquetschema.ColumnDefinition {
+	if len(path) == 0 {
+		return nil
+	}
+
+	col := root
+	for _, name := range path {
+		var next *parquetschema.ColumnDefinition
+		for _, child := range col.Children {
+			if child.SchemaElement.Name == name {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		col = next
+	}
+
+	return col
+}
+
+// collectLeafDefinitions calls fn for every leaf column below root in the order of the schema.
+func collectLeafDefinitions(root *parquetschema.ColumnDefinition, path ColumnPath, fn func(*parquetschema.ColumnDefinition, ColumnPath)) {
+	for _, child := range root.Children {
+		childPath := append(append(ColumnPath(nil), path...), child.SchemaElement.Name)
+		if child.SchemaElement.Type != nil {
+			fn(child, childPath)
+			continue
+		}
+		collectLeafDefinitions(child, childPath, fn)
+	}
+}
+
+// removeColumnDefinitions removes all columns in dropped below root, as well as all groups
+// that end up empty. It returns true if root itself ends up empty.
+func removeColumnDefinitions(root *parquetschema.ColumnDefinition, dropped map[*parquetschema.ColumnDefinition]bool) bool {
+	children := root.Children[:0]
+	for _, child := range root.Children {
+		if dropped[child] {
+			continue
+		}
+		if child.SchemaElement.Type == nil && removeColumnDefinitions(child, dropped) {
+			continue
+		}
+		children = append(children, child)
+	}
+	root.Children = children
+
+	return len(children) == 0
+}
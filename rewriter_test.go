@@ -0,0 +1,256 @@
+package goparquet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+const rewriterTestSchema = `message test {
+	required int64 id;
+	optional binary email (STRING);
+	required group address {
+		required binary city (STRING);
+		optional binary zip (STRING);
+	}
+}`
+
+func writeRewriterTestFile(t *testing.T, numRows int) []byte {
+	sd, err := parquetschema.ParseSchemaDefinition(rewriterTestSchema)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	fw := NewFileWriter(buf,
+		WithSchemaDefinition(sd),
+		WithMaxPageSize(256),
+		WithCompressionCodec(parquet.CompressionCodec_SNAPPY),
+		WithBloomFilter(ColumnPath{"id"}, 0.01),
+		WithSortingColumns(SortingColumn{Path: ColumnPath{"address", "city"}}, SortingColumn{Path: ColumnPath{"id"}}),
+		WithMetaData(map[string]string{"source": "test"}),
+	)
+
+	for i := 0; i < numRows; i++ {
+		address := map[string]interface{}{"city": []byte(fmt.Sprintf("city-%03d", i/10))}
+		if i%2 == 0 {
+			address["zip"] = []byte(fmt.Sprintf("%05d", i))
+		}
+		require.NoError(t, fw.AddData(map[string]interface{}{
+			"id":      int64(i),
+			"email":   []byte(fmt.Sprintf("user%d@example.com", i)),
+			"address": address,
+		}))
+		if i%100 == 99 {
+			require.NoError(t, fw.FlushRowGroup())
+		}
+	}
+	require.NoError(t, fw.Close())
+
+	return buf.Bytes()
+}
+
+func TestRewriter(t *testing.T) {
+	src := writeRewriterTestFile(t, 250)
+
+	rw, err := NewRewriter(bytes.NewReader(src))
+	require.NoError(t, err)
+
+	require.NoError(t, rw.DropColumn(ColumnPath{"email"}))
+	require.NoError(t, rw.RenameColumn(ColumnPath{"address", "city"}, "town"))
+	require.NoError(t, rw.RenameColumn(ColumnPath{"address"}, "location"))
+
+	addSchema, err := parquetschema.ParseSchemaDefinition(`message add {
+		optional binary label (STRING);
+		required int64 double_id;
+	}`)
+	require.NoError(t, err)
+
+	require.NoError(t, rw.AddColumn(addSchema.RootColumn.Children[0], []ColumnPath{{"id"}, {"address", "zip"}}, func(row map[string]interface{}) (interface{}, error) {
+		address := row["address"].(map[string]interface{})
+		if _, ok := address["zip"]; !ok {
+			return nil, nil
+		}
+		return []byte(fmt.Sprintf("label-%d", row["id"].(int64))), nil
+	}))
+	require.NoError(t, rw.AddColumn(addSchema.RootColumn.Children[1], []ColumnPath{{"id"}}, func(row map[string]interface{}) (interface{}, error) {
+		return row["id"].(int64) * 2, nil
+	}))
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, rw.Write(buf, WithCompressionCodec(parquet.CompressionCodec_GZIP)))
+
+	srcReader, err := NewFileReader(bytes.NewReader(src))
+	require.NoError(t, err)
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, 3, r.RowGroupCount())
+	require.Equal(t, int64(250), r.NumRows())
+	require.Equal(t, map[string]string{"source": "test"}, r.MetaData())
+
+	expectedSchema, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 id;
+		required group location {
+			required binary town (STRING);
+			optional binary zip (STRING);
+		}
+		optional binary label (STRING);
+		required int64 double_id;
+	}`)
+	require.NoError(t, err)
+	require.Equal(t, expectedSchema.String(), r.GetSchemaDefinition().String())
+
+	for rg := 0; rg < r.RowGroupCount(); rg++ {
+		// copied column chunks keep their compression codec, added ones use the writer's codec.
+		idInfo, err := r.ColumnChunkInfo(rg, ColumnPath{"id"})
+		require.NoError(t, err)
+		require.Equal(t, parquet.CompressionCodec_SNAPPY, idInfo.Codec)
+
+		srcPages, err := srcReader.ColumnChunkPages(rg, ColumnPath{"address", "city"})
+		require.NoError(t, err)
+		pages, err := r.ColumnChunkPages(rg, ColumnPath{"location", "town"})
+		require.NoError(t, err)
+		require.Equal(t, len(srcPages), len(pages))
+
+		labelInfo, err := r.ColumnChunkInfo(rg, ColumnPath{"label"})
+		require.NoError(t, err)
+		require.Equal(t, parquet.CompressionCodec_GZIP, labelInfo.Codec)
+
+		offsetIndex, err := r.OffsetIndex(rg, ColumnPath{"location", "town"})
+		require.NoError(t, err)
+		require.NotNil(t, offsetIndex)
+		for i, loc := range offsetIndex.PageLocations {
+			require.Equal(t, pages[len(pages)-len(offsetIndex.PageLocations)+i].Offset, loc.Offset)
+		}
+
+		ok, err := r.MightContain(rg, ColumnPath{"id"}, int64(rg*100))
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		require.Equal(t, []*parquet.SortingColumn{{ColumnIdx: 1}, {ColumnIdx: 0}}, r.meta.RowGroups[rg].SortingColumns)
+	}
+
+	for i := 0; i < 250; i++ {
+		row, err := r.NextRow()
+		require.NoError(t, err)
+
+		location := map[string]interface{}{"town": []byte(fmt.Sprintf("city-%03d", i/10))}
+		expected := map[string]interface{}{
+			"id":        int64(i),
+			"location":  location,
+			"double_id": int64(i * 2),
+		}
+		if i%2 == 0 {
+			location["zip"] = []byte(fmt.Sprintf("%05d", i))
+			expected["label"] = []byte(fmt.Sprintf("label-%d", i))
+		}
+		require.Equal(t, expected, row)
+	}
+}
+
+func TestRewriterColumnOrders(t *testing.T) {
+	addSchema, err := parquetschema.ParseSchemaDefinition(`message add {
+		required int64 n;
+	}`)
+	require.NoError(t, err)
+
+	rewrite := func(src []byte) []byte {
+		rw, err := NewRewriter(bytes.NewReader(src))
+		require.NoError(t, err)
+		require.NoError(t, rw.RenameColumn(ColumnPath{"a"}, "b"))
+		require.NoError(t, rw.AddColumn(addSchema.RootColumn.Children[0], nil, func(row map[string]interface{}) (interface{}, error) {
+			return int64(1), nil
+		}))
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, rw.Write(buf))
+		return buf.Bytes()
+	}
+
+	// the signed statistics of the legacy file must not be trusted for the unsigned column.
+	data := rewrite(writeLegacyUnsignedTestFile(t))
+	r, err := NewFileReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Nil(t, r.meta.ColumnOrders)
+	require.Equal(t, 2, countFilteredRows(t, data, Eq(ColumnPath{"b"}, uint32(1))))
+
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int32 a (UINT_32);
+	}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	fw := NewFileWriter(buf, WithSchemaDefinition(sd))
+	require.NoError(t, fw.AddData(map[string]interface{}{"a": int32(1)}))
+	require.NoError(t, fw.Close())
+
+	data = rewrite(buf.Bytes())
+	r, err = NewFileReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Len(t, r.meta.ColumnOrders, 2)
+	for _, order := range r.meta.ColumnOrders {
+		require.True(t, order.IsSetTYPE_ORDER())
+	}
+}
+
+func TestRewriterDropGroup(t *testing.T) {
+	rw, err := NewRewriter(bytes.NewReader(writeRewriterTestFile(t, 50)))
+	require.NoError(t, err)
+
+	require.NoError(t, rw.DropColumn(ColumnPath{"address", "city"}))
+	require.NoError(t, rw.DropColumn(ColumnPath{"address", "zip"}))
+	require.NoError(t, rw.DropColumn(ColumnPath{"id"}))
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, rw.Write(buf))
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, int64(50), r.NumRows())
+	require.Len(t, r.GetSchemaDefinition().RootColumn.Children, 1)
+
+	// the sort order doesn't apply anymore as its first column was dropped.
+	require.Nil(t, r.meta.RowGroups[0].SortingColumns)
+
+	row, err := r.NextRow()
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"email": []byte("user0@example.com")}, row)
+}
+
+func TestRewriterErrors(t *testing.T) {
+	src := writeRewriterTestFile(t, 10)
+
+	rw, err := NewRewriter(bytes.NewReader(src))
+	require.NoError(t, err)
+
+	require.Error(t, rw.DropColumn(ColumnPath{"foo"}))
+	require.Error(t, rw.RenameColumn(ColumnPath{"address", "foo"}, "bar"))
+	require.Error(t, rw.RenameColumn(ColumnPath{"id"}, ""))
+
+	def, err := parquetschema.ParseSchemaDefinition(`message add {
+		required int64 id;
+	}`)
+	require.NoError(t, err)
+
+	fn := func(row map[string]interface{}) (interface{}, error) {
+		return int64(1), nil
+	}
+	require.Error(t, rw.AddColumn(def.RootColumn.Children[0], []ColumnPath{{"foo"}}, fn))
+	require.Error(t, rw.AddColumn(def.RootColumn.Children[0], nil, nil))
+
+	// the added column collides with an existing one.
+	require.NoError(t, rw.AddColumn(def.RootColumn.Children[0], nil, fn))
+	require.Error(t, rw.Write(&bytes.Buffer{}))
+
+	rw, err = NewRewriter(bytes.NewReader(src))
+	require.NoError(t, err)
+	require.NoError(t, rw.RenameColumn(ColumnPath{"id"}, "key"))
+	require.NoError(t, rw.AddColumn(def.RootColumn.Children[0], nil, func(row map[string]interface{}) (interface{}, error) {
+		return nil, errors.New("failed")
+	}))
+	require.Error(t, rw.Write(&bytes.Buffer{}))
+}
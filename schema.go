@@ -210,6 +210,9 @@ func (c *Column) getDataSize() int64 {
 		return int64(c.data.values.numValues())/8 + 1
 	}
 	_, dataSize := c.data.values.sizes()
+	if c.data.spilled != nil {
+		dataSize += c.data.spilled.size
+	}
 	return dataSize
 }
 
@@ -317,14 +320,30 @@ type schema struct {
 	numRecords int64
 	readOnly   int
 
+	// columnBatches is set if the columns of the current row group are added using
+	// AddColumnValues, in which case every column counts its rows itself.
+	columnBatches bool
+
 	maxPageSize int64
 
 	// selected columns in reading. if the size is zero, it means all the columns
 	selectedColumns []ColumnPath
 
+	// columns that are required to evaluate the row filter when reading.
+	filterColumns []ColumnPath
+
 	enableCRC   bool // if true, CRC32 checksums will be computed for pages upon writing.
 	validateCRC bool // if true, CRC32 checksums will be validated for pages upon reading.
 
+	bloomFilters []bloomFilterColumn // columns for which bloom filters are written.
+
+	pageSpill *pageSpill // if set, data pages are encoded and spilled as soon as they are full.
+
+	columnCodecs []columnCodec // per-column compression codecs that override the file's default codec.
+
+	dictLimits       dictionaryLimits         // limits of the dictionaries of all column chunks.
+	columnDictLimits []columnDictionaryLimits // per-column dictionary limits that override dictLimits.
+
 	alloc *allocTracker
 }
 
@@ -366,6 +385,26 @@ func (r *schema) isSelectedByPath(path ColumnPath) bool {
 	return false
 }
 
+func (r *schema) isFilterColumn(path ColumnPath) bool {
+	for _, p := range r.filterColumns {
+		if p.Equal(path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// skipRow skips the current row in all columns without assembling it.
+func (r *schema) skipRow() error {
+	for _, c := range r.Columns() {
+		if err := c.data.skipRow(int32(c.maxD), int32(c.maxR)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *schema) getSchemaArray() []*parquet.SchemaElement {
 	r.ensureRoot()
 	elem := r.root.getSchemaArray()
@@ -431,7 +470,12 @@ func (r *schema) resetData() {
 		data[i].data.reset(data[i].rep, data[i].maxR, data[i].maxD)
 	}
 
+	if r.pageSpill != nil {
+		r.pageSpill.reset()
+	}
+
 	r.numRecords = 0
+	r.columnBatches = false
 }
 
 func (r *schema) setNumRecords(n int64) {
@@ -474,6 +518,7 @@ func (r *schema) SetSchemaDefinition(sd *parquetschema.SchemaDefinition) error {
 	for _, c := range r.root.children {
 		recursiveFix(c, ColumnPath{}, 0, 0, r.alloc)
 	}
+	r.sortIndex()
 
 	return nil
 }
@@ -565,6 +610,10 @@ type ColumnParameters struct {
 	FieldID       *int32
 	Scale         *int32
 	Precision     *int32
+
+	// Codec is the compression codec used for the column's chunks. If it is nil,
+	// the compression codec of the file writer is used.
+	Codec *parquet.CompressionCodec
 }
 
 // NewDataColumn creates a new data column of the provided field repetition type, using
@@ -772,6 +821,10 @@ func (r *schema) findDataColumn(path string) (*Column, error) {
 }
 
 func (r *schema) AddData(m map[string]interface{}) error {
+	if r.columnBatches {
+		return errors.New("rows can't be added to a row group that contains column values added using AddColumnValues")
+	}
+
 	r.readOnly = 1
 	r.ensureRoot()
 	err := r.recursiveAddColumnData(r.root.children, m, 0, 0, 0)
@@ -779,12 +832,11 @@ func (r *schema) AddData(m map[string]interface{}) error {
 		return err
 	}
 
-	if err := r.recursiveFlushPages(r.root.children); err != nil {
-		return err
-	}
-
+	// the record needs to be counted before pages are flushed, otherwise the
+	// number of rows in a data page would be off by one.
 	r.numRecords++
-	return nil
+
+	return r.recursiveFlushPages(r.root.children)
 }
 
 func (r *schema) getData() (map[string]interface{}, error) {
@@ -818,10 +870,85 @@ func (r *schema) recursiveAddColumnNil(c []*Column, defLvl, maxRepLvl uint16, re
 	return nil
 }
 
+type columnCodec struct {
+	path  ColumnPath
+	codec parquet.CompressionCodec
+}
+
+// compressionCodec returns the compression codec for col. A codec set for the column's path
+// takes precedence over the codec in the column parameters, which in turn takes precedence
+// over defaultCodec.
+func (r *schema) compressionCodec(col *Column, defaultCodec parquet.CompressionCodec) parquet.CompressionCodec {
+	for _, cc := range r.columnCodecs {
+		if cc.path.Equal(col.path) {
+			return cc.codec
+		}
+	}
+
+	if col.params != nil && col.params.Codec != nil {
+		return *col.params.Codec
+	}
+
+	return defaultCodec
+}
+
+// dictionaryLimits holds the maximum number of entries and the maximum size in bytes of
+// the dictionary of a column chunk. Zero values mean that the defaults are used.
+type dictionaryLimits struct {
+	maxEntries int
+	maxSize    int64
+}
+
+type columnDictionaryLimits struct {
+	path   ColumnPath
+	limits dictionaryLimits
+}
+
+// addToDictionary adds the values of page to the dictionary of the current column chunk of
+// col, and marks the page as dictionary-encoded if this succeeded.
+func (r *schema) addToDictionary(col *Column, page *dataPage) {
+	if !col.data.useDictionary() || *col.Type() == parquet.Type_BOOLEAN { // never ever use dictionary encoding on booleans.
+		return
+	}
+
+	if col.data.dict == nil {
+		limits := r.dictLimits
+		for _, cl := range r.columnDictLimits {
+			if cl.path.Equal(col.path) {
+				limits = cl.limits
+				break
+			}
+		}
+		col.data.dict = newChunkDictionary(limits.maxEntries, limits.maxSize)
+	}
+
+	page.dictionary = col.data.dict.addPage(col, page)
+}
+
+// flushColumnPage flushes the current data page of col if it is full or force is true, and
+// adds it to the column chunk's dictionary. In streaming mode, the page is then immediately
+// written to the page spill.
+func (r *schema) flushColumnPage(col *Column, force bool) error {
+	numPages := len(col.data.dataPages)
+	if err := col.data.flushPage(r, force); err != nil {
+		return err
+	}
+
+	for _, page := range col.data.dataPages[numPages:] {
+		r.addToDictionary(col, page)
+	}
+
+	if r.pageSpill != nil && len(col.data.dataPages) > 0 {
+		return r.pageSpill.spillPages(r, col)
+	}
+
+	return nil
+}
+
 func (r *schema) recursiveFlushPages(c []*Column) error {
 	for i := range c {
 		if c[i].data != nil {
-			if err := c[i].data.flushPage(r, false); err != nil {
+			if err := r.flushColumnPage(c[i], false); err != nil {
 				return err
 			}
 		}
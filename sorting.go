@@ -0,0 +1,207 @@
+package goparquet
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/fraugster/parquet-go/parquet"
+)
+
+// SortingColumn describes a column by which the rows of a row group are sorted.
+type SortingColumn struct {
+	// Path is the path of the column. It needs to identify a non-repeated leaf column
+	// that is not nested within a repeated group.
+	Path ColumnPath
+	// Descending is true if the values are sorted in descending order, otherwise they
+	// are sorted in ascending order.
+	Descending bool
+	// NullsFirst is true if null values come before all other values, otherwise they
+	// come after all other values.
+	NullsFirst bool
+}
+
+type boundSortingColumn struct {
+	SortingColumn
+	index int
+	order valueOrder
+}
+
+// rowSorter compares rows according to a list of sorting columns.
+type rowSorter struct {
+	columns []boundSortingColumn
+}
+
+func newRowSorter(sch *schema, sortingColumns []SortingColumn) (*rowSorter, error) {
+	s := &rowSorter{}
+
+	for _, sc := range sortingColumns {
+		col := sch.GetColumnByPath(sc.Path)
+		if col == nil || !col.DataColumn() {
+			return nil, fmt.Errorf("sorting column %q not found", sc.Path.flatName())
+		}
+		if col.MaxRepetitionLevel() > 0 {
+			return nil, fmt.Errorf("sorting column %q must not be repeated", sc.Path.flatName())
+		}
+
+		order := newValueOrder(col.Element(), true)
+		if order.kind == compareUndefined {
+			order.kind = compareBytes
+		}
+
+		s.columns = append(s.columns, boundSortingColumn{
+			SortingColumn: sc,
+			index:         col.Index(),
+			order:         order,
+		})
+	}
+
+	return s, nil
+}
+
+// parquetSortingColumns returns the sorting columns as they are stored in the row group meta data.
+func (s *rowSorter) parquetSortingColumns() []*parquet.SortingColumn {
+	cols := make([]*parquet.SortingColumn, 0, len(s.columns))
+	for _, sc := range s.columns {
+		cols = append(cols, &parquet.SortingColumn{
+			ColumnIdx:  int32(sc.index),
+			Descending: sc.Descending,
+			NullsFirst: sc.NullsFirst,
+		})
+	}
+	return cols
+}
+
+// sortKey extracts the values of the sorting columns from row. Null values are represented as nil.
+func (s *rowSorter) sortKey(row map[string]interface{}) []interface{} {
+	key := make([]interface{}, len(s.columns))
+	for i, sc := range s.columns {
+		if v, ok := sc.order.fromStored(rowValueByPath(row, sc.Path)); ok {
+			key[i] = v
+		}
+	}
+	return key
+}
+
+// compare returns a negative number if the sort key a sorts before b, a positive number if a
+// sorts after b, and 0 if both are equal.
+func (s *rowSorter) compare(a, b []interface{}) int {
+	for i, sc := range s.columns {
+		x, y := a[i], b[i]
+
+		var c int
+		switch {
+		case x == nil && y == nil:
+			continue
+		case x == nil:
+			c = 1
+			if sc.NullsFirst {
+				c = -1
+			}
+			return c
+		case y == nil:
+			c = -1
+			if sc.NullsFirst {
+				c = 1
+			}
+			return c
+		}
+
+		c = sc.order.compare(x, y)
+		if sc.Descending {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+
+	return 0
+}
+
+// sort sorts rows according to the sorting columns. The relative order of equal rows is kept.
+func (s *rowSorter) sort(rows []map[string]interface{}) {
+	keys := make([][]interface{}, len(rows))
+	for i := range rows {
+		keys[i] = s.sortKey(rows[i])
+	}
+
+	sort.Stable(&sortableRows{rows: rows, keys: keys, sorter: s})
+}
+
+type sortableRows struct {
+	rows   []map[string]interface{}
+	keys   [][]interface{}
+	sorter *rowSorter
+}
+
+func (r *sortableRows) Len() int {
+	return len(r.rows)
+}
+
+func (r *sortableRows) Less(i, j int) bool {
+	return r.sorter.compare(r.keys[i], r.keys[j]) < 0
+}
+
+func (r *sortableRows) Swap(i, j int) {
+	r.rows[i], r.rows[j] = r.rows[j], r.rows[i]
+	r.keys[i], r.keys[j] = r.keys[j], r.keys[i]
+}
+
+// rowValueByPath returns the value identified by path in a row, or nil if it doesn't exist.
+func rowValueByPath(row map[string]interface{}, path ColumnPath) interface{} {
+	var v interface{} = row
+	for _, name := range path {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[name]
+	}
+	return v
+}
+
+// estimateSize returns a rough estimation of the size of a value of a row, which is used as
+// the size of rows that are buffered to sort them before they are encoded.
+func estimateSize(v interface{}) int64 {
+	switch x := v.(type) {
+	case nil:
+		return 0
+	case bool:
+		return 1
+	case int32, float32:
+		return 4
+	case []byte:
+		return int64(len(x))
+	case string:
+		return int64(len(x))
+	case map[string]interface{}:
+		var size int64
+		for _, v := range x {
+			size += estimateSize(v)
+		}
+		return size
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice:
+		switch rv.Type().Elem().Kind() {
+		case reflect.Slice, reflect.Map, reflect.Interface, reflect.String:
+			var size int64
+			for i := 0; i < rv.Len(); i++ {
+				size += estimateSize(rv.Index(i).Interface())
+			}
+			return size
+		}
+		return int64(rv.Len()) * int64(rv.Type().Elem().Size())
+	case reflect.Map:
+		var size int64
+		for iter := rv.MapRange(); iter.Next(); {
+			size += estimateSize(iter.Value().Interface())
+		}
+		return size
+	}
+
+	return int64(rv.Type().Size())
+}
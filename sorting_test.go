@@ -0,0 +1,198 @@
+package goparquet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortingColumnsValidation(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 id;
+		optional binary name (STRING);
+	}`)
+	require.NoError(t, err)
+
+	fw := NewFileWriter(&bytes.Buffer{},
+		WithSchemaDefinition(sd),
+		WithSortingColumns(SortingColumn{Path: ColumnPath{"id"}}),
+	)
+
+	require.NoError(t, fw.AddData(map[string]interface{}{"id": int64(1)}))
+	require.NoError(t, fw.AddData(map[string]interface{}{"id": int64(1)}))
+	require.NoError(t, fw.AddData(map[string]interface{}{"id": int64(5)}))
+	require.Error(t, fw.AddData(map[string]interface{}{"id": int64(3)}))
+	require.Equal(t, int64(3), fw.schemaWriter.rowGroupNumRecords())
+
+	// the sort order only applies within a row group.
+	require.NoError(t, fw.FlushRowGroup())
+	require.NoError(t, fw.AddData(map[string]interface{}{"id": int64(3)}))
+	require.NoError(t, fw.Close())
+}
+
+func TestSortingColumnsInvalid(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 id;
+		repeated binary tags (STRING);
+	}`)
+	require.NoError(t, err)
+
+	for _, path := range []ColumnPath{{"does_not_exist"}, {"tags"}} {
+		fw := NewFileWriter(&bytes.Buffer{},
+			WithSchemaDefinition(sd),
+			WithSortingColumns(SortingColumn{Path: path}),
+		)
+		require.Error(t, fw.AddData(map[string]interface{}{"id": int64(1)}), "path %s", path.flatName())
+	}
+}
+
+func TestRowSorting(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 id;
+		optional binary name (STRING);
+		required int32 num (INT(32, false));
+	}`)
+	require.NoError(t, err)
+
+	rows := []map[string]interface{}{
+		{"id": int64(1), "name": []byte("b"), "num": int32(1)},
+		{"id": int64(2), "num": int32(-1)},
+		{"id": int64(3), "name": []byte("a"), "num": int32(2)},
+		{"id": int64(4), "name": []byte("b"), "num": int32(0)},
+		{"id": int64(5), "num": int32(3)},
+		{"id": int64(6), "name": []byte("c"), "num": int32(3)},
+	}
+
+	tests := []struct {
+		name     string
+		columns  []SortingColumn
+		expected []int64
+	}{
+		{
+			name:     "ascending nulls last",
+			columns:  []SortingColumn{{Path: ColumnPath{"name"}}},
+			expected: []int64{3, 1, 4, 6, 2, 5},
+		},
+		{
+			name:     "descending nulls first",
+			columns:  []SortingColumn{{Path: ColumnPath{"name"}, Descending: true, NullsFirst: true}},
+			expected: []int64{2, 5, 6, 1, 4, 3},
+		},
+		{
+			name: "multiple columns",
+			columns: []SortingColumn{
+				{Path: ColumnPath{"name"}, NullsFirst: true},
+				{Path: ColumnPath{"num"}, Descending: true},
+			},
+			expected: []int64{2, 5, 3, 1, 4, 6},
+		},
+		{
+			name:     "unsigned",
+			columns:  []SortingColumn{{Path: ColumnPath{"num"}}},
+			expected: []int64{4, 1, 3, 5, 6, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			fw := NewFileWriter(buf,
+				WithSchemaDefinition(sd),
+				WithSortingColumns(tt.columns...),
+				WithRowSorting(true),
+			)
+
+			for i := 0; i < 2; i++ {
+				for _, row := range rows {
+					require.NoError(t, fw.AddData(row))
+				}
+				require.NoError(t, fw.FlushRowGroup())
+			}
+			require.NoError(t, fw.Close())
+
+			r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+			require.NoError(t, err)
+			require.Equal(t, 2, r.RowGroupCount())
+
+			var expectedSortingColumns []*parquet.SortingColumn
+			for _, sc := range tt.columns {
+				expectedSortingColumns = append(expectedSortingColumns, &parquet.SortingColumn{
+					ColumnIdx:  int32(r.GetColumnByPath(sc.Path).Index()),
+					Descending: sc.Descending,
+					NullsFirst: sc.NullsFirst,
+				})
+			}
+
+			var ids []int64
+			for rg := 0; rg < r.RowGroupCount(); rg++ {
+				require.Equal(t, expectedSortingColumns, r.meta.RowGroups[rg].SortingColumns)
+				require.Equal(t, int64(len(rows)), r.meta.RowGroups[rg].NumRows)
+			}
+			for {
+				row, err := r.NextRow()
+				if err != nil {
+					break
+				}
+				ids = append(ids, row["id"].(int64))
+			}
+			require.Equal(t, append(tt.expected, tt.expected...), ids)
+		})
+	}
+}
+
+func TestRowSortingBuffersRows(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 id;
+		required binary name (STRING);
+	}`)
+	require.NoError(t, err)
+
+	sink := &memReadWriteSeeker{}
+	buf := &bytes.Buffer{}
+	fw := NewFileWriter(buf,
+		WithSchemaDefinition(sd),
+		WithSortingColumns(SortingColumn{Path: ColumnPath{"id"}}),
+		WithRowSorting(true),
+		WithPageSpill(sink),
+		WithMaxPageSize(256),
+		WithMaxRowGroupSize(4096),
+	)
+
+	const numRows = 1000
+	for i := 0; i < numRows; i++ {
+		id := int64((i * 7919) % numRows)
+		require.NoError(t, fw.AddData(map[string]interface{}{"id": id, "name": []byte("name")}))
+
+		// the rows are only encoded once the row group is flushed.
+		require.Zero(t, fw.schemaWriter.DataSize())
+		if len(fw.bufferedRows) > 0 {
+			require.True(t, fw.CurrentRowGroupSize() > 0)
+		}
+	}
+	require.NoError(t, fw.Close())
+	require.NotEmpty(t, sink.data)
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.True(t, r.RowGroupCount() > 1)
+
+	var (
+		numRead int
+		prevID  int64 = -1
+	)
+	for rg := 0; rg < r.RowGroupCount(); rg++ {
+		for i := int64(0); i < r.meta.RowGroups[rg].NumRows; i++ {
+			row, err := r.NextRow()
+			require.NoError(t, err)
+			id := row["id"].(int64)
+			require.True(t, id > prevID, "row %d is out of order", numRead)
+			prevID = id
+			numRead++
+		}
+		prevID = -1
+	}
+	require.Equal(t, numRows, numRead)
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/binary"
 	"math"
+
+	"github.com/fraugster/parquet-go/parquet"
 )
 
 type nilStats struct{}
@@ -19,9 +21,18 @@ func (s *nilStats) maxValue() []byte {
 func (s *nilStats) reset() {
 }
 
+// statistics keeps track of the minimum and maximum of byte array values. The values
+// are compared using compare, which implements the type defined order of the column.
+// If compare is nil, the sort order of the column is undefined and no statistics are
+// collected.
 type statistics struct {
-	min []byte
-	max []byte
+	min     []byte
+	max     []byte
+	compare func(a, b []byte) int
+}
+
+func newStatistics(compare func(a, b []byte) int) statistics {
+	return statistics{compare: compare}
 }
 
 func (s *statistics) minValue() []byte {
@@ -37,23 +48,28 @@ func (s *statistics) reset() {
 }
 
 func (s *statistics) setMinMax(j []byte) {
+	if s.compare == nil {
+		return
+	}
+
 	if s.max == nil || s.min == nil {
-		s.min = j
-		s.max = j
+		s.min = append([]byte{}, j...)
+		s.max = s.min
 		return
 	}
 
-	if bytes.Compare(j, s.min) < 0 {
-		s.min = j
+	if s.compare(j, s.min) < 0 {
+		s.min = append([]byte{}, j...)
 	}
-	if bytes.Compare(j, s.max) > 0 {
-		s.max = j
+	if s.compare(j, s.max) > 0 {
+		s.max = append([]byte{}, j...)
 	}
 }
 
 type floatStats struct {
-	min float32
-	max float32
+	min   float32
+	max   float32
+	valid bool
 }
 
 func newFloatStats() *floatStats {
@@ -63,29 +79,46 @@ func newFloatStats() *floatStats {
 }
 
 func (s *floatStats) reset() {
-	s.min = math.MaxFloat32
-	s.max = -math.MaxFloat32
+	s.min, s.max, s.valid = 0, 0, false
 }
 
 func (s *floatStats) minValue() []byte {
-	if s.min == math.MaxFloat32 {
+	if !s.valid {
 		return nil
 	}
+	min := s.min
+	if min == 0 {
+		// a minimum of zero is always written as -0.0.
+		min = float32(math.Copysign(0, -1))
+	}
 	ret := make([]byte, 4)
-	binary.LittleEndian.PutUint32(ret, math.Float32bits(s.min))
+	binary.LittleEndian.PutUint32(ret, math.Float32bits(min))
 	return ret
 }
 
 func (s *floatStats) maxValue() []byte {
-	if s.max == -math.MaxFloat32 {
+	if !s.valid {
 		return nil
 	}
+	max := s.max
+	if max == 0 {
+		// a maximum of zero is always written as +0.0.
+		max = 0
+	}
 	ret := make([]byte, 4)
-	binary.LittleEndian.PutUint32(ret, math.Float32bits(s.max))
+	binary.LittleEndian.PutUint32(ret, math.Float32bits(max))
 	return ret
 }
 
 func (s *floatStats) setMinMax(j float32) {
+	// NaN values are not ordered, so they are excluded from the statistics.
+	if math.IsNaN(float64(j)) {
+		return
+	}
+	if !s.valid {
+		s.min, s.max, s.valid = j, j, true
+		return
+	}
 	if j < s.min {
 		s.min = j
 	}
@@ -95,8 +128,9 @@ func (s *floatStats) setMinMax(j float32) {
 }
 
 type doubleStats struct {
-	min float64
-	max float64
+	min   float64
+	max   float64
+	valid bool
 }
 
 func newDoubleStats() *doubleStats {
@@ -106,29 +140,46 @@ func newDoubleStats() *doubleStats {
 }
 
 func (s *doubleStats) reset() {
-	s.min = math.MaxFloat64
-	s.max = -math.MaxFloat64
+	s.min, s.max, s.valid = 0, 0, false
 }
 
 func (s *doubleStats) minValue() []byte {
-	if s.min == math.MaxFloat64 {
+	if !s.valid {
 		return nil
 	}
+	min := s.min
+	if min == 0 {
+		// a minimum of zero is always written as -0.0.
+		min = math.Copysign(0, -1)
+	}
 	ret := make([]byte, 8)
-	binary.LittleEndian.PutUint64(ret, math.Float64bits(s.min))
+	binary.LittleEndian.PutUint64(ret, math.Float64bits(min))
 	return ret
 }
 
 func (s *doubleStats) maxValue() []byte {
-	if s.max == -math.MaxFloat64 {
+	if !s.valid {
 		return nil
 	}
+	max := s.max
+	if max == 0 {
+		// a maximum of zero is always written as +0.0.
+		max = 0
+	}
 	ret := make([]byte, 8)
-	binary.LittleEndian.PutUint64(ret, math.Float64bits(s.max))
+	binary.LittleEndian.PutUint64(ret, math.Float64bits(max))
 	return ret
 }
 
 func (s *doubleStats) setMinMax(j float64) {
+	// NaN values are not ordered, so they are excluded from the statistics.
+	if math.IsNaN(j) {
+		return
+	}
+	if !s.valid {
+		s.min, s.max, s.valid = j, j, true
+		return
+	}
 	if j < s.min {
 		s.min = j
 	}
@@ -137,24 +188,27 @@ func (s *doubleStats) setMinMax(j float64) {
 	}
 }
 
+// int32Stats keeps track of the minimum and maximum of int32 values. If unsigned is
+// true, the values are compared as unsigned integers.
 type int32Stats struct {
-	min int32
-	max int32
+	min      int32
+	max      int32
+	valid    bool
+	unsigned bool
 }
 
-func newInt32Stats() *int32Stats {
-	s := &int32Stats{}
+func newInt32Stats(unsigned bool) *int32Stats {
+	s := &int32Stats{unsigned: unsigned}
 	s.reset()
 	return s
 }
 
 func (s *int32Stats) reset() {
-	s.min = math.MaxInt32
-	s.max = math.MinInt32
+	s.min, s.max, s.valid = 0, 0, false
 }
 
 func (s *int32Stats) minValue() []byte {
-	if s.min == math.MaxInt32 {
+	if !s.valid {
 		return nil
 	}
 	ret := make([]byte, 4)
@@ -163,7 +217,7 @@ func (s *int32Stats) minValue() []byte {
 }
 
 func (s *int32Stats) maxValue() []byte {
-	if s.max == math.MinInt32 {
+	if !s.valid {
 		return nil
 	}
 	ret := make([]byte, 4)
@@ -171,33 +225,47 @@ func (s *int32Stats) maxValue() []byte {
 	return ret
 }
 
+func (s *int32Stats) less(a, b int32) bool {
+	if s.unsigned {
+		return uint32(a) < uint32(b)
+	}
+	return a < b
+}
+
 func (s *int32Stats) setMinMax(j int32) {
-	if j < s.min {
+	if !s.valid {
+		s.min, s.max, s.valid = j, j, true
+		return
+	}
+	if s.less(j, s.min) {
 		s.min = j
 	}
-	if j > s.max {
+	if s.less(s.max, j) {
 		s.max = j
 	}
 }
 
+// int64Stats keeps track of the minimum and maximum of int64 values. If unsigned is
+// true, the values are compared as unsigned integers.
 type int64Stats struct {
-	min int64
-	max int64
+	min      int64
+	max      int64
+	valid    bool
+	unsigned bool
 }
 
-func newInt64Stats() *int64Stats {
-	s := &int64Stats{}
+func newInt64Stats(unsigned bool) *int64Stats {
+	s := &int64Stats{unsigned: unsigned}
 	s.reset()
 	return s
 }
 
 func (s *int64Stats) reset() {
-	s.min = math.MaxInt64
-	s.max = math.MinInt64
+	s.min, s.max, s.valid = 0, 0, false
 }
 
 func (s *int64Stats) minValue() []byte {
-	if s.min == math.MaxInt64 {
+	if !s.valid {
 		return nil
 	}
 	ret := make([]byte, 8)
@@ -206,7 +274,7 @@ func (s *int64Stats) minValue() []byte {
 }
 
 func (s *int64Stats) maxValue() []byte {
-	if s.min == math.MinInt64 {
+	if !s.valid {
 		return nil
 	}
 	ret := make([]byte, 8)
@@ -214,11 +282,81 @@ func (s *int64Stats) maxValue() []byte {
 	return ret
 }
 
+func (s *int64Stats) less(a, b int64) bool {
+	if s.unsigned {
+		return uint64(a) < uint64(b)
+	}
+	return a < b
+}
+
 func (s *int64Stats) setMinMax(j int64) {
-	if j < s.min {
+	if !s.valid {
+		s.min, s.max, s.valid = j, j, true
+		return
+	}
+	if s.less(j, s.min) {
 		s.min = j
 	}
-	if j > s.max {
+	if s.less(s.max, j) {
 		s.max = j
 	}
 }
+
+// statsOrder returns the type defined order of a column of physical type typ with
+// the logical and converted type provided in params.
+func statsOrder(typ parquet.Type, params *ColumnParameters) valueOrder {
+	elem := &parquet.SchemaElement{Type: &typ}
+	if params != nil {
+		elem.LogicalType = params.LogicalType
+		elem.ConvertedType = params.ConvertedType
+		elem.TypeLength = params.TypeLength
+	}
+	return newValueOrder(elem, true)
+}
+
+func isUnsignedParams(typ parquet.Type, params *ColumnParameters) bool {
+	return statsOrder(typ, params).kind == compareUnsigned
+}
+
+// byteArrayCompareFunc returns the function that implements the type defined order
+// for byte array values, or nil if the sort order is undefined.
+func byteArrayCompareFunc(typ parquet.Type, params *ColumnParameters) func(a, b []byte) int {
+	switch statsOrder(typ, params).kind {
+	case compareBytes:
+		return bytes.Compare
+	case compareDecimalBytes:
+		return compareDecimal
+	}
+	return nil
+}
+
+// compareInt96 compares two INT96 timestamps chronologically. The first 8 bytes hold the
+// nanoseconds within the day, the last 4 bytes hold the Julian day number.
+func compareInt96(a, b []byte) int {
+	if c := compareInt64(int64(int32(binary.LittleEndian.Uint32(a[8:]))), int64(int32(binary.LittleEndian.Uint32(b[8:])))); c != 0 {
+		return c
+	}
+	return compareInt64(int64(binary.LittleEndian.Uint64(a[:8])), int64(binary.LittleEndian.Uint64(b[:8])))
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareFloat64(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
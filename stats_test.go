@@ -0,0 +1,184 @@
+package goparquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInt32StatsUnsigned(t *testing.T) {
+	signed, unsigned := newInt32Stats(false), newInt32Stats(true)
+	for _, v := range []int32{5, -1, 0, math.MaxInt32} {
+		signed.setMinMax(v)
+		unsigned.setMinMax(v)
+	}
+
+	require.Equal(t, int32(-1), int32(binary.LittleEndian.Uint32(signed.minValue())))
+	require.Equal(t, int32(math.MaxInt32), int32(binary.LittleEndian.Uint32(signed.maxValue())))
+	require.Equal(t, uint32(0), binary.LittleEndian.Uint32(unsigned.minValue()))
+	require.Equal(t, uint32(math.MaxUint32), binary.LittleEndian.Uint32(unsigned.maxValue()))
+
+	signed.reset()
+	require.Nil(t, signed.minValue())
+	require.Nil(t, signed.maxValue())
+
+	// values that were used as sentinels previously must still be recorded.
+	signed.setMinMax(math.MaxInt32)
+	require.Equal(t, int32(math.MaxInt32), int32(binary.LittleEndian.Uint32(signed.minValue())))
+}
+
+func TestInt64StatsUnsigned(t *testing.T) {
+	s := newInt64Stats(true)
+	for _, v := range []int64{-1, 7, 3} {
+		s.setMinMax(v)
+	}
+	require.Equal(t, uint64(3), binary.LittleEndian.Uint64(s.minValue()))
+	require.Equal(t, uint64(math.MaxUint64), binary.LittleEndian.Uint64(s.maxValue()))
+
+	s = newInt64Stats(false)
+	s.setMinMax(math.MinInt64)
+	require.Equal(t, int64(math.MinInt64), int64(binary.LittleEndian.Uint64(s.maxValue())))
+}
+
+func TestFloatStats(t *testing.T) {
+	d := newDoubleStats()
+	d.setMinMax(math.NaN())
+	require.Nil(t, d.minValue())
+	require.Nil(t, d.maxValue())
+
+	d.setMinMax(0)
+	d.setMinMax(math.NaN())
+	require.Equal(t, math.Float64bits(math.Copysign(0, -1)), binary.LittleEndian.Uint64(d.minValue()))
+	require.Equal(t, math.Float64bits(0), binary.LittleEndian.Uint64(d.maxValue()))
+
+	d.setMinMax(-2.5)
+	d.setMinMax(math.Inf(1))
+	require.Equal(t, -2.5, math.Float64frombits(binary.LittleEndian.Uint64(d.minValue())))
+	require.Equal(t, math.Inf(1), math.Float64frombits(binary.LittleEndian.Uint64(d.maxValue())))
+
+	f := newFloatStats()
+	f.setMinMax(float32(math.NaN()))
+	require.Nil(t, f.minValue())
+	f.setMinMax(float32(math.Copysign(0, -1)))
+	require.Equal(t, math.Float32bits(float32(math.Copysign(0, -1))), binary.LittleEndian.Uint32(f.minValue()))
+	require.Equal(t, math.Float32bits(0), binary.LittleEndian.Uint32(f.maxValue()))
+}
+
+func TestByteArrayStats(t *testing.T) {
+	s := newStatistics(byteArrayCompareFunc(parquet.Type_BYTE_ARRAY, &ColumnParameters{}))
+	for _, v := range []string{"b", "\xff", "a", "ab"} {
+		s.setMinMax([]byte(v))
+	}
+	require.Equal(t, []byte("a"), s.minValue())
+	require.Equal(t, []byte("\xff"), s.maxValue())
+
+	decimal := &ColumnParameters{ConvertedType: parquet.ConvertedTypePtr(parquet.ConvertedType_DECIMAL)}
+	s = newStatistics(byteArrayCompareFunc(parquet.Type_BYTE_ARRAY, decimal))
+	for _, v := range [][]byte{{0x01}, {0xff, 0x00}, {0x7f}, {0x00, 0x80}} {
+		s.setMinMax(v)
+	}
+	require.Equal(t, []byte{0xff, 0x00}, s.minValue()) // -256
+	require.Equal(t, []byte{0x00, 0x80}, s.maxValue()) // 128
+
+	typeLength := int32(12)
+	interval := &ColumnParameters{
+		ConvertedType: parquet.ConvertedTypePtr(parquet.ConvertedType_INTERVAL),
+		TypeLength:    &typeLength,
+	}
+	s = newStatistics(byteArrayCompareFunc(parquet.Type_FIXED_LEN_BYTE_ARRAY, interval))
+	s.setMinMax(make([]byte, 12))
+	require.Nil(t, s.minValue())
+	require.Nil(t, s.maxValue())
+}
+
+func TestByteArrayStatsCopiesValues(t *testing.T) {
+	s := newStatistics(bytes.Compare)
+	buf := []byte("m")
+	s.setMinMax(buf)
+	buf[0] = 'z'
+	require.Equal(t, []byte("m"), s.minValue())
+	require.Equal(t, []byte("m"), s.maxValue())
+}
+
+func TestInt96Stats(t *testing.T) {
+	s := newStatistics(compareInt96)
+
+	older := TimeToInt96(time.Date(2020, 1, 1, 23, 0, 0, 0, time.UTC))
+	newer := TimeToInt96(time.Date(2020, 1, 2, 1, 0, 0, 0, time.UTC))
+	s.setMinMax(newer[:])
+	s.setMinMax(older[:])
+
+	require.Equal(t, older[:], s.minValue())
+	require.Equal(t, newer[:], s.maxValue())
+}
+
+func TestWriteColumnOrdersAndStatistics(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int32 u (INT(32, false));
+		required int64 u64 (INT(64, false));
+		optional binary s (STRING);
+		required fixed_len_byte_array(2) d (DECIMAL(4, 2));
+		required double f;
+	}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	fw := NewFileWriter(buf, WithSchemaDefinition(sd))
+
+	rows := []map[string]interface{}{
+		{"u": int32(-1), "u64": int64(1), "s": []byte("foo"), "d": []byte{0xff, 0xfe}, "f": math.NaN()},
+		{"u": int32(2), "u64": int64(-5), "d": []byte{0x00, 0x10}, "f": 1.5},
+		{"u": int32(1), "u64": int64(3), "s": []byte("bar"), "d": []byte{0x80, 0x00}, "f": -3.0},
+	}
+	for _, row := range rows {
+		require.NoError(t, fw.AddData(row))
+	}
+	require.NoError(t, fw.Close())
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	require.Len(t, r.meta.ColumnOrders, 5)
+	for _, order := range r.meta.ColumnOrders {
+		require.True(t, order.IsSetTYPE_ORDER())
+	}
+
+	columns := r.meta.RowGroups[0].Columns
+	stats := func(idx int) (min, max []byte) {
+		s := columns[idx].MetaData.Statistics
+		require.Nil(t, s.Min)
+		require.Nil(t, s.Max)
+		return s.MinValue, s.MaxValue
+	}
+
+	min, max := stats(0)
+	require.Equal(t, uint32(1), binary.LittleEndian.Uint32(min))
+	require.Equal(t, uint32(math.MaxUint32), binary.LittleEndian.Uint32(max))
+
+	min, max = stats(1)
+	require.Equal(t, uint64(1), binary.LittleEndian.Uint64(min))
+	require.Equal(t, uint64(math.MaxUint64-4), binary.LittleEndian.Uint64(max))
+
+	min, max = stats(2)
+	require.Equal(t, []byte("bar"), min)
+	require.Equal(t, []byte("foo"), max)
+
+	min, max = stats(3)
+	require.Equal(t, []byte{0x80, 0x00}, min)
+	require.Equal(t, []byte{0x00, 0x10}, max)
+
+	min, max = stats(4)
+	require.Equal(t, -3.0, math.Float64frombits(binary.LittleEndian.Uint64(min)))
+	require.Equal(t, 1.5, math.Float64frombits(binary.LittleEndian.Uint64(max)))
+
+	columnIndex, err := r.ColumnIndex(0, ColumnPath{"s"})
+	require.NoError(t, err)
+	require.NotNil(t, columnIndex)
+	require.Equal(t, [][]byte{[]byte("bar")}, columnIndex.MinValues)
+}
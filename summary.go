@@ -0,0 +1,208 @@
+package goparquet
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+
+	"github.com/fraugster/parquet-go/parquet"
+)
+
+const (
+	// SummaryMetaDataFile is the name of the summary file that contains the meta data of all
+	// row groups of a dataset.
+	SummaryMetaDataFile = "_metadata"
+
+	// SummaryCommonMetaDataFile is the name of the summary file that only contains the schema
+	// and the key-value meta data of a dataset.
+	SummaryCommonMetaDataFile = "_common_metadata"
+)
+
+// SummaryWriter collects the meta data of the files of a dataset to write the summary files
+// _metadata and _common_metadata, which allow query engines such as Spark and Dask to plan
+// queries without reading the footers of all files.
+//
+// The _metadata file contains the row groups of all files, with the FilePath of each column
+// chunk set to the path of its file, relative to the directory of the summary file. All other
+// offsets keep referring to positions within that file. Such a file can be read using a
+// FileReader configured with WithFileOpener.
+type SummaryWriter struct {
+	meta      *parquet.FileMetaData
+	schemaDef string
+}
+
+// NewSummaryWriter creates a new, empty SummaryWriter.
+func NewSummaryWriter() *SummaryWriter {
+	return &SummaryWriter{}
+}
+
+// AddFile adds the row groups of the file at the slash-separated path, relative to the
+// directory of the summary files, whose meta data is meta. All files need to have the same
+// schema; the key-value meta data and the creator of the summary files are taken from the
+// first file. The column orders are only kept if all files declare the same ones, as the
+// statistics of the column chunks are taken over from them. Files that are summary files
+// themselves can be added as well, in which case the paths of their column chunks are
+// resolved relative to path.
+func (sw *SummaryWriter) AddFile(filePath string, meta *parquet.FileMetaData) error {
+	if meta.EncryptionAlgorithm != nil {
+		return fmt.Errorf("file %q is encrypted, which is not supported in summary files", filePath)
+	}
+
+	sch, err := makeSchema(meta, false, nil)
+	if err != nil {
+		return fmt.Errorf("creating schema of %q failed: %w", filePath, err)
+	}
+	schemaDef := sch.GetSchemaDefinition().String()
+
+	if sw.meta == nil {
+		sw.meta = &parquet.FileMetaData{
+			Version:          meta.Version,
+			Schema:           meta.Schema,
+			KeyValueMetadata: meta.KeyValueMetadata,
+			CreatedBy:        meta.CreatedBy,
+			ColumnOrders:     meta.ColumnOrders,
+			RowGroups:        []*parquet.RowGroup{},
+		}
+		sw.schemaDef = schemaDef
+	} else if schemaDef != sw.schemaDef {
+		return fmt.Errorf("schema of %q differs from the schema of the other files", filePath)
+	} else if !equalColumnOrders(sw.meta.ColumnOrders, meta.ColumnOrders) {
+		sw.meta.ColumnOrders = nil
+	}
+
+	for _, rg := range meta.RowGroups {
+		newRG := *rg
+		newRG.Columns = make([]*parquet.ColumnChunk, 0, len(rg.Columns))
+		for _, chunk := range rg.Columns {
+			newChunk := *chunk
+			p := filePath
+			if chunk.FilePath != nil {
+				p = path.Join(path.Dir(filePath), *chunk.FilePath)
+			}
+			newChunk.FilePath = &p
+			newRG.Columns = append(newRG.Columns, &newChunk)
+		}
+		sw.meta.RowGroups = append(sw.meta.RowGroups, &newRG)
+	}
+	sw.meta.NumRows += meta.NumRows
+
+	return nil
+}
+
+// equalColumnOrders returns true if a and b declare the same column orders.
+func equalColumnOrders(a, b []*parquet.ColumnOrder) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].IsSetTYPE_ORDER() != b[i].IsSetTYPE_ORDER() {
+			return false
+		}
+	}
+	return true
+}
+
+// AddFileFromReader reads the meta data of the file at the slash-separated path from r, and
+// adds it like AddFile.
+func (sw *SummaryWriter) AddFileFromReader(filePath string, r io.ReadSeeker) error {
+	meta, err := ReadFileMetaData(r, true)
+	if err != nil {
+		return fmt.Errorf("reading file meta data of %q failed: %w", filePath, err)
+	}
+
+	return sw.AddFile(filePath, meta)
+}
+
+// WriteMetaData writes the _metadata summary file, which contains the row groups of all files.
+func (sw *SummaryWriter) WriteMetaData(w io.Writer) error {
+	return sw.write(w, true)
+}
+
+// WriteCommonMetaData writes the _common_metadata summary file, which only contains the
+// schema and the key-value meta data.
+func (sw *SummaryWriter) WriteCommonMetaData(w io.Writer) error {
+	return sw.write(w, false)
+}
+
+func (sw *SummaryWriter) write(w io.Writer, rowGroups bool) error {
+	if sw.meta == nil {
+		return errors.New("no files have been added")
+	}
+
+	meta := *sw.meta
+	if !rowGroups {
+		meta.RowGroups = []*parquet.RowGroup{}
+		meta.NumRows = 0
+	}
+
+	buf := &bytes.Buffer{}
+	if err := writeThrift(context.Background(), &meta, buf); err != nil {
+		return err
+	}
+
+	if err := writeFull(w, magic); err != nil {
+		return err
+	}
+
+	if err := writeFull(w, buf.Bytes()); err != nil {
+		return err
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, int32(buf.Len())); err != nil {
+		return err
+	}
+
+	return writeFull(w, magic)
+}
+
+// WriteDatasetSummaryFiles writes the summary files _metadata and _common_metadata for the
+// dataset in fsys, using create to create them in the root directory of the dataset. The files
+// of the dataset are discovered like NewDataset does, i.e. existing summary files are ignored.
+func WriteDatasetSummaryFiles(fsys fs.FS, create FileCreator) error {
+	files, _, err := discoverDatasetFiles(fsys)
+	if err != nil {
+		return err
+	}
+
+	sw := NewSummaryWriter()
+	for _, file := range files {
+		if err := addSummaryFile(sw, fsys, file.path); err != nil {
+			return err
+		}
+	}
+
+	if err := writeSummaryFile(create, SummaryMetaDataFile, sw.WriteMetaData); err != nil {
+		return err
+	}
+
+	return writeSummaryFile(create, SummaryCommonMetaDataFile, sw.WriteCommonMetaData)
+}
+
+func addSummaryFile(sw *SummaryWriter, fsys fs.FS, name string) error {
+	r, err := NewFSFileOpener(fsys)(name)
+	if err != nil {
+		return err
+	}
+	defer r.(io.Closer).Close()
+
+	return sw.AddFileFromReader(name, r)
+}
+
+func writeSummaryFile(create FileCreator, name string, write func(io.Writer) error) error {
+	f, err := create(name)
+	if err != nil {
+		return fmt.Errorf("creating %q failed: %w", name, err)
+	}
+
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("writing %q failed: %w", name, err)
+	}
+
+	return f.Close()
+}
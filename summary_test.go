@@ -0,0 +1,166 @@
+package goparquet
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+	"testing/fstest"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummaryFiles(t *testing.T) {
+	const schemaText = `message test { required int64 id; optional double score; }`
+
+	fsys := fstest.MapFS{
+		"date=2024-01-01/part-0.parquet": writeDatasetTestFile(t, schemaText, 0, 20),
+		"date=2024-01-02/part-0.parquet": writeDatasetTestFile(t, schemaText, 20, 25),
+		"date=2024-01-02/part-1.parquet": writeDatasetTestFile(t, schemaText, 25, 45),
+	}
+
+	require.NoError(t, WriteDatasetSummaryFiles(fsys, mapFSFileCreator(fsys)))
+	require.Contains(t, fsys, SummaryMetaDataFile)
+	require.Contains(t, fsys, SummaryCommonMetaDataFile)
+
+	meta, err := ReadFileMetaData(bytes.NewReader(fsys[SummaryMetaDataFile].Data), true)
+	require.NoError(t, err)
+	require.Equal(t, int64(45), meta.NumRows)
+	require.Len(t, meta.RowGroups, 5)
+	require.Equal(t, "date=2024-01-02/part-0.parquet", meta.RowGroups[2].Columns[0].GetFilePath())
+
+	common, err := ReadFileMetaData(bytes.NewReader(fsys[SummaryCommonMetaDataFile].Data), true)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), common.NumRows)
+	require.Len(t, common.RowGroups, 0)
+	require.Equal(t, meta.Schema, common.Schema)
+
+	r, err := NewFileReader(bytes.NewReader(fsys[SummaryMetaDataFile].Data))
+	require.NoError(t, err)
+	_, err = r.NextRow()
+	require.Error(t, err)
+
+	r, err = NewFileReaderWithOptions(bytes.NewReader(fsys[SummaryMetaDataFile].Data), WithFileOpener(NewFSFileOpener(fsys)))
+	require.NoError(t, err)
+	require.Equal(t, int64(45), r.NumRows())
+
+	for i := 0; i < 45; i++ {
+		row, err := r.NextRow()
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{"id": int64(i), "score": float64(i) / 2}, row)
+	}
+	_, err = r.NextRow()
+	require.Equal(t, io.EOF, err)
+
+	pages, err := r.ColumnChunkPages(3, ColumnPath{"id"})
+	require.NoError(t, err)
+	require.NotEmpty(t, pages)
+	require.NoError(t, r.Close())
+
+	// the summary files are ignored when reading the dataset.
+	d, err := NewDataset(fsys)
+	require.NoError(t, err)
+	require.Len(t, d.Files(), 3)
+	require.Equal(t, int64(45), d.NumRows())
+	require.NoError(t, d.Close())
+
+	// a summary file of a subdirectory can be added to the summary of its parent directory.
+	sw := NewSummaryWriter()
+	require.NoError(t, sw.AddFileFromReader("date=2024-01-01/part-0.parquet", bytes.NewReader(fsys["date=2024-01-01/part-0.parquet"].Data)))
+	require.NoError(t, sw.AddFileFromReader("sub/"+SummaryMetaDataFile, bytes.NewReader(fsys[SummaryMetaDataFile].Data)))
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, sw.WriteMetaData(buf))
+	meta, err = ReadFileMetaData(bytes.NewReader(buf.Bytes()), true)
+	require.NoError(t, err)
+	require.Equal(t, int64(65), meta.NumRows)
+	require.Equal(t, "date=2024-01-01/part-0.parquet", meta.RowGroups[0].Columns[0].GetFilePath())
+	require.Equal(t, "sub/date=2024-01-02/part-1.parquet", meta.RowGroups[6].Columns[1].GetFilePath())
+}
+
+func TestSummaryWriterErrors(t *testing.T) {
+	sw := NewSummaryWriter()
+	require.Error(t, sw.WriteMetaData(&bytes.Buffer{}))
+
+	require.NoError(t, sw.AddFileFromReader("a.parquet", bytes.NewReader(writeDatasetTestFile(t, `message test { required int64 id; }`, 0, 10).Data)))
+	require.Error(t, sw.AddFileFromReader("b.parquet", bytes.NewReader(writeDatasetTestFile(t, `message test { optional int64 id; }`, 0, 10).Data)))
+	require.Error(t, sw.AddFileFromReader("c.parquet", bytes.NewReader([]byte("not a parquet file"))))
+
+	meta, err := ReadFileMetaData(bytes.NewReader(writeDatasetTestFile(t, `message test { required int64 id; }`, 0, 10).Data), true)
+	require.NoError(t, err)
+	meta.EncryptionAlgorithm = &parquet.EncryptionAlgorithm{AES_GCM_V1: &parquet.AesGcmV1{}}
+	require.Error(t, sw.AddFile("d.parquet", meta))
+}
+
+type countingReadSeeker struct {
+	io.ReadSeeker
+	open *int
+}
+
+func (r countingReadSeeker) Close() error {
+	*r.open--
+	return nil
+}
+
+func TestSummaryFilesCloseUnusedFiles(t *testing.T) {
+	const schemaText = `message test { required int64 id; }`
+
+	fsys := fstest.MapFS{}
+	for i := 0; i < 5; i++ {
+		fsys[fmt.Sprintf("part-%d.parquet", i)] = writeDatasetTestFile(t, schemaText, i*10, i*10+10)
+	}
+	require.NoError(t, WriteDatasetSummaryFiles(fsys, mapFSFileCreator(fsys)))
+
+	open, maxOpen := 0, 0
+	opener := NewFSFileOpener(fsys)
+	r, err := NewFileReaderWithOptions(bytes.NewReader(fsys[SummaryMetaDataFile].Data), WithFileOpener(func(path string) (io.ReadSeeker, error) {
+		f, err := opener(path)
+		if err != nil {
+			return nil, err
+		}
+		open++
+		if open > maxOpen {
+			maxOpen = open
+		}
+		return countingReadSeeker{ReadSeeker: f, open: &open}, nil
+	}))
+	require.NoError(t, err)
+
+	for i := 0; i < 50; i++ {
+		row, err := r.NextRow()
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{"id": int64(i)}, row)
+	}
+	_, err = r.NextRow()
+	require.Equal(t, io.EOF, err)
+	require.Equal(t, 1, maxOpen)
+	require.Equal(t, 0, open)
+	require.NoError(t, r.Close())
+}
+
+func TestSummaryWriterColumnOrders(t *testing.T) {
+	current := writeDatasetTestRows(t, `message test { required int32 a (UINT_32); }`, map[string]interface{}{"a": int32(1)}).Data
+	legacy := writeLegacyUnsignedTestFile(t)
+
+	sw := NewSummaryWriter()
+	require.NoError(t, sw.AddFileFromReader("a.parquet", bytes.NewReader(current)))
+	require.NoError(t, sw.AddFileFromReader("b.parquet", bytes.NewReader(current)))
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, sw.WriteMetaData(buf))
+	meta, err := ReadFileMetaData(bytes.NewReader(buf.Bytes()), true)
+	require.NoError(t, err)
+	require.Len(t, meta.ColumnOrders, 1)
+	require.True(t, meta.ColumnOrders[0].IsSetTYPE_ORDER())
+
+	// the statistics of the legacy file use the signed order, so the column orders can't be kept.
+	require.NoError(t, sw.AddFileFromReader("c.parquet", bytes.NewReader(legacy)))
+	require.NoError(t, sw.AddFileFromReader("d.parquet", bytes.NewReader(current)))
+
+	buf.Reset()
+	require.NoError(t, sw.WriteMetaData(buf))
+	meta, err = ReadFileMetaData(bytes.NewReader(buf.Bytes()), true)
+	require.NoError(t, err)
+	require.Nil(t, meta.ColumnOrders)
+}
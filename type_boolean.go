@@ -15,18 +15,9 @@ type booleanPlainDecoder struct {
 // copy the left overs from the previous call. instead of returning an empty subset of the old slice,
 // it delete the slice (by returning nil) so there is no memory leak because of the underlying array
 // the return value is the new left over and the number of read message
-func copyLeftOvers(dst []interface{}, src []bool) ([]bool, int) {
-	size := len(dst)
-	var clean bool
-	if len(src) <= size {
-		size = len(src)
-		clean = true
-	}
-
-	for i := 0; i < size; i++ {
-		dst[i] = src[i]
-	}
-	if clean {
+func copyLeftOvers(dst []bool, src []bool) ([]bool, int) {
+	size := copy(dst, src)
+	if size == len(src) {
 		return nil, size
 	}
 
@@ -40,32 +31,37 @@ func (b *booleanPlainDecoder) init(r io.Reader) error {
 	return nil
 }
 
-func (b *booleanPlainDecoder) decodeValues(dst []interface{}) (int, error) {
+func (b *booleanPlainDecoder) decodeValues(dst valuesBuffer) (int, error) {
+	values, ok := dst.(booleanValues)
+	if !ok {
+		return 0, unexpectedValuesBuffer("boolean", dst)
+	}
+
 	var start int
 	if len(b.left) > 0 {
 		// there is a leftover from the last run
-		b.left, start = copyLeftOvers(dst, b.left)
+		b.left, start = copyLeftOvers(values, b.left)
 		if b.left != nil {
-			return len(dst), nil
+			return len(values), nil
 		}
 	}
 
 	buf := make([]byte, 1)
-	for i := start; i < len(dst); i += 8 {
+	for i := start; i < len(values); i += 8 {
 		if _, err := io.ReadFull(b.r, buf); err != nil {
 			return i, err
 		}
 		d := unpack8int32_1(buf)
 		for j := 0; j < 8; j++ {
-			if i+j < len(dst) {
-				dst[i+j] = d[j] == 1
+			if i+j < len(values) {
+				values[i+j] = d[j] == 1
 			} else {
 				b.left = append(b.left, d[j] == 1)
 			}
 		}
 	}
 
-	return len(dst), nil
+	return len(values), nil
 }
 
 type booleanPlainEncoder struct {
@@ -85,10 +81,15 @@ func (b *booleanPlainEncoder) init(w io.Writer) error {
 	return nil
 }
 
-func (b *booleanPlainEncoder) encodeValues(values []interface{}) error {
+func (b *booleanPlainEncoder) encodeValues(dst valuesBuffer) error {
+	values, ok := dst.(booleanValues)
+	if !ok {
+		return unexpectedValuesBuffer("boolean", dst)
+	}
+
 	for i := range values {
 		var v int32
-		if values[i].(bool) {
+		if values[i] {
 			v = 1
 		}
 		b.data.appendSingle(v)
@@ -106,14 +107,19 @@ func (b *booleanRLEDecoder) init(r io.Reader) error {
 	return b.decoder.initSize(r)
 }
 
-func (b *booleanRLEDecoder) decodeValues(dst []interface{}) (int, error) {
-	total := len(dst)
+func (b *booleanRLEDecoder) decodeValues(dst valuesBuffer) (int, error) {
+	values, ok := dst.(booleanValues)
+	if !ok {
+		return 0, unexpectedValuesBuffer("boolean", dst)
+	}
+
+	total := len(values)
 	for i := 0; i < total; i++ {
 		n, err := b.decoder.next()
 		if err != nil {
 			return i, err
 		}
-		dst[i] = n == 1
+		values[i] = n == 1
 	}
 
 	return total, nil
@@ -132,10 +138,15 @@ func (b *booleanRLEEncoder) init(w io.Writer) error {
 	return b.encoder.initSize(w)
 }
 
-func (b *booleanRLEEncoder) encodeValues(values []interface{}) error {
+func (b *booleanRLEEncoder) encodeValues(dst valuesBuffer) error {
+	values, ok := dst.(booleanValues)
+	if !ok {
+		return unexpectedValuesBuffer("boolean", dst)
+	}
+
 	buf := make([]int32, len(values))
 	for i := range values {
-		if values[i].(bool) {
+		if values[i] {
 			buf[i] = 1
 		} else {
 			buf[i] = 0
@@ -183,29 +194,19 @@ func (b *booleanStore) getPageStats() minMaxValues {
 	return &nilStats{}
 }
 
-func (b *booleanStore) getValues(v interface{}) ([]interface{}, error) {
-	var vals []interface{}
+func (b *booleanStore) appendValues(dst valuesBuffer, v interface{}) (valuesBuffer, error) {
+	values, _ := dst.(booleanValues)
 	switch typed := v.(type) {
 	case bool:
-		vals = []interface{}{typed}
+		values = append(values, typed)
 	case []bool:
 		if b.repTyp != parquet.FieldRepetitionType_REPEATED {
-			return nil, fmt.Errorf("the value is not repeated but it is an array")
-		}
-		vals = make([]interface{}, len(typed))
-		for j := range typed {
-			vals[j] = typed[j]
+			return dst, fmt.Errorf("the value is not repeated but it is an array")
 		}
+		values = append(values, typed...)
 	default:
-		return nil, fmt.Errorf("unsupported type for storing in bool column: %T => %+v", v, v)
+		return dst, fmt.Errorf("unsupported type for storing in bool column: %T => %+v", v, v)
 	}
 
-	return vals, nil
-}
-
-func (b *booleanStore) append(arrayIn interface{}, value interface{}) interface{} {
-	if arrayIn == nil {
-		arrayIn = make([]bool, 0, 1)
-	}
-	return append(arrayIn.([]bool), value.(bool))
+	return values, nil
 }
@@ -14,6 +14,8 @@ type byteArrayPlainDecoder struct {
 	r io.Reader
 	// if the length is set, then this is a fix size array decoder, unless it reads the len first
 	length int
+	// lenBuf is used to read the length of variable size arrays
+	lenBuf [4]byte
 }
 
 func (b *byteArrayPlainDecoder) init(r io.Reader) error {
@@ -24,9 +26,10 @@ func (b *byteArrayPlainDecoder) init(r io.Reader) error {
 func (b *byteArrayPlainDecoder) next() ([]byte, error) {
 	var l = int32(b.length)
 	if l == 0 {
-		if err := binary.Read(b.r, binary.LittleEndian, &l); err != nil {
+		if _, err := io.ReadFull(b.r, b.lenBuf[:]); err != nil {
 			return nil, err
 		}
+		l = int32(binary.LittleEndian.Uint32(b.lenBuf[:]))
 
 		if l < 0 {
 			return nil, errors.New("bytearray/plain: len is negative")
@@ -44,14 +47,19 @@ func (b *byteArrayPlainDecoder) next() ([]byte, error) {
 	return buf, nil
 }
 
-func (b *byteArrayPlainDecoder) decodeValues(dst []interface{}) (int, error) {
+func (b *byteArrayPlainDecoder) decodeValues(dst valuesBuffer) (int, error) {
+	values, ok := dst.(byteArrayValues)
+	if !ok {
+		return 0, unexpectedValuesBuffer("byte array", dst)
+	}
+
 	var err error
-	for i := range dst {
-		if dst[i], err = b.next(); err != nil {
+	for i := range values {
+		if values[i], err = b.next(); err != nil {
 			return i, err
 		}
 	}
-	return len(dst), nil
+	return len(values), nil
 }
 
 type byteArrayPlainEncoder struct {
@@ -81,9 +89,14 @@ func (b *byteArrayPlainEncoder) writeBytes(data []byte) error {
 	return writeFull(b.w, data)
 }
 
-func (b *byteArrayPlainEncoder) encodeValues(values []interface{}) error {
+func (b *byteArrayPlainEncoder) encodeValues(dst valuesBuffer) error {
+	values, ok := dst.(byteArrayValues)
+	if !ok {
+		return unexpectedValuesBuffer("byte array", dst)
+	}
+
 	for i := range values {
-		if err := b.writeBytes(values[i].([]byte)); err != nil {
+		if err := b.writeBytes(values[i]); err != nil {
 			return err
 		}
 	}
@@ -127,14 +140,19 @@ func (b *byteArrayDeltaLengthDecoder) next() ([]byte, error) {
 	return value, nil
 }
 
-func (b *byteArrayDeltaLengthDecoder) decodeValues(dst []interface{}) (int, error) {
-	total := len(dst)
+func (b *byteArrayDeltaLengthDecoder) decodeValues(dst valuesBuffer) (int, error) {
+	values, ok := dst.(byteArrayValues)
+	if !ok {
+		return 0, unexpectedValuesBuffer("byte array", dst)
+	}
+
+	total := len(values)
 	for i := 0; i < total; i++ {
 		v, err := b.next()
 		if err != nil {
 			return i, err
 		}
-		dst[i] = v
+		values[i] = v
 	}
 	return total, nil
 }
@@ -143,7 +161,7 @@ func (b *byteArrayDeltaLengthDecoder) decodeValues(dst []interface{}) (int, erro
 type byteArrayDeltaLengthEncoder struct {
 	w    io.Writer
 	buf  *bytes.Buffer
-	lens []interface{}
+	lens int32Values
 }
 
 func (b *byteArrayDeltaLengthEncoder) init(w io.Writer) error {
@@ -157,13 +175,18 @@ func (b *byteArrayDeltaLengthEncoder) writeOne(data []byte) error {
 	return writeFull(b.buf, data)
 }
 
-func (b *byteArrayDeltaLengthEncoder) encodeValues(values []interface{}) error {
+func (b *byteArrayDeltaLengthEncoder) encodeValues(dst valuesBuffer) error {
+	values, ok := dst.(byteArrayValues)
+	if !ok {
+		return unexpectedValuesBuffer("byte array", dst)
+	}
+
 	if b.lens == nil {
 		// this is just for the first time, maybe we need to copy and increase the cap in the next calls?
-		b.lens = make([]interface{}, 0, len(values))
+		b.lens = make(int32Values, 0, len(values))
 	}
 	for i := range values {
-		if err := b.writeOne(values[i].([]byte)); err != nil {
+		if err := b.writeOne(values[i]); err != nil {
 			return err
 		}
 	}
@@ -214,8 +237,13 @@ func (d *byteArrayDeltaDecoder) init(r io.Reader) error {
 	return nil
 }
 
-func (d *byteArrayDeltaDecoder) decodeValues(dst []interface{}) (int, error) {
-	total := len(dst)
+func (d *byteArrayDeltaDecoder) decodeValues(dst valuesBuffer) (int, error) {
+	values, ok := dst.(byteArrayValues)
+	if !ok {
+		return 0, unexpectedValuesBuffer("byte array", dst)
+	}
+
+	total := len(values)
 	for i := 0; i < total; i++ {
 		suffix, err := d.suffixDecoder.next()
 		if err != nil {
@@ -233,7 +261,7 @@ func (d *byteArrayDeltaDecoder) decodeValues(dst []interface{}) (int, error) {
 		}
 		value = append(value, suffix...)
 		d.previousValue = value
-		dst[i] = value
+		values[i] = value
 	}
 
 	return total, nil
@@ -242,7 +270,7 @@ func (d *byteArrayDeltaDecoder) decodeValues(dst []interface{}) (int, error) {
 type byteArrayDeltaEncoder struct {
 	w io.Writer
 
-	prefixLens    []interface{}
+	prefixLens    int32Values
 	previousValue []byte
 
 	values *byteArrayDeltaLengthEncoder
@@ -256,14 +284,19 @@ func (b *byteArrayDeltaEncoder) init(w io.Writer) error {
 	return b.values.init(w)
 }
 
-func (b *byteArrayDeltaEncoder) encodeValues(values []interface{}) error {
+func (b *byteArrayDeltaEncoder) encodeValues(dst valuesBuffer) error {
+	values, ok := dst.(byteArrayValues)
+	if !ok {
+		return unexpectedValuesBuffer("byte array", dst)
+	}
+
 	if b.prefixLens == nil {
-		b.prefixLens = make([]interface{}, 0, len(values))
-		b.values.lens = make([]interface{}, 0, len(values))
+		b.prefixLens = make(int32Values, 0, len(values))
+		b.values.lens = make(int32Values, 0, len(values))
 	}
 
 	for i := range values {
-		data := values[i].([]byte)
+		data := values[i]
 		pLen := prefix(b.previousValue, data)
 		b.prefixLens = append(b.prefixLens, int32(pLen))
 		if err := b.values.writeOne(data[pLen:]); err != nil {
@@ -299,6 +332,15 @@ type byteArrayStore struct {
 	*ColumnParameters
 }
 
+func newByteArrayStore(typ parquet.Type, params *ColumnParameters) *byteArrayStore {
+	compare := byteArrayCompareFunc(typ, params)
+	return &byteArrayStore{
+		stats:            newStatistics(compare),
+		pageStats:        newStatistics(compare),
+		ColumnParameters: params,
+	}
+}
+
 func (is *byteArrayStore) getStats() minMaxValues {
 	return &is.stats
 }
@@ -343,10 +385,18 @@ func (is *byteArrayStore) reset(repetitionType parquet.FieldRepetitionType) {
 	is.pageStats.reset()
 }
 
-func (is *byteArrayStore) setMinMax(j []byte) error {
+// checkSize returns an error if the column is a fixed length byte array and j isn't of its length.
+func (is *byteArrayStore) checkSize(j []byte) error {
 	if is.TypeLength != nil && *is.TypeLength > 0 && int32(len(j)) != *is.TypeLength {
 		return fmt.Errorf("the size of data should be %d but is %d", *is.TypeLength, len(j))
 	}
+	return nil
+}
+
+func (is *byteArrayStore) setMinMax(j []byte) error {
+	if err := is.checkSize(j); err != nil {
+		return err
+	}
 	// For nil value there is no need to set the min/max
 	if j == nil {
 		return nil
@@ -358,29 +408,27 @@ func (is *byteArrayStore) setMinMax(j []byte) error {
 	return nil
 }
 
-func (is *byteArrayStore) getValues(v interface{}) ([]interface{}, error) {
-	var vals []interface{}
+func (is *byteArrayStore) appendValues(dst valuesBuffer, v interface{}) (valuesBuffer, error) {
+	values, _ := dst.(byteArrayValues)
 	switch typed := v.(type) {
 	case []byte:
-		vals = []interface{}{typed}
+		if err := is.setMinMax(typed); err != nil {
+			return dst, err
+		}
+		values = append(values, typed)
 	case [][]byte:
 		if is.repTyp != parquet.FieldRepetitionType_REPEATED {
-			return nil, fmt.Errorf("the value is not repeated but it is an array")
+			return dst, fmt.Errorf("the value is not repeated but it is an array")
 		}
-		vals = make([]interface{}, len(typed))
 		for j := range typed {
-			vals[j] = typed[j]
+			if err := is.setMinMax(typed[j]); err != nil {
+				return dst, err
+			}
 		}
+		values = append(values, typed...)
 	default:
-		return nil, fmt.Errorf("unsupported type for storing in []byte column %T => %+v", v, v)
+		return dst, fmt.Errorf("unsupported type for storing in []byte column %T => %+v", v, v)
 	}
 
-	return vals, nil
-}
-
-func (*byteArrayStore) append(arrayIn interface{}, value interface{}) interface{} {
-	if arrayIn == nil {
-		arrayIn = make([][]byte, 0, 1)
-	}
-	return append(arrayIn.([][]byte), value.([]byte))
+	return values, nil
 }
@@ -5,16 +5,20 @@ import (
 	"fmt"
 	"io"
 	"math"
+
+	"github.com/fraugster/parquet-go/parquet"
 )
 
 type dictDecoder struct {
-	uniqueValues []interface{}
+	uniqueValues valuesBuffer
 
 	keys decoder
+	// indices is reused to decode the keys before the values are looked up.
+	indices []int32
 }
 
 // just for tests
-func (d *dictDecoder) setValues(v []interface{}) {
+func (d *dictDecoder) setValues(v valuesBuffer) {
 	d.uniqueValues = v
 }
 
@@ -37,47 +41,63 @@ func (d *dictDecoder) init(r io.Reader) error {
 	return errors.New("bit width zero with non-empty dictionary")
 }
 
-func (d *dictDecoder) decodeValues(dst []interface{}) (int, error) {
+func (d *dictDecoder) decodeValues(dst valuesBuffer) (int, error) {
 	if d.keys == nil {
 		return 0, errors.New("no value is inside dictionary")
 	}
-	size := int32(len(d.uniqueValues))
+	if d.uniqueValues == nil {
+		return 0, errors.New("dict: no dictionary values")
+	}
+	size := int32(d.uniqueValues.len())
+
+	if cap(d.indices) < dst.len() {
+		d.indices = make([]int32, dst.len())
+	}
+	indices := d.indices[:dst.len()]
 
-	for i := range dst {
-		key, err := d.keys.next()
-		if err != nil {
-			return i, err
+	var err error
+	n := 0
+	for ; n < len(indices); n++ {
+		var key int32
+		if key, err = d.keys.next(); err != nil {
+			break
 		}
 
 		if key < 0 || key >= size {
 			return 0, fmt.Errorf("dict: invalid index %d, values count are %d", key, size)
 		}
 
-		dst[i] = d.uniqueValues[key]
+		indices[n] = key
 	}
 
-	return len(dst), nil
+	if gatherErr := dst.gather(d.uniqueValues, indices[:n]); gatherErr != nil {
+		return 0, gatherErr
+	}
+
+	return n, err
 }
 
 type dictStore struct {
-	valueList        []interface{}
-	uniqueValues     map[interface{}]struct{}
+	values           valuesBuffer
+	uniqueValues     valuesDict // nil if no dictionary is used.
 	uniqueValuesSize int64
 	allValuesSize    int64
 	readPos          int
 	nullCount        int32
 	useDict          bool
-
-	alloc *allocTracker
 }
 
-func (d *dictStore) getValues() []interface{} {
-	return d.valueList
+func (d *dictStore) getValues() valuesBuffer {
+	return d.values
 }
 
-func (d *dictStore) init() {
-	d.uniqueValues = make(map[interface{}]struct{})
-	d.valueList = nil
+// init initializes the store with values, which is an empty buffer of the column's type.
+func (d *dictStore) init(values valuesBuffer) {
+	d.values = values
+	d.uniqueValues = nil
+	if d.useDict {
+		d.uniqueValues = values.newDict()
+	}
 	d.reset()
 }
 
@@ -88,36 +108,53 @@ func (d *dictStore) reset() {
 	d.allValuesSize = 0
 }
 
-func (d *dictStore) addValue(v interface{}, size int) {
-	if v == nil {
-		d.nullCount++
-		return
+func (d *dictStore) addNull() {
+	d.nullCount++
+}
+
+// addValues adds the values extracted from v by store, and returns the number of values
+// that were added.
+func (d *dictStore) addValues(store typedColumnStore, v interface{}) (int, error) {
+	n := d.values.len()
+	values, err := store.appendValues(d.values, v)
+	if err != nil {
+		return 0, err
 	}
-	if d.useDict {
-		k := mapKey(v)
-		if _, found := d.uniqueValues[k]; !found {
-			d.uniqueValues[k] = struct{}{}
-			d.uniqueValuesSize += int64(size)
-			if len(d.uniqueValues) > math.MaxInt16 {
-				d.useDict = false
-			}
-		}
+	d.values = values
+	d.addSizes(n)
+	return values.len() - n, nil
+}
+
+// addValuesFrom adds the values from position i to j of src.
+func (d *dictStore) addValuesFrom(src valuesBuffer, i, j int) error {
+	n := d.values.len()
+	values, err := d.values.appendFrom(src, i, j)
+	if err != nil {
+		return err
 	}
-	d.allValuesSize += int64(size)
-	d.valueList = append(d.valueList, v)
-	d.alloc.register(v, uint64(size))
+	d.values = values
+	d.addSizes(n)
+	return nil
 }
 
-func (d *dictStore) getNextValue() (interface{}, error) {
-	if d.readPos >= len(d.valueList) {
-		return nil, errors.New("out of range")
+// addSizes adds the values from position n on to the sizes and the distinct values.
+func (d *dictStore) addSizes(n int) {
+	for i := n; i < d.values.len(); i++ {
+		size := int64(d.values.sizeOf(i))
+		if d.useDict {
+			if _, added := d.uniqueValues.add(d.values, i); added {
+				d.uniqueValuesSize += size
+				if d.uniqueValues.len() > math.MaxInt16 {
+					d.useDict = false
+				}
+			}
+		}
+		d.allValuesSize += size
 	}
-	d.readPos++
-	return d.valueList[d.readPos-1], nil
 }
 
 func (d *dictStore) numValues() int32 {
-	return int32(len(d.valueList))
+	return int32(d.values.len())
 }
 
 func (d *dictStore) nullValueCount() int32 {
@@ -125,11 +162,14 @@ func (d *dictStore) nullValueCount() int32 {
 }
 
 func (d *dictStore) distinctValueCount() int64 {
-	return int64(len(d.uniqueValues))
+	if d.uniqueValues == nil {
+		return 0
+	}
+	return int64(d.uniqueValues.len())
 }
 
 func (d *dictStore) sizes() (dictLen int64, noDictLen int64) {
-	return d.uniqueValuesSize + int64(4*len(d.valueList)), d.allValuesSize
+	return d.uniqueValuesSize + int64(4*d.values.len()), d.allValuesSize
 }
 
 type dictEncoder struct {
@@ -162,3 +202,103 @@ func (d *dictEncoder) encodeIndices(indices []int32) error {
 	d.indices = append(d.indices, indices...)
 	return nil
 }
+
+// defaultMaxDictionaryEntries is the default maximum number of entries in the dictionary of
+// a column chunk.
+const defaultMaxDictionaryEntries = math.MaxInt16
+
+// chunkDictionary is the dictionary of a column chunk that is being written. It is built
+// incrementally whenever a data page of the column is flushed. Once the dictionary exceeds
+// one of its limits, it is marked as full: the pages that have already been added remain
+// dictionary-encoded, but the current and all subsequent pages of the column chunk are
+// written using the column's encoding instead.
+type chunkDictionary struct {
+	dict valuesDict // created when the first page is added.
+	size int64      // the size of the PLAIN-encoded dictionary page.
+	full bool
+
+	maxEntries int
+	maxSize    int64 // zero or less means no limit.
+}
+
+func newChunkDictionary(maxEntries int, maxSize int64) *chunkDictionary {
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxDictionaryEntries
+	}
+	if maxEntries > math.MaxInt32 {
+		maxEntries = math.MaxInt32
+	}
+	return &chunkDictionary{
+		maxEntries: maxEntries,
+		maxSize:    maxSize,
+	}
+}
+
+// addPage adds the values of page to the dictionary and fills the page's index list. It
+// returns false if the page can't be dictionary-encoded because the dictionary is full.
+func (d *chunkDictionary) addPage(col *Column, page *dataPage) bool {
+	if d.full {
+		return false
+	}
+
+	if page.stats.DistinctCount != nil && *page.stats.DistinctCount > int64(d.maxEntries) {
+		d.full = true
+		return false
+	}
+
+	if d.dict == nil {
+		d.dict = page.values.newDict()
+	}
+
+	var (
+		prevLen   = d.dict.len()
+		prevSize  = d.size
+		numValues = page.values.len()
+		indexList = make([]int32, 0, numValues)
+	)
+
+	for i := 0; i < numValues; i++ {
+		idx, added := d.dict.add(page.values, i)
+		if added {
+			d.size += int64(page.values.sizeOf(i))
+			if *col.Type() == parquet.Type_BYTE_ARRAY {
+				d.size += 4 // the length prefix of PLAIN-encoded byte arrays.
+			}
+
+			if d.dict.len() > d.maxEntries || (d.maxSize > 0 && d.size > d.maxSize) {
+				// the dictionary is full, so roll back the values of this page, and
+				// write it and all subsequent pages without the dictionary.
+				d.dict.truncate(prevLen)
+				d.size = prevSize
+				d.full = true
+				return false
+			}
+		}
+		indexList = append(indexList, idx)
+	}
+
+	page.indexList = indexList
+	return true
+}
+
+// values returns the values of the dictionary ordered by their index, or nil if no page
+// has been added.
+func (d *chunkDictionary) values() valuesBuffer {
+	if d.dict == nil {
+		return nil
+	}
+	return d.dict.values()
+}
+
+// distinctCount returns the number of distinct values of the column chunk, which is only
+// known if all of its values have been added to the dictionary.
+func (d *chunkDictionary) distinctCount() *int64 {
+	if d == nil || d.full {
+		return nil
+	}
+	var n int
+	if d.dict != nil {
+		n = d.dict.len()
+	}
+	return int64Ptr(int64(n))
+}
@@ -1,31 +1,36 @@
 package goparquet
 
 import (
+	"bytes"
+	"fmt"
 	"testing"
 
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
 	"github.com/stretchr/testify/require"
 )
 
 func TestDictStore(t *testing.T) {
 	d := &dictStore{useDict: true}
-	d.init()
+	d.init(int32Values{})
 	require.Equal(t, d.allValuesSize, int64(0))
 	require.Equal(t, d.uniqueValuesSize, int64(0))
 
-	d.addValue(int32(1), 4)
-	d.addValue(int32(2), 4)
-	d.addValue(int32(3), 4)
-	d.addValue(int32(4), 4)
-	d.addValue(int32(1), 4)
-	d.addValue(int32(2), 4)
-	d.addValue(int32(3), 4)
-	d.addValue(int32(4), 4)
+	values := int32Values{1, 2, 3, 4, 1, 2, 3, 4}
+	require.NoError(t, d.addValuesFrom(values, 0, 4))
+	require.NoError(t, d.addValuesFrom(values, 4, 8))
 	require.Equal(t, d.allValuesSize, int64(32))
 	require.Equal(t, d.uniqueValuesSize, int64(16))
-	d.addValue(nil, 4)
+	require.Equal(t, values, d.getValues())
+	require.Equal(t, int64(4), d.distinctValueCount())
+	d.addNull()
 	require.Equal(t, d.allValuesSize, int64(32))
+	require.Equal(t, int32(1), d.nullValueCount())
 
-	d.init()
+	require.Error(t, d.addValuesFrom(int64Values{1}, 0, 1))
+	require.Equal(t, values, d.getValues())
+
+	d.init(int32Values{})
 	require.Equal(t, d.allValuesSize, int64(0))
 	require.Equal(t, d.uniqueValuesSize, int64(0))
 }
@@ -175,3 +180,133 @@ func TestFuzzCrashDictDecoderDecodeValues(t *testing.T) {
 
 	readAllData(t, data)
 }
+
+func writeDictionaryLimitsTestFile(t *testing.T, numRows int, opts ...FileWriterOption) []byte {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 id;
+		optional binary name (STRING);
+	}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	fw := NewFileWriter(buf, append([]FileWriterOption{WithSchemaDefinition(sd), WithMaxPageSize(1024)}, opts...)...)
+
+	for i := 0; i < numRows; i++ {
+		row := map[string]interface{}{"id": int64(i)}
+		if i%10 != 0 {
+			row["name"] = []byte(fmt.Sprintf("name-%d", i%500))
+		}
+		require.NoError(t, fw.AddData(row))
+	}
+	require.NoError(t, fw.Close())
+
+	return buf.Bytes()
+}
+
+func TestDictionaryLimits(t *testing.T) {
+	const numRows = 3000
+
+	testData := []struct {
+		name         string
+		opts         []FileWriterOption
+		idFallback   bool
+		nameFallback bool
+	}{
+		{name: "defaults"},
+		{name: "max_entries", opts: []FileWriterOption{WithDictionaryLimits(1000, 0)}, idFallback: true},
+		{name: "max_size", opts: []FileWriterOption{WithDictionaryLimits(0, 2048)}, idFallback: true, nameFallback: true},
+		{name: "column_limits", opts: []FileWriterOption{WithDictionaryLimits(0, 2048), WithColumnDictionaryLimits(ColumnPath{"name"}, 0, 0)}, idFallback: true},
+		{name: "data_page_v2", opts: []FileWriterOption{WithDictionaryLimits(100, 0), WithDataPageV2()}, idFallback: true, nameFallback: true},
+		{name: "page_spill", opts: []FileWriterOption{WithDictionaryLimits(100, 0), WithPageSpill(&memReadWriteSeeker{})}, idFallback: true, nameFallback: true},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			data := writeDictionaryLimitsTestFile(t, numRows, tt.opts...)
+
+			r, err := NewFileReader(bytes.NewReader(data))
+			require.NoError(t, err)
+			require.Equal(t, 1, r.RowGroupCount())
+
+			for i, fallback := range []bool{tt.idFallback, tt.nameFallback} {
+				meta := r.meta.RowGroups[0].Columns[i].MetaData
+
+				require.NotNil(t, meta.DictionaryPageOffset)
+				require.Contains(t, meta.Encodings, parquet.Encoding_RLE_DICTIONARY)
+
+				var dictPages, dictDataPages, plainDataPages int32
+				for _, st := range meta.EncodingStats {
+					switch {
+					case st.PageType == parquet.PageType_DICTIONARY_PAGE:
+						require.Equal(t, parquet.Encoding_PLAIN, st.Encoding)
+						dictPages += st.Count
+					case st.Encoding == parquet.Encoding_RLE_DICTIONARY:
+						dictDataPages += st.Count
+					default:
+						require.Equal(t, parquet.Encoding_PLAIN, st.Encoding)
+						plainDataPages += st.Count
+					}
+				}
+				require.Equal(t, int32(1), dictPages)
+				require.True(t, dictDataPages > 0)
+
+				if fallback {
+					require.True(t, plainDataPages > 0)
+					require.Nil(t, meta.Statistics.DistinctCount)
+				} else {
+					require.Equal(t, int32(0), plainDataPages)
+					require.NotNil(t, meta.Statistics.DistinctCount)
+				}
+			}
+
+			for i := 0; i < numRows; i++ {
+				row, err := r.NextRow()
+				require.NoError(t, err)
+				require.Equal(t, int64(i), row["id"])
+				if i%10 != 0 {
+					require.Equal(t, []byte(fmt.Sprintf("name-%d", i%500)), row["name"])
+				} else {
+					require.NotContains(t, row, "name")
+				}
+			}
+		})
+	}
+}
+
+func TestChunkDictionaryLimits(t *testing.T) {
+	col := &Column{data: mustColumnStore(NewByteArrayStore(parquet.Encoding_PLAIN, true, &ColumnParameters{}))}
+	col.data.reset(parquet.FieldRepetitionType_REQUIRED, 0, 0)
+
+	newPage := func(values ...string) *dataPage {
+		page := &dataPage{stats: &parquet.Statistics{}}
+		var buf byteArrayValues
+		for _, v := range values {
+			buf = append(buf, []byte(v))
+		}
+		page.values = buf
+		return page
+	}
+
+	d := newChunkDictionary(0, 16)
+	require.Equal(t, defaultMaxDictionaryEntries, d.maxEntries)
+
+	page := newPage("foo", "bar", "foo")
+	require.True(t, d.addPage(col, page))
+	require.Equal(t, []int32{0, 1, 0}, page.indexList)
+	require.Equal(t, int64(14), d.size)
+
+	// the page would grow the dictionary beyond 16 bytes, so it's rolled back.
+	require.False(t, d.addPage(col, newPage("bar", "baz")))
+	require.True(t, d.full)
+	require.Equal(t, byteArrayValues{[]byte("foo"), []byte("bar")}, d.values())
+	require.Len(t, d.dict.(*byteArrayDict).indices, 2)
+	require.Equal(t, int64(14), d.size)
+	require.Nil(t, d.distinctCount())
+
+	// once the dictionary is full, no further pages are added, even if they would fit.
+	require.False(t, d.addPage(col, newPage("foo")))
+
+	d = newChunkDictionary(2, 0)
+	require.True(t, d.addPage(col, newPage("foo", "bar")))
+	require.False(t, d.addPage(col, newPage("baz")))
+}
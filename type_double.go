@@ -19,16 +19,19 @@ func (d *doublePlainDecoder) init(r io.Reader) error {
 	return nil
 }
 
-func (d *doublePlainDecoder) decodeValues(dst []interface{}) (int, error) {
-	var data uint64
-	for i := range dst {
-		if err := binary.Read(d.r, binary.LittleEndian, &data); err != nil {
-			return i, err
-		}
-		dst[i] = math.Float64frombits(data)
+func (d *doublePlainDecoder) decodeValues(dst valuesBuffer) (int, error) {
+	values, ok := dst.(doubleValues)
+	if !ok {
+		return 0, unexpectedValuesBuffer("double", dst)
+	}
+
+	buf := make([]byte, 8*len(values))
+	n, err := readFixedWidth(d.r, buf, 8)
+	for i := 0; i < n; i++ {
+		values[i] = math.Float64frombits(binary.LittleEndian.Uint64(buf[8*i:]))
 	}
 
-	return len(dst), nil
+	return n, err
 }
 
 type doublePlainEncoder struct {
@@ -45,13 +48,13 @@ func (d *doublePlainEncoder) init(w io.Writer) error {
 	return nil
 }
 
-func (d *doublePlainEncoder) encodeValues(values []interface{}) error {
-	data := make([]uint64, len(values))
-	for i := range values {
-		data[i] = math.Float64bits(values[i].(float64))
+func (d *doublePlainEncoder) encodeValues(dst valuesBuffer) error {
+	values, ok := dst.(doubleValues)
+	if !ok {
+		return unexpectedValuesBuffer("double", dst)
 	}
 
-	return binary.Write(d.w, binary.LittleEndian, data)
+	return binary.Write(d.w, binary.LittleEndian, []float64(values))
 }
 
 type doubleStore struct {
@@ -104,31 +107,23 @@ func (f *doubleStore) setMinMax(j float64) {
 	f.pageStats.setMinMax(j)
 }
 
-func (f *doubleStore) getValues(v interface{}) ([]interface{}, error) {
-	var vals []interface{}
+func (f *doubleStore) appendValues(dst valuesBuffer, v interface{}) (valuesBuffer, error) {
+	values, _ := dst.(doubleValues)
 	switch typed := v.(type) {
 	case float64:
 		f.setMinMax(typed)
-		vals = []interface{}{typed}
+		values = append(values, typed)
 	case []float64:
 		if f.repTyp != parquet.FieldRepetitionType_REPEATED {
-			return nil, fmt.Errorf("the value is not repeated but it is an array")
+			return dst, fmt.Errorf("the value is not repeated but it is an array")
 		}
-		vals = make([]interface{}, len(typed))
 		for j := range typed {
 			f.setMinMax(typed[j])
-			vals[j] = typed[j]
 		}
+		values = append(values, typed...)
 	default:
-		return nil, fmt.Errorf("unsupported type for storing in float64 column: %T => %+v", v, v)
+		return dst, fmt.Errorf("unsupported type for storing in float64 column: %T => %+v", v, v)
 	}
 
-	return vals, nil
-}
-
-func (*doubleStore) append(arrayIn interface{}, value interface{}) interface{} {
-	if arrayIn == nil {
-		arrayIn = make([]float64, 0, 1)
-	}
-	return append(arrayIn.([]float64), value.(float64))
+	return values, nil
 }
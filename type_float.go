@@ -19,16 +19,19 @@ func (f *floatPlainDecoder) init(r io.Reader) error {
 	return nil
 }
 
-func (f *floatPlainDecoder) decodeValues(dst []interface{}) (int, error) {
-	var data uint32
-	for i := range dst {
-		if err := binary.Read(f.r, binary.LittleEndian, &data); err != nil {
-			return i, err
-		}
-		dst[i] = math.Float32frombits(data)
+func (f *floatPlainDecoder) decodeValues(dst valuesBuffer) (int, error) {
+	values, ok := dst.(floatValues)
+	if !ok {
+		return 0, unexpectedValuesBuffer("float", dst)
+	}
+
+	buf := make([]byte, 4*len(values))
+	n, err := readFixedWidth(f.r, buf, 4)
+	for i := 0; i < n; i++ {
+		values[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
 	}
 
-	return len(dst), nil
+	return n, err
 }
 
 type floatPlainEncoder struct {
@@ -45,13 +48,13 @@ func (d *floatPlainEncoder) init(w io.Writer) error {
 	return nil
 }
 
-func (d *floatPlainEncoder) encodeValues(values []interface{}) error {
-	data := make([]uint32, len(values))
-	for i := range values {
-		data[i] = math.Float32bits(values[i].(float32))
+func (d *floatPlainEncoder) encodeValues(dst valuesBuffer) error {
+	values, ok := dst.(floatValues)
+	if !ok {
+		return unexpectedValuesBuffer("float", dst)
 	}
 
-	return binary.Write(d.w, binary.LittleEndian, data)
+	return binary.Write(d.w, binary.LittleEndian, []float32(values))
 }
 
 type floatStore struct {
@@ -104,31 +107,23 @@ func (f *floatStore) setMinMax(j float32) {
 	f.pageStats.setMinMax(j)
 }
 
-func (f *floatStore) getValues(v interface{}) ([]interface{}, error) {
-	var vals []interface{}
+func (f *floatStore) appendValues(dst valuesBuffer, v interface{}) (valuesBuffer, error) {
+	values, _ := dst.(floatValues)
 	switch typed := v.(type) {
 	case float32:
 		f.setMinMax(typed)
-		vals = []interface{}{typed}
+		values = append(values, typed)
 	case []float32:
 		if f.repTyp != parquet.FieldRepetitionType_REPEATED {
-			return nil, fmt.Errorf("the value is not repeated but it is an array")
+			return dst, fmt.Errorf("the value is not repeated but it is an array")
 		}
-		vals = make([]interface{}, len(typed))
 		for j := range typed {
 			f.setMinMax(typed[j])
-			vals[j] = typed[j]
 		}
+		values = append(values, typed...)
 	default:
-		return nil, fmt.Errorf("unsupported type for storing in float32 column: %T => %+v", v, v)
+		return dst, fmt.Errorf("unsupported type for storing in float32 column: %T => %+v", v, v)
 	}
 
-	return vals, nil
-}
-
-func (*floatStore) append(arrayIn interface{}, value interface{}) interface{} {
-	if arrayIn == nil {
-		arrayIn = make([]float32, 0, 1)
-	}
-	return append(arrayIn.([]float32), value.(float32))
+	return values, nil
 }
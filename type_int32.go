@@ -18,16 +18,19 @@ func (i *int32PlainDecoder) init(r io.Reader) error {
 	return nil
 }
 
-func (i *int32PlainDecoder) decodeValues(dst []interface{}) (int, error) {
-	var d int32
-	for idx := range dst {
-		if err := binary.Read(i.r, binary.LittleEndian, &d); err != nil {
-			return idx, err
-		}
-		dst[idx] = d
+func (i *int32PlainDecoder) decodeValues(dst valuesBuffer) (int, error) {
+	d, ok := dst.(int32Values)
+	if !ok {
+		return 0, unexpectedValuesBuffer("int32", dst)
+	}
+
+	buf := make([]byte, 4*len(d))
+	n, err := readFixedWidth(i.r, buf, 4)
+	for idx := 0; idx < n; idx++ {
+		d[idx] = int32(binary.LittleEndian.Uint32(buf[4*idx:]))
 	}
 
-	return len(dst), nil
+	return n, err
 }
 
 type int32PlainEncoder struct {
@@ -44,37 +47,47 @@ func (i *int32PlainEncoder) init(w io.Writer) error {
 	return nil
 }
 
-func (i *int32PlainEncoder) encodeValues(values []interface{}) error {
-	d := make([]int32, len(values))
-	for j := range values {
-		d[j] = values[j].(int32)
+func (i *int32PlainEncoder) encodeValues(dst valuesBuffer) error {
+	values, ok := dst.(int32Values)
+	if !ok {
+		return unexpectedValuesBuffer("int32", dst)
 	}
-	return binary.Write(i.w, binary.LittleEndian, d)
+	return binary.Write(i.w, binary.LittleEndian, []int32(values))
 }
 
 type int32DeltaBPDecoder struct {
 	deltaBitPackDecoder32
 }
 
-func (d *int32DeltaBPDecoder) decodeValues(dst []interface{}) (int, error) {
-	for i := range dst {
+func (d *int32DeltaBPDecoder) decodeValues(dst valuesBuffer) (int, error) {
+	values, ok := dst.(int32Values)
+	if !ok {
+		return 0, unexpectedValuesBuffer("int32", dst)
+	}
+
+	for i := range values {
 		u, err := d.next()
 		if err != nil {
 			return i, err
 		}
-		dst[i] = u
+		values[i] = u
 	}
 
-	return len(dst), nil
+	return len(values), nil
 }
 
 type int32DeltaBPEncoder struct {
 	deltaBitPackEncoder32
 }
 
-func (d *int32DeltaBPEncoder) encodeValues(values []interface{}) error {
+func (d *int32DeltaBPEncoder) encodeValues(dst valuesBuffer) error {
+	values, ok := dst.(int32Values)
+	if !ok {
+		return unexpectedValuesBuffer("int32", dst)
+	}
+
 	for i := range values {
-		if err := d.addInt32(values[i].(int32)); err != nil {
+		if err := d.addInt32(values[i]); err != nil {
 			return err
 		}
 	}
@@ -132,31 +145,23 @@ func (is *int32Store) setMinMax(j int32) {
 	is.pageStats.setMinMax(j)
 }
 
-func (is *int32Store) getValues(v interface{}) ([]interface{}, error) {
-	var vals []interface{}
+func (is *int32Store) appendValues(dst valuesBuffer, v interface{}) (valuesBuffer, error) {
+	values, _ := dst.(int32Values)
 	switch typed := v.(type) {
 	case int32:
 		is.setMinMax(typed)
-		vals = []interface{}{typed}
+		values = append(values, typed)
 	case []int32:
 		if is.repTyp != parquet.FieldRepetitionType_REPEATED {
-			return nil, fmt.Errorf("the value is not repeated but it is an array")
+			return dst, fmt.Errorf("the value is not repeated but it is an array")
 		}
-		vals = make([]interface{}, len(typed))
 		for j := range typed {
 			is.setMinMax(typed[j])
-			vals[j] = typed[j]
 		}
+		values = append(values, typed...)
 	default:
-		return nil, fmt.Errorf("unsupported type for storing in int32 column: %T => %+v", v, v)
+		return dst, fmt.Errorf("unsupported type for storing in int32 column: %T => %+v", v, v)
 	}
 
-	return vals, nil
-}
-
-func (*int32Store) append(arrayIn interface{}, value interface{}) interface{} {
-	if arrayIn == nil {
-		arrayIn = make([]int32, 0, 1)
-	}
-	return append(arrayIn.([]int32), value.(int32))
+	return values, nil
 }
@@ -18,16 +18,18 @@ func (i *int64PlainDecoder) init(r io.Reader) error {
 	return nil
 }
 
-func (i *int64PlainDecoder) decodeValues(dst []interface{}) (int, error) {
-	var d int64
+func (i *int64PlainDecoder) decodeValues(dst valuesBuffer) (int, error) {
+	d, ok := dst.(int64Values)
+	if !ok {
+		return 0, unexpectedValuesBuffer("int64", dst)
+	}
 
-	for idx := range dst {
-		if err := binary.Read(i.r, binary.LittleEndian, &d); err != nil {
-			return idx, err
-		}
-		dst[idx] = d
+	buf := make([]byte, 8*len(d))
+	n, err := readFixedWidth(i.r, buf, 8)
+	for idx := 0; idx < n; idx++ {
+		d[idx] = int64(binary.LittleEndian.Uint64(buf[8*idx:]))
 	}
-	return len(dst), nil
+	return n, err
 }
 
 type int64PlainEncoder struct {
@@ -44,37 +46,47 @@ func (i *int64PlainEncoder) init(w io.Writer) error {
 	return nil
 }
 
-func (i *int64PlainEncoder) encodeValues(values []interface{}) error {
-	d := make([]int64, len(values))
-	for i := range values {
-		d[i] = values[i].(int64)
+func (i *int64PlainEncoder) encodeValues(dst valuesBuffer) error {
+	values, ok := dst.(int64Values)
+	if !ok {
+		return unexpectedValuesBuffer("int64", dst)
 	}
-	return binary.Write(i.w, binary.LittleEndian, d)
+	return binary.Write(i.w, binary.LittleEndian, []int64(values))
 }
 
 type int64DeltaBPDecoder struct {
 	deltaBitPackDecoder64
 }
 
-func (d *int64DeltaBPDecoder) decodeValues(dst []interface{}) (int, error) {
-	for i := range dst {
+func (d *int64DeltaBPDecoder) decodeValues(dst valuesBuffer) (int, error) {
+	values, ok := dst.(int64Values)
+	if !ok {
+		return 0, unexpectedValuesBuffer("int64", dst)
+	}
+
+	for i := range values {
 		u, err := d.next()
 		if err != nil {
 			return i, err
 		}
-		dst[i] = u
+		values[i] = u
 	}
 
-	return len(dst), nil
+	return len(values), nil
 }
 
 type int64DeltaBPEncoder struct {
 	deltaBitPackEncoder64
 }
 
-func (d *int64DeltaBPEncoder) encodeValues(values []interface{}) error {
+func (d *int64DeltaBPEncoder) encodeValues(dst valuesBuffer) error {
+	values, ok := dst.(int64Values)
+	if !ok {
+		return unexpectedValuesBuffer("int64", dst)
+	}
+
 	for i := range values {
-		if err := d.addInt64(values[i].(int64)); err != nil {
+		if err := d.addInt64(values[i]); err != nil {
 			return err
 		}
 	}
@@ -132,31 +144,23 @@ func (is *int64Store) setMinMax(j int64) {
 	is.pageStats.setMinMax(j)
 }
 
-func (is *int64Store) getValues(v interface{}) ([]interface{}, error) {
-	var vals []interface{}
+func (is *int64Store) appendValues(dst valuesBuffer, v interface{}) (valuesBuffer, error) {
+	values, _ := dst.(int64Values)
 	switch typed := v.(type) {
 	case int64:
 		is.setMinMax(typed)
-		vals = []interface{}{typed}
+		values = append(values, typed)
 	case []int64:
 		if is.repTyp != parquet.FieldRepetitionType_REPEATED {
-			return nil, fmt.Errorf("the value is not repeated but it is an array")
+			return dst, fmt.Errorf("the value is not repeated but it is an array")
 		}
-		vals = make([]interface{}, len(typed))
 		for j := range typed {
 			is.setMinMax(typed[j])
-			vals[j] = typed[j]
 		}
+		values = append(values, typed...)
 	default:
-		return nil, fmt.Errorf("unsupported type for storing in int64 column: %T => %+v", v, v)
+		return dst, fmt.Errorf("unsupported type for storing in int64 column: %T => %+v", v, v)
 	}
 
-	return vals, nil
-}
-
-func (*int64Store) append(arrayIn interface{}, value interface{}) interface{} {
-	if arrayIn == nil {
-		arrayIn = make([]int64, 0, 1)
-	}
-	return append(arrayIn.([]int64), value.(int64))
+	return values, nil
 }
@@ -18,15 +18,20 @@ func (i *int96PlainDecoder) init(r io.Reader) error {
 	return nil
 }
 
-func (i *int96PlainDecoder) decodeValues(dst []interface{}) (int, error) {
+func (i *int96PlainDecoder) decodeValues(dst valuesBuffer) (int, error) {
+	values, ok := dst.(int96Values)
+	if !ok {
+		return 0, unexpectedValuesBuffer("int96", dst)
+	}
+
 	idx := 0
-	for range dst {
+	for range values {
 		var data [12]byte
 		// this one is a little tricky do not use ReadFull here
 		n, err := i.r.Read(data[:])
 		// make sure we handle the read data first then handle the error
 		if n == 12 {
-			dst[idx] = data
+			values[idx] = data
 			idx++
 		}
 
@@ -38,7 +43,7 @@ func (i *int96PlainDecoder) decodeValues(dst []interface{}) (int, error) {
 			return idx, fmt.Errorf("not enough byte to read Int96: %w", err)
 		}
 	}
-	return len(dst), nil
+	return len(values), nil
 }
 
 type int96PlainEncoder struct {
@@ -55,11 +60,15 @@ func (i *int96PlainEncoder) init(w io.Writer) error {
 	return nil
 }
 
-func (i *int96PlainEncoder) encodeValues(values []interface{}) error {
+func (i *int96PlainEncoder) encodeValues(dst valuesBuffer) error {
+	values, ok := dst.(int96Values)
+	if !ok {
+		return unexpectedValuesBuffer("int96", dst)
+	}
+
 	data := make([]byte, len(values)*12)
 	for j := range values {
-		i96 := values[j].([12]byte)
-		copy(data[j*12:], i96[:])
+		copy(data[j*12:], values[j][:])
 	}
 
 	return writeFull(i.w, data)
@@ -69,6 +78,16 @@ type int96Store struct {
 	byteArrayStore
 }
 
+func newInt96Store(params *ColumnParameters) *int96Store {
+	return &int96Store{
+		byteArrayStore: byteArrayStore{
+			stats:            newStatistics(compareInt96),
+			pageStats:        newStatistics(compareInt96),
+			ColumnParameters: params,
+		},
+	}
+}
+
 func (*int96Store) sizeOf(v interface{}) int {
 	if vv, ok := v.([][12]byte); ok {
 		return 12 * len(vv)
@@ -84,35 +103,27 @@ func (is *int96Store) repetitionType() parquet.FieldRepetitionType {
 	return is.repTyp
 }
 
-func (is *int96Store) getValues(v interface{}) ([]interface{}, error) {
-	var vals []interface{}
+func (is *int96Store) appendValues(dst valuesBuffer, v interface{}) (valuesBuffer, error) {
+	values, _ := dst.(int96Values)
 	switch typed := v.(type) {
 	case [12]byte:
 		if err := is.setMinMax(typed[:]); err != nil {
-			return nil, err
+			return dst, err
 		}
-		vals = []interface{}{typed}
+		values = append(values, typed)
 	case [][12]byte:
 		if is.repTyp != parquet.FieldRepetitionType_REPEATED {
-			return nil, errors.New("the value is not repeated but it is an array")
+			return dst, errors.New("the value is not repeated but it is an array")
 		}
-		vals = make([]interface{}, len(typed))
 		for j := range typed {
 			if err := is.setMinMax(typed[j][:]); err != nil {
-				return nil, err
+				return dst, err
 			}
-			vals[j] = typed[j]
 		}
+		values = append(values, typed...)
 	default:
-		return nil, fmt.Errorf("unsupported type for storing in Int96 column: %T => %+v", v, v)
+		return dst, fmt.Errorf("unsupported type for storing in Int96 column: %T => %+v", v, v)
 	}
 
-	return vals, nil
-}
-
-func (*int96Store) append(arrayIn interface{}, value interface{}) interface{} {
-	if arrayIn == nil {
-		arrayIn = make([][12]byte, 0, 1)
-	}
-	return append(arrayIn.([][12]byte), value.([12]byte))
+	return values, nil
 }
@@ -0,0 +1,537 @@
+package goparquet
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/fraugster/parquet-go/parquet"
+)
+
+// valuesBuffer is a buffer of values of a single physical type. The values decoders write into
+// the typed slices directly, and the column stores collect the values to be written in them, so
+// that values are only boxed into an interface{} when rows are assembled or added.
+type valuesBuffer interface {
+	// len returns the number of values in the buffer.
+	len() int
+	// value returns the value at position i as interface{}.
+	value(i int) interface{}
+	// slice returns a copy of the values from position i to j as a typed slice, e.g. []int64.
+	slice(i, j int) interface{}
+	// copyTo copies the values from position i to j to dst at position pos. dst needs to be
+	// a typed slice of the buffer's type.
+	copyTo(dst interface{}, pos, i, j int) error
+	// gather sets the values of the buffer to the values of dict, which needs to be of the
+	// same type, at the positions in indices.
+	gather(dict valuesBuffer, indices []int32) error
+	// appendFrom appends the values from position i to j of src, which needs to be of the same
+	// type, and returns the resulting buffer.
+	appendFrom(src valuesBuffer, i, j int) (valuesBuffer, error)
+	// sizeOf returns the size of the value at position i, which is used to estimate the size
+	// of pages and dictionaries.
+	sizeOf(i int) int
+	// newDict returns an empty dictionary for values of the buffer's type.
+	newDict() valuesDict
+}
+
+// valuesDict holds the distinct values of a single physical type, and assigns them indices in
+// the order in which they were added. Floating point values are distinct if their bits are.
+type valuesDict interface {
+	// add adds the value at position i of values, which need to be of the dictionary's type,
+	// unless the dictionary already contains it. It returns the index of the value, and true
+	// if it was added.
+	add(values valuesBuffer, i int) (int32, bool)
+	// truncate removes all values with an index of n or greater.
+	truncate(n int)
+	// len returns the number of values in the dictionary.
+	len() int
+	// values returns the values of the dictionary, ordered by their index.
+	values() valuesBuffer
+}
+
+// newValuesBuffer returns a buffer for n values of the physical type typ.
+func newValuesBuffer(typ parquet.Type, n int) (valuesBuffer, error) {
+	switch typ {
+	case parquet.Type_BOOLEAN:
+		return make(booleanValues, n), nil
+	case parquet.Type_INT32:
+		return make(int32Values, n), nil
+	case parquet.Type_INT64:
+		return make(int64Values, n), nil
+	case parquet.Type_INT96:
+		return make(int96Values, n), nil
+	case parquet.Type_FLOAT:
+		return make(floatValues, n), nil
+	case parquet.Type_DOUBLE:
+		return make(doubleValues, n), nil
+	case parquet.Type_BYTE_ARRAY, parquet.Type_FIXED_LEN_BYTE_ARRAY:
+		return make(byteArrayValues, n), nil
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", typ)
+}
+
+func unexpectedValuesBuffer(expected string, buf interface{}) error {
+	return fmt.Errorf("expected %s values, but got %T", expected, buf)
+}
+
+type booleanValues []bool
+
+func (v booleanValues) len() int                   { return len(v) }
+func (v booleanValues) value(i int) interface{}    { return v[i] }
+func (v booleanValues) slice(i, j int) interface{} { return append([]bool(nil), v[i:j]...) }
+func (v booleanValues) sizeOf(i int) int           { return 0 }
+
+func (v booleanValues) copyTo(dst interface{}, pos, i, j int) error {
+	d, ok := dst.([]bool)
+	if !ok {
+		return unexpectedValuesBuffer("[]bool", dst)
+	}
+	copy(d[pos:], v[i:j])
+	return nil
+}
+
+func (v booleanValues) gather(dict valuesBuffer, indices []int32) error {
+	d, ok := dict.(booleanValues)
+	if !ok {
+		return unexpectedValuesBuffer("boolean", dict)
+	}
+	for i, idx := range indices {
+		v[i] = d[idx]
+	}
+	return nil
+}
+
+func (v booleanValues) appendFrom(src valuesBuffer, i, j int) (valuesBuffer, error) {
+	s, ok := src.(booleanValues)
+	if !ok {
+		return v, unexpectedValuesBuffer("boolean", src)
+	}
+	return append(v, s[i:j]...), nil
+}
+
+func (v booleanValues) newDict() valuesDict {
+	return &booleanDict{indices: make(map[bool]int32)}
+}
+
+type booleanDict struct {
+	dict    booleanValues
+	indices map[bool]int32
+}
+
+func (d *booleanDict) add(values valuesBuffer, i int) (int32, bool) {
+	v := values.(booleanValues)
+	k := v[i]
+	if idx, ok := d.indices[k]; ok {
+		return idx, false
+	}
+	idx := int32(len(d.dict))
+	d.indices[k] = idx
+	d.dict = append(d.dict, v[i])
+	return idx, true
+}
+
+func (d *booleanDict) truncate(n int) {
+	for i := n; i < len(d.dict); i++ {
+		delete(d.indices, d.dict[i])
+	}
+	d.dict = d.dict[:n]
+}
+
+func (d *booleanDict) len() int             { return len(d.dict) }
+func (d *booleanDict) values() valuesBuffer { return d.dict }
+
+type int32Values []int32
+
+func (v int32Values) len() int                   { return len(v) }
+func (v int32Values) value(i int) interface{}    { return v[i] }
+func (v int32Values) slice(i, j int) interface{} { return append([]int32(nil), v[i:j]...) }
+func (v int32Values) sizeOf(i int) int           { return 4 }
+
+func (v int32Values) copyTo(dst interface{}, pos, i, j int) error {
+	d, ok := dst.([]int32)
+	if !ok {
+		return unexpectedValuesBuffer("[]int32", dst)
+	}
+	copy(d[pos:], v[i:j])
+	return nil
+}
+
+func (v int32Values) gather(dict valuesBuffer, indices []int32) error {
+	d, ok := dict.(int32Values)
+	if !ok {
+		return unexpectedValuesBuffer("int32", dict)
+	}
+	for i, idx := range indices {
+		v[i] = d[idx]
+	}
+	return nil
+}
+
+func (v int32Values) appendFrom(src valuesBuffer, i, j int) (valuesBuffer, error) {
+	s, ok := src.(int32Values)
+	if !ok {
+		return v, unexpectedValuesBuffer("int32", src)
+	}
+	return append(v, s[i:j]...), nil
+}
+
+func (v int32Values) newDict() valuesDict {
+	return &int32Dict{indices: make(map[int32]int32)}
+}
+
+type int32Dict struct {
+	dict    int32Values
+	indices map[int32]int32
+}
+
+func (d *int32Dict) add(values valuesBuffer, i int) (int32, bool) {
+	v := values.(int32Values)
+	k := v[i]
+	if idx, ok := d.indices[k]; ok {
+		return idx, false
+	}
+	idx := int32(len(d.dict))
+	d.indices[k] = idx
+	d.dict = append(d.dict, v[i])
+	return idx, true
+}
+
+func (d *int32Dict) truncate(n int) {
+	for i := n; i < len(d.dict); i++ {
+		delete(d.indices, d.dict[i])
+	}
+	d.dict = d.dict[:n]
+}
+
+func (d *int32Dict) len() int             { return len(d.dict) }
+func (d *int32Dict) values() valuesBuffer { return d.dict }
+
+type int64Values []int64
+
+func (v int64Values) len() int                   { return len(v) }
+func (v int64Values) value(i int) interface{}    { return v[i] }
+func (v int64Values) slice(i, j int) interface{} { return append([]int64(nil), v[i:j]...) }
+func (v int64Values) sizeOf(i int) int           { return 8 }
+
+func (v int64Values) copyTo(dst interface{}, pos, i, j int) error {
+	d, ok := dst.([]int64)
+	if !ok {
+		return unexpectedValuesBuffer("[]int64", dst)
+	}
+	copy(d[pos:], v[i:j])
+	return nil
+}
+
+func (v int64Values) gather(dict valuesBuffer, indices []int32) error {
+	d, ok := dict.(int64Values)
+	if !ok {
+		return unexpectedValuesBuffer("int64", dict)
+	}
+	for i, idx := range indices {
+		v[i] = d[idx]
+	}
+	return nil
+}
+
+func (v int64Values) appendFrom(src valuesBuffer, i, j int) (valuesBuffer, error) {
+	s, ok := src.(int64Values)
+	if !ok {
+		return v, unexpectedValuesBuffer("int64", src)
+	}
+	return append(v, s[i:j]...), nil
+}
+
+func (v int64Values) newDict() valuesDict {
+	return &int64Dict{indices: make(map[int64]int32)}
+}
+
+type int64Dict struct {
+	dict    int64Values
+	indices map[int64]int32
+}
+
+func (d *int64Dict) add(values valuesBuffer, i int) (int32, bool) {
+	v := values.(int64Values)
+	k := v[i]
+	if idx, ok := d.indices[k]; ok {
+		return idx, false
+	}
+	idx := int32(len(d.dict))
+	d.indices[k] = idx
+	d.dict = append(d.dict, v[i])
+	return idx, true
+}
+
+func (d *int64Dict) truncate(n int) {
+	for i := n; i < len(d.dict); i++ {
+		delete(d.indices, d.dict[i])
+	}
+	d.dict = d.dict[:n]
+}
+
+func (d *int64Dict) len() int             { return len(d.dict) }
+func (d *int64Dict) values() valuesBuffer { return d.dict }
+
+type int96Values [][12]byte
+
+func (v int96Values) len() int                   { return len(v) }
+func (v int96Values) value(i int) interface{}    { return v[i] }
+func (v int96Values) slice(i, j int) interface{} { return append([][12]byte(nil), v[i:j]...) }
+func (v int96Values) sizeOf(i int) int           { return 12 }
+
+func (v int96Values) copyTo(dst interface{}, pos, i, j int) error {
+	d, ok := dst.([][12]byte)
+	if !ok {
+		return unexpectedValuesBuffer("[][12]byte", dst)
+	}
+	copy(d[pos:], v[i:j])
+	return nil
+}
+
+func (v int96Values) gather(dict valuesBuffer, indices []int32) error {
+	d, ok := dict.(int96Values)
+	if !ok {
+		return unexpectedValuesBuffer("int96", dict)
+	}
+	for i, idx := range indices {
+		v[i] = d[idx]
+	}
+	return nil
+}
+
+func (v int96Values) appendFrom(src valuesBuffer, i, j int) (valuesBuffer, error) {
+	s, ok := src.(int96Values)
+	if !ok {
+		return v, unexpectedValuesBuffer("int96", src)
+	}
+	return append(v, s[i:j]...), nil
+}
+
+func (v int96Values) newDict() valuesDict {
+	return &int96Dict{indices: make(map[[12]byte]int32)}
+}
+
+type int96Dict struct {
+	dict    int96Values
+	indices map[[12]byte]int32
+}
+
+func (d *int96Dict) add(values valuesBuffer, i int) (int32, bool) {
+	v := values.(int96Values)
+	k := v[i]
+	if idx, ok := d.indices[k]; ok {
+		return idx, false
+	}
+	idx := int32(len(d.dict))
+	d.indices[k] = idx
+	d.dict = append(d.dict, v[i])
+	return idx, true
+}
+
+func (d *int96Dict) truncate(n int) {
+	for i := n; i < len(d.dict); i++ {
+		delete(d.indices, d.dict[i])
+	}
+	d.dict = d.dict[:n]
+}
+
+func (d *int96Dict) len() int             { return len(d.dict) }
+func (d *int96Dict) values() valuesBuffer { return d.dict }
+
+type floatValues []float32
+
+func (v floatValues) len() int                   { return len(v) }
+func (v floatValues) value(i int) interface{}    { return v[i] }
+func (v floatValues) slice(i, j int) interface{} { return append([]float32(nil), v[i:j]...) }
+func (v floatValues) sizeOf(i int) int           { return 4 }
+
+func (v floatValues) copyTo(dst interface{}, pos, i, j int) error {
+	d, ok := dst.([]float32)
+	if !ok {
+		return unexpectedValuesBuffer("[]float32", dst)
+	}
+	copy(d[pos:], v[i:j])
+	return nil
+}
+
+func (v floatValues) gather(dict valuesBuffer, indices []int32) error {
+	d, ok := dict.(floatValues)
+	if !ok {
+		return unexpectedValuesBuffer("float", dict)
+	}
+	for i, idx := range indices {
+		v[i] = d[idx]
+	}
+	return nil
+}
+
+func (v floatValues) appendFrom(src valuesBuffer, i, j int) (valuesBuffer, error) {
+	s, ok := src.(floatValues)
+	if !ok {
+		return v, unexpectedValuesBuffer("float", src)
+	}
+	return append(v, s[i:j]...), nil
+}
+
+func (v floatValues) newDict() valuesDict {
+	return &floatDict{indices: make(map[uint32]int32)}
+}
+
+type floatDict struct {
+	dict    floatValues
+	indices map[uint32]int32
+}
+
+func (d *floatDict) add(values valuesBuffer, i int) (int32, bool) {
+	v := values.(floatValues)
+	k := math.Float32bits(v[i])
+	if idx, ok := d.indices[k]; ok {
+		return idx, false
+	}
+	idx := int32(len(d.dict))
+	d.indices[k] = idx
+	d.dict = append(d.dict, v[i])
+	return idx, true
+}
+
+func (d *floatDict) truncate(n int) {
+	for i := n; i < len(d.dict); i++ {
+		delete(d.indices, math.Float32bits(d.dict[i]))
+	}
+	d.dict = d.dict[:n]
+}
+
+func (d *floatDict) len() int             { return len(d.dict) }
+func (d *floatDict) values() valuesBuffer { return d.dict }
+
+type doubleValues []float64
+
+func (v doubleValues) len() int                   { return len(v) }
+func (v doubleValues) value(i int) interface{}    { return v[i] }
+func (v doubleValues) slice(i, j int) interface{} { return append([]float64(nil), v[i:j]...) }
+func (v doubleValues) sizeOf(i int) int           { return 8 }
+
+func (v doubleValues) copyTo(dst interface{}, pos, i, j int) error {
+	d, ok := dst.([]float64)
+	if !ok {
+		return unexpectedValuesBuffer("[]float64", dst)
+	}
+	copy(d[pos:], v[i:j])
+	return nil
+}
+
+func (v doubleValues) gather(dict valuesBuffer, indices []int32) error {
+	d, ok := dict.(doubleValues)
+	if !ok {
+		return unexpectedValuesBuffer("double", dict)
+	}
+	for i, idx := range indices {
+		v[i] = d[idx]
+	}
+	return nil
+}
+
+func (v doubleValues) appendFrom(src valuesBuffer, i, j int) (valuesBuffer, error) {
+	s, ok := src.(doubleValues)
+	if !ok {
+		return v, unexpectedValuesBuffer("double", src)
+	}
+	return append(v, s[i:j]...), nil
+}
+
+func (v doubleValues) newDict() valuesDict {
+	return &doubleDict{indices: make(map[uint64]int32)}
+}
+
+type doubleDict struct {
+	dict    doubleValues
+	indices map[uint64]int32
+}
+
+func (d *doubleDict) add(values valuesBuffer, i int) (int32, bool) {
+	v := values.(doubleValues)
+	k := math.Float64bits(v[i])
+	if idx, ok := d.indices[k]; ok {
+		return idx, false
+	}
+	idx := int32(len(d.dict))
+	d.indices[k] = idx
+	d.dict = append(d.dict, v[i])
+	return idx, true
+}
+
+func (d *doubleDict) truncate(n int) {
+	for i := n; i < len(d.dict); i++ {
+		delete(d.indices, math.Float64bits(d.dict[i]))
+	}
+	d.dict = d.dict[:n]
+}
+
+func (d *doubleDict) len() int             { return len(d.dict) }
+func (d *doubleDict) values() valuesBuffer { return d.dict }
+
+type byteArrayValues [][]byte
+
+func (v byteArrayValues) len() int                   { return len(v) }
+func (v byteArrayValues) value(i int) interface{}    { return v[i] }
+func (v byteArrayValues) slice(i, j int) interface{} { return append([][]byte(nil), v[i:j]...) }
+func (v byteArrayValues) sizeOf(i int) int           { return len(v[i]) }
+
+func (v byteArrayValues) copyTo(dst interface{}, pos, i, j int) error {
+	d, ok := dst.([][]byte)
+	if !ok {
+		return unexpectedValuesBuffer("[][]byte", dst)
+	}
+	copy(d[pos:], v[i:j])
+	return nil
+}
+
+func (v byteArrayValues) gather(dict valuesBuffer, indices []int32) error {
+	d, ok := dict.(byteArrayValues)
+	if !ok {
+		return unexpectedValuesBuffer("byte array", dict)
+	}
+	for i, idx := range indices {
+		v[i] = d[idx]
+	}
+	return nil
+}
+
+func (v byteArrayValues) appendFrom(src valuesBuffer, i, j int) (valuesBuffer, error) {
+	s, ok := src.(byteArrayValues)
+	if !ok {
+		return v, unexpectedValuesBuffer("byte array", src)
+	}
+	return append(v, s[i:j]...), nil
+}
+
+func (v byteArrayValues) newDict() valuesDict {
+	return &byteArrayDict{indices: make(map[string]int32)}
+}
+
+type byteArrayDict struct {
+	dict    byteArrayValues
+	indices map[string]int32
+}
+
+func (d *byteArrayDict) add(values valuesBuffer, i int) (int32, bool) {
+	v := values.(byteArrayValues)
+	// the conversion in the lookup doesn't allocate, so the key is only copied for new values.
+	if idx, ok := d.indices[string(v[i])]; ok {
+		return idx, false
+	}
+	idx := int32(len(d.dict))
+	d.indices[string(v[i])] = idx
+	d.dict = append(d.dict, v[i])
+	return idx, true
+}
+
+func (d *byteArrayDict) truncate(n int) {
+	for i := n; i < len(d.dict); i++ {
+		delete(d.indices, string(d.dict[i]))
+	}
+	d.dict = d.dict[:n]
+}
+
+func (d *byteArrayDict) len() int             { return len(d.dict) }
+func (d *byteArrayDict) values() valuesBuffer { return d.dict }
@@ -2,6 +2,7 @@ package goparquet
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"math/rand"
 	"reflect"
@@ -92,6 +93,38 @@ var (
 				return rand.Float32()
 			},
 		},
+		{
+			name: "Int32ByteStreamSplit",
+			enc:  newInt32ByteStreamSplitEncoder(),
+			dec:  newInt32ByteStreamSplitDecoder(),
+			rand: func() interface{} {
+				return int32(rand.Int())
+			},
+		},
+		{
+			name: "Int64ByteStreamSplit",
+			enc:  newInt64ByteStreamSplitEncoder(),
+			dec:  newInt64ByteStreamSplitDecoder(),
+			rand: func() interface{} {
+				return rand.Int63()
+			},
+		},
+		{
+			name: "DoubleByteStreamSplit",
+			enc:  newDoubleByteStreamSplitEncoder(),
+			dec:  newDoubleByteStreamSplitDecoder(),
+			rand: func() interface{} {
+				return rand.Float64()
+			},
+		},
+		{
+			name: "FloatByteStreamSplit",
+			enc:  newFloatByteStreamSplitEncoder(),
+			dec:  newFloatByteStreamSplitDecoder(),
+			rand: func() interface{} {
+				return rand.Float32()
+			},
+		},
 		{
 			name: "BooleanRLE",
 			enc:  &booleanRLEEncoder{},
@@ -143,6 +176,18 @@ var (
 				}
 			},
 		},
+		{
+			name: "ByteArrayFixedLenByteStreamSplit",
+			enc:  newFixedLenByteArrayByteStreamSplitEncoder(3),
+			dec:  newFixedLenByteArrayByteStreamSplitDecoder(3),
+			rand: func() interface{} {
+				return []byte{
+					byte(rand.Intn(256)),
+					byte(rand.Intn(256)),
+					byte(rand.Intn(256)),
+				}
+			},
+		},
 		{
 			name: "ByteArrayPlain",
 			enc:  &byteArrayPlainEncoder{},
@@ -196,32 +241,78 @@ func TestTypes(t *testing.T) {
 			arr2 := buildRandArray(bufLen, data.rand)
 			w := &bytes.Buffer{}
 			require.NoError(t, data.enc.init(w))
-			require.NoError(t, data.enc.encodeValues(arr1))
-			require.NoError(t, data.enc.encodeValues(arr2))
+			require.NoError(t, data.enc.encodeValues(toTestValuesBuffer(arr1)))
+			require.NoError(t, data.enc.encodeValues(toTestValuesBuffer(arr2)))
 			require.NoError(t, data.enc.Close())
-			var v []interface{}
+			var v valuesBuffer
 			if d, ok := data.enc.(dictValuesEncoder); ok {
 				v = d.getValues()
 			}
-			ret := make([]interface{}, bufRead)
+			buf := newTestValuesBuffer(arr1[0], bufRead)
 			r := bytes.NewReader(w.Bytes())
 			if d, ok := data.dec.(dictValuesDecoder); ok {
 				d.setValues(v)
 			}
 			require.NoError(t, data.dec.init(r))
-			n, err := data.dec.decodeValues(ret)
+			n, err := data.dec.decodeValues(buf)
 			require.NoError(t, err)
 			require.Equal(t, bufRead, n)
+			ret := fromTestValuesBuffer(buf)
 			require.Equal(t, ret[:bufLen], arr1)
 			//require.Equal(t, len(ret[bufRead:]), len(arr2[:bufRead-bufLen]))
 			require.Equal(t, ret[bufLen:], arr2[:bufRead-bufLen])
-			n, err = data.dec.decodeValues(ret)
+			n, err = data.dec.decodeValues(buf)
 			require.Equal(t, io.EOF, err)
-			require.Equal(t, ret[:n], arr2[bufRead-bufLen:])
+			require.Equal(t, fromTestValuesBuffer(buf)[:n], arr2[bufRead-bufLen:])
 		})
 	}
 }
 
+// newTestValuesBuffer returns a values buffer of length n for values of the same type as sample.
+func newTestValuesBuffer(sample interface{}, n int) valuesBuffer {
+	switch sample.(type) {
+	case bool:
+		return make(booleanValues, n)
+	case int32:
+		return make(int32Values, n)
+	case int64:
+		return make(int64Values, n)
+	case [12]byte:
+		return make(int96Values, n)
+	case float32:
+		return make(floatValues, n)
+	case float64:
+		return make(doubleValues, n)
+	case []byte:
+		return make(byteArrayValues, n)
+	}
+
+	panic(fmt.Sprintf("unsupported type %T", sample))
+}
+
+func toTestValuesBuffer(values []interface{}) valuesBuffer {
+	if len(values) == 0 {
+		return nil
+	}
+
+	buf := newTestValuesBuffer(values[0], len(values))
+	v := reflect.ValueOf(buf)
+	for i := range values {
+		v.Index(i).Set(reflect.ValueOf(values[i]))
+	}
+
+	return buf
+}
+
+func fromTestValuesBuffer(buf valuesBuffer) []interface{} {
+	ret := make([]interface{}, buf.len())
+	for i := range ret {
+		ret[i] = buf.value(i)
+	}
+
+	return ret
+}
+
 func convertToInterface(arr interface{}) []interface{} {
 	v := reflect.ValueOf(arr)
 	ret := make([]interface{}, v.Len())
@@ -341,7 +432,7 @@ func TestStores(t *testing.T) {
 			err := st.add(data, 3, 3, 0)
 			require.NoError(t, err)
 
-			assert.Equal(t, convertToInterface(data), st.values.getValues())
+			assert.Equal(t, convertToInterface(data), fromTestValuesBuffer(st.values.getValues()))
 			// Field is not Required, so def level should be one more
 			assert.Equal(t, []int32{4, 4, 4}, st.dLevels.toArray())
 			// Field is repeated so the rep level (except for the first one which is the new record)
@@ -351,7 +442,7 @@ func TestStores(t *testing.T) {
 			err = st.add(randArr(0), 3, 3, 0)
 			require.NoError(t, err)
 			// No Reset
-			assert.Equal(t, convertToInterface(data), st.values.getValues())
+			assert.Equal(t, convertToInterface(data), fromTestValuesBuffer(st.values.getValues()))
 			// The new field is nil
 			assert.Equal(t, []int32{4, 4, 4, 3}, st.dLevels.toArray())
 			assert.Equal(t, []int32{0, 4, 4, 0}, st.rLevels.toArray())
@@ -362,7 +453,7 @@ func TestStores(t *testing.T) {
 			err = st.add(getOne(data), 3, 3, 0)
 			require.NoError(t, err)
 
-			assert.Equal(t, convertToInterface(data), st.values.getValues())
+			assert.Equal(t, convertToInterface(data), fromTestValuesBuffer(st.values.getValues()))
 			// Field is Required, so def level should be exact
 			assert.Equal(t, []int32{3}, st.dLevels.toArray())
 			assert.Equal(t, []int32{0}, st.rLevels.toArray())
@@ -372,7 +463,7 @@ func TestStores(t *testing.T) {
 			require.NoError(t, err)
 			// No reset
 			dArr := []interface{}{getOne(data), getOne(data2)}
-			assert.Equal(t, dArr, st.values.getValues())
+			assert.Equal(t, dArr, fromTestValuesBuffer(st.values.getValues()))
 			// Field is Required, so def level should be exact
 			assert.Equal(t, []int32{3, 3}, st.dLevels.toArray())
 			// rLevel is more than max, so its max now
@@ -389,7 +480,7 @@ func TestStores(t *testing.T) {
 			err = st.add(nil, 3, 3, 0)
 			assert.NoError(t, err)
 
-			assert.Equal(t, dArr, st.values.getValues())
+			assert.Equal(t, dArr, fromTestValuesBuffer(st.values.getValues()))
 
 			// Field is Required, so def level should be exact
 			assert.Equal(t, []int32{3, 3, 3}, st.dLevels.toArray())
@@ -0,0 +1,89 @@
+package goparquet
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// This is an implementation of the 64 bit variant of the xxHash algorithm
+// (XXH64) with a seed of 0, which is the hash function mandated by the
+// parquet specification for bloom filters.
+
+const (
+	xxhPrime64_1 uint64 = 11400714785074694791
+	xxhPrime64_2 uint64 = 14029467366897019727
+	xxhPrime64_3 uint64 = 1609587929392839161
+	xxhPrime64_4 uint64 = 9650029242287828579
+	xxhPrime64_5 uint64 = 2870177450012600261
+)
+
+func xxhRound(acc, input uint64) uint64 {
+	acc += input * xxhPrime64_2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * xxhPrime64_1
+}
+
+func xxhMergeRound(acc, val uint64) uint64 {
+	val = xxhRound(0, val)
+	acc ^= val
+	return acc*xxhPrime64_1 + xxhPrime64_4
+}
+
+func xxhash64(data []byte) uint64 {
+	var (
+		h uint64
+		n = len(data)
+	)
+
+	if n >= 32 {
+		prime1, prime2 := xxhPrime64_1, xxhPrime64_2
+		v1 := prime1 + prime2
+		v2 := prime2
+		v3 := uint64(0)
+		v4 := -prime1
+
+		for len(data) >= 32 {
+			v1 = xxhRound(v1, binary.LittleEndian.Uint64(data[0:8]))
+			v2 = xxhRound(v2, binary.LittleEndian.Uint64(data[8:16]))
+			v3 = xxhRound(v3, binary.LittleEndian.Uint64(data[16:24]))
+			v4 = xxhRound(v4, binary.LittleEndian.Uint64(data[24:32]))
+			data = data[32:]
+		}
+
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) + bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = xxhMergeRound(h, v1)
+		h = xxhMergeRound(h, v2)
+		h = xxhMergeRound(h, v3)
+		h = xxhMergeRound(h, v4)
+	} else {
+		h = xxhPrime64_5
+	}
+
+	h += uint64(n)
+
+	for len(data) >= 8 {
+		k := xxhRound(0, binary.LittleEndian.Uint64(data[:8]))
+		h ^= k
+		h = bits.RotateLeft64(h, 27)*xxhPrime64_1 + xxhPrime64_4
+		data = data[8:]
+	}
+
+	if len(data) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(data[:4])) * xxhPrime64_1
+		h = bits.RotateLeft64(h, 23)*xxhPrime64_2 + xxhPrime64_3
+		data = data[4:]
+	}
+
+	for _, b := range data {
+		h ^= uint64(b) * xxhPrime64_5
+		h = bits.RotateLeft64(h, 11) * xxhPrime64_1
+	}
+
+	h ^= h >> 33
+	h *= xxhPrime64_2
+	h ^= h >> 29
+	h *= xxhPrime64_3
+	h ^= h >> 32
+
+	return h
+}
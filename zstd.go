@@ -0,0 +1,615 @@
+package goparquet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// This file contains a dependency-free implementation of the ZSTD format as described
+// in RFC 8878. Frames that reference a dictionary are not supported.
+
+// DefaultZSTDCompressionLevel is the compression level of the ZSTD block compressor
+// that is registered by default.
+const DefaultZSTDCompressionLevel = 3
+
+const (
+	zstdMagic          = 0xFD2FB528
+	zstdSkippableMagic = 0x184D2A50
+	zstdMaxBlockSize   = 128 << 10
+
+	zstdMaxLLSymbol = 35
+	zstdMaxMLSymbol = 52
+	zstdMaxOFSymbol = 31
+	zstdMaxLLLog    = 9
+	zstdMaxMLLog    = 9
+	zstdMaxOFLog    = 8
+)
+
+var errZstdCorrupt = errors.New("zstd: corrupt input")
+
+var (
+	zstdLLBase = [zstdMaxLLSymbol + 1]uint32{
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+		16, 18, 20, 22, 24, 28, 32, 40, 48, 64, 128, 256, 512, 1024, 2048, 4096,
+		8192, 16384, 32768, 65536,
+	}
+	zstdLLBits = [zstdMaxLLSymbol + 1]uint8{
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		1, 1, 1, 1, 2, 2, 3, 3, 4, 6, 7, 8, 9, 10, 11, 12,
+		13, 14, 15, 16,
+	}
+	zstdMLBase = [zstdMaxMLSymbol + 1]uint32{
+		3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18,
+		19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33, 34,
+		35, 37, 39, 41, 43, 47, 51, 59, 67, 83, 99, 131, 259, 515, 1027, 2051,
+		4099, 8195, 16387, 32771, 65539,
+	}
+	zstdMLBits = [zstdMaxMLSymbol + 1]uint8{
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		1, 1, 1, 1, 2, 2, 3, 3, 4, 4, 5, 7, 8, 9, 10, 11,
+		12, 13, 14, 15, 16,
+	}
+
+	zstdPredefinedLLNorm = []int16{
+		4, 3, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 1, 1, 1,
+		2, 2, 2, 2, 2, 2, 2, 2, 2, 3, 2, 1, 1, 1, 1, 1,
+		-1, -1, -1, -1,
+	}
+	zstdPredefinedMLNorm = []int16{
+		1, 4, 3, 2, 2, 2, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, -1,
+		-1, -1, -1, -1, -1,
+	}
+	zstdPredefinedOFNorm = []int16{
+		1, 1, 1, 1, 1, 1, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, -1, -1, -1, -1, -1,
+	}
+
+	zstdPredefinedLL = zstdBuildFSETable(zstdPredefinedLLNorm, 6)
+	zstdPredefinedML = zstdBuildFSETable(zstdPredefinedMLNorm, 6)
+	zstdPredefinedOF = zstdBuildFSETable(zstdPredefinedOFNorm, 5)
+)
+
+// zstdCompressor is a block compressor for the ZSTD compression codec.
+type zstdCompressor struct {
+	level int
+}
+
+// NewZSTDCompressor returns a block compressor for the ZSTD compression codec that
+// compresses with the given level. Like with the reference implementation, levels
+// range from 1 (fastest) to 22 (best compression); other values are clamped to that
+// range. Higher levels search more thoroughly for matches, but the compression ratio
+// is not comparable to the reference implementation at the same level. To change the
+// level used for ZSTD, register the compressor using RegisterBlockCompressor.
+func NewZSTDCompressor(level int) BlockCompressor {
+	if level < 1 {
+		level = 1
+	}
+	if level > 22 {
+		level = 22
+	}
+	return zstdCompressor{level: level}
+}
+
+func (c zstdCompressor) CompressBlock(block []byte) ([]byte, error) {
+	return zstdCompress(nil, block, c.level), nil
+}
+
+func (zstdCompressor) DecompressBlock(block []byte) ([]byte, error) {
+	return zstdDecompress(block)
+}
+
+// zstdDecompress decompresses all frames in src.
+func zstdDecompress(src []byte) ([]byte, error) {
+	var (
+		dst    []byte
+		frames int
+	)
+
+	for len(src) > 0 {
+		if len(src) < 4 {
+			return nil, errZstdCorrupt
+		}
+
+		magic := binary.LittleEndian.Uint32(src)
+		if magic&0xFFFFFFF0 == zstdSkippableMagic {
+			if len(src) < 8 {
+				return nil, errZstdCorrupt
+			}
+			size := uint64(binary.LittleEndian.Uint32(src[4:]))
+			if size > uint64(len(src)-8) {
+				return nil, errZstdCorrupt
+			}
+			src = src[8+size:]
+			continue
+		}
+
+		if magic != zstdMagic {
+			return nil, fmt.Errorf("zstd: invalid magic number %08x", magic)
+		}
+
+		var err error
+		d := zstdFrameDecoder{}
+		if dst, src, err = d.decode(dst, src[4:]); err != nil {
+			return nil, err
+		}
+		frames++
+	}
+
+	if frames == 0 {
+		return nil, errors.New("zstd: no frame found")
+	}
+
+	return dst, nil
+}
+
+// zstdFrameDecoder holds the state that is carried from block to block within a frame.
+type zstdFrameDecoder struct {
+	frameStart int
+	reps       [3]int
+	huffman    *zstdHuffmanTable
+	ll, of, ml *zstdFSETable
+}
+
+// decode decodes the frame at the beginning of src, appends the content to dst and
+// returns the remaining input.
+func (d *zstdFrameDecoder) decode(dst, src []byte) ([]byte, []byte, error) {
+	if len(src) < 1 {
+		return nil, nil, errZstdCorrupt
+	}
+
+	fhd := src[0]
+	src = src[1:]
+
+	if fhd&0x08 != 0 {
+		return nil, nil, errZstdCorrupt
+	}
+
+	var (
+		fcsFlag       = fhd >> 6
+		singleSegment = fhd&0x20 != 0
+		checksum      = fhd&0x04 != 0
+		dictIDSize    = [4]int{0, 1, 2, 4}[fhd&3]
+		fcsSize       = [4]int{0, 2, 4, 8}[fcsFlag]
+	)
+	if singleSegment && fcsFlag == 0 {
+		fcsSize = 1
+	}
+
+	headerSize := dictIDSize + fcsSize
+	if !singleSegment {
+		headerSize++
+	}
+	if len(src) < headerSize {
+		return nil, nil, errZstdCorrupt
+	}
+
+	if !singleSegment {
+		// the window size is irrelevant, as the whole content is kept in memory anyway.
+		src = src[1:]
+	}
+
+	var dictID uint32
+	for i := dictIDSize - 1; i >= 0; i-- {
+		dictID = dictID<<8 | uint32(src[i])
+	}
+	if dictID != 0 {
+		return nil, nil, errors.New("zstd: dictionaries are not supported")
+	}
+	src = src[dictIDSize:]
+
+	var contentSize uint64
+	for i := fcsSize - 1; i >= 0; i-- {
+		contentSize = contentSize<<8 | uint64(src[i])
+	}
+	if fcsSize == 2 {
+		contentSize += 256
+	}
+	src = src[fcsSize:]
+
+	if fcsSize > 0 && contentSize <= zstdMaxBlockSize*64 {
+		if free := uint64(cap(dst) - len(dst)); free < contentSize {
+			grown := make([]byte, len(dst), uint64(len(dst))+contentSize)
+			copy(grown, dst)
+			dst = grown
+		}
+	}
+
+	d.frameStart = len(dst)
+	d.reps = [3]int{1, 4, 8}
+
+	for last := false; !last; {
+		if len(src) < 3 {
+			return nil, nil, errZstdCorrupt
+		}
+		header := uint32(src[0]) | uint32(src[1])<<8 | uint32(src[2])<<16
+		src = src[3:]
+
+		last = header&1 != 0
+		size := int(header >> 3)
+		if size > zstdMaxBlockSize {
+			return nil, nil, errZstdCorrupt
+		}
+
+		switch (header >> 1) & 3 {
+		case 0:
+			if len(src) < size {
+				return nil, nil, errZstdCorrupt
+			}
+			dst = append(dst, src[:size]...)
+			src = src[size:]
+		case 1:
+			if len(src) < 1 {
+				return nil, nil, errZstdCorrupt
+			}
+			for i := 0; i < size; i++ {
+				dst = append(dst, src[0])
+			}
+			src = src[1:]
+		case 2:
+			if len(src) < size {
+				return nil, nil, errZstdCorrupt
+			}
+			var err error
+			if dst, err = d.decodeBlock(dst, src[:size]); err != nil {
+				return nil, nil, err
+			}
+			src = src[size:]
+		default:
+			return nil, nil, errZstdCorrupt
+		}
+	}
+
+	if fcsSize > 0 && uint64(len(dst)-d.frameStart) != contentSize {
+		return nil, nil, fmt.Errorf("zstd: frame decompressed to %d bytes instead of %d bytes", len(dst)-d.frameStart, contentSize)
+	}
+
+	if checksum {
+		if len(src) < 4 {
+			return nil, nil, errZstdCorrupt
+		}
+		if uint32(xxhash64(dst[d.frameStart:])) != binary.LittleEndian.Uint32(src) {
+			return nil, nil, errors.New("zstd: checksum mismatch")
+		}
+		src = src[4:]
+	}
+
+	return dst, src, nil
+}
+
+// decodeBlock decodes a compressed block and appends its content to dst.
+func (d *zstdFrameDecoder) decodeBlock(dst, src []byte) ([]byte, error) {
+	literals, n, err := d.decodeLiterals(src)
+	if err != nil {
+		return nil, err
+	}
+	src = src[n:]
+
+	if len(src) < 1 {
+		return nil, errZstdCorrupt
+	}
+
+	var numSeqs int
+	switch b := int(src[0]); {
+	case b < 128:
+		numSeqs = b
+		src = src[1:]
+	case b < 255:
+		if len(src) < 2 {
+			return nil, errZstdCorrupt
+		}
+		numSeqs = (b-128)<<8 | int(src[1])
+		src = src[2:]
+	default:
+		if len(src) < 3 {
+			return nil, errZstdCorrupt
+		}
+		numSeqs = (int(src[1]) | int(src[2])<<8) + 0x7F00
+		src = src[3:]
+	}
+
+	start := len(dst)
+
+	if numSeqs > 0 {
+		if dst, err = d.decodeSequences(dst, src, numSeqs, literals); err != nil {
+			return nil, err
+		}
+	} else {
+		dst = append(dst, literals...)
+	}
+
+	if len(dst)-start > zstdMaxBlockSize {
+		return nil, errZstdCorrupt
+	}
+
+	return dst, nil
+}
+
+// decodeLiterals decodes the literals section of a compressed block. It returns the
+// literals and the size of the section.
+func (d *zstdFrameDecoder) decodeLiterals(src []byte) ([]byte, int, error) {
+	if len(src) < 1 {
+		return nil, 0, errZstdCorrupt
+	}
+
+	var (
+		typ        = src[0] & 3
+		sizeFormat = (src[0] >> 2) & 3
+	)
+
+	if typ < 2 {
+		var regenSize, headerSize int
+		switch sizeFormat {
+		case 0, 2:
+			regenSize, headerSize = int(src[0]>>3), 1
+		case 1:
+			if len(src) < 2 {
+				return nil, 0, errZstdCorrupt
+			}
+			regenSize, headerSize = int(src[0]>>4)|int(src[1])<<4, 2
+		default:
+			if len(src) < 3 {
+				return nil, 0, errZstdCorrupt
+			}
+			regenSize, headerSize = int(src[0]>>4)|int(src[1])<<4|int(src[2])<<12, 3
+		}
+
+		if regenSize > zstdMaxBlockSize {
+			return nil, 0, errZstdCorrupt
+		}
+
+		if typ == 0 {
+			if len(src) < headerSize+regenSize {
+				return nil, 0, errZstdCorrupt
+			}
+			return src[headerSize : headerSize+regenSize], headerSize + regenSize, nil
+		}
+
+		if len(src) < headerSize+1 {
+			return nil, 0, errZstdCorrupt
+		}
+		literals := make([]byte, regenSize)
+		for i := range literals {
+			literals[i] = src[headerSize]
+		}
+		return literals, headerSize + 1, nil
+	}
+
+	var (
+		regenSize, compSize, headerSize int
+		streams                         = 4
+	)
+	switch sizeFormat {
+	case 0, 1:
+		if len(src) < 3 {
+			return nil, 0, errZstdCorrupt
+		}
+		v := uint32(src[0]) | uint32(src[1])<<8 | uint32(src[2])<<16
+		regenSize, compSize, headerSize = int(v>>4)&0x3FF, int(v>>14)&0x3FF, 3
+		if sizeFormat == 0 {
+			streams = 1
+		}
+	case 2:
+		if len(src) < 4 {
+			return nil, 0, errZstdCorrupt
+		}
+		v := binary.LittleEndian.Uint32(src)
+		regenSize, compSize, headerSize = int(v>>4)&0x3FFF, int(v>>18)&0x3FFF, 4
+	default:
+		if len(src) < 5 {
+			return nil, 0, errZstdCorrupt
+		}
+		v := uint64(binary.LittleEndian.Uint32(src)) | uint64(src[4])<<32
+		regenSize, compSize, headerSize = int(v>>4)&0x3FFFF, int(v>>22)&0x3FFFF, 5
+	}
+
+	if regenSize > zstdMaxBlockSize || len(src) < headerSize+compSize {
+		return nil, 0, errZstdCorrupt
+	}
+	data := src[headerSize : headerSize+compSize]
+
+	if typ == 2 {
+		t, n, err := zstdReadHuffmanTable(data)
+		if err != nil {
+			return nil, 0, err
+		}
+		d.huffman = t
+		data = data[n:]
+	} else if d.huffman == nil {
+		return nil, 0, errZstdCorrupt
+	}
+
+	literals := make([]byte, 0, regenSize)
+
+	if streams == 1 {
+		var err error
+		if literals, err = d.huffman.decodeStream(literals, data, regenSize); err != nil {
+			return nil, 0, err
+		}
+		return literals, headerSize + compSize, nil
+	}
+
+	if len(data) < 6 {
+		return nil, 0, errZstdCorrupt
+	}
+	var sizes [4]int
+	sizes[3] = len(data) - 6
+	for i := 0; i < 3; i++ {
+		sizes[i] = int(binary.LittleEndian.Uint16(data[2*i:]))
+		sizes[3] -= sizes[i]
+	}
+	if sizes[3] < 0 {
+		return nil, 0, errZstdCorrupt
+	}
+	data = data[6:]
+
+	segment := (regenSize + 3) / 4
+	if 3*segment > regenSize {
+		return nil, 0, errZstdCorrupt
+	}
+	for i, size := range sizes {
+		n := segment
+		if i == 3 {
+			n = regenSize - 3*segment
+		}
+		var err error
+		if literals, err = d.huffman.decodeStream(literals, data[:size], n); err != nil {
+			return nil, 0, err
+		}
+		data = data[size:]
+	}
+
+	return literals, headerSize + compSize, nil
+}
+
+// decodeSequences decodes the sequences section of a compressed block and executes the
+// sequences, appending the result to dst.
+func (d *zstdFrameDecoder) decodeSequences(dst, src []byte, numSeqs int, literals []byte) ([]byte, error) {
+	if len(src) < 1 {
+		return nil, errZstdCorrupt
+	}
+	modes := src[0]
+	src = src[1:]
+	if modes&3 != 0 {
+		return nil, errZstdCorrupt
+	}
+
+	tables := []struct {
+		table      **zstdFSETable
+		mode       byte
+		predefined *zstdFSETable
+		maxSymbol  int
+		maxLog     uint
+	}{
+		{&d.ll, modes >> 6, zstdPredefinedLL, zstdMaxLLSymbol, zstdMaxLLLog},
+		{&d.of, (modes >> 4) & 3, zstdPredefinedOF, zstdMaxOFSymbol, zstdMaxOFLog},
+		{&d.ml, (modes >> 2) & 3, zstdPredefinedML, zstdMaxMLSymbol, zstdMaxMLLog},
+	}
+	for _, t := range tables {
+		switch t.mode {
+		case 0:
+			*t.table = t.predefined
+		case 1:
+			if len(src) < 1 || int(src[0]) > t.maxSymbol {
+				return nil, errZstdCorrupt
+			}
+			*t.table = zstdRLETable(src[0])
+			src = src[1:]
+		case 2:
+			table, n, err := zstdReadFSETable(src, t.maxSymbol, t.maxLog)
+			if err != nil {
+				return nil, err
+			}
+			*t.table = table
+			src = src[n:]
+		default:
+			if *t.table == nil {
+				return nil, errZstdCorrupt
+			}
+		}
+	}
+
+	var br zstdReverseBitReader
+	if err := br.init(src); err != nil {
+		return nil, err
+	}
+
+	var (
+		llState = br.read(d.ll.log)
+		ofState = br.read(d.of.log)
+		mlState = br.read(d.ml.log)
+	)
+
+	for i := 0; i < numSeqs; i++ {
+		var (
+			llCode = d.ll.entries[llState].symbol
+			ofCode = d.of.entries[ofState].symbol
+			mlCode = d.ml.entries[mlState].symbol
+		)
+
+		offsetValue := uint64(1)<<ofCode + br.read(uint(ofCode))
+		matchLen := int(zstdMLBase[mlCode]) + int(br.read(uint(zstdMLBits[mlCode])))
+		litLen := int(zstdLLBase[llCode]) + int(br.read(uint(zstdLLBits[llCode])))
+
+		if i < numSeqs-1 {
+			llState = d.ll.next(llState, &br)
+			mlState = d.ml.next(mlState, &br)
+			ofState = d.of.next(ofState, &br)
+		}
+
+		if br.overflow() || litLen > len(literals) {
+			return nil, errZstdCorrupt
+		}
+		dst = append(dst, literals[:litLen]...)
+		literals = literals[litLen:]
+
+		offset := zstdUpdateOffsets(&d.reps, offsetValue, litLen)
+		if offset <= 0 || offset > len(dst)-d.frameStart || matchLen > zstdMaxBlockSize {
+			return nil, errZstdCorrupt
+		}
+
+		start := len(dst) - offset
+		if offset >= matchLen {
+			dst = append(dst, dst[start:start+matchLen]...)
+		} else {
+			// the match overlaps with the data it produces, so it needs to be copied byte by byte.
+			for j := 0; j < matchLen; j++ {
+				dst = append(dst, dst[start+j])
+			}
+		}
+	}
+
+	if br.pos != 0 {
+		return nil, errZstdCorrupt
+	}
+
+	return append(dst, literals...), nil
+}
+
+// zstdUpdateOffsets resolves an offset value to the actual offset, and updates the
+// repeated offsets accordingly. It returns 0 for invalid offset values.
+func zstdUpdateOffsets(reps *[3]int, offsetValue uint64, litLen int) int {
+	if offsetValue > 3 {
+		if offsetValue-3 > math.MaxInt32 {
+			return 0
+		}
+		offset := int(offsetValue - 3)
+		reps[2], reps[1], reps[0] = reps[1], reps[0], offset
+		return offset
+	}
+
+	idx := int(offsetValue) - 1
+	if litLen == 0 {
+		idx++
+	}
+
+	var offset int
+	switch idx {
+	case 0:
+		return reps[0]
+	case 1:
+		offset = reps[1]
+		reps[1] = reps[0]
+	case 2:
+		offset = reps[2]
+		reps[2], reps[1] = reps[1], reps[0]
+	default:
+		offset = reps[0] - 1
+		reps[2], reps[1] = reps[1], reps[0]
+	}
+	reps[0] = offset
+
+	return offset
+}
+
+// zstdCode returns the code for value v, given the baselines of all codes.
+func zstdCode(base []uint32, v int) uint8 {
+	return uint8(sort.Search(len(base), func(i int) bool {
+		return base[i] > uint32(v)
+	}) - 1)
+}
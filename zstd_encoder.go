@@ -0,0 +1,534 @@
+package goparquet
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+)
+
+const (
+	zstdMinMatch     = 4
+	zstdMaxWindowLog = 23
+)
+
+// zstdSequence is a run of literals followed by a match.
+type zstdSequence struct {
+	litLen      int
+	matchLen    int
+	offsetValue uint32
+}
+
+// zstdEncoder finds matches using hash chains. The number of candidates that are
+// examined for each position depends on the compression level.
+type zstdEncoder struct {
+	src    []byte
+	window int
+
+	depth   int
+	niceLen int
+	lazy    bool
+
+	hashLog   uint
+	head      []int32 // position + 1 of the most recent occurrence of a hash, 0 means none.
+	chain     []int32 // position + 1 of the previous occurrence of the same hash.
+	chainMask int
+	next      int // next position to insert into the hash chains.
+
+	reps     [3]int
+	literals []byte
+	seqs     []zstdSequence
+}
+
+func newZstdEncoder(src []byte, level, window int) *zstdEncoder {
+	e := &zstdEncoder{
+		src:    src,
+		window: window,
+		reps:   [3]int{1, 4, 8},
+		lazy:   level >= 4,
+	}
+
+	e.depth = 1 << uint(level-1)
+	if e.depth > 1024 {
+		e.depth = 1024
+	}
+	e.niceLen = 16 * e.depth
+	if e.niceLen < 32 {
+		e.niceLen = 32
+	}
+	if e.niceLen > 4096 {
+		e.niceLen = 4096
+	}
+
+	e.hashLog = uint(bits.Len(uint(len(src))))
+	if e.hashLog < 10 {
+		e.hashLog = 10
+	}
+	if e.hashLog > 17 {
+		e.hashLog = 17
+	}
+	e.head = make([]int32, 1<<e.hashLog)
+
+	if e.depth > 1 {
+		size := len(src)
+		if size > window {
+			size = window
+		}
+		size = 1 << uint(bits.Len(uint(size)))
+		e.chain = make([]int32, size)
+		e.chainMask = size - 1
+	}
+
+	return e
+}
+
+// zstdCompress compresses src into a single frame with the given level and appends it to dst.
+func zstdCompress(dst, src []byte, level int) []byte {
+	dst = append(dst, 0x28, 0xB5, 0x2F, 0xFD)
+
+	var (
+		n      = uint64(len(src))
+		window = 1 << zstdMaxWindowLog
+		fhd    = byte(0x04) // the frame has a content checksum.
+	)
+
+	if n <= uint64(window) {
+		// the whole content is a single segment, so the window size is implied by the
+		// content size.
+		fhd |= 0x20
+		switch {
+		case n < 256:
+			dst = append(dst, fhd, byte(n))
+		case n < 256+1<<16:
+			dst = append(dst, fhd|1<<6, byte(n-256), byte((n-256)>>8))
+		default:
+			dst = append(dst, fhd|2<<6)
+			dst = zstdAppendLE(dst, n, 4)
+		}
+	} else {
+		if n < 1<<32 {
+			dst = append(dst, fhd|2<<6, (zstdMaxWindowLog-10)<<3)
+			dst = zstdAppendLE(dst, n, 4)
+		} else {
+			dst = append(dst, fhd|3<<6, (zstdMaxWindowLog-10)<<3)
+			dst = zstdAppendLE(dst, n, 8)
+		}
+	}
+
+	if len(src) == 0 {
+		dst = append(dst, 1, 0, 0)
+	}
+
+	e := newZstdEncoder(src, level, window)
+	for start := 0; start < len(src); start += zstdMaxBlockSize {
+		end := start + zstdMaxBlockSize
+		if end > len(src) {
+			end = len(src)
+		}
+		dst = e.appendBlock(dst, start, end, end == len(src))
+	}
+
+	return zstdAppendLE(dst, xxhash64(src), 4)
+}
+
+func zstdAppendLE(dst []byte, v uint64, n int) []byte {
+	for i := 0; i < n; i++ {
+		dst = append(dst, byte(v>>(8*uint(i))))
+	}
+	return dst
+}
+
+// appendBlock appends the block src[start:end] to dst, compressed if that makes it smaller.
+func (e *zstdEncoder) appendBlock(dst []byte, start, end int, last bool) []byte {
+	block := e.src[start:end]
+
+	var header uint32
+	if last {
+		header = 1
+	}
+
+	if len(block) > 1 && zstdIsRun(block) {
+		header |= 1<<1 | uint32(len(block))<<3
+		return append(dst, byte(header), byte(header>>8), byte(header>>16), block[0])
+	}
+
+	reps := e.reps
+	e.findSequences(start, end)
+
+	headerPos := len(dst)
+	dst = append(dst, 0, 0, 0)
+	dst, ok := e.appendCompressedBlock(dst)
+	if size := len(dst) - headerPos - 3; ok && size < len(block) {
+		header |= 2<<1 | uint32(size)<<3
+		dst[headerPos], dst[headerPos+1], dst[headerPos+2] = byte(header), byte(header>>8), byte(header>>16)
+		return dst
+	}
+
+	// the raw block doesn't carry the sequences, so the repeated offsets must not change.
+	e.reps = reps
+	dst = dst[:headerPos]
+	header |= uint32(len(block)) << 3
+	dst = append(dst, byte(header), byte(header>>8), byte(header>>16))
+	return append(dst, block...)
+}
+
+func zstdIsRun(b []byte) bool {
+	for _, c := range b[1:] {
+		if c != b[0] {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *zstdEncoder) hash(pos int) uint32 {
+	return (binary.LittleEndian.Uint32(e.src[pos:]) * 2654435761) >> (32 - e.hashLog)
+}
+
+func (e *zstdEncoder) insert(pos int) {
+	h := e.hash(pos)
+	if e.chain != nil {
+		e.chain[pos&e.chainMask] = e.head[h]
+	}
+	e.head[h] = int32(pos + 1)
+}
+
+// insertUntil inserts all positions before pos into the hash chains.
+func (e *zstdEncoder) insertUntil(pos int) {
+	for ; e.next < pos && e.next+zstdMinMatch <= len(e.src); e.next++ {
+		e.insert(e.next)
+	}
+}
+
+// matchLen returns the length of the match between the data at positions a and b,
+// where the match must not extend beyond end.
+func (e *zstdEncoder) matchLen(a, b, end int) int {
+	n := 0
+	for b+n+8 <= end {
+		if x := binary.LittleEndian.Uint64(e.src[a+n:]) ^ binary.LittleEndian.Uint64(e.src[b+n:]); x != 0 {
+			return n + bits.TrailingZeros64(x)/8
+		}
+		n += 8
+	}
+	for b+n < end && e.src[a+n] == e.src[b+n] {
+		n++
+	}
+	return n
+}
+
+// findMatch returns the length and offset of the longest match for pos that was found.
+func (e *zstdEncoder) findMatch(pos, end int) (int, int) {
+	e.insertUntil(pos)
+
+	var (
+		h                 = e.hash(pos)
+		cand              = int(e.head[h]) - 1
+		bestLen, bestOffs int
+	)
+
+	for i := 0; cand >= 0 && i < e.depth; i++ {
+		if pos-cand > e.window {
+			break
+		}
+
+		if e.src[cand+bestLen] == e.src[pos+bestLen] {
+			if n := e.matchLen(cand, pos, end); n > bestLen {
+				bestLen, bestOffs = n, pos-cand
+				if pos+n == end || n >= e.niceLen {
+					break
+				}
+			}
+		}
+
+		if e.chain == nil {
+			break
+		}
+		next := int(e.chain[cand&e.chainMask]) - 1
+		if next >= cand {
+			break
+		}
+		cand = next
+	}
+
+	if e.next == pos {
+		e.insert(pos)
+		e.next++
+	}
+
+	if bestLen < zstdMinMatch {
+		return 0, 0
+	}
+	return bestLen, bestOffs
+}
+
+// findSequences splits the block src[start:end] into sequences and literals.
+func (e *zstdEncoder) findSequences(start, end int) {
+	e.literals = e.literals[:0]
+	e.seqs = e.seqs[:0]
+
+	anchor, pos := start, start
+	for pos+zstdMinMatch <= end {
+		matchLen, offs := e.findMatch(pos, end)
+		offsetValue := uint32(offs + 3)
+
+		// the most recent offset is cheapest to encode, but it can only be referenced
+		// this way if the sequence has literals.
+		if r := e.reps[0]; pos > anchor && r <= pos {
+			if n := e.matchLen(pos-r, pos, end); n >= zstdMinMatch && n >= matchLen {
+				matchLen, offsetValue = n, 1
+			}
+		}
+
+		if matchLen == 0 {
+			pos += 1 + (pos-anchor)>>7
+			continue
+		}
+
+		for e.lazy && pos+1+zstdMinMatch <= end {
+			n, o := e.findMatch(pos+1, end)
+			if n <= matchLen+1 {
+				break
+			}
+			pos++
+			matchLen, offsetValue = n, uint32(o+3)
+		}
+
+		litLen := pos - anchor
+		e.literals = append(e.literals, e.src[anchor:pos]...)
+		e.seqs = append(e.seqs, zstdSequence{litLen: litLen, matchLen: matchLen, offsetValue: offsetValue})
+		zstdUpdateOffsets(&e.reps, uint64(offsetValue), litLen)
+
+		pos += matchLen
+		anchor = pos
+	}
+
+	e.literals = append(e.literals, e.src[anchor:end]...)
+}
+
+// appendCompressedBlock appends the literals and sequences of the current block. It
+// returns false if the block can't be compressed.
+func (e *zstdEncoder) appendCompressedBlock(dst []byte) ([]byte, bool) {
+	dst = zstdAppendLiterals(dst, e.literals)
+
+	n := len(e.seqs)
+	switch {
+	case n < 128:
+		dst = append(dst, byte(n))
+	case n < 0x7F00:
+		dst = append(dst, byte(n>>8+128), byte(n))
+	default:
+		dst = append(dst, 255, byte(n-0x7F00), byte((n-0x7F00)>>8))
+	}
+	if n == 0 {
+		return dst, true
+	}
+
+	var (
+		llCodes = make([]uint8, n)
+		ofCodes = make([]uint8, n)
+		mlCodes = make([]uint8, n)
+	)
+	for i, seq := range e.seqs {
+		llCodes[i] = zstdCode(zstdLLBase[:], seq.litLen)
+		mlCodes[i] = zstdCode(zstdMLBase[:], seq.matchLen)
+		ofCodes[i] = uint8(bits.Len32(seq.offsetValue) - 1)
+	}
+
+	modesPos := len(dst)
+	dst = append(dst, 0)
+
+	var llMode, ofMode, mlMode byte
+	var llTable, ofTable, mlTable *zstdFSEEncTable
+	dst, llMode, llTable = zstdAppendSequenceTable(dst, llCodes, zstdMaxLLSymbol, zstdMaxLLLog, zstdPredefinedLLNorm, 6)
+	dst, ofMode, ofTable = zstdAppendSequenceTable(dst, ofCodes, zstdMaxOFSymbol, zstdMaxOFLog, zstdPredefinedOFNorm, 5)
+	dst, mlMode, mlTable = zstdAppendSequenceTable(dst, mlCodes, zstdMaxMLSymbol, zstdMaxMLLog, zstdPredefinedMLNorm, 6)
+	dst[modesPos] = llMode<<6 | ofMode<<4 | mlMode<<2
+
+	// the sequences are encoded in reverse, as the decoder reads the bit stream backwards.
+	var (
+		w                         = zstdBitWriter{out: dst}
+		llState, ofState, mlState zstdFSEState
+		last                      = n - 1
+	)
+	addExtraBits := func(i int) {
+		seq := e.seqs[i]
+		w.add(uint64(seq.litLen)-uint64(zstdLLBase[llCodes[i]]), uint(zstdLLBits[llCodes[i]]))
+		w.add(uint64(seq.matchLen)-uint64(zstdMLBase[mlCodes[i]]), uint(zstdMLBits[mlCodes[i]]))
+		w.add(uint64(seq.offsetValue), uint(ofCodes[i]))
+	}
+
+	mlState.init(mlTable, mlCodes[last])
+	ofState.init(ofTable, ofCodes[last])
+	llState.init(llTable, llCodes[last])
+	addExtraBits(last)
+
+	for i := last - 1; i >= 0; i-- {
+		ofState.encode(&w, ofCodes[i])
+		mlState.encode(&w, mlCodes[i])
+		llState.encode(&w, llCodes[i])
+		addExtraBits(i)
+	}
+
+	mlState.flush(&w)
+	ofState.flush(&w)
+	llState.flush(&w)
+	w.close()
+
+	return w.out, true
+}
+
+// zstdAppendSequenceTable chooses how the codes of one of the sequence fields are
+// encoded, appends the table description if needed and returns the mode and the
+// encoding table. A nil table means that all codes are the same.
+func zstdAppendSequenceTable(dst, codes []uint8, maxSymbol int, maxLog uint, predefined []int16, predefinedLog uint) ([]byte, byte, *zstdFSEEncTable) {
+	counts := make([]int, maxSymbol+1)
+	distinct, last := 0, 0
+	for _, c := range codes {
+		if counts[c] == 0 {
+			distinct++
+		}
+		counts[c]++
+		if int(c) > last {
+			last = int(c)
+		}
+	}
+
+	if distinct == 1 {
+		return append(dst, codes[0]), 1, nil
+	}
+
+	log := zstdFSETableLog(len(codes), distinct, maxLog)
+	norm := zstdNormalizeCounts(counts[:last+1], len(codes), log)
+
+	desc := zstdBitWriter{}
+	zstdWriteFSETable(&desc, norm, log)
+
+	customCost := zstdFSECost(counts, norm, log) + float64(8*len(desc.out))
+	if last < len(predefined) && zstdFSECost(counts, predefined, predefinedLog) <= customCost {
+		return dst, 0, zstdBuildFSEEncTable(predefined, predefinedLog)
+	}
+
+	return append(dst, desc.out...), 2, zstdBuildFSEEncTable(norm, log)
+}
+
+// zstdFSECost estimates the number of bits needed to encode symbols with the given
+// counts using the given normalized counts.
+func zstdFSECost(counts []int, norm []int16, log uint) float64 {
+	cost := 0.0
+	for s, c := range counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(norm[s])
+		if p < 1 {
+			p = 1
+		}
+		cost += float64(c) * (float64(log) - math.Log2(p))
+	}
+	return cost
+}
+
+// zstdAppendLiterals appends the literals section, Huffman-coded if that makes it smaller.
+func zstdAppendLiterals(dst, literals []byte) []byte {
+	n := len(literals)
+
+	var counts [256]int
+	distinct := 0
+	for _, b := range literals {
+		if counts[b] == 0 {
+			distinct++
+		}
+		counts[b]++
+	}
+
+	if distinct == 1 && n > 1 {
+		return append(zstdAppendLiteralsHeader(dst, 1, n), literals[0])
+	}
+
+	if distinct > 1 && n >= 32 {
+		start := len(dst)
+		res, ok := zstdAppendHuffmanLiterals(dst, literals, &counts)
+		if ok {
+			return res
+		}
+		dst = res[:start]
+	}
+
+	return append(zstdAppendLiteralsHeader(dst, 0, n), literals...)
+}
+
+// zstdAppendLiteralsHeader appends the header of raw or RLE literals.
+func zstdAppendLiteralsHeader(dst []byte, typ byte, n int) []byte {
+	switch {
+	case n < 32:
+		return append(dst, typ|byte(n)<<3)
+	case n < 4096:
+		return append(dst, typ|1<<2|byte(n)<<4, byte(n>>4))
+	default:
+		return append(dst, typ|3<<2|byte(n)<<4, byte(n>>4), byte(n>>12))
+	}
+}
+
+// zstdAppendHuffmanLiterals appends Huffman-coded literals. It returns false if they
+// don't end up smaller than the literals themselves.
+func zstdAppendHuffmanLiterals(dst, literals []byte, counts *[256]int) ([]byte, bool) {
+	var (
+		n          = len(literals)
+		start      = len(dst)
+		sizeFormat uint64
+		headerSize int
+	)
+
+	switch {
+	case n < 1024:
+		sizeFormat, headerSize = 0, 3
+	case n < 1<<14:
+		sizeFormat, headerSize = 2, 4
+	default:
+		sizeFormat, headerSize = 3, 5
+	}
+	dst = append(dst, make([]byte, headerSize)...)
+
+	enc := zstdBuildHuffmanEncoder(counts)
+	dst, ok := enc.appendDescription(dst)
+	if !ok {
+		return dst, false
+	}
+
+	if sizeFormat == 0 {
+		dst = enc.appendStream(dst, literals)
+	} else {
+		jumpTable := len(dst)
+		dst = append(dst, make([]byte, 6)...)
+
+		segment := (n + 3) / 4
+		for i := 0; i < 4; i++ {
+			end := (i + 1) * segment
+			if end > n {
+				end = n
+			}
+
+			streamStart := len(dst)
+			dst = enc.appendStream(dst, literals[i*segment:end])
+			if i < 3 {
+				size := len(dst) - streamStart
+				if size > math.MaxUint16 {
+					return dst, false
+				}
+				binary.LittleEndian.PutUint16(dst[jumpTable+2*i:], uint16(size))
+			}
+		}
+	}
+
+	compSize := len(dst) - start - headerSize
+	if compSize >= n {
+		return dst, false
+	}
+
+	shift := [4]uint{14, 14, 18, 22}[sizeFormat]
+	header := 2 | sizeFormat<<2 | uint64(n)<<4 | uint64(compSize)<<shift
+	for i := 0; i < headerSize; i++ {
+		dst[start+i] = byte(header >> (8 * uint(i)))
+	}
+
+	return dst, true
+}
@@ -0,0 +1,888 @@
+package goparquet
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"sort"
+)
+
+// This file contains the entropy coding stages of the ZSTD format: the bit streams,
+// finite state entropy (FSE) tables and Huffman tables, both for decoding and encoding.
+
+const (
+	zstdMaxHuffmanBits    = 11
+	zstdMaxHuffmanWeights = 255
+	zstdHuffmanWeightLog  = 6
+)
+
+// zstdLoadBits returns n bits (n <= 56) of in, starting at bit position start. Bits
+// beyond the end of in are zero.
+func zstdLoadBits(in []byte, start int, n uint) uint64 {
+	i := start >> 3
+	var v uint64
+	if i+8 <= len(in) {
+		v = binary.LittleEndian.Uint64(in[i:])
+	} else {
+		for j := len(in) - 1; j >= i; j-- {
+			v = v<<8 | uint64(in[j])
+		}
+	}
+	return (v >> uint(start&7)) & (1<<n - 1)
+}
+
+// zstdForwardBitReader reads bits starting with the least significant bit of the
+// first byte. It is used for FSE table descriptions.
+type zstdForwardBitReader struct {
+	in  []byte
+	pos int
+}
+
+func (r *zstdForwardBitReader) peek(n uint) uint64 {
+	return zstdLoadBits(r.in, r.pos, n)
+}
+
+func (r *zstdForwardBitReader) read(n uint) uint64 {
+	v := r.peek(n)
+	r.pos += int(n)
+	return v
+}
+
+func (r *zstdForwardBitReader) overflow() bool {
+	return r.pos > len(r.in)*8
+}
+
+// zstdReverseBitReader reads a bit stream backwards, starting right below the highest
+// set bit of the last byte. Reading beyond the start of the stream yields zero bits.
+type zstdReverseBitReader struct {
+	in  []byte
+	pos int // number of bits left to read, negative once the stream has been overrun.
+}
+
+func (r *zstdReverseBitReader) init(in []byte) error {
+	if len(in) == 0 || in[len(in)-1] == 0 {
+		return errZstdCorrupt
+	}
+	r.in = in
+	r.pos = len(in)*8 - 9 + bits.Len8(in[len(in)-1])
+	return nil
+}
+
+func (r *zstdReverseBitReader) read(n uint) uint64 {
+	if n == 0 {
+		return 0
+	}
+	r.pos -= int(n)
+	start, shift := r.pos, uint(0)
+	if start < 0 {
+		if -start >= int(n) {
+			return 0
+		}
+		shift = uint(-start)
+		n -= shift
+		start = 0
+	}
+	return zstdLoadBits(r.in, start, n) << shift
+}
+
+func (r *zstdReverseBitReader) overflow() bool {
+	return r.pos < 0
+}
+
+// zstdBitWriter writes bits starting with the least significant bit of the first byte.
+type zstdBitWriter struct {
+	out []byte
+	acc uint64
+	n   uint
+}
+
+func (w *zstdBitWriter) add(v uint64, n uint) {
+	w.acc |= (v & (1<<n - 1)) << w.n
+	w.n += n
+	for w.n >= 8 {
+		w.out = append(w.out, byte(w.acc))
+		w.acc >>= 8
+		w.n -= 8
+	}
+}
+
+// align pads the stream with zero bits up to the next byte boundary.
+func (w *zstdBitWriter) align() {
+	if w.n > 0 {
+		w.out = append(w.out, byte(w.acc))
+		w.acc, w.n = 0, 0
+	}
+}
+
+// close terminates a stream that is meant to be read backwards.
+func (w *zstdBitWriter) close() {
+	w.add(1, 1)
+	w.align()
+}
+
+type zstdFSEEntry struct {
+	symbol uint8
+	nbBits uint8
+	base   uint16
+}
+
+// zstdFSETable is an FSE decoding table.
+type zstdFSETable struct {
+	log     uint
+	entries []zstdFSEEntry
+}
+
+func (t *zstdFSETable) next(state uint64, br *zstdReverseBitReader) uint64 {
+	e := t.entries[state]
+	return uint64(e.base) + br.read(uint(e.nbBits))
+}
+
+// zstdSpreadSymbols distributes the symbols across the table according to their
+// normalized counts, the same way for both decoding and encoding tables.
+func zstdSpreadSymbols(norm []int16, log uint) []uint8 {
+	size := 1 << log
+	table := make([]uint8, size)
+	high := size - 1
+
+	for s, n := range norm {
+		if n == -1 {
+			table[high] = uint8(s)
+			high--
+		}
+	}
+
+	step := (size >> 1) + (size >> 3) + 3
+	mask := size - 1
+	pos := 0
+	for s, n := range norm {
+		for i := 0; i < int(n); i++ {
+			table[pos] = uint8(s)
+			pos = (pos + step) & mask
+			for pos > high {
+				pos = (pos + step) & mask
+			}
+		}
+	}
+
+	return table
+}
+
+func zstdBuildFSETable(norm []int16, log uint) *zstdFSETable {
+	size := 1 << log
+	symbols := zstdSpreadSymbols(norm, log)
+
+	next := make([]int, len(norm))
+	for s, n := range norm {
+		if n == -1 {
+			next[s] = 1
+		} else {
+			next[s] = int(n)
+		}
+	}
+
+	t := &zstdFSETable{log: log, entries: make([]zstdFSEEntry, size)}
+	for u, s := range symbols {
+		n := next[s]
+		next[s]++
+		nbBits := int(log) + 1 - bits.Len(uint(n))
+		t.entries[u] = zstdFSEEntry{
+			symbol: s,
+			nbBits: uint8(nbBits),
+			base:   uint16((n << uint(nbBits)) - size),
+		}
+	}
+
+	return t
+}
+
+func zstdRLETable(symbol uint8) *zstdFSETable {
+	return &zstdFSETable{entries: []zstdFSEEntry{{symbol: symbol}}}
+}
+
+// zstdReadFSETable reads an FSE table description and returns the decoding table and
+// the number of bytes the description occupied.
+func zstdReadFSETable(in []byte, maxSymbol int, maxLog uint) (*zstdFSETable, int, error) {
+	br := zstdForwardBitReader{in: in}
+
+	log := uint(br.read(4)) + 5
+	if log > maxLog {
+		return nil, 0, errZstdCorrupt
+	}
+
+	var (
+		norm      = make([]int16, 0, maxSymbol+1)
+		remaining = (1 << log) + 1
+		threshold = 1 << log
+		nbBits    = log + 1
+		prev0     = false
+	)
+
+	for remaining > 1 && len(norm) <= maxSymbol {
+		if prev0 {
+			for {
+				repeat := int(br.read(2))
+				for i := 0; i < repeat; i++ {
+					norm = append(norm, 0)
+				}
+				if repeat != 3 {
+					break
+				}
+			}
+			if len(norm) > maxSymbol {
+				return nil, 0, errZstdCorrupt
+			}
+		}
+
+		max := 2*threshold - 1 - remaining
+		var count int
+		if v := int(br.peek(nbBits)); v&(threshold-1) < max {
+			count = v & (threshold - 1)
+			br.pos += int(nbBits) - 1
+		} else {
+			count = v & (2*threshold - 1)
+			if count >= threshold {
+				count -= max
+			}
+			br.pos += int(nbBits)
+		}
+		count--
+
+		if count < 0 {
+			remaining += count
+		} else {
+			remaining -= count
+		}
+		if remaining < 1 {
+			return nil, 0, errZstdCorrupt
+		}
+
+		norm = append(norm, int16(count))
+		prev0 = count == 0
+
+		for remaining < threshold {
+			nbBits--
+			threshold >>= 1
+		}
+	}
+
+	if remaining != 1 || br.overflow() {
+		return nil, 0, errZstdCorrupt
+	}
+
+	return zstdBuildFSETable(norm, log), (br.pos + 7) / 8, nil
+}
+
+// zstdFSEEncTable is an FSE encoding table.
+type zstdFSEEncTable struct {
+	log        uint
+	stateTable []uint16
+	symbols    []zstdFSESymbolTransform
+}
+
+type zstdFSESymbolTransform struct {
+	deltaNbBits    uint32
+	deltaFindState int32
+	start          int32
+}
+
+func zstdBuildFSEEncTable(norm []int16, log uint) *zstdFSEEncTable {
+	size := 1 << log
+	symbols := zstdSpreadSymbols(norm, log)
+
+	cumul := make([]int, len(norm)+1)
+	for s, n := range norm {
+		if n == -1 {
+			n = 1
+		}
+		cumul[s+1] = cumul[s] + int(n)
+	}
+
+	t := &zstdFSEEncTable{
+		log:        log,
+		stateTable: make([]uint16, size),
+		symbols:    make([]zstdFSESymbolTransform, len(norm)),
+	}
+
+	pos := append([]int(nil), cumul...)
+	for u, s := range symbols {
+		t.stateTable[pos[s]] = uint16(size + u)
+		pos[s]++
+	}
+
+	for s, n := range norm {
+		switch n {
+		case 0:
+		case -1, 1:
+			t.symbols[s] = zstdFSESymbolTransform{
+				deltaNbBits:    uint32(log<<16) - uint32(size),
+				deltaFindState: int32(cumul[s] - 1),
+				start:          int32(cumul[s]),
+			}
+		default:
+			maxBitsOut := log + 1 - uint(bits.Len(uint(n-1)))
+			minStatePlus := uint32(n) << maxBitsOut
+			t.symbols[s] = zstdFSESymbolTransform{
+				deltaNbBits:    uint32(maxBitsOut<<16) - minStatePlus,
+				deltaFindState: int32(cumul[s] - int(n)),
+				start:          int32(cumul[s]),
+			}
+		}
+	}
+
+	return t
+}
+
+// zstdFSEState is the state of an FSE encoder. Without a table, all symbols are the
+// same and nothing is written.
+type zstdFSEState struct {
+	t     *zstdFSEEncTable
+	value uint32
+}
+
+func (s *zstdFSEState) init(t *zstdFSEEncTable, symbol uint8) {
+	s.t = t
+	if t == nil {
+		return
+	}
+	s.value = uint32(t.stateTable[t.symbols[symbol].start])
+}
+
+func (s *zstdFSEState) encode(w *zstdBitWriter, symbol uint8) {
+	if s.t == nil {
+		return
+	}
+	tt := s.t.symbols[symbol]
+	nbBits := (s.value + tt.deltaNbBits) >> 16
+	w.add(uint64(s.value), uint(nbBits))
+	s.value = uint32(s.t.stateTable[int32(s.value>>nbBits)+tt.deltaFindState])
+}
+
+func (s *zstdFSEState) flush(w *zstdBitWriter) {
+	if s.t == nil {
+		return
+	}
+	w.add(uint64(s.value), s.t.log)
+}
+
+// zstdNormalizeCounts scales the symbol counts so that they add up to 1 << log. Every
+// symbol that occurs keeps a probability of at least 1.
+func zstdNormalizeCounts(counts []int, total int, log uint) []int16 {
+	size := 1 << log
+	norm := make([]int16, len(counts))
+
+	sum, largest := 0, -1
+	for s, c := range counts {
+		if c == 0 {
+			continue
+		}
+		n := (c*size + total/2) / total
+		if n < 1 {
+			n = 1
+		}
+		norm[s] = int16(n)
+		sum += n
+		if largest < 0 || norm[s] > norm[largest] {
+			largest = s
+		}
+	}
+
+	if sum < size {
+		norm[largest] += int16(size - sum)
+	}
+	for sum > size {
+		// take away from the currently most probable symbol.
+		s := 0
+		for i := range norm {
+			if norm[i] > norm[s] {
+				s = i
+			}
+		}
+		norm[s]--
+		sum--
+	}
+
+	return norm
+}
+
+// zstdFSETableLog chooses the accuracy log for an FSE table of n values with the given
+// number of distinct symbols.
+func zstdFSETableLog(n, distinct int, maxLog uint) uint {
+	log := uint(bits.Len(uint(n)))
+	if min := uint(bits.Len(uint(distinct-1))) + 1; log < min {
+		log = min
+	}
+	if log < 5 {
+		log = 5
+	}
+	if log > maxLog {
+		log = maxLog
+	}
+	return log
+}
+
+// zstdWriteFSETable writes the description of an FSE table with the given normalized counts.
+func zstdWriteFSETable(w *zstdBitWriter, norm []int16, log uint) {
+	w.add(uint64(log-5), 4)
+
+	var (
+		remaining = (1 << log) + 1
+		threshold = 1 << log
+		nbBits    = log + 1
+		symbol    = 0
+		prev0     = false
+	)
+
+	for remaining > 1 {
+		if prev0 {
+			start := symbol
+			for norm[symbol] == 0 {
+				symbol++
+			}
+			n := symbol - start
+			for n >= 3 {
+				w.add(3, 2)
+				n -= 3
+			}
+			w.add(uint64(n), 2)
+		}
+
+		count := int(norm[symbol])
+		symbol++
+
+		max := 2*threshold - 1 - remaining
+		if count < 0 {
+			remaining += count
+		} else {
+			remaining -= count
+		}
+		count++
+		if count >= threshold {
+			count += max
+		}
+		if count < max {
+			w.add(uint64(count), nbBits-1)
+		} else {
+			w.add(uint64(count), nbBits)
+		}
+		prev0 = count == 1
+
+		for remaining < threshold {
+			nbBits--
+			threshold >>= 1
+		}
+	}
+
+	w.align()
+}
+
+type zstdHuffmanEntry struct {
+	symbol uint8
+	nbBits uint8
+}
+
+// zstdHuffmanTable is a Huffman decoding table.
+type zstdHuffmanTable struct {
+	maxBits uint
+	entries []zstdHuffmanEntry
+}
+
+// zstdReadHuffmanTable reads a Huffman tree description and returns the decoding table
+// and the number of bytes the description occupied.
+func zstdReadHuffmanTable(in []byte) (*zstdHuffmanTable, int, error) {
+	if len(in) == 0 {
+		return nil, 0, errZstdCorrupt
+	}
+
+	var (
+		weights []uint8
+		size    int
+	)
+
+	if header := int(in[0]); header < 128 {
+		size = 1 + header
+		if len(in) < size {
+			return nil, 0, errZstdCorrupt
+		}
+
+		var err error
+		if weights, err = zstdDecodeHuffmanWeights(in[1:size]); err != nil {
+			return nil, 0, err
+		}
+	} else {
+		n := header - 127
+		size = 1 + (n+1)/2
+		if len(in) < size {
+			return nil, 0, errZstdCorrupt
+		}
+
+		weights = make([]uint8, n)
+		for i := range weights {
+			if b := in[1+i/2]; i%2 == 0 {
+				weights[i] = b >> 4
+			} else {
+				weights[i] = b & 15
+			}
+		}
+	}
+
+	sum := 0
+	for _, w := range weights {
+		if w > zstdMaxHuffmanBits {
+			return nil, 0, errZstdCorrupt
+		}
+		if w > 0 {
+			sum += 1 << (w - 1)
+		}
+	}
+	if sum == 0 {
+		return nil, 0, errZstdCorrupt
+	}
+
+	maxBits := uint(bits.Len(uint(sum)))
+	if maxBits > zstdMaxHuffmanBits {
+		return nil, 0, errZstdCorrupt
+	}
+
+	// the weight of the last symbol is implied, as it has to complete the tree.
+	left := 1<<maxBits - sum
+	if left&(left-1) != 0 {
+		return nil, 0, errZstdCorrupt
+	}
+	weights = append(weights, uint8(bits.Len(uint(left))))
+
+	var rankStart [zstdMaxHuffmanBits + 2]int
+	for _, w := range weights {
+		if w > 0 {
+			rankStart[maxBits+1-uint(w)] += 1 << (w - 1)
+		}
+	}
+	pos := 0
+	for nb := maxBits; nb >= 1; nb-- {
+		n := rankStart[nb]
+		rankStart[nb] = pos
+		pos += n
+	}
+
+	t := &zstdHuffmanTable{maxBits: maxBits, entries: make([]zstdHuffmanEntry, 1<<maxBits)}
+	for s, w := range weights {
+		if w == 0 {
+			continue
+		}
+		nb := maxBits + 1 - uint(w)
+		start := rankStart[nb]
+		for i := start; i < start+1<<(w-1); i++ {
+			t.entries[i] = zstdHuffmanEntry{symbol: uint8(s), nbBits: uint8(nb)}
+		}
+		rankStart[nb] += 1 << (w - 1)
+	}
+
+	return t, size, nil
+}
+
+// zstdDecodeHuffmanWeights decodes FSE-compressed Huffman weights, which are encoded
+// using two interleaved states.
+func zstdDecodeHuffmanWeights(in []byte) ([]uint8, error) {
+	t, n, err := zstdReadFSETable(in, zstdMaxHuffmanBits, zstdHuffmanWeightLog)
+	if err != nil {
+		return nil, err
+	}
+
+	var br zstdReverseBitReader
+	if err := br.init(in[n:]); err != nil {
+		return nil, err
+	}
+
+	state1 := br.read(t.log)
+	state2 := br.read(t.log)
+
+	var weights []uint8
+	for {
+		if len(weights) >= zstdMaxHuffmanWeights-1 {
+			return nil, errZstdCorrupt
+		}
+
+		weights = append(weights, t.entries[state1].symbol)
+		state1 = t.next(state1, &br)
+		if br.overflow() {
+			weights = append(weights, t.entries[state2].symbol)
+			break
+		}
+
+		weights = append(weights, t.entries[state2].symbol)
+		state2 = t.next(state2, &br)
+		if br.overflow() {
+			weights = append(weights, t.entries[state1].symbol)
+			break
+		}
+	}
+
+	return weights, nil
+}
+
+// decodeStream decodes n symbols from a single Huffman-coded stream and appends them to dst.
+func (t *zstdHuffmanTable) decodeStream(dst, in []byte, n int) ([]byte, error) {
+	var br zstdReverseBitReader
+	if err := br.init(in); err != nil {
+		return nil, err
+	}
+
+	mask := uint64(1)<<t.maxBits - 1
+	state := br.read(t.maxBits)
+	for i := 0; i < n; i++ {
+		e := t.entries[state]
+		dst = append(dst, e.symbol)
+		state = (state<<e.nbBits | br.read(uint(e.nbBits))) & mask
+	}
+
+	if br.pos != -int(t.maxBits) {
+		return nil, errZstdCorrupt
+	}
+
+	return dst, nil
+}
+
+// zstdHuffmanEncoder holds the codes of a Huffman table for encoding literals.
+type zstdHuffmanEncoder struct {
+	codes   [256]uint16
+	nbBits  [256]uint8
+	maxBits uint
+	last    int
+}
+
+// zstdBuildHuffmanEncoder builds a length-limited Huffman code for the given symbol
+// counts. At least two symbols need to occur.
+func zstdBuildHuffmanEncoder(counts *[256]int) *zstdHuffmanEncoder {
+	var symbols []int
+	for s, c := range counts {
+		if c > 0 {
+			symbols = append(symbols, s)
+		}
+	}
+	sort.SliceStable(symbols, func(i, j int) bool {
+		return counts[symbols[i]] < counts[symbols[j]]
+	})
+
+	// build the Huffman tree with two queues: leaves are sorted by count and internal
+	// nodes are created in order of increasing weight.
+	n := len(symbols)
+	weight := make([]int, 2*n-1)
+	parent := make([]int, 2*n-1)
+	for i, s := range symbols {
+		weight[i] = counts[s]
+	}
+
+	leaf, node := 0, n
+	pick := func(next int) int {
+		if leaf < n && (node >= next || weight[leaf] <= weight[node]) {
+			leaf++
+			return leaf - 1
+		}
+		node++
+		return node - 1
+	}
+	for next := n; next < 2*n-1; next++ {
+		a, b := pick(next), pick(next)
+		weight[next] = weight[a] + weight[b]
+		parent[a], parent[b] = next, next
+	}
+
+	depth := make([]int, 2*n-1)
+	for i := 2*n - 3; i >= 0; i-- {
+		depth[i] = depth[parent[i]] + 1
+	}
+
+	lengths := make([]int, n)
+	for i := range symbols {
+		lengths[i] = depth[i]
+		if lengths[i] > zstdMaxHuffmanBits {
+			lengths[i] = zstdMaxHuffmanBits
+		}
+	}
+	zstdLimitHuffmanLengths(lengths)
+
+	e := &zstdHuffmanEncoder{}
+	for i, s := range symbols {
+		e.nbBits[s] = uint8(lengths[i])
+		if uint(lengths[i]) > e.maxBits {
+			e.maxBits = uint(lengths[i])
+		}
+		if s > e.last {
+			e.last = s
+		}
+	}
+
+	// assign the codes in the same order as the decoding table is filled.
+	var rankStart [zstdMaxHuffmanBits + 2]int
+	for _, nb := range e.nbBits {
+		if nb > 0 {
+			rankStart[nb] += 1 << (e.maxBits - uint(nb))
+		}
+	}
+	pos := 0
+	for nb := e.maxBits; nb >= 1; nb-- {
+		n := rankStart[nb]
+		rankStart[nb] = pos
+		pos += n
+	}
+	for s, nb := range e.nbBits {
+		if nb == 0 {
+			continue
+		}
+		shift := e.maxBits - uint(nb)
+		e.codes[s] = uint16(rankStart[nb] >> shift)
+		rankStart[nb] += 1 << shift
+	}
+
+	return e
+}
+
+// zstdLimitHuffmanLengths adjusts code lengths that were capped at zstdMaxHuffmanBits
+// so that they form a complete prefix code again. lengths is sorted by increasing
+// symbol count.
+func zstdLimitHuffmanLengths(lengths []int) {
+	const full = 1 << zstdMaxHuffmanBits
+
+	kraft := 0
+	for _, l := range lengths {
+		kraft += 1 << uint(zstdMaxHuffmanBits-l)
+	}
+
+	for kraft > full {
+		// lengthen the code of the rarest symbol among those with the longest codes that
+		// can still be lengthened.
+		best := -1
+		for i, l := range lengths {
+			if l < zstdMaxHuffmanBits && (best < 0 || l > lengths[best]) {
+				best = i
+			}
+		}
+		lengths[best]++
+		kraft -= 1 << uint(zstdMaxHuffmanBits-lengths[best])
+	}
+
+	for kraft < full {
+		// shorten the code of the most frequent symbol among those with the longest codes.
+		best := -1
+		for i, l := range lengths {
+			if best < 0 || l >= lengths[best] {
+				best = i
+			}
+		}
+		kraft += 1 << uint(zstdMaxHuffmanBits-lengths[best])
+		lengths[best]--
+	}
+}
+
+func (e *zstdHuffmanEncoder) weight(s int) uint8 {
+	if e.nbBits[s] == 0 {
+		return 0
+	}
+	return uint8(e.maxBits + 1 - uint(e.nbBits[s]))
+}
+
+// appendDescription appends the Huffman tree description. It returns false if the
+// tree can't be described.
+func (e *zstdHuffmanEncoder) appendDescription(dst []byte) ([]byte, bool) {
+	weights := make([]uint8, e.last)
+	for s := range weights {
+		weights[s] = e.weight(s)
+	}
+
+	compressed := zstdCompressHuffmanWeights(weights)
+
+	if len(weights) <= 128 && (compressed == nil || len(compressed) >= (len(weights)+1)/2) {
+		dst = append(dst, byte(127+len(weights)))
+		for i := 0; i < len(weights); i += 2 {
+			b := weights[i] << 4
+			if i+1 < len(weights) {
+				b |= weights[i+1]
+			}
+			dst = append(dst, b)
+		}
+		return dst, true
+	}
+
+	if compressed == nil {
+		return dst, false
+	}
+
+	dst = append(dst, byte(len(compressed)))
+	return append(dst, compressed...), true
+}
+
+// zstdCompressHuffmanWeights FSE-compresses the Huffman weights. It returns nil if they
+// can't be compressed.
+func zstdCompressHuffmanWeights(weights []uint8) []byte {
+	if len(weights) < 2 {
+		return nil
+	}
+
+	counts := make([]int, zstdMaxHuffmanBits+1)
+	distinct, last := 0, 0
+	for _, w := range weights {
+		if counts[w] == 0 {
+			distinct++
+		}
+		counts[w]++
+		if int(w) > last {
+			last = int(w)
+		}
+	}
+	if distinct < 2 {
+		return nil
+	}
+	counts = counts[:last+1]
+
+	log := zstdFSETableLog(len(weights), distinct, zstdHuffmanWeightLog)
+	norm := zstdNormalizeCounts(counts, len(weights), log)
+	t := zstdBuildFSEEncTable(norm, log)
+
+	w := zstdBitWriter{}
+	zstdWriteFSETable(&w, norm, log)
+
+	var (
+		state1, state2 zstdFSEState
+		i              = len(weights)
+	)
+	if i%2 == 1 {
+		state1.init(t, weights[i-1])
+		state2.init(t, weights[i-2])
+		state1.encode(&w, weights[i-3])
+		i -= 3
+	} else {
+		state2.init(t, weights[i-1])
+		state1.init(t, weights[i-2])
+		i -= 2
+	}
+	for i > 0 {
+		state2.encode(&w, weights[i-1])
+		state1.encode(&w, weights[i-2])
+		i -= 2
+	}
+	state2.flush(&w)
+	state1.flush(&w)
+	w.close()
+
+	if len(w.out) >= 128 {
+		return nil
+	}
+
+	// the end of the weights is only marked by running out of bits, which is ambiguous
+	// for some distributions, so make sure the weights decode as intended.
+	decoded, err := zstdDecodeHuffmanWeights(w.out)
+	if err != nil || string(decoded) != string(weights) {
+		return nil
+	}
+
+	return w.out
+}
+
+// appendStream Huffman-codes src as a single stream and appends it to dst.
+func (e *zstdHuffmanEncoder) appendStream(dst, src []byte) []byte {
+	w := zstdBitWriter{out: dst}
+	for i := len(src) - 1; i >= 0; i-- {
+		s := src[i]
+		w.add(uint64(e.codes[s]), uint(e.nbBits[s]))
+	}
+	w.close()
+	return w.out
+}
@@ -0,0 +1,40 @@
+//go:build gofuzz
+// +build gofuzz
+
+package goparquet
+
+import (
+	"bytes"
+	"fmt"
+)
+
+func FuzzZSTD(data []byte) int {
+	if len(data) == 0 {
+		return 0
+	}
+
+	// the first byte selects the compression level, the remaining bytes are compressed
+	// and need to decompress to the same bytes again.
+	level, block := int(data[0])%22+1, data[1:]
+
+	c := NewZSTDCompressor(level)
+	compressed, err := c.CompressBlock(block)
+	if err != nil {
+		panic(fmt.Sprintf("compressing with level %d failed: %v", level, err))
+	}
+
+	decompressed, err := c.DecompressBlock(compressed)
+	if err != nil {
+		panic(fmt.Sprintf("decompressing data compressed with level %d failed: %v", level, err))
+	}
+	if !bytes.Equal(block, decompressed) {
+		panic(fmt.Sprintf("data compressed with level %d doesn't decompress to the original data", level))
+	}
+
+	// arbitrary frames must not crash the decoder.
+	if _, err := c.DecompressBlock(data); err != nil {
+		return 0
+	}
+
+	return 1
+}